@@ -0,0 +1,17 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+type logEmailSender struct {
+	logger *slog.Logger
+}
+
+func (s logEmailSender) Send(ctx context.Context, kind app.EmailKind, data app.EmailCode) error {
+	s.logger.Info("email", "kind", kind, "to", data.To, "code", data.Code)
+	return nil
+}
@@ -1,3 +1,299 @@
 package main
 
-func main() {}
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/Nemagu/dnd_users/internal/i18n"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/background"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/email"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/memory"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/password"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/random"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/ratelimit"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/token"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/totp"
+	"github.com/Nemagu/dnd_users/internal/transport/rest"
+	"github.com/Nemagu/dnd_users/internal/transport/rpc"
+	"google.golang.org/grpc"
+)
+
+const (
+	issuer       = "dnd_users_dev"
+	accessTTL    = 15 * time.Minute
+	sessionTTL   = 30 * 24 * time.Hour
+	serviceToken = "dev-service-token"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "HTTP listen address")
+	grpcAddr := flag.String("grpc-addr", ":9090", "gRPC listen address")
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		logger.Error("generate token key", "error", err)
+		os.Exit(1)
+	}
+	keys := token.MustKeySet("dev", token.Key{ID: "dev", PrivateKey: privateKey})
+	tokenIssuer := token.MustIssuer(keys, issuer, accessTTL)
+	catalog := i18n.MustDefaultCatalog()
+	tokenVerifier := token.MustVerifier(keys, issuer)
+
+	users := memory.MustUserRepository()
+	sessions := memory.MustSessionRepository()
+	codes := memory.MustCodeStore(memory.CodeTTL{
+		ConfirmEmail:  15 * time.Minute,
+		NewEmail:      15 * time.Minute,
+		ResetPassword: 15 * time.Minute,
+		NewPassword:   15 * time.Minute,
+	}, 5)
+
+	emailValidator := email.MustValidator(
+		email.Options{LowercaseLocalPart: true},
+		email.MustDomainList("mailinator.com"),
+	)
+	passwordValidator := password.MustPolicyValidator(
+		password.DefaultPolicy(),
+		password.MustBreachedList("password", "12345678", "qwerty123"),
+	)
+	passwordHasher := password.MustArgon2idHasher(
+		password.DefaultArgon2idParams(),
+		password.MustBcryptComparer(),
+	)
+	codeGenerator := random.MustCodeGenerator(random.Profile{Kind: random.Numeric, Length: 6})
+	tokenGenerator := random.MustTokenGenerator()
+	emailOutbox := memory.MustEmailOutbox()
+	emailDispatcher := background.MustEmailDispatcher(
+		emailOutbox,
+		logEmailSender{logger: logger},
+		background.DispatcherConfig{
+			PollInterval:  500 * time.Millisecond,
+			BatchSize:     20,
+			MaxAttempts:   3,
+			BaseBackoff:   time.Second,
+			MaxBackoff:    time.Minute,
+			Lease:         time.Minute,
+			DeadRetention: time.Hour,
+		},
+		logger,
+	)
+	emailDispatcher.Start()
+	codeCleaner := background.MustCodeCleaner(codes, time.Minute, logger)
+	codeCleaner.Start()
+	policy := domain.MustPolicyService()
+	limiter := memory.MustRateLimiter(ratelimit.Config{
+		Window:      time.Minute,
+		MaxAttempts: 10,
+		MaxFailures: 5,
+		Lockout:     time.Minute,
+		MaxLockout:  time.Hour,
+	})
+	totpAuth := totp.MustAuthenticator(totp.Config{
+		Issuer: issuer,
+		Digits: 6,
+		Period: 30 * time.Second,
+		Skew:   1,
+	})
+	changeUser := app.MustChangeUserUseCase(
+		users,
+		sessions,
+		emailValidator,
+		passwordValidator,
+		passwordHasher,
+		policy,
+	)
+
+	handler := rest.MustHandler(
+		rest.UseCases{
+			ConfirmEmail: app.MustConfirmEmailUseCase(
+				users,
+				codes,
+				emailValidator,
+				emailOutbox,
+				codeGenerator,
+				app.EnumerationProtection{},
+			),
+			Registration: app.MustRegistrationUseCase(
+				users,
+				codes,
+				codeGenerator,
+				limiter,
+				emailValidator,
+				passwordValidator,
+				passwordHasher,
+			),
+			Login: app.MustLoginUseCase(
+				users,
+				sessions,
+				limiter,
+				emailValidator,
+				passwordHasher,
+				passwordHasher,
+				passwordHasher,
+				totpAuth,
+				tokenIssuer,
+				tokenGenerator,
+				sessionTTL,
+			),
+			Refresh: app.MustRefreshUseCase(
+				users,
+				sessions,
+				tokenIssuer,
+				tokenGenerator,
+				sessionTTL,
+			),
+			Logout: app.MustLogoutUseCase(sessions),
+			ConfirmResetPassword: app.MustConfirmResetPasswordUseCase(
+				users,
+				codes,
+				emailValidator,
+				emailOutbox,
+				codeGenerator,
+				app.EnumerationProtection{},
+			),
+			ResetPassword: app.MustResetPasswordUseCase(
+				users,
+				codes,
+				codeGenerator,
+				limiter,
+				sessions,
+				emailValidator,
+				passwordValidator,
+				passwordHasher,
+				app.EnumerationProtection{},
+			),
+			ConfirmNewEmail: app.MustConfirmNewEmailUseCase(
+				users,
+				codes,
+				emailValidator,
+				emailOutbox,
+				codeGenerator,
+			),
+			NewEmail: app.MustNewEmailUseCase(
+				users,
+				codes,
+				codeGenerator,
+				limiter,
+				emailValidator,
+				passwordHasher,
+			),
+			ConfirmNewPassword: app.MustConfirmNewPasswordUseCase(
+				users,
+				codes,
+				emailOutbox,
+				codeGenerator,
+			),
+			NewPassword: app.MustNewPasswordUseCase(
+				users,
+				codes,
+				codeGenerator,
+				limiter,
+				sessions,
+				passwordHasher,
+				passwordValidator,
+				passwordHasher,
+			),
+			ChangeUser:   changeUser,
+			ChangeLocale: app.MustChangeLocaleUseCase(users),
+			GetUser:      app.MustGetUserUseCase(users, policy),
+			ListUsers:    app.MustListUsersUseCase(users, policy),
+			EnrollTwoFactor: app.MustEnrollTwoFactorUseCase(
+				users,
+				limiter,
+				passwordHasher,
+				totpAuth,
+			),
+			EnableTwoFactor: app.MustEnableTwoFactorUseCase(users, limiter, totpAuth),
+			DisableTwoFactor: app.MustDisableTwoFactorUseCase(
+				users,
+				limiter,
+				passwordHasher,
+				totpAuth,
+			),
+		},
+		tokenVerifier,
+		nil,
+		catalog,
+		logger,
+	)
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	serviceTokenHash := sha256.Sum256([]byte(serviceToken))
+	rpcServer := rpc.MustServer(
+		app.MustLookupUserUseCase(users, emailValidator),
+		changeUser,
+		tokenVerifier,
+		[]rpc.ServiceClient{{
+			Name:        "dev",
+			TokenSHA256: hex.EncodeToString(serviceTokenHash[:]),
+			Methods:     []string{"GetUser", "ValidateToken", "ChangeUser"},
+		}},
+		catalog,
+		logger,
+	)
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(rpcServer.UnaryInterceptor()))
+	rpcServer.Register(grpcServer)
+	listener, err := net.Listen("tcp", *grpcAddr)
+	if err != nil {
+		logger.Error("listen grpc", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Error("shutdown http server", "error", err)
+		}
+		grpcServer.GracefulStop()
+		if err := emailDispatcher.Shutdown(shutdownCtx); err != nil {
+			logger.Error("shutdown email dispatcher", "error", err)
+		}
+		if err := codeCleaner.Shutdown(shutdownCtx); err != nil {
+			logger.Error("shutdown code cleaner", "error", err)
+		}
+	}()
+
+	go func() {
+		logger.Info(
+			"dev grpc server started",
+			"addr", *grpcAddr,
+			"service_token_header", "x-service-token",
+			"service_token", serviceToken,
+		)
+		if err := grpcServer.Serve(listener); err != nil {
+			logger.Error("grpc server stopped", "error", err)
+		}
+	}()
+
+	logger.Info("dev server started", "addr", *addr)
+	if err = server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("http server stopped", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/postgres"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const dsnEnv = "DND_USERS_POSTGRES_DSN"
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "outbox: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	dsn := flag.String("dsn", os.Getenv(dsnEnv), "PostgreSQL DSN, defaults to $"+dsnEnv)
+	limit := flag.Int("limit", 100, "maximum number of messages to list or replay")
+	status := flag.String("status", string(app.OutboxDead), "status of listed messages: dead|pending")
+	flag.Usage = func() {
+		fmt.Fprintf(
+			flag.CommandLine.Output(),
+			"usage: outbox [-dsn DSN] [-limit N] [-status STATUS] list|replay ID...|replay-all\n",
+		)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		return fmt.Errorf("expected command")
+	}
+	if *dsn == "" {
+		return fmt.Errorf("dsn is not set, use -dsn or $%s", dsnEnv)
+	}
+	if *limit <= 0 {
+		return fmt.Errorf("limit must be greater than 0")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := pgxpool.New(ctx, *dsn)
+	if err != nil {
+		return fmt.Errorf("connect to postgres: %w", err)
+	}
+	defer pool.Close()
+
+	outbox := postgres.MustEmailOutbox(pool)
+	switch command := flag.Arg(0); command {
+	case "list":
+		if flag.NArg() != 1 {
+			return fmt.Errorf("list does not take arguments")
+		}
+		listStatus := app.OutboxStatus(*status)
+		if listStatus != app.OutboxDead && listStatus != app.OutboxPending {
+			return fmt.Errorf("unknown status %q", *status)
+		}
+		emails, err := outbox.List(ctx, listStatus, *limit)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tKIND\tTO\tATTEMPTS\tCREATED AT\tNEXT ATTEMPT AT\tDEAD AT\tLAST ERROR")
+		for _, email := range emails {
+			fmt.Fprintf(
+				w,
+				"%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+				email.ID,
+				email.Kind,
+				email.To,
+				email.Attempts,
+				email.CreatedAt.Format(time.RFC3339),
+				email.NextAttemptAt.Format(time.RFC3339),
+				deadAt(email),
+				email.LastError,
+			)
+		}
+		return w.Flush()
+	case "replay":
+		if flag.NArg() < 2 {
+			return fmt.Errorf("replay expects at least one message id")
+		}
+		for _, arg := range flag.Args()[1:] {
+			id, err := uuid.Parse(arg)
+			if err != nil {
+				return fmt.Errorf("parse message id %q: %w", arg, err)
+			}
+			if err = outbox.Replay(ctx, id); err != nil {
+				return err
+			}
+			fmt.Printf("replayed %s\n", id)
+		}
+	case "replay-all":
+		if flag.NArg() != 1 {
+			return fmt.Errorf("replay-all does not take arguments")
+		}
+		emails, err := outbox.List(ctx, app.OutboxDead, *limit)
+		if err != nil {
+			return err
+		}
+		if len(emails) == 0 {
+			fmt.Println("no dead messages to replay")
+		}
+		for _, email := range emails {
+			if email.Kind.HasCode() {
+				fmt.Printf(
+					"skipped %s: its code was discarded, the user must request a new one\n",
+					email.ID,
+				)
+				continue
+			}
+			if err = outbox.Replay(ctx, email.ID); err != nil {
+				return err
+			}
+			fmt.Printf("replayed %s\n", email.ID)
+		}
+	default:
+		flag.Usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+	return nil
+}
+
+func deadAt(email app.OutboxEmail) string {
+	if email.DeadAt.IsZero() {
+		return "-"
+	}
+	return email.DeadAt.Format(time.RFC3339)
+}
@@ -0,0 +1,605 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/config"
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/Nemagu/dnd_users/internal/i18n"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/background"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/email"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/memory"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/password"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/postgres"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/random"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/ratelimit"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/redis"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/smtp"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/token"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/totp"
+	"github.com/Nemagu/dnd_users/internal/transport/rest"
+	"github.com/Nemagu/dnd_users/internal/transport/rpc"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const configEnv = "DND_USERS_CONFIG"
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	if err := run(logger); err != nil {
+		logger.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(logger *slog.Logger) error {
+	configPath := flag.String(
+		"config",
+		os.Getenv(configEnv),
+		"path to JSON config file, defaults to $"+configEnv,
+	)
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath, os.Getenv)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	keys, err := loadKeys(cfg.Token)
+	if err != nil {
+		return fmt.Errorf("load token keys: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := connect(ctx, cfg.Postgres.DSN)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	tokenIssuer := token.MustIssuer(keys, cfg.Token.Issuer, time.Duration(cfg.Token.AccessTTL))
+	catalog := i18n.MustDefaultCatalog()
+	tokenVerifier := token.MustVerifier(keys, cfg.Token.Issuer)
+	sessionTTL := time.Duration(cfg.Token.SessionTTL)
+
+	users := postgres.MustUserRepository(pool)
+	sessions := postgres.MustSessionRepository(pool)
+	redisClient, err := connectRedis(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if redisClient != nil {
+		defer redisClient.Close()
+	}
+	codes := newCodeStore(cfg, pool, redisClient)
+
+	breached, err := loadBreachedList(cfg.Password.BreachedListFile)
+	if err != nil {
+		return fmt.Errorf("load breached passwords: %w", err)
+	}
+	if breached.Len() == 0 {
+		logger.Warn("breached password list is empty")
+	}
+
+	disposable, err := loadDisposableDomains(cfg.Email.DisposableDomainsFile)
+	if err != nil {
+		return fmt.Errorf("load disposable domains: %w", err)
+	}
+	emailValidator := email.MustValidator(
+		email.Options{
+			LowercaseLocalPart: cfg.Email.LowercaseLocalPart,
+			StripPlusTag:       cfg.Email.StripPlusTag,
+		},
+		disposable,
+	)
+	passwordValidator := password.MustPolicyValidator(
+		password.Policy{
+			MinLength:            cfg.Password.MinLength,
+			MaxLength:            cfg.Password.MaxLength,
+			RequireLower:         cfg.Password.RequireLower,
+			RequireUpper:         cfg.Password.RequireUpper,
+			RequireDigit:         cfg.Password.RequireDigit,
+			RequireSymbol:        cfg.Password.RequireSymbol,
+			ForbidEmailLocalPart: cfg.Password.ForbidEmailLocalPart,
+		},
+		breached,
+	)
+	passwordHasher := password.MustArgon2idHasher(
+		password.Argon2idParams{
+			Memory:      uint32(cfg.Password.Argon2MemoryKiB),
+			Iterations:  uint32(cfg.Password.Argon2Iterations),
+			Parallelism: uint8(cfg.Password.Argon2Parallelism),
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+		password.MustBcryptComparer(),
+	)
+	tokenGenerator := random.MustTokenGenerator()
+	templates, err := loadEmailTemplates(cfg.SMTP.TemplatesDir, catalog)
+	if err != nil {
+		return fmt.Errorf("load email templates: %w", err)
+	}
+	emailOutbox := postgres.MustEmailOutbox(pool)
+	emailDispatcher := background.MustEmailDispatcher(
+		emailOutbox,
+		smtp.MustProvider(
+			smtp.Config{
+				Host:     cfg.SMTP.Host,
+				Port:     cfg.SMTP.Port,
+				Username: cfg.SMTP.Username,
+				Password: cfg.SMTP.Password,
+				From:     cfg.SMTP.From,
+				Security: smtp.Security(cfg.SMTP.Security),
+				Timeout:  time.Duration(cfg.SMTP.Timeout),
+			},
+			templates,
+		),
+		background.DispatcherConfig{
+			PollInterval:  time.Duration(cfg.Outbox.PollInterval),
+			BatchSize:     cfg.Outbox.BatchSize,
+			MaxAttempts:   cfg.Outbox.MaxAttempts,
+			BaseBackoff:   time.Duration(cfg.Outbox.BaseBackoff),
+			MaxBackoff:    time.Duration(cfg.Outbox.MaxBackoff),
+			Lease:         time.Duration(cfg.Outbox.Lease),
+			DeadRetention: time.Duration(cfg.Outbox.DeadRetention),
+		},
+		logger,
+	)
+	codeCleaner := background.MustCodeCleaner(
+		codes,
+		time.Duration(cfg.Codes.CleanupInterval),
+		logger,
+	)
+	policy := domain.MustPolicyService()
+	limiter := newRateLimiter(cfg.RateLimit, redisClient)
+	if cfg.RateLimit.Store == config.RateLimitStoreMemory {
+		logger.Warn("rate limiter keeps state in process memory, run a single instance or use redis")
+	}
+	enumeration := app.EnumerationProtection{
+		Enabled:     cfg.Enumeration.Protection,
+		MinDuration: time.Duration(cfg.Enumeration.MinResponseTime),
+	}
+	totpAuth := totp.MustAuthenticator(totp.Config{
+		Issuer: cfg.TwoFactor.Issuer,
+		Digits: cfg.TwoFactor.Digits,
+		Period: time.Duration(cfg.TwoFactor.Period),
+		Skew:   cfg.TwoFactor.Skew,
+	})
+	changeUser := app.MustChangeUserUseCase(
+		users,
+		sessions,
+		emailValidator,
+		passwordValidator,
+		passwordHasher,
+		policy,
+	)
+
+	confirmEmailCodes := random.MustCodeGenerator(cfg.Codes.ConfirmEmailProfile.Random())
+	resetPasswordCodes := random.MustCodeGenerator(cfg.Codes.ResetPasswordProfile.Random())
+	newEmailCodes := random.MustCodeGenerator(cfg.Codes.NewEmailProfile.Random())
+	newPasswordCodes := random.MustCodeGenerator(cfg.Codes.NewPasswordProfile.Random())
+	trustedProxies, err := cfg.HTTP.TrustedProxyPrefixes()
+	if err != nil {
+		return fmt.Errorf("parse trusted proxies: %w", err)
+	}
+	handler := rest.MustHandler(
+		rest.UseCases{
+			ConfirmEmail: app.MustConfirmEmailUseCase(
+				users,
+				codes,
+				emailValidator,
+				emailOutbox,
+				confirmEmailCodes,
+				enumeration,
+			),
+			Registration: app.MustRegistrationUseCase(
+				users,
+				codes,
+				confirmEmailCodes,
+				limiter,
+				emailValidator,
+				passwordValidator,
+				passwordHasher,
+			),
+			Login: app.MustLoginUseCase(
+				users,
+				sessions,
+				limiter,
+				emailValidator,
+				passwordHasher,
+				passwordHasher,
+				passwordHasher,
+				totpAuth,
+				tokenIssuer,
+				tokenGenerator,
+				sessionTTL,
+			),
+			Refresh: app.MustRefreshUseCase(
+				users,
+				sessions,
+				tokenIssuer,
+				tokenGenerator,
+				sessionTTL,
+			),
+			Logout: app.MustLogoutUseCase(sessions),
+			ConfirmResetPassword: app.MustConfirmResetPasswordUseCase(
+				users,
+				codes,
+				emailValidator,
+				emailOutbox,
+				resetPasswordCodes,
+				enumeration,
+			),
+			ResetPassword: app.MustResetPasswordUseCase(
+				users,
+				codes,
+				resetPasswordCodes,
+				limiter,
+				sessions,
+				emailValidator,
+				passwordValidator,
+				passwordHasher,
+				enumeration,
+			),
+			ConfirmNewEmail: app.MustConfirmNewEmailUseCase(
+				users,
+				codes,
+				emailValidator,
+				emailOutbox,
+				newEmailCodes,
+			),
+			NewEmail: app.MustNewEmailUseCase(
+				users,
+				codes,
+				newEmailCodes,
+				limiter,
+				emailValidator,
+				passwordHasher,
+			),
+			ConfirmNewPassword: app.MustConfirmNewPasswordUseCase(
+				users,
+				codes,
+				emailOutbox,
+				newPasswordCodes,
+			),
+			NewPassword: app.MustNewPasswordUseCase(
+				users,
+				codes,
+				newPasswordCodes,
+				limiter,
+				sessions,
+				passwordHasher,
+				passwordValidator,
+				passwordHasher,
+			),
+			ChangeUser:   changeUser,
+			ChangeLocale: app.MustChangeLocaleUseCase(users),
+			GetUser:      app.MustGetUserUseCase(users, policy),
+			ListUsers:    app.MustListUsersUseCase(users, policy),
+			EnrollTwoFactor: app.MustEnrollTwoFactorUseCase(
+				users,
+				limiter,
+				passwordHasher,
+				totpAuth,
+			),
+			EnableTwoFactor: app.MustEnableTwoFactorUseCase(users, limiter, totpAuth),
+			DisableTwoFactor: app.MustDisableTwoFactorUseCase(
+				users,
+				limiter,
+				passwordHasher,
+				totpAuth,
+			),
+		},
+		tokenVerifier,
+		trustedProxies,
+		catalog,
+		logger,
+	)
+
+	httpServer := &http.Server{
+		Addr:              cfg.HTTP.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: time.Duration(cfg.HTTP.ReadHeaderTimeout),
+	}
+	grpcCreds, err := loadGRPCCredentials(cfg.GRPC)
+	if err != nil {
+		return fmt.Errorf("load grpc credentials: %w", err)
+	}
+	rpcServer := rpc.MustServer(
+		app.MustLookupUserUseCase(users, emailValidator),
+		changeUser,
+		tokenVerifier,
+		serviceClients(cfg.GRPC.Clients),
+		catalog,
+		logger,
+	)
+	grpcServer := grpc.NewServer(
+		grpc.Creds(grpcCreds),
+		grpc.UnaryInterceptor(rpcServer.UnaryInterceptor()),
+	)
+	rpcServer.Register(grpcServer)
+
+	httpListener, err := net.Listen("tcp", cfg.HTTP.Addr)
+	if err != nil {
+		return fmt.Errorf("listen http: %w", err)
+	}
+	grpcListener, err := net.Listen("tcp", cfg.GRPC.Addr)
+	if err != nil {
+		httpListener.Close()
+		return fmt.Errorf("listen grpc: %w", err)
+	}
+
+	emailDispatcher.Start()
+	codeCleaner.Start()
+	serveErr := make(chan error, 2)
+	go func() {
+		logger.Info("http server started", "addr", cfg.HTTP.Addr)
+		if err := httpServer.Serve(httpListener); !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- fmt.Errorf("http server: %w", err)
+		}
+	}()
+	go func() {
+		logger.Info("grpc server started", "addr", cfg.GRPC.Addr)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			serveErr <- fmt.Errorf("grpc server: %w", err)
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		logger.Info("shutdown signal received")
+	case err = <-serveErr:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(
+		context.Background(),
+		time.Duration(cfg.ShutdownTimeout),
+	)
+	defer cancel()
+	return errors.Join(
+		err,
+		shutdown(shutdownCtx, httpServer, grpcServer, emailDispatcher, codeCleaner),
+	)
+}
+
+func shutdown(
+	ctx context.Context,
+	httpServer *http.Server,
+	grpcServer *grpc.Server,
+	emailDispatcher *background.EmailDispatcher,
+	codeCleaner *background.CodeCleaner,
+) error {
+	var errs []error
+	if err := httpServer.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("shutdown http server: %w", err))
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+		errs = append(errs, fmt.Errorf("shutdown grpc server: %w", ctx.Err()))
+	}
+
+	if err := emailDispatcher.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("shutdown email dispatcher: %w", err))
+	}
+	if err := codeCleaner.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("shutdown code cleaner: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+func connect(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("connect to postgres: %w", err)
+	}
+	if err = pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("ping postgres: %w", err)
+	}
+	statuses, err := postgres.MustMigrator(pool).Status(ctx)
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("check migrations: %w", err)
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			pool.Close()
+			return nil, fmt.Errorf(
+				"migration %04d_%s is not applied, run migrate up",
+				status.Version,
+				status.Name,
+			)
+		}
+	}
+	return pool, nil
+}
+
+type codeStore interface {
+	SetConfirmEmail(ctx context.Context, key, code string) error
+	ConsumeConfirmEmail(ctx context.Context, key, code string) error
+	SetNewEmail(ctx context.Context, key, code string) error
+	ConsumeNewEmail(ctx context.Context, key, code string) error
+	SetResetPassword(ctx context.Context, key, code string) error
+	ConsumeResetPassword(ctx context.Context, key, code string) error
+	SetNewPassword(ctx context.Context, key, code string) error
+	ConsumeNewPassword(ctx context.Context, key, code string) error
+	PurgeExpired(ctx context.Context) (int, error)
+}
+
+func connectRedis(ctx context.Context, cfg *config.Config) (*redis.Client, error) {
+	if cfg.Codes.Store != config.CodeStoreRedis && cfg.RateLimit.Store != config.RateLimitStoreRedis {
+		return nil, nil
+	}
+	client := redis.MustClient(redis.Config{
+		Addr:     cfg.Redis.Addr,
+		Username: cfg.Redis.Username,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	if err := client.Ping(ctx); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("ping redis: %w", err)
+	}
+	return client, nil
+}
+
+func newCodeStore(cfg *config.Config, pool *pgxpool.Pool, client *redis.Client) codeStore {
+	if cfg.Codes.Store == config.CodeStorePostgres {
+		return postgres.MustCodeStore(pool, postgres.CodeTTL{
+			ConfirmEmail:  time.Duration(cfg.Codes.ConfirmEmail),
+			NewEmail:      time.Duration(cfg.Codes.NewEmail),
+			ResetPassword: time.Duration(cfg.Codes.ResetPassword),
+			NewPassword:   time.Duration(cfg.Codes.NewPassword),
+		}, cfg.Codes.MaxAttempts)
+	}
+	return redis.MustCodeStore(client, cfg.Redis.KeyPrefix, redis.CodeTTL{
+		ConfirmEmail:  time.Duration(cfg.Codes.ConfirmEmail),
+		NewEmail:      time.Duration(cfg.Codes.NewEmail),
+		ResetPassword: time.Duration(cfg.Codes.ResetPassword),
+		NewPassword:   time.Duration(cfg.Codes.NewPassword),
+	}, cfg.Codes.MaxAttempts)
+}
+
+type rateLimiter interface {
+	Allow(ctx context.Context, keys ...string) error
+	Fail(ctx context.Context, keys ...string) error
+	Reset(ctx context.Context, keys ...string) error
+}
+
+func newRateLimiter(cfg config.RateLimit, client *redis.Client) rateLimiter {
+	if cfg.Store == config.RateLimitStoreRedis {
+		return redis.MustRateLimiter(client, cfg.KeyPrefix, ratelimit.Config{
+			Window:      time.Duration(cfg.Window),
+			MaxAttempts: cfg.MaxAttempts,
+			MaxFailures: cfg.MaxFailures,
+			Lockout:     time.Duration(cfg.Lockout),
+			MaxLockout:  time.Duration(cfg.MaxLockout),
+		})
+	}
+	return memory.MustRateLimiter(ratelimit.Config{
+		Window:      time.Duration(cfg.Window),
+		MaxAttempts: cfg.MaxAttempts,
+		MaxFailures: cfg.MaxFailures,
+		Lockout:     time.Duration(cfg.Lockout),
+		MaxLockout:  time.Duration(cfg.MaxLockout),
+	})
+}
+
+func loadKeys(cfg config.Token) (*token.KeySet, error) {
+	keys := make([]token.Key, 0, len(cfg.Keys))
+	for _, k := range cfg.Keys {
+		var (
+			key token.Key
+			err error
+		)
+		if k.PrivateKeyFile != "" {
+			key, err = loadKey(k.ID, k.PrivateKeyFile, token.ParsePrivateKey)
+		} else {
+			key, err = loadKey(k.ID, k.PublicKeyFile, token.ParsePublicKey)
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return token.MustKeySet(cfg.ActiveKeyID, keys...), nil
+}
+
+func loadKey(id, path string, parse func(string, []byte) (token.Key, error)) (token.Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return token.Key{}, fmt.Errorf("read key %s: %w", id, err)
+	}
+	return parse(id, data)
+}
+
+func loadGRPCCredentials(cfg config.GRPC) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.ClientCAFile != "" {
+		data, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates in %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+func serviceClients(clients []config.GRPCClient) []rpc.ServiceClient {
+	result := make([]rpc.ServiceClient, 0, len(clients))
+	for _, client := range clients {
+		result = append(result, rpc.ServiceClient{
+			Name:        client.Name,
+			TokenSHA256: client.TokenSHA256,
+			Methods:     client.Methods,
+		})
+	}
+	return result
+}
+
+func loadBreachedList(path string) (*password.BreachedList, error) {
+	if path == "" {
+		return password.MustBreachedList(), nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return password.LoadBreachedList(file)
+}
+
+func loadDisposableDomains(path string) (*email.DomainList, error) {
+	if path == "" {
+		return email.MustDomainList(), nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return email.LoadDomainList(file)
+}
+
+func loadEmailTemplates(dir string, catalog *i18n.Catalog) (*smtp.Templates, error) {
+	if dir == "" {
+		return smtp.MustDefaultTemplates(catalog), nil
+	}
+	return smtp.LoadTemplates(os.DirFS(dir), catalog)
+}
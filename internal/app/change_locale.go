@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type ChangeLocaleUseCase struct {
+	repo changeLocaleRepository
+}
+
+type ChangeLocaleCommand struct {
+	InitiatorID uuid.UUID
+	UserID      uuid.UUID
+	Locale      string
+}
+
+type changeLocaleRepository interface {
+	ByID(ctx context.Context, id uuid.UUID) (*User, error)
+	Save(ctx context.Context, user *User) error
+}
+
+func MustChangeLocaleUseCase(repo changeLocaleRepository) *ChangeLocaleUseCase {
+	if repo == nil {
+		panic("change locale use case did not get user repository")
+	}
+	return &ChangeLocaleUseCase{repo: repo}
+}
+
+func (u *ChangeLocaleUseCase) Execute(ctx context.Context, command *ChangeLocaleCommand) error {
+	if command.InitiatorID != command.UserID {
+		return accessDenied("вы не можете изменять язык другим пользователям")
+	}
+
+	locale, err := domainLocale(command.Locale)
+	if err != nil {
+		return err
+	}
+
+	appUser, err := u.repo.ByID(ctx, command.UserID)
+	if err != nil {
+		return err
+	}
+
+	domainUser, err := domainUser(appUser)
+	if err != nil {
+		return err
+	}
+	if err = domainUser.NewLocale(locale); err != nil {
+		return handleDomainError(err)
+	}
+
+	newAppUser, err := modifiedUser(domainUser)
+	if err != nil {
+		return err
+	}
+	return u.repo.Save(ctx, newAppUser)
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+type mockChangeLocaleRepository struct {
+	User    *User
+	Saved   *User
+	ErrByID error
+	ErrSave error
+}
+
+func (m *mockChangeLocaleRepository) ByID(ctx context.Context, id uuid.UUID) (*User, error) {
+	return m.User, m.ErrByID
+}
+
+func (m *mockChangeLocaleRepository) Save(ctx context.Context, user *User) error {
+	m.Saved = user
+	return m.ErrSave
+}
+
+func TestChangeLocaleUseCase_Execute(t *testing.T) {
+	user := &User{
+		ID:           uuid.New(),
+		Email:        "test@mail.com",
+		State:        domain.ACTIVE,
+		Status:       domain.USER,
+		Locale:       domain.RU,
+		PasswordHash: "hash",
+		Version:      1,
+	}
+	frozenUser := *user
+	frozenUser.State = domain.FROZEN
+	cases := []struct {
+		TestName string
+		Expected error
+		Repo     *mockChangeLocaleRepository
+		Command  *ChangeLocaleCommand
+	}{
+		{
+			TestName: "test_change_locale_use_case_ok",
+			Expected: nil,
+			Repo:     &mockChangeLocaleRepository{User: user},
+			Command: &ChangeLocaleCommand{
+				InitiatorID: user.ID,
+				UserID:      user.ID,
+				Locale:      domain.EN,
+			},
+		},
+		{
+			TestName: "test_change_locale_use_case_other_user",
+			Expected: ErrNotAllowed,
+			Repo:     &mockChangeLocaleRepository{User: user},
+			Command: &ChangeLocaleCommand{
+				InitiatorID: uuid.New(),
+				UserID:      user.ID,
+				Locale:      domain.EN,
+			},
+		},
+		{
+			TestName: "test_change_locale_use_case_unsupported_locale",
+			Expected: ErrInvalidData,
+			Repo:     &mockChangeLocaleRepository{User: user},
+			Command: &ChangeLocaleCommand{
+				InitiatorID: user.ID,
+				UserID:      user.ID,
+				Locale:      "de",
+			},
+		},
+		{
+			TestName: "test_change_locale_use_case_same_locale",
+			Expected: ErrIdempotent,
+			Repo:     &mockChangeLocaleRepository{User: user},
+			Command: &ChangeLocaleCommand{
+				InitiatorID: user.ID,
+				UserID:      user.ID,
+				Locale:      domain.RU,
+			},
+		},
+		{
+			TestName: "test_change_locale_use_case_not_active",
+			Expected: ErrUserNotActive,
+			Repo:     &mockChangeLocaleRepository{User: &frozenUser},
+			Command: &ChangeLocaleCommand{
+				InitiatorID: user.ID,
+				UserID:      user.ID,
+				Locale:      domain.EN,
+			},
+		},
+		{
+			TestName: "test_change_locale_use_case_save_error",
+			Expected: ErrInternal,
+			Repo: &mockChangeLocaleRepository{
+				User:    user,
+				ErrSave: fmt.Errorf("%w: internal error", ErrInternal),
+			},
+			Command: &ChangeLocaleCommand{
+				InitiatorID: user.ID,
+				UserID:      user.ID,
+				Locale:      domain.EN,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := MustChangeLocaleUseCase(c.Repo).Execute(context.Background(), c.Command)
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected nil, but got %v", err)
+				}
+				if c.Repo.Saved == nil || c.Repo.Saved.Locale != c.Command.Locale ||
+					c.Repo.Saved.Version != user.Version+1 {
+					t.Errorf("expected user with new locale to be saved, but got %v", c.Repo.Saved)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+}
@@ -2,7 +2,6 @@ package app
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/Nemagu/dnd_users/internal/domain"
 	"github.com/google/uuid"
@@ -10,6 +9,7 @@ import (
 
 type ChangeUserUseCase struct {
 	repo              changeUserRepository
+	sessions          sessionRevoker
 	emailValidator    emailValidator
 	passwordValidator passwordValidator
 	passwordHasher    passwordHasher
@@ -22,6 +22,7 @@ type ChangeUserCommand struct {
 	Email       string
 	State       string
 	Status      string
+	Locale      string
 	Password    string
 }
 
@@ -34,6 +35,7 @@ type changeUserRepository interface {
 
 func MustChangeUserUseCase(
 	repo changeUserRepository,
+	sessions sessionRevoker,
 	emailValidator emailValidator,
 	passwordValidator passwordValidator,
 	passwordHasher passwordHasher,
@@ -42,6 +44,9 @@ func MustChangeUserUseCase(
 	if repo == nil {
 		panic("change user use case did not get user repository")
 	}
+	if sessions == nil {
+		panic("change user use case did not get session revoker")
+	}
 	if emailValidator == nil {
 		panic("change user use case did not get email validator")
 	}
@@ -56,6 +61,7 @@ func MustChangeUserUseCase(
 	}
 	return &ChangeUserUseCase{
 		repo:              repo,
+		sessions:          sessions,
 		emailValidator:    emailValidator,
 		passwordValidator: passwordValidator,
 		passwordHasher:    passwordHasher,
@@ -69,7 +75,7 @@ func (u *ChangeUserUseCase) Execute(ctx context.Context, command *ChangeUserComm
 		return err
 	}
 	if !exists {
-		return fmt.Errorf("%w: пользователь с id %s не найден", ErrNotFound, command.InitiatorID)
+		return userNotFound(command.InitiatorID)
 	}
 	initiator, err := u.repo.ByID(ctx, command.InitiatorID)
 	if err != nil {
@@ -81,7 +87,7 @@ func (u *ChangeUserUseCase) Execute(ctx context.Context, command *ChangeUserComm
 		return err
 	}
 	if !u.policy.CanEditOthers(domainInitiator) {
-		return fmt.Errorf("%w: вы не можете редактировать других пользователей", ErrNotAllowed)
+		return accessDenied("вы не можете редактировать других пользователей")
 	}
 
 	exists, err = u.repo.IDExists(ctx, command.UserID)
@@ -89,7 +95,7 @@ func (u *ChangeUserUseCase) Execute(ctx context.Context, command *ChangeUserComm
 		return err
 	}
 	if !exists {
-		return fmt.Errorf("%w: пользователь с id %s не найден", ErrNotFound, command.UserID)
+		return userNotFound(command.UserID)
 	}
 
 	user, err := u.repo.ByID(ctx, command.UserID)
@@ -102,19 +108,20 @@ func (u *ChangeUserUseCase) Execute(ctx context.Context, command *ChangeUserComm
 		return err
 	}
 	if command.Email != "" {
-		if err = u.emailValidator.Validate(command.Email); err != nil {
+		email, err := u.emailValidator.Validate(command.Email)
+		if err != nil {
 			return err
 		}
-		exists, err := u.repo.EmailExists(ctx, command.Email)
+		exists, err := u.repo.EmailExists(ctx, email)
 		if err != nil {
 			return err
 		}
 		if exists {
-			if command.Email != domainUser.Email() {
-				return fmt.Errorf("%w: email %s уже существует", ErrInvalidData, command.Email)
+			if email != domainUser.Email() {
+				return emailTaken(ErrInvalidData, "email", email)
 			}
 		}
-		if err = domainUser.NewEmail(command.Email); err != nil {
+		if err = domainUser.NewEmail(email); err != nil {
 			return handleDomainError(err)
 		}
 	}
@@ -139,6 +146,16 @@ func (u *ChangeUserUseCase) Execute(ctx context.Context, command *ChangeUserComm
 		}
 	}
 
+	if command.Locale != "" {
+		locale, err := domainLocale(command.Locale)
+		if err != nil {
+			return err
+		}
+		if err = domainUser.NewLocale(locale); err != nil {
+			return handleDomainError(err)
+		}
+	}
+
 	if command.Password != "" {
 		if err = u.passwordValidator.Validate(command.Password, domainUser.Email()); err != nil {
 			return err
@@ -159,6 +176,11 @@ func (u *ChangeUserUseCase) Execute(ctx context.Context, command *ChangeUserComm
 	if err = u.repo.Save(ctx, user); err != nil {
 		return err
 	}
+	if command.Password != "" {
+		if err = u.sessions.RevokeUser(ctx, user.ID); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
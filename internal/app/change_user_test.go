@@ -52,6 +52,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 		Email:        "test@example.com",
 		State:        domain.ACTIVE,
 		Status:       domain.ADMIN,
+		Locale:       domain.RU,
 		PasswordHash: "password_hash",
 		Version:      3,
 	}
@@ -60,6 +61,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 		Email:        "test@example.com",
 		State:        domain.ACTIVE,
 		Status:       domain.USER,
+		Locale:       domain.RU,
 		PasswordHash: "password_hash",
 		Version:      3,
 	}
@@ -68,6 +70,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 		Email:        "test@example.com",
 		State:        domain.FROZEN,
 		Status:       domain.ADMIN,
+		Locale:       domain.RU,
 		PasswordHash: "password_hash",
 		Version:      3,
 	}
@@ -87,6 +90,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 					InitiatorID:   adminUser.ID,
 					UserID:        ordinaryUser.ID,
 				},
+				&mockSessionRevoker{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -97,6 +101,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 				UserID:      ordinaryUser.ID,
 				Email:       "new_email@example.com",
 				Status:      domain.ADMIN,
+				Locale:      domain.EN,
 				Password:    "new_password",
 			},
 		},
@@ -110,6 +115,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 					InitiatorID:   adminUser.ID,
 					UserID:        ordinaryUser.ID,
 				},
+				&mockSessionRevoker{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -121,6 +127,28 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 				State:       domain.FROZEN,
 			},
 		},
+		{
+			TestName: "test_change_user_use_case_unsupported_locale",
+			Expected: ErrInvalidData,
+			UC: MustChangeUserUseCase(
+				&mockChangeUserRepository{
+					InitiatorUser: adminUser,
+					User:          ordinaryUser,
+					InitiatorID:   adminUser.ID,
+					UserID:        ordinaryUser.ID,
+				},
+				&mockSessionRevoker{},
+				&mockEmailValidator{},
+				&mockPasswordValidator{},
+				&mockPasswordHasher{},
+				domain.MustPolicyService(),
+			),
+			Command: &ChangeUserCommand{
+				InitiatorID: adminUser.ID,
+				UserID:      ordinaryUser.ID,
+				Locale:      "de",
+			},
+		},
 		{
 			TestName: "test_change_user_use_case_not_admin",
 			Expected: ErrNotAllowed,
@@ -131,6 +159,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 					InitiatorID:   ordinaryUser.ID,
 					UserID:        ordinaryUser.ID,
 				},
+				&mockSessionRevoker{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -152,6 +181,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 					InitiatorID:   adminUser.ID,
 					UserID:        notActiveUser.ID,
 				},
+				&mockSessionRevoker{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -174,6 +204,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 					UserID:        ordinaryUser.ID,
 					ExistsEmails:  []string{"new_email@example.com"},
 				},
+				&mockSessionRevoker{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -196,6 +227,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 					UserID:        ordinaryUser.ID,
 					NotExistsIDs:  []uuid.UUID{ordinaryUser.ID},
 				},
+				&mockSessionRevoker{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -218,6 +250,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 					UserID:        ordinaryUser.ID,
 					ErrEmail:      ErrInternal,
 				},
+				&mockSessionRevoker{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -240,6 +273,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 					UserID:        ordinaryUser.ID,
 					ErrID:         ErrInternal,
 				},
+				&mockSessionRevoker{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -262,6 +296,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 					UserID:        ordinaryUser.ID,
 					ErrSave:       ErrInternal,
 				},
+				&mockSessionRevoker{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -284,6 +319,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 					UserID:        ordinaryUser.ID,
 					ErrByID:       ErrInternal,
 				},
+				&mockSessionRevoker{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -305,6 +341,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 					InitiatorID:   adminUser.ID,
 					UserID:        ordinaryUser.ID,
 				},
+				&mockSessionRevoker{},
 				&mockEmailValidator{
 					InvalidEmails: []string{"new_email@example.com"},
 				},
@@ -328,6 +365,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 					InitiatorID:   adminUser.ID,
 					UserID:        ordinaryUser.ID,
 				},
+				&mockSessionRevoker{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{
@@ -352,6 +390,7 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 					InitiatorID:   adminUser.ID,
 					UserID:        ordinaryUser.ID,
 				},
+				&mockSessionRevoker{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{
 					InvalidPasswords: []string{"new_password"},
@@ -381,3 +420,82 @@ func TestChangeUserUseCase_Execute(t *testing.T) {
 		})
 	}
 }
+
+func TestChangeUserUseCase_RevokesSessions(t *testing.T) {
+	admin := &User{
+		ID:           uuid.New(),
+		Email:        "admin@example.com",
+		State:        domain.ACTIVE,
+		Status:       domain.ADMIN,
+		Locale:       domain.RU,
+		PasswordHash: "password_hash",
+		Version:      1,
+	}
+	cases := []struct {
+		TestName string
+		Expected error
+		Revoked  bool
+		Revoker  *mockSessionRevoker
+		Command  ChangeUserCommand
+	}{
+		{
+			TestName: "test_change_user_password_revokes_sessions",
+			Expected: nil,
+			Revoked:  true,
+			Revoker:  &mockSessionRevoker{},
+			Command:  ChangeUserCommand{Password: "new_password"},
+		},
+		{
+			TestName: "test_change_user_without_password_keeps_sessions",
+			Expected: nil,
+			Revoked:  false,
+			Revoker:  &mockSessionRevoker{},
+			Command:  ChangeUserCommand{Locale: domain.EN},
+		},
+		{
+			TestName: "test_change_user_revoke_error",
+			Expected: ErrInternal,
+			Revoked:  true,
+			Revoker:  &mockSessionRevoker{Err: ErrInternal},
+			Command:  ChangeUserCommand{Password: "new_password"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			user := &User{
+				ID:           uuid.New(),
+				Email:        "user@example.com",
+				State:        domain.ACTIVE,
+				Status:       domain.USER,
+				Locale:       domain.RU,
+				PasswordHash: "password_hash",
+				Version:      1,
+			}
+			uc := MustChangeUserUseCase(
+				&mockChangeUserRepository{
+					InitiatorUser: admin,
+					User:          user,
+					InitiatorID:   admin.ID,
+					UserID:        user.ID,
+				},
+				c.Revoker,
+				&mockEmailValidator{},
+				&mockPasswordValidator{},
+				&mockPasswordHasher{},
+				domain.MustPolicyService(),
+			)
+			c.Command.InitiatorID, c.Command.UserID = admin.ID, user.ID
+			err := uc.Execute(context.Background(), &c.Command)
+			if c.Expected == nil && err != nil {
+				t.Errorf("expected nil, but got %v", err)
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, err)
+			}
+			revoked := slices.Equal(c.Revoker.Revoked, []uuid.UUID{user.ID})
+			if revoked != c.Revoked {
+				t.Errorf("expected revoked %v, but got %v", c.Revoked, c.Revoker.Revoked)
+			}
+		})
+	}
+}
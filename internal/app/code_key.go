@@ -0,0 +1,45 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+type codeFlow string
+
+const (
+	confirmEmailFlow  codeFlow = "confirm_email"
+	newEmailFlow      codeFlow = "new_email"
+	resetPasswordFlow codeFlow = "reset_password"
+	newPasswordFlow   codeFlow = "new_password"
+)
+
+func confirmEmailKey(email string) string {
+	return codeKey(confirmEmailFlow, email)
+}
+
+func newEmailKey(userID uuid.UUID, newEmail string) string {
+	return codeKey(newEmailFlow, userID.String(), newEmail)
+}
+
+func resetPasswordKey(email string) string {
+	return codeKey(resetPasswordFlow, email)
+}
+
+func newPasswordKey(userID uuid.UUID, email string) string {
+	return codeKey(newPasswordFlow, userID.String(), email)
+}
+
+// codeKey derives the key code stores are addressed by, their methods take
+// this key rather than the raw email.
+func codeKey(flow codeFlow, parts ...string) string {
+	h := sha256.New()
+	h.Write([]byte(flow))
+	for _, part := range parts {
+		h.Write([]byte(":" + strconv.Itoa(len(part)) + ":" + part))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
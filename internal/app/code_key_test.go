@@ -0,0 +1,37 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCodeKey(t *testing.T) {
+	id := uuid.New()
+	email := "test@mail.com"
+	keys := map[string]string{
+		"confirm_email":  confirmEmailKey(email),
+		"reset_password": resetPasswordKey(email),
+		"new_email":      newEmailKey(id, email),
+		"new_password":   newPasswordKey(id, email),
+		"split_left":     codeKey(confirmEmailFlow, "ab", "c"),
+		"split_right":    codeKey(confirmEmailFlow, "a", "bc"),
+	}
+	seen := make(map[string]string, len(keys))
+	for name, key := range keys {
+		if other, ok := seen[key]; ok {
+			t.Errorf("expected %s and %s to have different keys", name, other)
+		}
+		seen[key] = name
+		if strings.Contains(key, email) || strings.Contains(key, id.String()) {
+			t.Errorf("expected %s key not to contain key material, but got %s", name, key)
+		}
+		if len(key) != 64 {
+			t.Errorf("expected %s key to be sha256 hex, but got %s", name, key)
+		}
+	}
+	if confirmEmailKey(email) != confirmEmailKey(email) {
+		t.Error("expected key derivation to be deterministic")
+	}
+}
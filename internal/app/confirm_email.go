@@ -2,11 +2,12 @@ package app
 
 import (
 	"context"
-	"fmt"
+	"time"
 )
 
 type ConfirmEmailCommand struct {
-	Email string
+	Email  string
+	Locale string
 }
 
 type ConfirmEmailUseCase struct {
@@ -15,6 +16,7 @@ type ConfirmEmailUseCase struct {
 	validator     emailValidator
 	emailProvider confirmEmailProvider
 	codeGenerator codeGenerator
+	enumeration   EnumerationProtection
 }
 
 type confirmEmailRepository interface {
@@ -22,11 +24,12 @@ type confirmEmailRepository interface {
 }
 
 type confirmEmailCodeStore interface {
-	SetConfirmEmail(ctx context.Context, key, value string) error
+	SetConfirmEmail(ctx context.Context, key, code string) error
 }
 
 type confirmEmailProvider interface {
-	SendConfirmationEmail(data EmailCode)
+	SendConfirmationEmail(ctx context.Context, data EmailCode) error
+	SendRegistrationAttemptEmail(ctx context.Context, data EmailCode) error
 }
 
 func MustConfirmEmailUseCase(
@@ -35,6 +38,7 @@ func MustConfirmEmailUseCase(
 	validator emailValidator,
 	emailProvider confirmEmailProvider,
 	codeGenerator codeGenerator,
+	enumeration EnumerationProtection,
 ) *ConfirmEmailUseCase {
 	if repo == nil {
 		panic("confirm email use case did not get user repository")
@@ -51,34 +55,54 @@ func MustConfirmEmailUseCase(
 	if codeGenerator == nil {
 		panic("confirm email use case did not get code generator")
 	}
+	if enumeration.MinDuration < 0 {
+		panic("confirm email use case got negative enumeration min duration")
+	}
 	return &ConfirmEmailUseCase{
 		repo:          repo,
 		store:         store,
 		validator:     validator,
 		emailProvider: emailProvider,
 		codeGenerator: codeGenerator,
+		enumeration:   enumeration,
 	}
 }
 
 func (u *ConfirmEmailUseCase) Execute(ctx context.Context, command *ConfirmEmailCommand) error {
-	if err := u.validator.Validate(command.Email); err != nil {
+	if u.enumeration.Enabled {
+		defer u.enumeration.wait(ctx, time.Now())
+	}
+
+	email, err := u.validator.Validate(command.Email)
+	if err != nil {
+		return err
+	}
+	locale, err := requestedLocale(command.Locale)
+	if err != nil {
 		return err
 	}
 
-	exists, err := u.repo.EmailExists(ctx, command.Email)
+	exists, err := u.repo.EmailExists(ctx, email)
 	if err != nil {
 		return err
 	}
 	if exists {
-		return fmt.Errorf("%w: email %s уже существует", ErrAlreadyExists, command.Email)
+		if u.enumeration.Enabled {
+			return u.emailProvider.SendRegistrationAttemptEmail(
+				ctx,
+				EmailCode{To: email, Locale: locale.String()},
+			)
+		}
+		return emailTaken(ErrAlreadyExists, "email", email)
 	}
 
 	code := u.codeGenerator.Generate()
-	if err = u.store.SetConfirmEmail(ctx, command.Email, code); err != nil {
+	if err = u.store.SetConfirmEmail(ctx, confirmEmailKey(email), code); err != nil {
 		return err
 	}
 
-	go u.emailProvider.SendConfirmationEmail(EmailCode{To: command.Email, Code: code})
-
-	return nil
+	return u.emailProvider.SendConfirmationEmail(
+		ctx,
+		EmailCode{To: email, Code: code, Locale: locale.String()},
+	)
 }
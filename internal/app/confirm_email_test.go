@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"slices"
 	"testing"
+	"time"
 )
 
 type mockConfirmEmailRepository struct {
@@ -17,16 +18,32 @@ func (m *mockConfirmEmailRepository) EmailExists(ctx context.Context, email stri
 }
 
 type mockConfirmEmailCodeStore struct {
-	Err error
+	Err  error
+	Keys []string
 }
 
-func (m *mockConfirmEmailCodeStore) SetConfirmEmail(ctx context.Context, key, value string) error {
+func (m *mockConfirmEmailCodeStore) SetConfirmEmail(ctx context.Context, key, code string) error {
+	m.Keys = append(m.Keys, key)
 	return m.Err
 }
 
-type mockConfirmEmailProvider struct{}
+type mockConfirmEmailProvider struct {
+	Err  error
+	Sent []EmailKind
+}
+
+func (m *mockConfirmEmailProvider) SendConfirmationEmail(ctx context.Context, data EmailCode) error {
+	m.Sent = append(m.Sent, ConfirmationEmail)
+	return m.Err
+}
 
-func (m *mockConfirmEmailProvider) SendConfirmationEmail(data EmailCode) {}
+func (m *mockConfirmEmailProvider) SendRegistrationAttemptEmail(
+	ctx context.Context,
+	data EmailCode,
+) error {
+	m.Sent = append(m.Sent, RegistrationAttempt)
+	return m.Err
+}
 
 func TestConfirmEmailUseCase_Execute(t *testing.T) {
 	validEmail := "test@mail.com"
@@ -45,6 +62,20 @@ func TestConfirmEmailUseCase_Execute(t *testing.T) {
 				&mockEmailValidator{},
 				&mockConfirmEmailProvider{},
 				&mockCodeGenerator{},
+				EnumerationProtection{},
+			),
+			Command: &ConfirmEmailCommand{Email: validEmail},
+		},
+		{
+			TestName: "test_confirm_email_use_case_enqueue_email_error",
+			Expected: ErrInternal,
+			UC: MustConfirmEmailUseCase(
+				&mockConfirmEmailRepository{NotExistsEmails: []string{validEmail}},
+				&mockConfirmEmailCodeStore{},
+				&mockEmailValidator{},
+				&mockConfirmEmailProvider{Err: fmt.Errorf("%w: internal error", ErrInternal)},
+				&mockCodeGenerator{},
+				EnumerationProtection{},
 			),
 			Command: &ConfirmEmailCommand{Email: validEmail},
 		},
@@ -57,6 +88,7 @@ func TestConfirmEmailUseCase_Execute(t *testing.T) {
 				&mockEmailValidator{},
 				&mockConfirmEmailProvider{},
 				&mockCodeGenerator{},
+				EnumerationProtection{},
 			),
 			Command: &ConfirmEmailCommand{Email: validEmail},
 		},
@@ -69,9 +101,23 @@ func TestConfirmEmailUseCase_Execute(t *testing.T) {
 				&mockEmailValidator{},
 				&mockConfirmEmailProvider{},
 				&mockCodeGenerator{},
+				EnumerationProtection{},
 			),
 			Command: &ConfirmEmailCommand{Email: validEmail},
 		},
+		{
+			TestName: "test_confirm_email_use_case_unsupported_locale",
+			Expected: ErrInvalidData,
+			UC: MustConfirmEmailUseCase(
+				&mockConfirmEmailRepository{NotExistsEmails: []string{validEmail}},
+				&mockConfirmEmailCodeStore{},
+				&mockEmailValidator{},
+				&mockConfirmEmailProvider{},
+				&mockCodeGenerator{},
+				EnumerationProtection{},
+			),
+			Command: &ConfirmEmailCommand{Email: validEmail, Locale: "de"},
+		},
 		{
 			TestName: "test_confirm_email_use_case_invalid_email",
 			Expected: ErrInvalidData,
@@ -81,6 +127,7 @@ func TestConfirmEmailUseCase_Execute(t *testing.T) {
 				&mockEmailValidator{InvalidEmails: []string{validEmail}},
 				&mockConfirmEmailProvider{},
 				&mockCodeGenerator{},
+				EnumerationProtection{},
 			),
 			Command: &ConfirmEmailCommand{Email: validEmail},
 		},
@@ -99,3 +146,54 @@ func TestConfirmEmailUseCase_Execute(t *testing.T) {
 		})
 	}
 }
+
+func TestConfirmEmailUseCase_EnumerationProtection(t *testing.T) {
+	newEmail := "new@mail.com"
+	cases := []struct {
+		TestName string
+		Expected EmailKind
+		Email    string
+		Stored   int
+	}{
+		{
+			TestName: "test_confirm_email_use_case_protected_new_email",
+			Expected: ConfirmationEmail,
+			Email:    newEmail,
+			Stored:   1,
+		},
+		{
+			TestName: "test_confirm_email_use_case_protected_existing_email",
+			Expected: RegistrationAttempt,
+			Email:    "taken@mail.com",
+			Stored:   0,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			store := &mockConfirmEmailCodeStore{}
+			provider := &mockConfirmEmailProvider{}
+			uc := MustConfirmEmailUseCase(
+				&mockConfirmEmailRepository{NotExistsEmails: []string{newEmail}},
+				store,
+				&mockEmailValidator{},
+				provider,
+				&mockCodeGenerator{},
+				EnumerationProtection{Enabled: true, MinDuration: 20 * time.Millisecond},
+			)
+			started := time.Now()
+			err := uc.Execute(context.Background(), &ConfirmEmailCommand{Email: c.Email})
+			if err != nil {
+				t.Fatalf("expected nil, but got %v", err)
+			}
+			if elapsed := time.Since(started); elapsed < 20*time.Millisecond {
+				t.Errorf("expected response to take at least 20ms, but got %v", elapsed)
+			}
+			if !slices.Equal(provider.Sent, []EmailKind{c.Expected}) {
+				t.Errorf("expected %s email, but got %v", c.Expected, provider.Sent)
+			}
+			if len(store.Keys) != c.Stored {
+				t.Errorf("expected %d stored codes, but got %v", c.Stored, store.Keys)
+			}
+		})
+	}
+}
@@ -2,7 +2,6 @@ package app
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/google/uuid"
 )
@@ -13,11 +12,11 @@ type confirmNewEmailRepository interface {
 }
 
 type confirmNewEmailCodeStore interface {
-	SetNewEmail(ctx context.Context, key, value string) error
+	SetNewEmail(ctx context.Context, key, code string) error
 }
 
 type confirmNewEmailProvider interface {
-	SendConfirmationNewEmail(data []EmailCode)
+	SendConfirmationNewEmail(ctx context.Context, data []EmailCode) error
 }
 
 type ConfirmNewEmailCommand struct {
@@ -70,19 +69,20 @@ func (u *ConfirmNewEmailUseCase) Execute(
 	command *ConfirmNewEmailCommand,
 ) error {
 	if command.InitiatorID != command.UserID {
-		return fmt.Errorf("%w: вы не можете изменять email другим пользователям", ErrNotAllowed)
+		return accessDenied("вы не можете изменять email другим пользователям")
 	}
 
-	if err := u.validator.Validate(command.NewEmail); err != nil {
+	newEmail, err := u.validator.Validate(command.NewEmail)
+	if err != nil {
 		return err
 	}
 
-	exists, err := u.repo.EmailExists(ctx, command.NewEmail)
+	exists, err := u.repo.EmailExists(ctx, newEmail)
 	if err != nil {
 		return err
 	}
 	if exists {
-		return fmt.Errorf("%w: email %s уже существует", ErrAlreadyExists, command.NewEmail)
+		return emailTaken(ErrAlreadyExists, "new_email", newEmail)
 	}
 
 	user, err := u.repo.ByID(ctx, command.UserID)
@@ -93,19 +93,20 @@ func (u *ConfirmNewEmailUseCase) Execute(
 	oldEmailCode := u.codeGenerator.Generate()
 	newEmailCode := u.codeGenerator.Generate()
 
-	if err = u.store.SetNewEmail(ctx, user.ID.String()+user.Email, oldEmailCode); err != nil {
-		return err
-	}
-	if err = u.store.SetNewEmail(ctx, user.ID.String()+command.NewEmail, newEmailCode); err != nil {
+	err = u.store.SetNewEmail(
+		ctx,
+		newEmailKey(user.ID, newEmail),
+		newEmailCodes(oldEmailCode, newEmailCode),
+	)
+	if err != nil {
 		return err
 	}
 
-	go u.emailProvider.SendConfirmationNewEmail(
+	return u.emailProvider.SendConfirmationNewEmail(
+		ctx,
 		[]EmailCode{
-			{To: user.Email, Code: oldEmailCode},
-			{To: command.NewEmail, Code: newEmailCode},
+			{To: user.Email, Code: oldEmailCode, Locale: user.Locale},
+			{To: newEmail, Code: newEmailCode, Locale: user.Locale},
 		},
 	)
-
-	return nil
 }
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"slices"
 	"testing"
 
@@ -32,13 +33,20 @@ type mockConfirmNewEmailCodeStore struct {
 	Err error
 }
 
-func (m *mockConfirmNewEmailCodeStore) SetNewEmail(ctx context.Context, key, value string) error {
+func (m *mockConfirmNewEmailCodeStore) SetNewEmail(ctx context.Context, key, code string) error {
 	return m.Err
 }
 
-type mockConfirmNewEmailProvider struct{}
+type mockConfirmNewEmailProvider struct {
+	Err error
+}
 
-func (m *mockConfirmNewEmailProvider) SendConfirmationNewEmail(data []EmailCode) {}
+func (m *mockConfirmNewEmailProvider) SendConfirmationNewEmail(
+	ctx context.Context,
+	data []EmailCode,
+) error {
+	return m.Err
+}
 
 func TestConfirmNewEmailUseCase_Execute(t *testing.T) {
 	user := &User{
@@ -46,6 +54,7 @@ func TestConfirmNewEmailUseCase_Execute(t *testing.T) {
 		Email:        "test@mail.com",
 		State:        domain.ACTIVE,
 		Status:       domain.USER,
+		Locale:       domain.RU,
 		PasswordHash: "test",
 		Version:      1,
 	}
@@ -72,6 +81,18 @@ func TestConfirmNewEmailUseCase_Execute(t *testing.T) {
 			),
 			Command: command,
 		},
+		{
+			TestName: "test_confirm_new_email_use_case_enqueue_email_error",
+			Expected: ErrInternal,
+			UC: MustConfirmNewEmailUseCase(
+				&mockConfirmNewEmailRepository{User: user},
+				&mockConfirmNewEmailCodeStore{},
+				&mockEmailValidator{},
+				&mockConfirmNewEmailProvider{Err: fmt.Errorf("%w: internal error", ErrInternal)},
+				&mockCodeGenerator{},
+			),
+			Command: command,
+		},
 		{
 			TestName: "test_confirm_new_email_use_case_email_exists",
 			Expected: ErrAlreadyExists,
@@ -2,7 +2,6 @@ package app
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/google/uuid"
 )
@@ -24,11 +23,11 @@ type confirmNewPasswordRepository interface {
 }
 
 type confirmNewPasswordCodeStore interface {
-	SetNewPassword(ctx context.Context, key, value string) error
+	SetNewPassword(ctx context.Context, key, code string) error
 }
 
 type confirmNewPasswordEmailProvider interface {
-	SendConfirmationNewPassword(data EmailCode)
+	SendConfirmationNewPassword(ctx context.Context, data EmailCode) error
 }
 
 func MustConfirmNewPasswordUseCase(
@@ -62,7 +61,7 @@ func (u *ConfirmNewPasswordUseCase) Execute(
 	command *ConfirmNewPasswordCommand,
 ) error {
 	if command.InitiatorID != command.UserID {
-		return fmt.Errorf("%w: вы не можете изменять пароль другим пользователям", ErrNotAllowed)
+		return accessDenied("вы не можете изменять пароль другим пользователям")
 	}
 
 	user, err := u.repo.ByID(ctx, command.UserID)
@@ -72,11 +71,12 @@ func (u *ConfirmNewPasswordUseCase) Execute(
 
 	code := u.codeGenerator.Generate()
 
-	if err = u.store.SetNewPassword(ctx, user.ID.String()+user.Email, code); err != nil {
+	if err = u.store.SetNewPassword(ctx, newPasswordKey(user.ID, user.Email), code); err != nil {
 		return err
 	}
 
-	go u.emailProvider.SendConfirmationNewPassword(EmailCode{To: user.Email, Code: code})
-
-	return nil
+	return u.emailProvider.SendConfirmationNewPassword(
+		ctx,
+		EmailCode{To: user.Email, Code: code, Locale: user.Locale},
+	)
 }
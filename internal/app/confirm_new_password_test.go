@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/Nemagu/dnd_users/internal/domain"
@@ -24,14 +25,21 @@ type mockConfirmNewPasswordCodeStore struct {
 
 func (m *mockConfirmNewPasswordCodeStore) SetNewPassword(
 	ctx context.Context,
-	key, value string,
+	key, code string,
 ) error {
 	return m.Err
 }
 
-type mockConfirmNewPasswordEmailProvider struct{}
+type mockConfirmNewPasswordEmailProvider struct {
+	Err error
+}
 
-func (m *mockConfirmNewPasswordEmailProvider) SendConfirmationNewPassword(data EmailCode) {}
+func (m *mockConfirmNewPasswordEmailProvider) SendConfirmationNewPassword(
+	ctx context.Context,
+	data EmailCode,
+) error {
+	return m.Err
+}
 
 func TestConfirmNewPasswordUseCase_Execute(t *testing.T) {
 	user := &User{
@@ -39,6 +47,7 @@ func TestConfirmNewPasswordUseCase_Execute(t *testing.T) {
 		Email:        "test@mail.com",
 		State:        domain.ACTIVE,
 		Status:       domain.USER,
+		Locale:       domain.RU,
 		PasswordHash: "hash",
 		Version:      1,
 	}
@@ -62,6 +71,22 @@ func TestConfirmNewPasswordUseCase_Execute(t *testing.T) {
 				UserID:      user.ID,
 			},
 		},
+		{
+			TestName: "test_confirm_new_password_use_case_enqueue_email_error",
+			Expected: ErrInternal,
+			UC: MustConfirmNewPasswordUseCase(
+				&mockConfirmNewPasswordRepository{User: user},
+				&mockConfirmNewPasswordCodeStore{},
+				&mockConfirmNewPasswordEmailProvider{
+					Err: fmt.Errorf("%w: internal error", ErrInternal),
+				},
+				&mockCodeGenerator{},
+			),
+			Command: &ConfirmNewPasswordCommand{
+				InitiatorID: user.ID,
+				UserID:      user.ID,
+			},
+		},
 		{
 			TestName: "test_confirm_new_password_use_case_initiator_and_user_are diff",
 			Expected: ErrNotAllowed,
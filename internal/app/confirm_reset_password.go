@@ -2,18 +2,21 @@ package app
 
 import (
 	"context"
-	"fmt"
+	"time"
 )
 
 type ConfirmResetPasswordUseCase struct {
 	repo          confirmResetPasswordRepository
 	store         confirmResetPasswordCodeStore
+	validator     emailValidator
 	emailProvider confirmResetPasswordProvider
 	codeGenerator codeGenerator
+	enumeration   EnumerationProtection
 }
 
 type ConfirmResetPasswordCommand struct {
-	Email string
+	Email  string
+	Locale string
 }
 
 type confirmResetPasswordRepository interface {
@@ -21,18 +24,21 @@ type confirmResetPasswordRepository interface {
 }
 
 type confirmResetPasswordCodeStore interface {
-	SetResetPassword(ctx context.Context, key, value string) error
+	SetResetPassword(ctx context.Context, key, code string) error
 }
 
 type confirmResetPasswordProvider interface {
-	SendResetPasswordEmail(data EmailCode)
+	SendResetPasswordEmail(ctx context.Context, data EmailCode) error
+	SendUnknownAccountResetEmail(ctx context.Context, data EmailCode) error
 }
 
 func MustConfirmResetPasswordUseCase(
 	repo confirmResetPasswordRepository,
 	store confirmResetPasswordCodeStore,
+	validator emailValidator,
 	emailProvider confirmResetPasswordProvider,
 	codeGenerator codeGenerator,
+	enumeration EnumerationProtection,
 ) *ConfirmResetPasswordUseCase {
 	if repo == nil {
 		panic("confirm reset password did not get user repository")
@@ -40,17 +46,25 @@ func MustConfirmResetPasswordUseCase(
 	if store == nil {
 		panic("confirm reset password did not get code store")
 	}
+	if validator == nil {
+		panic("confirm reset password did not get email validator")
+	}
 	if emailProvider == nil {
 		panic("confirm reset password did not get email provider")
 	}
 	if codeGenerator == nil {
 		panic("confirm reset password did not get code generator")
 	}
+	if enumeration.MinDuration < 0 {
+		panic("confirm reset password got negative enumeration min duration")
+	}
 	return &ConfirmResetPasswordUseCase{
 		repo:          repo,
 		store:         store,
+		validator:     validator,
 		emailProvider: emailProvider,
 		codeGenerator: codeGenerator,
+		enumeration:   enumeration,
 	}
 }
 
@@ -58,20 +72,40 @@ func (u *ConfirmResetPasswordUseCase) Execute(
 	ctx context.Context,
 	command *ConfirmResetPasswordCommand,
 ) error {
-	exists, err := u.repo.EmailExists(ctx, command.Email)
+	if u.enumeration.Enabled {
+		defer u.enumeration.wait(ctx, time.Now())
+	}
+
+	email, err := u.validator.Validate(command.Email)
+	if err != nil {
+		return err
+	}
+	locale, err := requestedLocale(command.Locale)
+	if err != nil {
+		return err
+	}
+
+	exists, err := u.repo.EmailExists(ctx, email)
 	if err != nil {
 		return err
 	}
 	if !exists {
-		return fmt.Errorf("%w: такого email не существует", ErrNotFound)
+		if u.enumeration.Enabled {
+			return u.emailProvider.SendUnknownAccountResetEmail(
+				ctx,
+				EmailCode{To: email, Locale: locale.String()},
+			)
+		}
+		return NewError(ErrNotFound, CodeUserNotFound, "такого email не существует").WithField("email")
 	}
 
 	code := u.codeGenerator.Generate()
-	if err = u.store.SetResetPassword(ctx, command.Email, code); err != nil {
+	if err = u.store.SetResetPassword(ctx, resetPasswordKey(email), code); err != nil {
 		return err
 	}
 
-	go u.emailProvider.SendResetPasswordEmail(EmailCode{To: command.Email, Code: code})
-
-	return nil
+	return u.emailProvider.SendResetPasswordEmail(
+		ctx,
+		EmailCode{To: email, Code: code, Locale: locale.String()},
+	)
 }
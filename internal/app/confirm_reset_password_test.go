@@ -3,7 +3,10 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
 	"testing"
+	"time"
 )
 
 type mockConfirmResetPasswordRepository struct {
@@ -19,19 +22,38 @@ func (m *mockConfirmResetPasswordRepository) EmailExists(
 }
 
 type mockConfirmResetPasswordCodeStore struct {
-	Err error
+	Err  error
+	Keys []string
 }
 
 func (m *mockConfirmResetPasswordCodeStore) SetResetPassword(
 	ctx context.Context,
-	key, value string,
+	key, code string,
 ) error {
+	m.Keys = append(m.Keys, key)
 	return m.Err
 }
 
-type mockConfirmResetPasswordProvider struct{}
+type mockConfirmResetPasswordProvider struct {
+	Err  error
+	Sent []EmailKind
+}
+
+func (m *mockConfirmResetPasswordProvider) SendResetPasswordEmail(
+	ctx context.Context,
+	data EmailCode,
+) error {
+	m.Sent = append(m.Sent, ResetPasswordEmail)
+	return m.Err
+}
 
-func (m *mockConfirmResetPasswordProvider) SendResetPasswordEmail(data EmailCode) {}
+func (m *mockConfirmResetPasswordProvider) SendUnknownAccountResetEmail(
+	ctx context.Context,
+	data EmailCode,
+) error {
+	m.Sent = append(m.Sent, UnknownAccountReset)
+	return m.Err
+}
 
 func TestConfirmResetPasswordUseCase_Execute(t *testing.T) {
 	validEmail := "test@mail.com"
@@ -47,8 +69,38 @@ func TestConfirmResetPasswordUseCase_Execute(t *testing.T) {
 			UC: MustConfirmResetPasswordUseCase(
 				&mockConfirmResetPasswordRepository{Exists: true},
 				&mockConfirmResetPasswordCodeStore{},
+				&mockEmailValidator{},
 				&mockConfirmResetPasswordProvider{},
 				&mockCodeGenerator{},
+				EnumerationProtection{},
+			),
+			Command: &ConfirmResetPasswordCommand{Email: validEmail},
+		},
+		{
+			TestName: "test_confirm_reset_password_use_case_enqueue_email_error",
+			Expected: ErrInternal,
+			UC: MustConfirmResetPasswordUseCase(
+				&mockConfirmResetPasswordRepository{Exists: true},
+				&mockConfirmResetPasswordCodeStore{},
+				&mockEmailValidator{},
+				&mockConfirmResetPasswordProvider{
+					Err: fmt.Errorf("%w: internal error", ErrInternal),
+				},
+				&mockCodeGenerator{},
+				EnumerationProtection{},
+			),
+			Command: &ConfirmResetPasswordCommand{Email: validEmail},
+		},
+		{
+			TestName: "test_confirm_reset_password_use_case_invalid_email",
+			Expected: ErrInvalidData,
+			UC: MustConfirmResetPasswordUseCase(
+				&mockConfirmResetPasswordRepository{Exists: true},
+				&mockConfirmResetPasswordCodeStore{},
+				&mockEmailValidator{InvalidEmails: []string{validEmail}},
+				&mockConfirmResetPasswordProvider{},
+				&mockCodeGenerator{},
+				EnumerationProtection{},
 			),
 			Command: &ConfirmResetPasswordCommand{Email: validEmail},
 		},
@@ -58,8 +110,10 @@ func TestConfirmResetPasswordUseCase_Execute(t *testing.T) {
 			UC: MustConfirmResetPasswordUseCase(
 				&mockConfirmResetPasswordRepository{Err: ErrNotFound},
 				&mockConfirmResetPasswordCodeStore{},
+				&mockEmailValidator{},
 				&mockConfirmResetPasswordProvider{},
 				&mockCodeGenerator{},
+				EnumerationProtection{},
 			),
 			Command: &ConfirmResetPasswordCommand{Email: validEmail},
 		},
@@ -69,8 +123,10 @@ func TestConfirmResetPasswordUseCase_Execute(t *testing.T) {
 			UC: MustConfirmResetPasswordUseCase(
 				&mockConfirmResetPasswordRepository{Exists: true},
 				&mockConfirmResetPasswordCodeStore{Err: ErrInternal},
+				&mockEmailValidator{},
 				&mockConfirmResetPasswordProvider{},
 				&mockCodeGenerator{},
+				EnumerationProtection{},
 			),
 			Command: &ConfirmResetPasswordCommand{Email: validEmail},
 		},
@@ -80,8 +136,10 @@ func TestConfirmResetPasswordUseCase_Execute(t *testing.T) {
 			UC: MustConfirmResetPasswordUseCase(
 				&mockConfirmResetPasswordRepository{Exists: true, Err: ErrInternal},
 				&mockConfirmResetPasswordCodeStore{},
+				&mockEmailValidator{},
 				&mockConfirmResetPasswordProvider{},
 				&mockCodeGenerator{},
+				EnumerationProtection{},
 			),
 			Command: &ConfirmResetPasswordCommand{Email: validEmail},
 		},
@@ -100,3 +158,56 @@ func TestConfirmResetPasswordUseCase_Execute(t *testing.T) {
 		})
 	}
 }
+
+func TestConfirmResetPasswordUseCase_EnumerationProtection(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Expected EmailKind
+		Exists   bool
+		Stored   int
+	}{
+		{
+			TestName: "test_confirm_reset_password_use_case_protected_existing_email",
+			Expected: ResetPasswordEmail,
+			Exists:   true,
+			Stored:   1,
+		},
+		{
+			TestName: "test_confirm_reset_password_use_case_protected_unknown_email",
+			Expected: UnknownAccountReset,
+			Exists:   false,
+			Stored:   0,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			store := &mockConfirmResetPasswordCodeStore{}
+			provider := &mockConfirmResetPasswordProvider{}
+			uc := MustConfirmResetPasswordUseCase(
+				&mockConfirmResetPasswordRepository{Exists: c.Exists},
+				store,
+				&mockEmailValidator{},
+				provider,
+				&mockCodeGenerator{},
+				EnumerationProtection{Enabled: true, MinDuration: 20 * time.Millisecond},
+			)
+			started := time.Now()
+			err := uc.Execute(
+				context.Background(),
+				&ConfirmResetPasswordCommand{Email: "test@mail.com"},
+			)
+			if err != nil {
+				t.Fatalf("expected nil, but got %v", err)
+			}
+			if elapsed := time.Since(started); elapsed < 20*time.Millisecond {
+				t.Errorf("expected response to take at least 20ms, but got %v", elapsed)
+			}
+			if !slices.Equal(provider.Sent, []EmailKind{c.Expected}) {
+				t.Errorf("expected %s email, but got %v", c.Expected, provider.Sent)
+			}
+			if len(store.Keys) != c.Stored {
+				t.Errorf("expected %d stored codes, but got %v", c.Stored, store.Keys)
+			}
+		})
+	}
+}
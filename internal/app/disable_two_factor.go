@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type DisableTwoFactorUseCase struct {
+	repo             disableTwoFactorRepository
+	limiter          attemptLimiter
+	passwordComparer passwordComparer
+	verifier         totpVerifier
+}
+
+type DisableTwoFactorCommand struct {
+	InitiatorID uuid.UUID
+	UserID      uuid.UUID
+	Password    string
+	Code        string
+	ClientIP    string
+}
+
+type disableTwoFactorRepository interface {
+	ByID(ctx context.Context, id uuid.UUID) (*User, error)
+	Save(ctx context.Context, user *User) error
+}
+
+func MustDisableTwoFactorUseCase(
+	repo disableTwoFactorRepository,
+	limiter attemptLimiter,
+	passwordComparer passwordComparer,
+	verifier totpVerifier,
+) *DisableTwoFactorUseCase {
+	if repo == nil {
+		panic("disable two factor use case did not get user repository")
+	}
+	if limiter == nil {
+		panic("disable two factor use case did not get attempt limiter")
+	}
+	if passwordComparer == nil {
+		panic("disable two factor use case did not get password comparer")
+	}
+	if verifier == nil {
+		panic("disable two factor use case did not get totp verifier")
+	}
+	return &DisableTwoFactorUseCase{
+		repo:             repo,
+		limiter:          limiter,
+		passwordComparer: passwordComparer,
+		verifier:         verifier,
+	}
+}
+
+func (u *DisableTwoFactorUseCase) Execute(
+	ctx context.Context,
+	command *DisableTwoFactorCommand,
+) error {
+	if command.InitiatorID != command.UserID {
+		return accessDenied(
+			"вы не можете отключать двухфакторную аутентификацию другим пользователям",
+		)
+	}
+
+	appUser, err := u.repo.ByID(ctx, command.UserID)
+	if err != nil {
+		return err
+	}
+
+	domainUser, err := domainUser(appUser)
+	if err != nil {
+		return err
+	}
+	if err = domainUser.CanDisableTwoFactor(); err != nil {
+		return handleDomainError(err)
+	}
+
+	keys := twoFactorKeys(domainUser, command.ClientIP)
+	if err = allowAttempt(ctx, u.limiter, keys); err != nil {
+		return err
+	}
+	compare, err := u.passwordComparer.Compare(command.Password, appUser.PasswordHash)
+	if err != nil {
+		return err
+	}
+	if !compare {
+		return failAttempt(ctx, u.limiter, keys, wrongPassword("password"))
+	}
+
+	if err = useTwoFactor(ctx, u.verifier, u.limiter, keys, domainUser, command.Code); err != nil {
+		return err
+	}
+
+	if err = domainUser.DisableTwoFactor(); err != nil {
+		return handleDomainError(err)
+	}
+
+	newAppUser, err := modifiedUser(domainUser)
+	if err != nil {
+		return err
+	}
+
+	return u.repo.Save(ctx, newAppUser)
+}
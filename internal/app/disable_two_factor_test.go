@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestDisableTwoFactorUseCase_Execute(t *testing.T) {
+	enabledUser := twoFactorTestUser("secret", true, domain.ACTIVE)
+	pendingUser := twoFactorTestUser("secret", false, domain.ACTIVE)
+	cases := []struct {
+		TestName string
+		Expected error
+		User     *User
+		Command  *DisableTwoFactorCommand
+		Failed   int
+	}{
+		{
+			TestName: "test_disable_two_factor_use_case_ok",
+			Expected: nil,
+			User:     enabledUser,
+			Command: &DisableTwoFactorCommand{
+				InitiatorID: enabledUser.ID,
+				UserID:      enabledUser.ID,
+				Password:    "password",
+				Code:        "123456",
+			},
+		},
+		{
+			TestName: "test_disable_two_factor_use_case_other_user",
+			Expected: ErrNotAllowed,
+			User:     enabledUser,
+			Command: &DisableTwoFactorCommand{
+				InitiatorID: uuid.New(),
+				UserID:      enabledUser.ID,
+				Password:    "password",
+				Code:        "123456",
+			},
+		},
+		{
+			TestName: "test_disable_two_factor_use_case_wrong_password",
+			Expected: ErrInvalidData,
+			User:     enabledUser,
+			Command: &DisableTwoFactorCommand{
+				InitiatorID: enabledUser.ID,
+				UserID:      enabledUser.ID,
+				Password:    "wrong",
+				Code:        "123456",
+			},
+			Failed: 1,
+		},
+		{
+			TestName: "test_disable_two_factor_use_case_invalid_code",
+			Expected: ErrInvalidData,
+			User:     enabledUser,
+			Command: &DisableTwoFactorCommand{
+				InitiatorID: enabledUser.ID,
+				UserID:      enabledUser.ID,
+				Password:    "password",
+				Code:        "654321",
+			},
+			Failed: 1,
+		},
+		{
+			TestName: "test_disable_two_factor_use_case_replayed_code",
+			Expected: ErrInvalidData,
+			User:     enabledUser,
+			Command: &DisableTwoFactorCommand{
+				InitiatorID: enabledUser.ID,
+				UserID:      enabledUser.ID,
+				Password:    "password",
+				Code:        "101010",
+			},
+			Failed: 1,
+		},
+		{
+			TestName: "test_disable_two_factor_use_case_not_enabled",
+			Expected: ErrInvalidData,
+			User:     pendingUser,
+			Command: &DisableTwoFactorCommand{
+				InitiatorID: pendingUser.ID,
+				UserID:      pendingUser.ID,
+				Password:    "password",
+				Code:        "123456",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			repo := &mockTwoFactorRepository{User: c.User}
+			limiter := &mockAttemptLimiter{}
+			uc := MustDisableTwoFactorUseCase(
+				repo,
+				limiter,
+				&mockPasswordComparer{InvalidPassword: []string{"wrong"}},
+				&mockTOTPVerifier{Steps: map[string]int64{"123456": 42, "101010": 10}},
+			)
+			err := uc.Execute(context.Background(), c.Command)
+			if !errors.Is(err, c.Expected) {
+				t.Fatalf("expected %v, but got %v", c.Expected, err)
+			}
+			if len(limiter.Failed) != c.Failed {
+				t.Errorf("expected %d failed attempts, but got %v", c.Failed, limiter.Failed)
+			}
+			if c.Expected != nil {
+				if repo.Saved != nil {
+					t.Errorf("expected user not to be saved, but got %v", repo.Saved)
+				}
+				return
+			}
+			if repo.Saved == nil || repo.Saved.TwoFactorEnabled || repo.Saved.TOTPSecret != "" {
+				t.Errorf("expected two factor to be cleared, but got %v", repo.Saved)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"errors"
 	"fmt"
+	"maps"
 
 	"github.com/Nemagu/dnd_users/internal/domain"
 )
@@ -15,12 +16,16 @@ func currentUser(u *domain.User) (*User, error) {
 		)
 	}
 	return &User{
-		ID:           u.ID(),
-		Email:        u.Email(),
-		State:        u.State().String(),
-		Status:       u.Status().String(),
-		PasswordHash: u.PasswordHash(),
-		Version:      u.Version(),
+		ID:               u.ID(),
+		Email:            u.Email(),
+		State:            u.State().String(),
+		Status:           u.Status().String(),
+		Locale:           u.Locale().String(),
+		PasswordHash:     u.PasswordHash(),
+		TOTPSecret:       u.TwoFactor().Secret(),
+		TwoFactorEnabled: u.TwoFactor().Enabled(),
+		TOTPLastStep:     u.TwoFactor().LastStep(),
+		Version:          u.Version(),
 	}, nil
 }
 
@@ -32,12 +37,16 @@ func modifiedUser(u *domain.User) (*User, error) {
 		)
 	}
 	return &User{
-		ID:           u.ID(),
-		Email:        u.Email(),
-		State:        u.State().String(),
-		Status:       u.Status().String(),
-		PasswordHash: u.PasswordHash(),
-		Version:      u.ModifiedVersion(),
+		ID:               u.ID(),
+		Email:            u.Email(),
+		State:            u.State().String(),
+		Status:           u.Status().String(),
+		Locale:           u.Locale().String(),
+		PasswordHash:     u.PasswordHash(),
+		TOTPSecret:       u.TwoFactor().Secret(),
+		TwoFactorEnabled: u.TwoFactor().Enabled(),
+		TOTPLastStep:     u.TwoFactor().LastStep(),
+		Version:          u.ModifiedVersion(),
 	}, nil
 }
 
@@ -59,7 +68,26 @@ func domainUser(u *User) (*domain.User, error) {
 		return nil, err
 	}
 
-	user, err := domain.RestoreUser(u.ID, u.Email, u.PasswordHash, dState, dStatus, u.Version)
+	dLocale, err := domainLocale(u.Locale)
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := domain.RestoreTwoFactor(u.TOTPSecret, u.TwoFactorEnabled, u.TOTPLastStep)
+	if err != nil {
+		return nil, handleDomainError(err)
+	}
+
+	user, err := domain.RestoreUser(
+		u.ID,
+		u.Email,
+		u.PasswordHash,
+		dState,
+		dStatus,
+		dLocale,
+		twoFactor,
+		u.Version,
+	)
 	if err != nil {
 		return nil, handleDomainError(err)
 	}
@@ -83,15 +111,44 @@ func domainStatus(s string) (domain.Status, error) {
 	return status, nil
 }
 
+func domainLocale(l string) (domain.Locale, error) {
+	locale, err := domain.NewLocale(l)
+	if err != nil {
+		return domain.NilLocale, handleDomainError(err)
+	}
+	return locale, nil
+}
+
+func requestedLocale(l string) (domain.Locale, error) {
+	if l == "" {
+		return domain.DefaultLocale(), nil
+	}
+	return domainLocale(l)
+}
+
 func handleDomainError(err error) error {
+	var kind error
 	switch {
 	case errors.Is(err, domain.ErrInvalidData):
-		return fmt.Errorf("%w: %s", ErrInvalidData, err)
+		kind = ErrInvalidData
 	case errors.Is(err, domain.ErrUserNotActive):
-		return fmt.Errorf("%w: %s", ErrUserNotActive, err)
+		kind = ErrUserNotActive
 	case errors.Is(err, domain.ErrIdempotent):
-		return fmt.Errorf("%w: %s", ErrIdempotent, err)
+		kind = ErrIdempotent
 	default:
-		return fmt.Errorf("%w: %s", ErrInternal, err)
+		return fmt.Errorf("%w: %w", ErrInternal, err)
+	}
+
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) {
+		return fmt.Errorf("%w: %w", kind, err)
+	}
+	return &Error{
+		Kind:    kind,
+		Code:    domainErr.Code,
+		Field:   domainErr.Field,
+		Params:  maps.Clone(domainErr.Params),
+		Message: domainErr.Message,
+		Cause:   err,
 	}
 }
@@ -1,19 +1,111 @@
 package app
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
 type User struct {
-	ID           uuid.UUID
-	Email        string
-	State        string
-	Status       string
-	PasswordHash string
-	Version      uint
+	ID               uuid.UUID
+	Email            string
+	State            string
+	Status           string
+	Locale           string
+	PasswordHash     string
+	TOTPSecret       string
+	TwoFactorEnabled bool
+	TOTPLastStep     int64
+	Version          uint
+}
+
+type TwoFactorEnrollment struct {
+	Secret string
+	URI    string
 }
 
 type EmailCode struct {
-	To   string
-	Code string
+	To     string
+	Code   string
+	Locale string
+}
+
+type EmailKind string
+
+const (
+	ConfirmationEmail       EmailKind = "confirm_email"
+	NewEmailConfirmation    EmailKind = "new_email"
+	ResetPasswordEmail      EmailKind = "reset_password"
+	NewPasswordConfirmation EmailKind = "new_password"
+	RegistrationAttempt     EmailKind = "registration_attempt"
+	UnknownAccountReset     EmailKind = "reset_password_unknown"
+)
+
+func (k EmailKind) HasCode() bool {
+	return k != RegistrationAttempt && k != UnknownAccountReset
+}
+
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "pending"
+	OutboxDead    OutboxStatus = "dead"
+)
+
+type OutboxEmail struct {
+	ID            uuid.UUID
+	Kind          EmailKind
+	To            string
+	Code          string
+	Locale        string
+	Status        OutboxStatus
+	Attempts      int
+	LastError     string
+	CreatedAt     time.Time
+	NextAttemptAt time.Time
+	DeadAt        time.Time
+}
+
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+type Session struct {
+	ID        uuid.UUID
+	FamilyID  uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	Used      bool
+	Revoked   bool
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type UserView struct {
+	ID        uuid.UUID
+	Email     string
+	State     string
+	Status    string
+	Locale    string
+	Version   uint
+	CreatedAt time.Time
+}
+
+type UserFilter struct {
+	State         string
+	Status        string
+	EmailContains string
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+}
+
+type UserCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+type UserPage struct {
+	Users      []UserView
+	NextCursor string
 }
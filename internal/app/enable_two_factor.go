@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type EnableTwoFactorUseCase struct {
+	repo     enableTwoFactorRepository
+	limiter  attemptLimiter
+	verifier totpVerifier
+}
+
+type EnableTwoFactorCommand struct {
+	InitiatorID uuid.UUID
+	UserID      uuid.UUID
+	Code        string
+	ClientIP    string
+}
+
+type enableTwoFactorRepository interface {
+	ByID(ctx context.Context, id uuid.UUID) (*User, error)
+	Save(ctx context.Context, user *User) error
+}
+
+func MustEnableTwoFactorUseCase(
+	repo enableTwoFactorRepository,
+	limiter attemptLimiter,
+	verifier totpVerifier,
+) *EnableTwoFactorUseCase {
+	if repo == nil {
+		panic("enable two factor use case did not get user repository")
+	}
+	if limiter == nil {
+		panic("enable two factor use case did not get attempt limiter")
+	}
+	if verifier == nil {
+		panic("enable two factor use case did not get totp verifier")
+	}
+	return &EnableTwoFactorUseCase{
+		repo:     repo,
+		limiter:  limiter,
+		verifier: verifier,
+	}
+}
+
+func (u *EnableTwoFactorUseCase) Execute(
+	ctx context.Context,
+	command *EnableTwoFactorCommand,
+) error {
+	if command.InitiatorID != command.UserID {
+		return accessDenied(
+			"вы не можете включать двухфакторную аутентификацию другим пользователям",
+		)
+	}
+
+	appUser, err := u.repo.ByID(ctx, command.UserID)
+	if err != nil {
+		return err
+	}
+
+	domainUser, err := domainUser(appUser)
+	if err != nil {
+		return err
+	}
+	if err = domainUser.CanEnableTwoFactor(); err != nil {
+		return handleDomainError(err)
+	}
+
+	keys := twoFactorKeys(domainUser, command.ClientIP)
+	if err = allowAttempt(ctx, u.limiter, keys); err != nil {
+		return err
+	}
+	step, err := verifyTwoFactor(
+		ctx,
+		u.verifier,
+		u.limiter,
+		keys,
+		domainUser.TwoFactor().Secret(),
+		command.Code,
+	)
+	if err != nil {
+		return err
+	}
+	if err = resetAttempts(ctx, u.limiter, keys); err != nil {
+		return err
+	}
+
+	if err = domainUser.EnableTwoFactor(step); err != nil {
+		return handleDomainError(err)
+	}
+
+	newAppUser, err := modifiedUser(domainUser)
+	if err != nil {
+		return err
+	}
+
+	return u.repo.Save(ctx, newAppUser)
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestEnableTwoFactorUseCase_Execute(t *testing.T) {
+	pendingUser := twoFactorTestUser("secret", false, domain.ACTIVE)
+	enabledUser := twoFactorTestUser("secret", true, domain.ACTIVE)
+	notEnrolledUser := twoFactorTestUser("", false, domain.ACTIVE)
+	cases := []struct {
+		TestName string
+		Expected error
+		User     *User
+		Command  *EnableTwoFactorCommand
+		Limiter  *mockAttemptLimiter
+		Failed   int
+	}{
+		{
+			TestName: "test_enable_two_factor_use_case_ok",
+			Expected: nil,
+			User:     pendingUser,
+			Command: &EnableTwoFactorCommand{
+				InitiatorID: pendingUser.ID,
+				UserID:      pendingUser.ID,
+				Code:        "123456",
+			},
+			Limiter: &mockAttemptLimiter{},
+		},
+		{
+			TestName: "test_enable_two_factor_use_case_other_user",
+			Expected: ErrNotAllowed,
+			User:     pendingUser,
+			Command: &EnableTwoFactorCommand{
+				InitiatorID: uuid.New(),
+				UserID:      pendingUser.ID,
+				Code:        "123456",
+			},
+			Limiter: &mockAttemptLimiter{},
+		},
+		{
+			TestName: "test_enable_two_factor_use_case_invalid_code",
+			Expected: ErrInvalidData,
+			User:     pendingUser,
+			Command: &EnableTwoFactorCommand{
+				InitiatorID: pendingUser.ID,
+				UserID:      pendingUser.ID,
+				Code:        "654321",
+			},
+			Limiter: &mockAttemptLimiter{},
+			Failed:  1,
+		},
+		{
+			TestName: "test_enable_two_factor_use_case_rate_limited",
+			Expected: ErrTooManyRequests,
+			User:     pendingUser,
+			Command: &EnableTwoFactorCommand{
+				InitiatorID: pendingUser.ID,
+				UserID:      pendingUser.ID,
+				Code:        "123456",
+			},
+			Limiter: &mockAttemptLimiter{Err: TooManyRequestsError(time.Minute)},
+		},
+		{
+			TestName: "test_enable_two_factor_use_case_not_enrolled",
+			Expected: ErrInvalidData,
+			User:     notEnrolledUser,
+			Command: &EnableTwoFactorCommand{
+				InitiatorID: notEnrolledUser.ID,
+				UserID:      notEnrolledUser.ID,
+				Code:        "123456",
+			},
+			Limiter: &mockAttemptLimiter{},
+		},
+		{
+			TestName: "test_enable_two_factor_use_case_already_enabled",
+			Expected: ErrIdempotent,
+			User:     enabledUser,
+			Command: &EnableTwoFactorCommand{
+				InitiatorID: enabledUser.ID,
+				UserID:      enabledUser.ID,
+				Code:        "123456",
+			},
+			Limiter: &mockAttemptLimiter{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			repo := &mockTwoFactorRepository{User: c.User}
+			uc := MustEnableTwoFactorUseCase(
+				repo,
+				c.Limiter,
+				&mockTOTPVerifier{Steps: map[string]int64{"123456": 42}},
+			)
+			err := uc.Execute(context.Background(), c.Command)
+			if !errors.Is(err, c.Expected) {
+				t.Fatalf("expected %v, but got %v", c.Expected, err)
+			}
+			if len(c.Limiter.Failed) != c.Failed {
+				t.Errorf("expected %d failed attempts, but got %v", c.Failed, c.Limiter.Failed)
+			}
+			if c.Expected != nil {
+				if repo.Saved != nil {
+					t.Errorf("expected user not to be saved, but got %v", repo.Saved)
+				}
+				return
+			}
+			if repo.Saved == nil || !repo.Saved.TwoFactorEnabled || repo.Saved.TOTPLastStep != 42 {
+				t.Errorf("expected enabled two factor at step 42, but got %v", repo.Saved)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type EnrollTwoFactorUseCase struct {
+	repo             enrollTwoFactorRepository
+	limiter          attemptLimiter
+	passwordComparer passwordComparer
+	generator        totpGenerator
+}
+
+type EnrollTwoFactorCommand struct {
+	InitiatorID uuid.UUID
+	UserID      uuid.UUID
+	Password    string
+	ClientIP    string
+}
+
+type enrollTwoFactorRepository interface {
+	ByID(ctx context.Context, id uuid.UUID) (*User, error)
+	Save(ctx context.Context, user *User) error
+}
+
+func MustEnrollTwoFactorUseCase(
+	repo enrollTwoFactorRepository,
+	limiter attemptLimiter,
+	passwordComparer passwordComparer,
+	generator totpGenerator,
+) *EnrollTwoFactorUseCase {
+	if repo == nil {
+		panic("enroll two factor use case did not get user repository")
+	}
+	if limiter == nil {
+		panic("enroll two factor use case did not get attempt limiter")
+	}
+	if passwordComparer == nil {
+		panic("enroll two factor use case did not get password comparer")
+	}
+	if generator == nil {
+		panic("enroll two factor use case did not get totp generator")
+	}
+	return &EnrollTwoFactorUseCase{
+		repo:             repo,
+		limiter:          limiter,
+		passwordComparer: passwordComparer,
+		generator:        generator,
+	}
+}
+
+func (u *EnrollTwoFactorUseCase) Execute(
+	ctx context.Context,
+	command *EnrollTwoFactorCommand,
+) (*TwoFactorEnrollment, error) {
+	if command.InitiatorID != command.UserID {
+		return nil, accessDenied(
+			"вы не можете подключать двухфакторную аутентификацию другим пользователям",
+		)
+	}
+
+	appUser, err := u.repo.ByID(ctx, command.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	domainUser, err := domainUser(appUser)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := twoFactorKeys(domainUser, command.ClientIP)
+	if err = allowAttempt(ctx, u.limiter, keys); err != nil {
+		return nil, err
+	}
+	compare, err := u.passwordComparer.Compare(command.Password, appUser.PasswordHash)
+	if err != nil {
+		return nil, err
+	}
+	if !compare {
+		return nil, failAttempt(ctx, u.limiter, keys, wrongPassword("password"))
+	}
+
+	enrollment, err := u.generator.Generate(appUser.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = domainUser.EnrollTwoFactor(enrollment.Secret); err != nil {
+		return nil, handleDomainError(err)
+	}
+
+	newAppUser, err := modifiedUser(domainUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = u.repo.Save(ctx, newAppUser); err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+type mockTwoFactorRepository struct {
+	User    *User
+	Saved   *User
+	ErrByID error
+	ErrSave error
+}
+
+func (m *mockTwoFactorRepository) ByID(ctx context.Context, id uuid.UUID) (*User, error) {
+	return m.User, m.ErrByID
+}
+
+func (m *mockTwoFactorRepository) Save(ctx context.Context, user *User) error {
+	m.Saved = user
+	return m.ErrSave
+}
+
+func twoFactorTestUser(secret string, enabled bool, state string) *User {
+	return &User{
+		ID:               uuid.New(),
+		Email:            "test@mail.com",
+		State:            state,
+		Status:           domain.ADMIN,
+		Locale:           domain.RU,
+		PasswordHash:     "password",
+		TOTPSecret:       secret,
+		TwoFactorEnabled: enabled,
+		TOTPLastStep:     10,
+		Version:          1,
+	}
+}
+
+func TestEnrollTwoFactorUseCase_Execute(t *testing.T) {
+	activeUser := twoFactorTestUser("", false, domain.ACTIVE)
+	enabledUser := twoFactorTestUser("secret", true, domain.ACTIVE)
+	frozenUser := twoFactorTestUser("", false, domain.FROZEN)
+	cases := []struct {
+		TestName string
+		Expected error
+		User     *User
+		Command  *EnrollTwoFactorCommand
+		Comparer *mockPasswordComparer
+		Limiter  *mockAttemptLimiter
+	}{
+		{
+			TestName: "test_enroll_two_factor_use_case_ok",
+			Expected: nil,
+			User:     activeUser,
+			Command: &EnrollTwoFactorCommand{
+				InitiatorID: activeUser.ID,
+				UserID:      activeUser.ID,
+				Password:    "password",
+			},
+			Comparer: &mockPasswordComparer{},
+			Limiter:  &mockAttemptLimiter{},
+		},
+		{
+			TestName: "test_enroll_two_factor_use_case_other_user",
+			Expected: ErrNotAllowed,
+			User:     activeUser,
+			Command: &EnrollTwoFactorCommand{
+				InitiatorID: uuid.New(),
+				UserID:      activeUser.ID,
+				Password:    "password",
+			},
+			Comparer: &mockPasswordComparer{},
+			Limiter:  &mockAttemptLimiter{},
+		},
+		{
+			TestName: "test_enroll_two_factor_use_case_wrong_password",
+			Expected: ErrInvalidData,
+			User:     activeUser,
+			Command: &EnrollTwoFactorCommand{
+				InitiatorID: activeUser.ID,
+				UserID:      activeUser.ID,
+				Password:    "wrong",
+			},
+			Comparer: &mockPasswordComparer{InvalidPassword: []string{"wrong"}},
+			Limiter:  &mockAttemptLimiter{},
+		},
+		{
+			TestName: "test_enroll_two_factor_use_case_rate_limited",
+			Expected: ErrTooManyRequests,
+			User:     activeUser,
+			Command: &EnrollTwoFactorCommand{
+				InitiatorID: activeUser.ID,
+				UserID:      activeUser.ID,
+				Password:    "password",
+			},
+			Comparer: &mockPasswordComparer{},
+			Limiter:  &mockAttemptLimiter{Err: TooManyRequestsError(time.Minute)},
+		},
+		{
+			TestName: "test_enroll_two_factor_use_case_already_enabled",
+			Expected: ErrInvalidData,
+			User:     enabledUser,
+			Command: &EnrollTwoFactorCommand{
+				InitiatorID: enabledUser.ID,
+				UserID:      enabledUser.ID,
+				Password:    "password",
+			},
+			Comparer: &mockPasswordComparer{},
+			Limiter:  &mockAttemptLimiter{},
+		},
+		{
+			TestName: "test_enroll_two_factor_use_case_user_frozen",
+			Expected: ErrUserNotActive,
+			User:     frozenUser,
+			Command: &EnrollTwoFactorCommand{
+				InitiatorID: frozenUser.ID,
+				UserID:      frozenUser.ID,
+				Password:    "password",
+			},
+			Comparer: &mockPasswordComparer{},
+			Limiter:  &mockAttemptLimiter{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			repo := &mockTwoFactorRepository{User: c.User}
+			uc := MustEnrollTwoFactorUseCase(
+				repo,
+				c.Limiter,
+				c.Comparer,
+				&mockTOTPGenerator{Secret: "new_secret"},
+			)
+			enrollment, err := uc.Execute(context.Background(), c.Command)
+			if !errors.Is(err, c.Expected) {
+				t.Fatalf("expected %v, but got %v", c.Expected, err)
+			}
+			if c.Expected != nil {
+				if repo.Saved != nil {
+					t.Errorf("expected user not to be saved, but got %v", repo.Saved)
+				}
+				return
+			}
+			if enrollment.Secret != "new_secret" || enrollment.URI == "" {
+				t.Errorf("expected enrollment with secret and uri, but got %v", enrollment)
+			}
+			if repo.Saved == nil || repo.Saved.TOTPSecret != "new_secret" ||
+				repo.Saved.TwoFactorEnabled || repo.Saved.Version != 2 {
+				t.Errorf("expected pending two factor to be saved, but got %v", repo.Saved)
+			}
+		})
+	}
+}
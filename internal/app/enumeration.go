@@ -0,0 +1,24 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+type EnumerationProtection struct {
+	Enabled     bool
+	MinDuration time.Duration
+}
+
+func (p EnumerationProtection) wait(ctx context.Context, started time.Time) {
+	remaining := time.Until(started.Add(p.MinDuration))
+	if remaining <= 0 {
+		return
+	}
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -1,13 +1,169 @@
 package app
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
 
 var (
-	ErrInvalidData   = errors.New("не корректные данные")
-	ErrIdempotent    = errors.New("попытка изменения пользователя без изменения данных")
-	ErrUserNotActive = errors.New("пользователь имеет не активный статус")
-	ErrAlreadyExists = errors.New("объект уже существует")
-	ErrNotAllowed    = errors.New("действие не разрешено")
-	ErrNotFound      = errors.New("объект не найден")
-	ErrInternal      = errors.New("внутренняя ошибка")
+	ErrInvalidData     = errors.New("не корректные данные")
+	ErrIdempotent      = errors.New("попытка изменения пользователя без изменения данных")
+	ErrUserNotActive   = errors.New("пользователь имеет не активный статус")
+	ErrAlreadyExists   = errors.New("объект уже существует")
+	ErrNotAllowed      = errors.New("действие не разрешено")
+	ErrNotFound        = errors.New("объект не найден")
+	ErrUnauthenticated = errors.New("пользователь не аутентифицирован")
+	ErrConflict        = errors.New("объект был изменен другим запросом")
+	ErrTooManyRequests = errors.New("слишком много попыток")
+	ErrInternal        = errors.New("внутренняя ошибка")
+)
+
+const (
+	CodeRequired             = domain.CodeRequired
+	CodeUnsupported          = domain.CodeUnsupported
+	CodeUnchanged            = domain.CodeUnchanged
+	CodeUserNotActive        = domain.CodeUserNotActive
+	CodeTwoFactorEnabled     = domain.CodeTwoFactorEnabled
+	CodeTwoFactorNotEnrolled = domain.CodeTwoFactorNotEnrolled
+	CodeTwoFactorNotEnabled  = domain.CodeTwoFactorNotEnabled
+	CodeTwoFactorReplayed    = domain.CodeTwoFactorReplayed
+	CodeUserNotFound         = "user.not_found"
+	CodeUserEmailTaken       = "user.email.taken"
+	CodeUserLookupConflict   = "user.lookup.conflict"
+	CodeAccessDenied         = "access.denied"
+	CodeEmailInvalid         = "email.invalid"
+	CodePasswordInvalid      = "password.invalid"
+	CodePasswordTooWeak      = "password.too_weak"
+	CodePasswordReused       = "password.reused"
+	CodeCredentialsInvalid   = "credentials.invalid"
+	CodeCodeInvalid          = "code.invalid"
+	CodeCodeExpired          = "code.expired"
+	CodeCodeExhausted        = "code.exhausted"
+	CodeSessionInvalid       = "session.invalid"
+	CodeSessionRevoked       = "session.revoked"
+	CodeSessionReused        = "session.reused"
+	CodeSessionExpired       = "session.expired"
+	CodeTokenRevoked         = "token.revoked"
+	CodeTooManyRequests      = "rate.limited"
+	CodeTwoFactorRequired    = "two_factor.required"
+	CodeTwoFactorInvalid     = "two_factor.invalid"
 )
+
+type Error struct {
+	Kind       error
+	Code       string
+	Field      string
+	Params     map[string]string
+	Message    string
+	Cause      error
+	RetryAfter time.Duration
+}
+
+func NewError(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) WithField(field string) *Error {
+	e.Field = field
+	return e
+}
+
+func (e *Error) WithParam(key, value string) *Error {
+	if e.Params == nil {
+		e.Params = make(map[string]string)
+	}
+	e.Params[key] = value
+	return e
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Kind, e.Message)
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Cause}
+}
+
+func accessDenied(message string) *Error {
+	return NewError(ErrNotAllowed, CodeAccessDenied, message)
+}
+
+func userNotFound(id uuid.UUID) *Error {
+	return NewError(ErrNotFound, CodeUserNotFound, fmt.Sprintf("пользователь с id %s не найден", id)).
+		WithParam("id", id.String())
+}
+
+func userNotActive(user *domain.User) *Error {
+	return NewError(
+		ErrUserNotActive,
+		CodeUserNotActive,
+		fmt.Sprintf("пользователь с id %s находится в состоянии %s", user.ID(), user.State()),
+	).WithParam("state", user.State().String())
+}
+
+func emailTaken(kind error, field, email string) *Error {
+	return NewError(kind, CodeUserEmailTaken, fmt.Sprintf("email %s уже существует", email)).
+		WithField(field).
+		WithParam("email", email)
+}
+
+func invalidCredentials() *Error {
+	return NewError(ErrInvalidData, CodeCredentialsInvalid, "неверный email или пароль")
+}
+
+func wrongPassword(field string) *Error {
+	return NewError(ErrInvalidData, CodePasswordInvalid, "неверный пароль").WithField(field)
+}
+
+func twoFactorRequired() *Error {
+	return NewError(
+		ErrUnauthenticated,
+		CodeTwoFactorRequired,
+		"требуется код двухфакторной аутентификации",
+	).WithField("totp_code")
+}
+
+func twoFactorInvalid() *Error {
+	return NewError(
+		ErrInvalidData,
+		CodeTwoFactorInvalid,
+		"неверный код двухфакторной аутентификации",
+	).WithField("totp_code")
+}
+
+func CodeExpiredError() *Error {
+	return NewError(ErrNotFound, CodeCodeExpired, "код подтверждения не найден или истек").
+		WithField("code")
+}
+
+func CodeExhaustedError() *Error {
+	return NewError(
+		ErrInvalidData,
+		CodeCodeExhausted,
+		"превышено количество попыток, запросите новый код",
+	).WithField("code")
+}
+
+func CodeInvalidError() *Error {
+	return NewError(ErrInvalidData, CodeCodeInvalid, "неверный код подтверждения").WithField("code")
+}
+
+func TooManyRequestsError(retryAfter time.Duration) *Error {
+	seconds := strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+	err := NewError(
+		ErrTooManyRequests,
+		CodeTooManyRequests,
+		fmt.Sprintf("слишком много попыток, повторите через %s с", seconds),
+	).WithParam("retry_after", seconds)
+	err.RetryAfter = retryAfter
+	return err
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+)
+
+func TestHandleDomainError(t *testing.T) {
+	_, unsupported := domain.NewState("other")
+	plain := errors.New("plain")
+	cases := []struct {
+		TestName string
+		Expected []error
+		Code     string
+		Field    string
+		Err      error
+	}{
+		{
+			TestName: "test_handle_domain_error_typed",
+			Expected: []error{ErrInvalidData, domain.ErrInvalidData, unsupported},
+			Code:     CodeUnsupported,
+			Field:    "state",
+			Err:      unsupported,
+		},
+		{
+			TestName: "test_handle_domain_error_sentinel",
+			Expected: []error{ErrIdempotent, domain.ErrIdempotent},
+			Code:     "",
+			Field:    "",
+			Err:      domain.ErrIdempotent,
+		},
+		{
+			TestName: "test_handle_domain_error_unknown",
+			Expected: []error{ErrInternal, plain},
+			Code:     "",
+			Field:    "",
+			Err:      plain,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := handleDomainError(c.Err)
+			for _, expected := range c.Expected {
+				if !errors.Is(err, expected) {
+					t.Errorf("expected %v in chain of %v", expected, err)
+				}
+			}
+			var appErr *Error
+			if errors.As(err, &appErr) != (c.Code != "") {
+				t.Fatalf("unexpected typed error %v", err)
+			}
+			if appErr != nil && (appErr.Code != c.Code || appErr.Field != c.Field) {
+				t.Errorf("expected %s on %s, but got %s on %s", c.Code, c.Field, appErr.Code, appErr.Field)
+			}
+		})
+	}
+}
+
+func TestError_Error(t *testing.T) {
+	err := emailTaken(ErrAlreadyExists, "new_email", "test@mail.com")
+	if err.Error() != "объект уже существует: email test@mail.com уже существует" {
+		t.Errorf("expected sentinel prefix, but got %q", err.Error())
+	}
+	if !errors.Is(err, ErrAlreadyExists) || errors.Is(err, ErrInvalidData) {
+		t.Errorf("expected only %v in chain", ErrAlreadyExists)
+	}
+	if err.Field != "new_email" || err.Params["email"] != "test@mail.com" {
+		t.Errorf("expected field and params, but got %s %v", err.Field, err.Params)
+	}
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+type GetUserUseCase struct {
+	repo   getUserRepository
+	policy *domain.PolicyService
+}
+
+type GetUserQuery struct {
+	InitiatorID uuid.UUID
+	UserID      uuid.UUID
+}
+
+type getUserRepository interface {
+	ByID(ctx context.Context, id uuid.UUID) (*User, error)
+	ViewByID(ctx context.Context, id uuid.UUID) (*UserView, error)
+}
+
+func MustGetUserUseCase(repo getUserRepository, policy *domain.PolicyService) *GetUserUseCase {
+	if repo == nil {
+		panic("get user use case did not get user repository")
+	}
+	if policy == nil {
+		panic("get user use case did not get policy service")
+	}
+	return &GetUserUseCase{repo: repo, policy: policy}
+}
+
+func (u *GetUserUseCase) Execute(ctx context.Context, query *GetUserQuery) (*UserView, error) {
+	if query.InitiatorID != query.UserID {
+		if err := canReadOthers(ctx, u.repo, u.policy, query.InitiatorID); err != nil {
+			return nil, err
+		}
+	}
+	return u.repo.ViewByID(ctx, query.UserID)
+}
+
+func canReadOthers(
+	ctx context.Context,
+	repo userReader,
+	policy *domain.PolicyService,
+	initiatorID uuid.UUID,
+) error {
+	initiator, err := repo.ByID(ctx, initiatorID)
+	if err != nil {
+		return err
+	}
+	domainInitiator, err := domainUser(initiator)
+	if err != nil {
+		return err
+	}
+	if !policy.CanReadOthers(domainInitiator) {
+		return accessDenied("вы не можете просматривать других пользователей")
+	}
+	return nil
+}
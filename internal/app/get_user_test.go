@@ -0,0 +1,107 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+type mockGetUserRepository struct {
+	Initiator *User
+	View      *UserView
+	ErrByID   error
+	ErrView   error
+}
+
+func (m *mockGetUserRepository) ByID(ctx context.Context, id uuid.UUID) (*User, error) {
+	return m.Initiator, m.ErrByID
+}
+
+func (m *mockGetUserRepository) ViewByID(ctx context.Context, id uuid.UUID) (*UserView, error) {
+	return m.View, m.ErrView
+}
+
+func TestGetUserUseCase_Execute(t *testing.T) {
+	admin := &User{
+		ID:           uuid.New(),
+		Email:        "admin@mail.com",
+		State:        domain.ACTIVE,
+		Status:       domain.ADMIN,
+		Locale:       domain.RU,
+		PasswordHash: "hash",
+		Version:      1,
+	}
+	user := *admin
+	user.ID = uuid.New()
+	user.Status = domain.USER
+	frozenAdmin := *admin
+	frozenAdmin.State = domain.FROZEN
+	view := &UserView{ID: user.ID, Email: user.Email, State: user.State, Status: user.Status}
+	cases := []struct {
+		TestName string
+		Expected error
+		Repo     *mockGetUserRepository
+		Query    *GetUserQuery
+	}{
+		{
+			TestName: "test_get_user_use_case_self_ok",
+			Expected: nil,
+			Repo:     &mockGetUserRepository{View: view, ErrByID: ErrInternal},
+			Query:    &GetUserQuery{InitiatorID: user.ID, UserID: user.ID},
+		},
+		{
+			TestName: "test_get_user_use_case_admin_ok",
+			Expected: nil,
+			Repo:     &mockGetUserRepository{Initiator: admin, View: view},
+			Query:    &GetUserQuery{InitiatorID: admin.ID, UserID: user.ID},
+		},
+		{
+			TestName: "test_get_user_use_case_not_admin",
+			Expected: ErrNotAllowed,
+			Repo:     &mockGetUserRepository{Initiator: &user, View: view},
+			Query:    &GetUserQuery{InitiatorID: user.ID, UserID: admin.ID},
+		},
+		{
+			TestName: "test_get_user_use_case_frozen_admin",
+			Expected: ErrNotAllowed,
+			Repo:     &mockGetUserRepository{Initiator: &frozenAdmin, View: view},
+			Query:    &GetUserQuery{InitiatorID: admin.ID, UserID: user.ID},
+		},
+		{
+			TestName: "test_get_user_use_case_initiator_not_found",
+			Expected: ErrNotFound,
+			Repo:     &mockGetUserRepository{ErrByID: ErrNotFound, View: view},
+			Query:    &GetUserQuery{InitiatorID: admin.ID, UserID: user.ID},
+		},
+		{
+			TestName: "test_get_user_use_case_user_not_found",
+			Expected: ErrNotFound,
+			Repo: &mockGetUserRepository{
+				Initiator: admin,
+				ErrView:   fmt.Errorf("%w: user", ErrNotFound),
+			},
+			Query: &GetUserQuery{InitiatorID: admin.ID, UserID: user.ID},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			uc := MustGetUserUseCase(c.Repo, domain.MustPolicyService())
+			got, err := uc.Execute(context.Background(), c.Query)
+			if c.Expected == nil {
+				if err != nil {
+					t.Fatalf("expected nil, but got %v", err)
+				}
+				if got != view {
+					t.Errorf("expected %v, but got %v", view, got)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+}
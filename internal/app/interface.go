@@ -1,7 +1,17 @@
 package app
 
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type userReader interface {
+	ByID(ctx context.Context, id uuid.UUID) (*User, error)
+}
+
 type emailValidator interface {
-	Validate(email string) error
+	Validate(email string) (string, error)
 }
 
 type passwordValidator interface {
@@ -16,6 +26,30 @@ type passwordComparer interface {
 	Compare(password, hash string) (bool, error)
 }
 
+type passwordRehashChecker interface {
+	NeedsRehash(hash string) bool
+}
+
 type codeGenerator interface {
 	Generate() string
 }
+
+type codeNormalizer interface {
+	Normalize(code string) string
+}
+
+type totpGenerator interface {
+	Generate(account string) (*TwoFactorEnrollment, error)
+}
+
+type totpVerifier interface {
+	Verify(secret, code string) (int64, bool, error)
+}
+
+type tokenGenerator interface {
+	Generate() string
+}
+
+type sessionRevoker interface {
+	RevokeUser(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultUsersPageSize = 50
+	maxUsersPageSize     = 100
+)
+
+type ListUsersUseCase struct {
+	repo   listUsersRepository
+	policy *domain.PolicyService
+}
+
+type ListUsersQuery struct {
+	InitiatorID   uuid.UUID
+	State         string
+	Status        string
+	EmailContains string
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+	Cursor        string
+	Limit         int
+}
+
+type listUsersRepository interface {
+	ByID(ctx context.Context, id uuid.UUID) (*User, error)
+	List(ctx context.Context, filter UserFilter, after *UserCursor, limit int) ([]UserView, error)
+}
+
+func MustListUsersUseCase(
+	repo listUsersRepository,
+	policy *domain.PolicyService,
+) *ListUsersUseCase {
+	if repo == nil {
+		panic("list users use case did not get user repository")
+	}
+	if policy == nil {
+		panic("list users use case did not get policy service")
+	}
+	return &ListUsersUseCase{repo: repo, policy: policy}
+}
+
+func (u *ListUsersUseCase) Execute(ctx context.Context, query *ListUsersQuery) (*UserPage, error) {
+	if err := canReadOthers(ctx, u.repo, u.policy, query.InitiatorID); err != nil {
+		return nil, err
+	}
+
+	filter, err := userFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	limit := query.Limit
+	switch {
+	case limit == 0:
+		limit = defaultUsersPageSize
+	case limit < 0 || limit > maxUsersPageSize:
+		return nil, NewError(
+			ErrInvalidData,
+			CodeUnsupported,
+			fmt.Sprintf("размер страницы должен быть от 1 до %d", maxUsersPageSize),
+		).WithField("limit").WithParam("max", strconv.Itoa(maxUsersPageSize))
+	}
+	var after *UserCursor
+	if query.Cursor != "" {
+		if after, err = decodeUserCursor(query.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	users, err := u.repo.List(ctx, filter, after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &UserPage{Users: users}
+	if len(users) > limit {
+		page.Users = users[:limit]
+		last := page.Users[limit-1]
+		page.NextCursor = encodeUserCursor(UserCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	return page, nil
+}
+
+func userFilter(query *ListUsersQuery) (UserFilter, error) {
+	filter := UserFilter{
+		EmailContains: strings.ToLower(strings.TrimSpace(query.EmailContains)),
+		CreatedFrom:   query.CreatedFrom,
+		CreatedTo:     query.CreatedTo,
+	}
+	if query.State != "" {
+		state, err := domainState(query.State)
+		if err != nil {
+			return UserFilter{}, err
+		}
+		filter.State = state.String()
+	}
+	if query.Status != "" {
+		status, err := domainStatus(query.Status)
+		if err != nil {
+			return UserFilter{}, err
+		}
+		filter.Status = status.String()
+	}
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() &&
+		!filter.CreatedFrom.Before(filter.CreatedTo) {
+		return UserFilter{}, NewError(
+			ErrInvalidData,
+			CodeUnsupported,
+			"начало периода создания должно быть раньше его конца",
+		).WithField("created_from")
+	}
+	return filter, nil
+}
+
+func encodeUserCursor(cursor UserCursor) string {
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + "," + cursor.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeUserCursor(s string) (*UserCursor, error) {
+	invalid := NewError(ErrInvalidData, CodeUnsupported, "некорректный курсор страницы").
+		WithField("cursor")
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+	createdAt, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return nil, invalid
+	}
+	cursor := &UserCursor{}
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, invalid
+	}
+	if cursor.ID, err = uuid.Parse(id); err != nil {
+		return nil, invalid
+	}
+	return cursor, nil
+}
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+type mockListUsersRepository struct {
+	Initiator *User
+	Views     []UserView
+	ErrByID   error
+	ErrList   error
+	Filter    UserFilter
+	After     *UserCursor
+	Limit     int
+}
+
+func (m *mockListUsersRepository) ByID(ctx context.Context, id uuid.UUID) (*User, error) {
+	return m.Initiator, m.ErrByID
+}
+
+func (m *mockListUsersRepository) List(
+	ctx context.Context,
+	filter UserFilter,
+	after *UserCursor,
+	limit int,
+) ([]UserView, error) {
+	m.Filter, m.After, m.Limit = filter, after, limit
+	return m.Views[:min(limit, len(m.Views))], m.ErrList
+}
+
+func TestListUsersUseCase_Execute(t *testing.T) {
+	admin := &User{
+		ID:           uuid.New(),
+		Email:        "admin@mail.com",
+		State:        domain.ACTIVE,
+		Status:       domain.ADMIN,
+		Locale:       domain.RU,
+		PasswordHash: "hash",
+		Version:      1,
+	}
+	user := *admin
+	user.Status = domain.USER
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	views := []UserView{
+		{ID: uuid.New(), CreatedAt: created},
+		{ID: uuid.New(), CreatedAt: created.Add(time.Second)},
+		{ID: uuid.New(), CreatedAt: created.Add(2 * time.Second)},
+	}
+	cursor := encodeUserCursor(UserCursor{CreatedAt: views[1].CreatedAt, ID: views[1].ID})
+	cases := []struct {
+		TestName string
+		Expected error
+		Repo     *mockListUsersRepository
+		Query    *ListUsersQuery
+	}{
+		{
+			TestName: "test_list_users_use_case_ok",
+			Expected: nil,
+			Repo:     &mockListUsersRepository{Initiator: admin, Views: views},
+			Query: &ListUsersQuery{
+				InitiatorID:   admin.ID,
+				State:         domain.ACTIVE,
+				Status:        domain.USER,
+				EmailContains: " Mail ",
+				CreatedFrom:   created,
+				CreatedTo:     created.Add(time.Hour),
+				Cursor:        cursor,
+				Limit:         2,
+			},
+		},
+		{
+			TestName: "test_list_users_use_case_not_admin",
+			Expected: ErrNotAllowed,
+			Repo:     &mockListUsersRepository{Initiator: &user, Views: views},
+			Query:    &ListUsersQuery{InitiatorID: user.ID},
+		},
+		{
+			TestName: "test_list_users_use_case_invalid_state",
+			Expected: ErrInvalidData,
+			Repo:     &mockListUsersRepository{Initiator: admin, Views: views},
+			Query:    &ListUsersQuery{InitiatorID: admin.ID, State: "other"},
+		},
+		{
+			TestName: "test_list_users_use_case_invalid_status",
+			Expected: ErrInvalidData,
+			Repo:     &mockListUsersRepository{Initiator: admin, Views: views},
+			Query:    &ListUsersQuery{InitiatorID: admin.ID, Status: "other"},
+		},
+		{
+			TestName: "test_list_users_use_case_invalid_range",
+			Expected: ErrInvalidData,
+			Repo:     &mockListUsersRepository{Initiator: admin, Views: views},
+			Query: &ListUsersQuery{
+				InitiatorID: admin.ID,
+				CreatedFrom: created,
+				CreatedTo:   created,
+			},
+		},
+		{
+			TestName: "test_list_users_use_case_invalid_limit",
+			Expected: ErrInvalidData,
+			Repo:     &mockListUsersRepository{Initiator: admin, Views: views},
+			Query:    &ListUsersQuery{InitiatorID: admin.ID, Limit: maxUsersPageSize + 1},
+		},
+		{
+			TestName: "test_list_users_use_case_invalid_cursor",
+			Expected: ErrInvalidData,
+			Repo:     &mockListUsersRepository{Initiator: admin, Views: views},
+			Query:    &ListUsersQuery{InitiatorID: admin.ID, Cursor: "not-a-cursor"},
+		},
+		{
+			TestName: "test_list_users_use_case_repository_error",
+			Expected: ErrInternal,
+			Repo:     &mockListUsersRepository{Initiator: admin, ErrList: ErrInternal},
+			Query:    &ListUsersQuery{InitiatorID: admin.ID},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			uc := MustListUsersUseCase(c.Repo, domain.MustPolicyService())
+			_, err := uc.Execute(context.Background(), c.Query)
+			if c.Expected == nil && err != nil {
+				t.Errorf("expected nil, but got %v", err)
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+}
+
+func TestListUsersUseCase_Pagination(t *testing.T) {
+	admin := &User{
+		ID:           uuid.New(),
+		Email:        "admin@mail.com",
+		State:        domain.ACTIVE,
+		Status:       domain.ADMIN,
+		Locale:       domain.RU,
+		PasswordHash: "hash",
+		Version:      1,
+	}
+	created := time.Date(2026, 1, 1, 0, 0, 0, 123456789, time.UTC)
+	views := []UserView{
+		{ID: uuid.New(), CreatedAt: created},
+		{ID: uuid.New(), CreatedAt: created.Add(time.Second)},
+		{ID: uuid.New(), CreatedAt: created.Add(2 * time.Second)},
+	}
+	repo := &mockListUsersRepository{Initiator: admin, Views: views}
+	uc := MustListUsersUseCase(repo, domain.MustPolicyService())
+
+	page, err := uc.Execute(context.Background(), &ListUsersQuery{
+		InitiatorID:   admin.ID,
+		EmailContains: " Mail ",
+		Limit:         2,
+	})
+	if err != nil {
+		t.Fatalf("list users: %v", err)
+	}
+	if repo.Limit != 3 || repo.Filter.EmailContains != "mail" {
+		t.Errorf("expected one extra row and normalized filter, but got %d %v", repo.Limit, repo.Filter)
+	}
+	if len(page.Users) != 2 || page.NextCursor == "" {
+		t.Fatalf("expected first page with cursor, but got %v", page)
+	}
+
+	repo.Views = views[2:]
+	page, err = uc.Execute(context.Background(), &ListUsersQuery{
+		InitiatorID: admin.ID,
+		Cursor:      page.NextCursor,
+		Limit:       2,
+	})
+	if err != nil {
+		t.Fatalf("list users: %v", err)
+	}
+	if repo.After == nil || repo.After.ID != views[1].ID || !repo.After.CreatedAt.Equal(views[1].CreatedAt) {
+		t.Errorf("expected cursor after %s, but got %v", views[1].ID, repo.After)
+	}
+	if len(page.Users) != 1 || page.NextCursor != "" {
+		t.Errorf("expected last page without cursor, but got %v", page)
+	}
+}
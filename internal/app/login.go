@@ -0,0 +1,226 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+type LoginUseCase struct {
+	repo              loginRepository
+	sessions          loginSessionRepository
+	limiter           attemptLimiter
+	emailValidator    emailValidator
+	passwordComparer  passwordComparer
+	rehashChecker     passwordRehashChecker
+	passwordHasher    passwordHasher
+	totpVerifier      totpVerifier
+	accessTokenIssuer accessTokenIssuer
+	tokenGenerator    tokenGenerator
+	sessionTTL        time.Duration
+}
+
+type LoginCommand struct {
+	Email    string
+	Password string
+	TOTPCode string
+	ClientIP string
+}
+
+type loginRepository interface {
+	ByEmail(ctx context.Context, email string) (*User, error)
+	Save(ctx context.Context, user *User) error
+}
+
+type accessTokenIssuer interface {
+	Issue(user *User) (string, error)
+}
+
+type loginSessionRepository interface {
+	Save(ctx context.Context, session *Session) error
+}
+
+func MustLoginUseCase(
+	repo loginRepository,
+	sessions loginSessionRepository,
+	limiter attemptLimiter,
+	emailValidator emailValidator,
+	passwordComparer passwordComparer,
+	rehashChecker passwordRehashChecker,
+	passwordHasher passwordHasher,
+	totpVerifier totpVerifier,
+	accessTokenIssuer accessTokenIssuer,
+	tokenGenerator tokenGenerator,
+	sessionTTL time.Duration,
+) *LoginUseCase {
+	if repo == nil {
+		panic("login use case did not get user repository")
+	}
+	if sessions == nil {
+		panic("login use case did not get session repository")
+	}
+	if limiter == nil {
+		panic("login use case did not get attempt limiter")
+	}
+	if emailValidator == nil {
+		panic("login use case did not get email validator")
+	}
+	if passwordComparer == nil {
+		panic("login use case did not get password comparer")
+	}
+	if rehashChecker == nil {
+		panic("login use case did not get password rehash checker")
+	}
+	if passwordHasher == nil {
+		panic("login use case did not get password hasher")
+	}
+	if totpVerifier == nil {
+		panic("login use case did not get totp verifier")
+	}
+	if accessTokenIssuer == nil {
+		panic("login use case did not get access token issuer")
+	}
+	if tokenGenerator == nil {
+		panic("login use case did not get token generator")
+	}
+	if sessionTTL <= 0 {
+		panic("login use case did not get session ttl")
+	}
+	return &LoginUseCase{
+		repo:              repo,
+		sessions:          sessions,
+		limiter:           limiter,
+		emailValidator:    emailValidator,
+		passwordComparer:  passwordComparer,
+		rehashChecker:     rehashChecker,
+		passwordHasher:    passwordHasher,
+		totpVerifier:      totpVerifier,
+		accessTokenIssuer: accessTokenIssuer,
+		tokenGenerator:    tokenGenerator,
+		sessionTTL:        sessionTTL,
+	}
+}
+
+func (u *LoginUseCase) Execute(ctx context.Context, command *LoginCommand) (*Tokens, error) {
+	email, err := u.emailValidator.Validate(command.Email)
+	if errors.Is(err, ErrInvalidData) {
+		return nil, invalidCredentials()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keys := newAttemptKeys(loginLimit, "email:"+email, command.ClientIP)
+	if err = allowAttempt(ctx, u.limiter, keys); err != nil {
+		return nil, err
+	}
+
+	appUser, err := u.repo.ByEmail(ctx, email)
+	if errors.Is(err, ErrNotFound) {
+		return nil, failAttempt(ctx, u.limiter, keys, invalidCredentials())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	compare, err := u.passwordComparer.Compare(command.Password, appUser.PasswordHash)
+	if err != nil {
+		return nil, err
+	}
+	if !compare {
+		return nil, failAttempt(ctx, u.limiter, keys, invalidCredentials())
+	}
+	if err = resetAttempts(ctx, u.limiter, keys); err != nil {
+		return nil, err
+	}
+
+	domainUser, err := domainUser(appUser)
+	if err != nil {
+		return nil, err
+	}
+	if !domainUser.State().IsActive() {
+		return nil, userNotActive(domainUser)
+	}
+
+	twoFactor := domainUser.TwoFactor().Enabled()
+	if twoFactor {
+		if err = u.useTwoFactor(ctx, domainUser, command); err != nil {
+			return nil, err
+		}
+	}
+
+	rehash := u.rehashChecker.NeedsRehash(appUser.PasswordHash)
+	if rehash {
+		if err = u.rehash(domainUser, command.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	if twoFactor || rehash {
+		if appUser, err = u.save(ctx, domainUser, appUser, twoFactor); err != nil {
+			return nil, err
+		}
+	}
+
+	accessToken, err := u.accessTokenIssuer.Issue(appUser)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken := u.tokenGenerator.Generate()
+	session := newSession(appUser.ID, uuid.New(), refreshToken, u.sessionTTL)
+	if err = u.sessions.Save(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return &Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+func (u *LoginUseCase) useTwoFactor(
+	ctx context.Context,
+	domainUser *domain.User,
+	command *LoginCommand,
+) error {
+	if command.TOTPCode == "" {
+		return twoFactorRequired()
+	}
+	keys := twoFactorKeys(domainUser, command.ClientIP)
+	if err := allowAttempt(ctx, u.limiter, keys); err != nil {
+		return err
+	}
+	return useTwoFactor(ctx, u.totpVerifier, u.limiter, keys, domainUser, command.TOTPCode)
+}
+
+func (u *LoginUseCase) rehash(domainUser *domain.User, password string) error {
+	passwordHash, err := u.passwordHasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	if err = domainUser.NewPasswordHash(passwordHash); err != nil {
+		return handleDomainError(err)
+	}
+	return nil
+}
+
+func (u *LoginUseCase) save(
+	ctx context.Context,
+	domainUser *domain.User,
+	appUser *User,
+	twoFactor bool,
+) (*User, error) {
+	modified, err := modifiedUser(domainUser)
+	if err != nil {
+		return nil, err
+	}
+	err = u.repo.Save(ctx, modified)
+	if errors.Is(err, ErrConflict) && !twoFactor {
+		return appUser, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return modified, nil
+}
@@ -0,0 +1,546 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+type mockLoginRepository struct {
+	User       *User
+	Saved      *User
+	ErrByEmail error
+	ErrSave    error
+}
+
+func (m *mockLoginRepository) ByEmail(ctx context.Context, email string) (*User, error) {
+	return m.User, m.ErrByEmail
+}
+
+func (m *mockLoginRepository) Save(ctx context.Context, user *User) error {
+	m.Saved = user
+	return m.ErrSave
+}
+
+type mockAccessTokenIssuer struct {
+	Err error
+}
+
+func (m *mockAccessTokenIssuer) Issue(user *User) (string, error) {
+	return "access_" + user.ID.String(), m.Err
+}
+
+type mockLoginSessionRepository struct {
+	ErrSave error
+}
+
+func (m *mockLoginSessionRepository) Save(ctx context.Context, session *Session) error {
+	return m.ErrSave
+}
+
+func TestLoginUseCase_Execute(t *testing.T) {
+	activeUser := &User{
+		ID:           uuid.New(),
+		Email:        "test@mail.com",
+		State:        domain.ACTIVE,
+		Status:       domain.USER,
+		Locale:       domain.RU,
+		PasswordHash: "password",
+		Version:      1,
+	}
+	frozenUser := &User{
+		ID:           uuid.New(),
+		Email:        "test@mail.com",
+		State:        domain.FROZEN,
+		Status:       domain.USER,
+		Locale:       domain.RU,
+		PasswordHash: "password",
+		Version:      1,
+	}
+	deletedUser := &User{
+		ID:           uuid.New(),
+		Email:        "test@mail.com",
+		State:        domain.DELETED,
+		Status:       domain.ADMIN,
+		Locale:       domain.RU,
+		PasswordHash: "password",
+		Version:      1,
+	}
+	validPassword := "password"
+	invalidPassword := "invalid_password"
+	cases := []struct {
+		TestName string
+		Expected error
+		UC       *LoginUseCase
+		Command  *LoginCommand
+	}{
+		{
+			TestName: "test_login_use_case_ok",
+			Expected: nil,
+			UC: MustLoginUseCase(
+				&mockLoginRepository{User: activeUser},
+				&mockLoginSessionRepository{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordComparer{},
+				&mockPasswordRehashChecker{},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			),
+			Command: &LoginCommand{Email: activeUser.Email, Password: validPassword},
+		},
+		{
+			TestName: "test_login_use_case_invalid_email",
+			Expected: ErrInvalidData,
+			UC: MustLoginUseCase(
+				&mockLoginRepository{User: activeUser},
+				&mockLoginSessionRepository{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{InvalidEmails: []string{activeUser.Email}},
+				&mockPasswordComparer{},
+				&mockPasswordRehashChecker{},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			),
+			Command: &LoginCommand{Email: activeUser.Email, Password: validPassword},
+		},
+		{
+			TestName: "test_login_use_case_email_not_found",
+			Expected: ErrInvalidData,
+			UC: MustLoginUseCase(
+				&mockLoginRepository{ErrByEmail: ErrNotFound},
+				&mockLoginSessionRepository{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordComparer{},
+				&mockPasswordRehashChecker{},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			),
+			Command: &LoginCommand{Email: activeUser.Email, Password: validPassword},
+		},
+		{
+			TestName: "test_login_use_case_by_email_error",
+			Expected: ErrInternal,
+			UC: MustLoginUseCase(
+				&mockLoginRepository{ErrByEmail: ErrInternal},
+				&mockLoginSessionRepository{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordComparer{},
+				&mockPasswordRehashChecker{},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			),
+			Command: &LoginCommand{Email: activeUser.Email, Password: validPassword},
+		},
+		{
+			TestName: "test_login_use_case_invalid_password",
+			Expected: ErrInvalidData,
+			UC: MustLoginUseCase(
+				&mockLoginRepository{User: activeUser},
+				&mockLoginSessionRepository{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordComparer{InvalidPassword: []string{invalidPassword}},
+				&mockPasswordRehashChecker{},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			),
+			Command: &LoginCommand{Email: activeUser.Email, Password: invalidPassword},
+		},
+		{
+			TestName: "test_login_use_case_comparing_error",
+			Expected: ErrInternal,
+			UC: MustLoginUseCase(
+				&mockLoginRepository{User: activeUser},
+				&mockLoginSessionRepository{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordComparer{Err: ErrInternal},
+				&mockPasswordRehashChecker{},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			),
+			Command: &LoginCommand{Email: activeUser.Email, Password: validPassword},
+		},
+		{
+			TestName: "test_login_use_case_frozen_user",
+			Expected: ErrUserNotActive,
+			UC: MustLoginUseCase(
+				&mockLoginRepository{User: frozenUser},
+				&mockLoginSessionRepository{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordComparer{},
+				&mockPasswordRehashChecker{},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			),
+			Command: &LoginCommand{Email: frozenUser.Email, Password: validPassword},
+		},
+		{
+			TestName: "test_login_use_case_deleted_user",
+			Expected: ErrUserNotActive,
+			UC: MustLoginUseCase(
+				&mockLoginRepository{User: deletedUser},
+				&mockLoginSessionRepository{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordComparer{},
+				&mockPasswordRehashChecker{},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			),
+			Command: &LoginCommand{Email: deletedUser.Email, Password: validPassword},
+		},
+		{
+			TestName: "test_login_use_case_access_token_error",
+			Expected: ErrInternal,
+			UC: MustLoginUseCase(
+				&mockLoginRepository{User: activeUser},
+				&mockLoginSessionRepository{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordComparer{},
+				&mockPasswordRehashChecker{},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{Err: ErrInternal},
+				&mockTokenGenerator{},
+				time.Hour,
+			),
+			Command: &LoginCommand{Email: activeUser.Email, Password: validPassword},
+		},
+		{
+			TestName: "test_login_use_case_saving_session_error",
+			Expected: ErrInternal,
+			UC: MustLoginUseCase(
+				&mockLoginRepository{User: activeUser},
+				&mockLoginSessionRepository{ErrSave: ErrInternal},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordComparer{},
+				&mockPasswordRehashChecker{},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			),
+			Command: &LoginCommand{Email: activeUser.Email, Password: validPassword},
+		},
+		{
+			TestName: "test_login_use_case_rehash_ok",
+			Expected: nil,
+			UC: MustLoginUseCase(
+				&mockLoginRepository{User: activeUser},
+				&mockLoginSessionRepository{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordComparer{},
+				&mockPasswordRehashChecker{Rehash: true},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			),
+			Command: &LoginCommand{Email: activeUser.Email, Password: validPassword},
+		},
+		{
+			TestName: "test_login_use_case_rehash_conflict",
+			Expected: nil,
+			UC: MustLoginUseCase(
+				&mockLoginRepository{User: activeUser, ErrSave: ErrConflict},
+				&mockLoginSessionRepository{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordComparer{},
+				&mockPasswordRehashChecker{Rehash: true},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			),
+			Command: &LoginCommand{Email: activeUser.Email, Password: validPassword},
+		},
+		{
+			TestName: "test_login_use_case_rehash_hashing_error",
+			Expected: ErrInternal,
+			UC: MustLoginUseCase(
+				&mockLoginRepository{User: activeUser},
+				&mockLoginSessionRepository{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordComparer{},
+				&mockPasswordRehashChecker{Rehash: true},
+				&mockPasswordHasher{Err: ErrInternal},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			),
+			Command: &LoginCommand{Email: activeUser.Email, Password: validPassword},
+		},
+		{
+			TestName: "test_login_use_case_rehash_saving_error",
+			Expected: ErrInternal,
+			UC: MustLoginUseCase(
+				&mockLoginRepository{User: activeUser, ErrSave: ErrInternal},
+				&mockLoginSessionRepository{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordComparer{},
+				&mockPasswordRehashChecker{Rehash: true},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			),
+			Command: &LoginCommand{Email: activeUser.Email, Password: validPassword},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			tokens, err := c.UC.Execute(context.Background(), c.Command)
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected nil, but got %v", err)
+				}
+				if tokens == nil || tokens.AccessToken == "" || tokens.RefreshToken == "" {
+					t.Errorf("expected access and refresh tokens, but got %v", tokens)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+}
+
+func TestLoginUseCase_Rehash(t *testing.T) {
+	user := &User{
+		ID:           uuid.New(),
+		Email:        "test@mail.com",
+		State:        domain.ACTIVE,
+		Status:       domain.USER,
+		Locale:       domain.RU,
+		PasswordHash: "legacy_hash",
+		Version:      3,
+	}
+	cases := []struct {
+		TestName string
+		Rehash   bool
+		Expected *User
+	}{
+		{
+			TestName: "test_login_use_case_rehash_saves_new_hash",
+			Rehash:   true,
+			Expected: &User{
+				ID:           user.ID,
+				Email:        user.Email,
+				State:        user.State,
+				Status:       user.Status,
+				Locale:       user.Locale,
+				PasswordHash: "new_password",
+				Version:      4,
+			},
+		},
+		{
+			TestName: "test_login_use_case_current_hash_not_saved",
+			Rehash:   false,
+			Expected: nil,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			repo := &mockLoginRepository{User: user}
+			uc := MustLoginUseCase(
+				repo,
+				&mockLoginSessionRepository{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordComparer{},
+				&mockPasswordRehashChecker{Rehash: c.Rehash},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			)
+			_, err := uc.Execute(
+				context.Background(),
+				&LoginCommand{Email: user.Email, Password: "new_password"},
+			)
+			if err != nil {
+				t.Fatalf("expected nil, but got %v", err)
+			}
+			if c.Expected == nil && repo.Saved != nil {
+				t.Errorf("expected user not to be saved, but got %v", repo.Saved)
+			}
+			if c.Expected != nil && (repo.Saved == nil || *repo.Saved != *c.Expected) {
+				t.Errorf("expected %v to be saved, but got %v", c.Expected, repo.Saved)
+			}
+		})
+	}
+}
+
+func TestLoginUseCase_TwoFactor(t *testing.T) {
+	user := &User{
+		ID:               uuid.New(),
+		Email:            "test@mail.com",
+		State:            domain.ACTIVE,
+		Status:           domain.ADMIN,
+		Locale:           domain.RU,
+		PasswordHash:     "password",
+		TOTPSecret:       "secret",
+		TwoFactorEnabled: true,
+		TOTPLastStep:     10,
+		Version:          3,
+	}
+	verifier := &mockTOTPVerifier{Steps: map[string]int64{"111111": 11, "101010": 10}}
+	cases := []struct {
+		TestName string
+		Expected error
+		Code     string
+		Rehash   bool
+		Limiter  *mockAttemptLimiter
+		ErrSave  error
+		Saved    *User
+	}{
+		{
+			TestName: "test_login_use_case_two_factor_ok",
+			Expected: nil,
+			Code:     "111111",
+			Limiter:  &mockAttemptLimiter{},
+			Saved: &User{
+				ID:               user.ID,
+				Email:            user.Email,
+				State:            user.State,
+				Status:           user.Status,
+				Locale:           user.Locale,
+				PasswordHash:     "password",
+				TOTPSecret:       "secret",
+				TwoFactorEnabled: true,
+				TOTPLastStep:     11,
+				Version:          4,
+			},
+		},
+		{
+			TestName: "test_login_use_case_two_factor_with_rehash",
+			Expected: nil,
+			Code:     "111111",
+			Rehash:   true,
+			Limiter:  &mockAttemptLimiter{},
+			Saved: &User{
+				ID:               user.ID,
+				Email:            user.Email,
+				State:            user.State,
+				Status:           user.Status,
+				Locale:           user.Locale,
+				PasswordHash:     "new_password",
+				TOTPSecret:       "secret",
+				TwoFactorEnabled: true,
+				TOTPLastStep:     11,
+				Version:          4,
+			},
+		},
+		{
+			TestName: "test_login_use_case_two_factor_required",
+			Expected: ErrUnauthenticated,
+			Code:     "",
+			Limiter:  &mockAttemptLimiter{},
+		},
+		{
+			TestName: "test_login_use_case_two_factor_invalid",
+			Expected: ErrInvalidData,
+			Code:     "222222",
+			Limiter:  &mockAttemptLimiter{},
+		},
+		{
+			TestName: "test_login_use_case_two_factor_replayed",
+			Expected: ErrInvalidData,
+			Code:     "101010",
+			Limiter:  &mockAttemptLimiter{},
+		},
+		{
+			TestName: "test_login_use_case_two_factor_rate_limited",
+			Expected: ErrTooManyRequests,
+			Code:     "111111",
+			Limiter:  &mockAttemptLimiter{Err: TooManyRequestsError(time.Minute)},
+		},
+		{
+			TestName: "test_login_use_case_two_factor_concurrent_use",
+			Expected: ErrConflict,
+			Code:     "111111",
+			Limiter:  &mockAttemptLimiter{},
+			ErrSave:  ErrConflict,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			repo := &mockLoginRepository{User: user, ErrSave: c.ErrSave}
+			uc := MustLoginUseCase(
+				repo,
+				&mockLoginSessionRepository{},
+				c.Limiter,
+				&mockEmailValidator{},
+				&mockPasswordComparer{},
+				&mockPasswordRehashChecker{Rehash: c.Rehash},
+				&mockPasswordHasher{},
+				verifier,
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			)
+			tokens, err := uc.Execute(context.Background(), &LoginCommand{
+				Email:    user.Email,
+				Password: "new_password",
+				TOTPCode: c.Code,
+			})
+			if !errors.Is(err, c.Expected) {
+				t.Fatalf("expected %v, but got %v", c.Expected, err)
+			}
+			if c.Expected == nil && tokens == nil {
+				t.Error("expected tokens")
+			}
+			if c.Saved != nil && (repo.Saved == nil || *repo.Saved != *c.Saved) {
+				t.Errorf("expected %v to be saved, but got %v", c.Saved, repo.Saved)
+			}
+			if c.Saved == nil && c.ErrSave == nil && repo.Saved != nil {
+				t.Errorf("expected user not to be saved, but got %v", repo.Saved)
+			}
+		})
+	}
+}
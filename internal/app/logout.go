@@ -0,0 +1,44 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+type LogoutUseCase struct {
+	sessions logoutSessionRepository
+}
+
+type LogoutCommand struct {
+	RefreshToken string
+}
+
+type logoutSessionRepository interface {
+	ByTokenHash(ctx context.Context, tokenHash string) (*Session, error)
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+}
+
+func MustLogoutUseCase(sessions logoutSessionRepository) *LogoutUseCase {
+	if sessions == nil {
+		panic("logout use case did not get session repository")
+	}
+	return &LogoutUseCase{sessions: sessions}
+}
+
+func (u *LogoutUseCase) Execute(ctx context.Context, command *LogoutCommand) error {
+	session, err := u.sessions.ByTokenHash(ctx, hashRefreshToken(command.RefreshToken))
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if session.Revoked {
+		return nil
+	}
+
+	return u.sessions.RevokeFamily(ctx, session.FamilyID)
+}
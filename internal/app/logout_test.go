@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type mockLogoutSessionRepository struct {
+	Session         *Session
+	ErrByTokenHash  error
+	ErrRevokeFamily error
+}
+
+func (m *mockLogoutSessionRepository) ByTokenHash(
+	ctx context.Context,
+	tokenHash string,
+) (*Session, error) {
+	return m.Session, m.ErrByTokenHash
+}
+
+func (m *mockLogoutSessionRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	return m.ErrRevokeFamily
+}
+
+func TestLogoutUseCase_Execute(t *testing.T) {
+	session := &Session{ID: uuid.New(), FamilyID: uuid.New(), UserID: uuid.New()}
+	cases := []struct {
+		TestName string
+		Expected error
+		UC       *LogoutUseCase
+	}{
+		{
+			TestName: "test_logout_use_case_ok",
+			Expected: nil,
+			UC:       MustLogoutUseCase(&mockLogoutSessionRepository{Session: session}),
+		},
+		{
+			TestName: "test_logout_use_case_unknown_token",
+			Expected: nil,
+			UC:       MustLogoutUseCase(&mockLogoutSessionRepository{ErrByTokenHash: ErrNotFound}),
+		},
+		{
+			TestName: "test_logout_use_case_already_revoked",
+			Expected: nil,
+			UC: MustLogoutUseCase(&mockLogoutSessionRepository{
+				Session:         &Session{ID: uuid.New(), FamilyID: uuid.New(), Revoked: true},
+				ErrRevokeFamily: ErrInternal,
+			}),
+		},
+		{
+			TestName: "test_logout_use_case_getting_session_error",
+			Expected: ErrInternal,
+			UC:       MustLogoutUseCase(&mockLogoutSessionRepository{ErrByTokenHash: ErrInternal}),
+		},
+		{
+			TestName: "test_logout_use_case_revoking_error",
+			Expected: ErrInternal,
+			UC: MustLogoutUseCase(&mockLogoutSessionRepository{
+				Session:         session,
+				ErrRevokeFamily: ErrInternal,
+			}),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := c.UC.Execute(context.Background(), &LogoutCommand{RefreshToken: "token"})
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected nil, but got %v", err)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type LookupUserUseCase struct {
+	repo           lookupUserRepository
+	emailValidator emailValidator
+}
+
+type LookupUserQuery struct {
+	ID    uuid.UUID
+	Email string
+}
+
+type lookupUserRepository interface {
+	ByEmail(ctx context.Context, email string) (*User, error)
+	ViewByID(ctx context.Context, id uuid.UUID) (*UserView, error)
+}
+
+func MustLookupUserUseCase(
+	repo lookupUserRepository,
+	emailValidator emailValidator,
+) *LookupUserUseCase {
+	if repo == nil {
+		panic("lookup user use case did not get user repository")
+	}
+	if emailValidator == nil {
+		panic("lookup user use case did not get email validator")
+	}
+	return &LookupUserUseCase{repo: repo, emailValidator: emailValidator}
+}
+
+func (u *LookupUserUseCase) Execute(ctx context.Context, query *LookupUserQuery) (*UserView, error) {
+	switch {
+	case query.ID != uuid.Nil && query.Email != "":
+		return nil, NewError(
+			ErrInvalidData,
+			CodeUserLookupConflict,
+			"нужно указать либо id, либо email пользователя",
+		)
+	case query.ID != uuid.Nil:
+		return u.repo.ViewByID(ctx, query.ID)
+	case query.Email != "":
+		email, err := u.emailValidator.Validate(query.Email)
+		if err != nil {
+			return nil, err
+		}
+		user, err := u.repo.ByEmail(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+		return u.repo.ViewByID(ctx, user.ID)
+	default:
+		return nil, NewError(ErrInvalidData, CodeRequired, "не указан id или email пользователя").
+			WithField("id")
+	}
+}
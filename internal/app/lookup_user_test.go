@@ -0,0 +1,136 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+type mockLookupUserRepository struct {
+	User       *User
+	CreatedAt  time.Time
+	ErrByID    error
+	ErrByEmail error
+}
+
+func (m *mockLookupUserRepository) ByEmail(ctx context.Context, email string) (*User, error) {
+	return m.User, m.ErrByEmail
+}
+
+func (m *mockLookupUserRepository) ViewByID(ctx context.Context, id uuid.UUID) (*UserView, error) {
+	if m.ErrByID != nil {
+		return nil, m.ErrByID
+	}
+	return &UserView{
+		ID:        m.User.ID,
+		Email:     m.User.Email,
+		State:     m.User.State,
+		Status:    m.User.Status,
+		Locale:    m.User.Locale,
+		Version:   m.User.Version,
+		CreatedAt: m.CreatedAt,
+	}, nil
+}
+
+func TestLookupUserUseCase_Execute(t *testing.T) {
+	user := &User{
+		ID:           uuid.New(),
+		Email:        "test@mail.com",
+		State:        domain.ACTIVE,
+		Status:       domain.USER,
+		Locale:       domain.RU,
+		PasswordHash: "password_hash",
+		Version:      2,
+	}
+	createdAt := time.Now()
+	cases := []struct {
+		TestName string
+		Expected error
+		UC       *LookupUserUseCase
+		Query    *LookupUserQuery
+	}{
+		{
+			TestName: "test_lookup_user_use_case_by_id_ok",
+			Expected: nil,
+			UC: MustLookupUserUseCase(
+				&mockLookupUserRepository{User: user, CreatedAt: createdAt},
+				&mockEmailValidator{},
+			),
+			Query: &LookupUserQuery{ID: user.ID},
+		},
+		{
+			TestName: "test_lookup_user_use_case_by_email_ok",
+			Expected: nil,
+			UC: MustLookupUserUseCase(
+				&mockLookupUserRepository{User: user, CreatedAt: createdAt},
+				&mockEmailValidator{},
+			),
+			Query: &LookupUserQuery{Email: user.Email},
+		},
+		{
+			TestName: "test_lookup_user_use_case_by_id_not_found",
+			Expected: ErrNotFound,
+			UC: MustLookupUserUseCase(
+				&mockLookupUserRepository{ErrByID: ErrNotFound},
+				&mockEmailValidator{},
+			),
+			Query: &LookupUserQuery{ID: user.ID},
+		},
+		{
+			TestName: "test_lookup_user_use_case_by_email_not_found",
+			Expected: ErrNotFound,
+			UC: MustLookupUserUseCase(
+				&mockLookupUserRepository{ErrByEmail: ErrNotFound},
+				&mockEmailValidator{},
+			),
+			Query: &LookupUserQuery{Email: user.Email},
+		},
+		{
+			TestName: "test_lookup_user_use_case_invalid_email",
+			Expected: ErrInvalidData,
+			UC: MustLookupUserUseCase(
+				&mockLookupUserRepository{User: user, CreatedAt: createdAt},
+				&mockEmailValidator{InvalidEmails: []string{"invalid"}},
+			),
+			Query: &LookupUserQuery{Email: "invalid"},
+		},
+		{
+			TestName: "test_lookup_user_use_case_both_keys",
+			Expected: ErrInvalidData,
+			UC: MustLookupUserUseCase(
+				&mockLookupUserRepository{User: user, CreatedAt: createdAt},
+				&mockEmailValidator{},
+			),
+			Query: &LookupUserQuery{ID: user.ID, Email: user.Email},
+		},
+		{
+			TestName: "test_lookup_user_use_case_without_keys",
+			Expected: ErrInvalidData,
+			UC: MustLookupUserUseCase(
+				&mockLookupUserRepository{User: user, CreatedAt: createdAt},
+				&mockEmailValidator{},
+			),
+			Query: &LookupUserQuery{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			view, err := c.UC.Execute(context.Background(), c.Query)
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected nil, but got %v", err)
+				}
+				if view == nil || view.ID != user.ID || !view.CreatedAt.Equal(createdAt) {
+					t.Errorf("expected view of %v, but got %v", user, view)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+}
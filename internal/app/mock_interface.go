@@ -1,19 +1,23 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"slices"
+	"strings"
+
+	"github.com/google/uuid"
 )
 
 type mockEmailValidator struct {
 	InvalidEmails []string
 }
 
-func (m *mockEmailValidator) Validate(email string) error {
+func (m *mockEmailValidator) Validate(email string) (string, error) {
 	if !slices.Contains(m.InvalidEmails, email) {
-		return nil
+		return strings.ToLower(email), nil
 	} else {
-		return fmt.Errorf("%w: invalid email %s", ErrInvalidData, email)
+		return "", fmt.Errorf("%w: invalid email %s", ErrInvalidData, email)
 	}
 }
 
@@ -29,6 +33,10 @@ func (m *mockCodeGenerator) Generate() string {
 	}
 }
 
+func (m *mockCodeGenerator) Normalize(code string) string {
+	return strings.TrimSpace(code)
+}
+
 type mockPasswordValidator struct {
 	InvalidPasswords []string
 }
@@ -57,3 +65,69 @@ type mockPasswordComparer struct {
 func (m *mockPasswordComparer) Compare(password, hash string) (bool, error) {
 	return !slices.Contains(m.InvalidPassword, password), m.Err
 }
+
+type mockPasswordRehashChecker struct {
+	Rehash bool
+}
+
+func (m *mockPasswordRehashChecker) NeedsRehash(hash string) bool {
+	return m.Rehash
+}
+
+type mockTokenGenerator struct{}
+
+func (m *mockTokenGenerator) Generate() string {
+	return uuid.NewString()
+}
+
+type mockTOTPGenerator struct {
+	Secret string
+	Err    error
+}
+
+func (m *mockTOTPGenerator) Generate(account string) (*TwoFactorEnrollment, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return &TwoFactorEnrollment{Secret: m.Secret, URI: "otpauth://totp/" + account}, nil
+}
+
+type mockTOTPVerifier struct {
+	Steps map[string]int64
+	Err   error
+}
+
+func (m *mockTOTPVerifier) Verify(secret, code string) (int64, bool, error) {
+	step, ok := m.Steps[code]
+	return step, ok, m.Err
+}
+
+type mockSessionRevoker struct {
+	Err     error
+	Revoked []uuid.UUID
+}
+
+func (m *mockSessionRevoker) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	m.Revoked = append(m.Revoked, userID)
+	return m.Err
+}
+
+type mockAttemptLimiter struct {
+	Err     error
+	Failed  [][]string
+	Cleared [][]string
+}
+
+func (m *mockAttemptLimiter) Allow(ctx context.Context, keys ...string) error {
+	return m.Err
+}
+
+func (m *mockAttemptLimiter) Fail(ctx context.Context, keys ...string) error {
+	m.Failed = append(m.Failed, keys)
+	return nil
+}
+
+func (m *mockAttemptLimiter) Reset(ctx context.Context, keys ...string) error {
+	m.Cleared = append(m.Cleared, keys)
+	return nil
+}
@@ -2,7 +2,6 @@ package app
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/google/uuid"
 )
@@ -14,8 +13,7 @@ type newEmailRepository interface {
 }
 
 type newEmailCodeStore interface {
-	GetNewEmail(ctx context.Context, key string) (string, error)
-	DelNewEmail(ctx context.Context, key string) error
+	ConsumeNewEmail(ctx context.Context, key, code string) error
 }
 
 type NewEmailCommand struct {
@@ -25,11 +23,14 @@ type NewEmailCommand struct {
 	NewEmailCode string
 	OldEmailCode string
 	Password     string
+	ClientIP     string
 }
 
 type NewEmailUseCase struct {
 	repo             newEmailRepository
 	store            newEmailCodeStore
+	codeNormalizer   codeNormalizer
+	limiter          attemptLimiter
 	emailValidator   emailValidator
 	passwordComparer passwordComparer
 }
@@ -37,6 +38,8 @@ type NewEmailUseCase struct {
 func MustNewEmailUseCase(
 	repo newEmailRepository,
 	store newEmailCodeStore,
+	codeNormalizer codeNormalizer,
+	limiter attemptLimiter,
 	emailValidator emailValidator,
 	passwordComparer passwordComparer,
 ) *NewEmailUseCase {
@@ -46,6 +49,12 @@ func MustNewEmailUseCase(
 	if store == nil {
 		panic("new email use case did not get code store")
 	}
+	if codeNormalizer == nil {
+		panic("new email use case did not get code normalizer")
+	}
+	if limiter == nil {
+		panic("new email use case did not get attempt limiter")
+	}
 	if emailValidator == nil {
 		panic("new email use case did not get email validator")
 	}
@@ -55,6 +64,8 @@ func MustNewEmailUseCase(
 	return &NewEmailUseCase{
 		repo:             repo,
 		store:            store,
+		codeNormalizer:   codeNormalizer,
+		limiter:          limiter,
 		emailValidator:   emailValidator,
 		passwordComparer: passwordComparer,
 	}
@@ -62,20 +73,18 @@ func MustNewEmailUseCase(
 
 func (u *NewEmailUseCase) Execute(ctx context.Context, command *NewEmailCommand) error {
 	if command.InitiatorID != command.UserID {
-		return fmt.Errorf("%w: вы не можете изменять email другим пользователям", ErrNotAllowed)
+		return accessDenied("вы не можете изменять email другим пользователям")
 	}
 
-	if err := u.emailValidator.Validate(command.NewEmail); err != nil {
+	keys := newAttemptKeys(newEmailLimit, "user:"+command.UserID.String(), command.ClientIP)
+	if err := allowAttempt(ctx, u.limiter, keys); err != nil {
 		return err
 	}
 
-	exists, err := u.repo.EmailExists(ctx, command.NewEmail)
+	newEmail, err := u.emailValidator.Validate(command.NewEmail)
 	if err != nil {
 		return err
 	}
-	if exists {
-		return fmt.Errorf("%w: email %s уже существует", ErrAlreadyExists, command.NewEmail)
-	}
 
 	appUser, err := u.repo.ByID(ctx, command.UserID)
 	if err != nil {
@@ -87,23 +96,35 @@ func (u *NewEmailUseCase) Execute(ctx context.Context, command *NewEmailCommand)
 		return err
 	}
 	if !compare {
-		return fmt.Errorf("%w: неверный пароль", ErrInvalidData)
+		return failAttempt(ctx, u.limiter, keys, wrongPassword("password"))
 	}
 
-	oldEmailCode, err := u.store.GetNewEmail(ctx, appUser.ID.String()+appUser.Email)
+	exists, err := u.repo.EmailExists(ctx, newEmail)
 	if err != nil {
 		return err
 	}
-	newEmailCode, err := u.store.GetNewEmail(ctx, appUser.ID.String()+command.NewEmail)
+	if exists {
+		return rejectAttempt(
+			ctx,
+			u.limiter,
+			keys,
+			emailTaken(ErrAlreadyExists, "new_email", newEmail),
+		)
+	}
+
+	err = u.store.ConsumeNewEmail(
+		ctx,
+		newEmailKey(appUser.ID, newEmail),
+		newEmailCodes(
+			u.codeNormalizer.Normalize(command.OldEmailCode),
+			u.codeNormalizer.Normalize(command.NewEmailCode),
+		),
+	)
 	if err != nil {
-		return err
-	}
-
-	if oldEmailCode != command.OldEmailCode {
-		return fmt.Errorf("%w: не верный код для текущего email", ErrInvalidData)
+		return failAttempt(ctx, u.limiter, keys, err)
 	}
-	if newEmailCode != command.NewEmailCode {
-		return fmt.Errorf("%w: не верный код для нового email", ErrInvalidData)
+	if err = resetAttempts(ctx, u.limiter, keys); err != nil {
+		return err
 	}
 
 	domainUser, err := domainUser(appUser)
@@ -111,14 +132,10 @@ func (u *NewEmailUseCase) Execute(ctx context.Context, command *NewEmailCommand)
 		return err
 	}
 
-	if err = domainUser.NewEmail(command.NewEmail); err != nil {
+	if err = domainUser.NewEmail(newEmail); err != nil {
 		return handleDomainError(err)
 	}
 
-	if err = u.store.DelNewEmail(ctx, appUser.ID.String()+appUser.Email); err != nil {
-		return err
-	}
-
 	newAppUser, err := modifiedUser(domainUser)
 	if err != nil {
 		return err
@@ -129,3 +146,7 @@ func (u *NewEmailUseCase) Execute(ctx context.Context, command *NewEmailCommand)
 
 	return nil
 }
+
+func newEmailCodes(oldEmailCode, newEmailCode string) string {
+	return oldEmailCode + ":" + newEmailCode
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"slices"
 	"testing"
 
@@ -31,27 +32,23 @@ func (m *mockNewEmailRepository) Save(ctx context.Context, user *User) error {
 }
 
 type mockNewEmailCodeStore struct {
-	ErrGet       error
-	ErrDel       error
+	ErrConsume   error
 	NewEmailCode string
 	NewEmailKey  string
 	OldEmailCode string
-	OldEmailKey  string
 }
 
-func (m *mockNewEmailCodeStore) GetNewEmail(ctx context.Context, key string) (string, error) {
-	switch key {
-	case m.NewEmailKey:
-		return m.NewEmailCode, m.ErrGet
-	case m.OldEmailKey:
-		return m.OldEmailCode, m.ErrGet
-	default:
-		return "", m.ErrGet
+func (m *mockNewEmailCodeStore) ConsumeNewEmail(ctx context.Context, key, code string) error {
+	if m.ErrConsume != nil {
+		return m.ErrConsume
 	}
-}
-
-func (m *mockNewEmailCodeStore) DelNewEmail(ctx context.Context, key string) error {
-	return m.ErrDel
+	if key != m.NewEmailKey {
+		return fmt.Errorf("%w: code not found", ErrNotFound)
+	}
+	if code != newEmailCodes(m.OldEmailCode, m.NewEmailCode) {
+		return fmt.Errorf("%w: invalid code", ErrInvalidData)
+	}
+	return nil
 }
 
 func TestNewEmailUseCase_Execute(t *testing.T) {
@@ -60,14 +57,14 @@ func TestNewEmailUseCase_Execute(t *testing.T) {
 		Email:        "test@mail.com",
 		State:        domain.ACTIVE,
 		Status:       domain.USER,
+		Locale:       domain.RU,
 		PasswordHash: "test",
 		Version:      1,
 	}
 	newEmail := "new.test@mail.com"
 	newEmailCode := "new"
-	newEmailKey := user.ID.String() + newEmail
+	key := newEmailKey(user.ID, newEmail)
 	oldEmailCode := "old"
-	oldEmailKey := user.ID.String() + user.Email
 	password := "password"
 	cases := []struct {
 		TestName string
@@ -82,10 +79,11 @@ func TestNewEmailUseCase_Execute(t *testing.T) {
 				&mockNewEmailRepository{User: user},
 				&mockNewEmailCodeStore{
 					NewEmailCode: newEmailCode,
-					NewEmailKey:  newEmailKey,
+					NewEmailKey:  key,
 					OldEmailCode: oldEmailCode,
-					OldEmailKey:  oldEmailKey,
 				},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordComparer{},
 			),
@@ -105,10 +103,11 @@ func TestNewEmailUseCase_Execute(t *testing.T) {
 				&mockNewEmailRepository{User: user, ExistsEmails: []string{newEmail}},
 				&mockNewEmailCodeStore{
 					NewEmailCode: newEmailCode,
-					NewEmailKey:  newEmailKey,
+					NewEmailKey:  key,
 					OldEmailCode: oldEmailCode,
-					OldEmailKey:  oldEmailKey,
 				},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordComparer{},
 			),
@@ -128,10 +127,11 @@ func TestNewEmailUseCase_Execute(t *testing.T) {
 				&mockNewEmailRepository{User: user, ErrExists: ErrInternal},
 				&mockNewEmailCodeStore{
 					NewEmailCode: newEmailCode,
-					NewEmailKey:  newEmailKey,
+					NewEmailKey:  key,
 					OldEmailCode: oldEmailCode,
-					OldEmailKey:  oldEmailKey,
 				},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordComparer{},
 			),
@@ -151,10 +151,11 @@ func TestNewEmailUseCase_Execute(t *testing.T) {
 				&mockNewEmailRepository{User: user, ErrSave: ErrInternal},
 				&mockNewEmailCodeStore{
 					NewEmailCode: newEmailCode,
-					NewEmailKey:  newEmailKey,
+					NewEmailKey:  key,
 					OldEmailCode: oldEmailCode,
-					OldEmailKey:  oldEmailKey,
 				},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordComparer{},
 			),
@@ -174,10 +175,11 @@ func TestNewEmailUseCase_Execute(t *testing.T) {
 				&mockNewEmailRepository{User: user, ErrByID: ErrInternal},
 				&mockNewEmailCodeStore{
 					NewEmailCode: newEmailCode,
-					NewEmailKey:  newEmailKey,
+					NewEmailKey:  key,
 					OldEmailCode: oldEmailCode,
-					OldEmailKey:  oldEmailKey,
 				},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordComparer{},
 			),
@@ -191,17 +193,18 @@ func TestNewEmailUseCase_Execute(t *testing.T) {
 			},
 		},
 		{
-			TestName: "test_new_email_use_case_getting_codes_error",
+			TestName: "test_new_email_use_case_consuming_code_error",
 			Expected: ErrInternal,
 			UC: MustNewEmailUseCase(
 				&mockNewEmailRepository{User: user},
 				&mockNewEmailCodeStore{
 					NewEmailCode: newEmailCode,
-					NewEmailKey:  newEmailKey,
+					NewEmailKey:  key,
 					OldEmailCode: oldEmailCode,
-					OldEmailKey:  oldEmailKey,
-					ErrGet:       ErrInternal,
+					ErrConsume:   ErrInternal,
 				},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordComparer{},
 			),
@@ -221,10 +224,11 @@ func TestNewEmailUseCase_Execute(t *testing.T) {
 				&mockNewEmailRepository{User: user},
 				&mockNewEmailCodeStore{
 					NewEmailCode: newEmailCode,
-					NewEmailKey:  newEmailKey,
+					NewEmailKey:  key,
 					OldEmailCode: oldEmailCode,
-					OldEmailKey:  oldEmailKey,
 				},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordComparer{},
 			),
@@ -244,10 +248,11 @@ func TestNewEmailUseCase_Execute(t *testing.T) {
 				&mockNewEmailRepository{User: user},
 				&mockNewEmailCodeStore{
 					NewEmailCode: newEmailCode,
-					NewEmailKey:  newEmailKey,
+					NewEmailKey:  key,
 					OldEmailCode: oldEmailCode,
-					OldEmailKey:  oldEmailKey,
 				},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordComparer{},
 			),
@@ -267,10 +272,11 @@ func TestNewEmailUseCase_Execute(t *testing.T) {
 				&mockNewEmailRepository{User: user},
 				&mockNewEmailCodeStore{
 					NewEmailCode: newEmailCode,
-					NewEmailKey:  newEmailKey,
+					NewEmailKey:  key,
 					OldEmailCode: oldEmailCode,
-					OldEmailKey:  oldEmailKey,
 				},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{InvalidEmails: []string{newEmail}},
 				&mockPasswordComparer{},
 			),
@@ -290,10 +296,11 @@ func TestNewEmailUseCase_Execute(t *testing.T) {
 				&mockNewEmailRepository{User: user},
 				&mockNewEmailCodeStore{
 					NewEmailCode: newEmailCode,
-					NewEmailKey:  newEmailKey,
+					NewEmailKey:  key,
 					OldEmailCode: oldEmailCode,
-					OldEmailKey:  oldEmailKey,
 				},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordComparer{InvalidPassword: []string{password}},
 			),
@@ -313,10 +320,11 @@ func TestNewEmailUseCase_Execute(t *testing.T) {
 				&mockNewEmailRepository{User: user},
 				&mockNewEmailCodeStore{
 					NewEmailCode: newEmailCode,
-					NewEmailKey:  newEmailKey,
+					NewEmailKey:  key,
 					OldEmailCode: oldEmailCode,
-					OldEmailKey:  oldEmailKey,
 				},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordComparer{Err: ErrInternal},
 			),
@@ -336,10 +344,11 @@ func TestNewEmailUseCase_Execute(t *testing.T) {
 				&mockNewEmailRepository{User: user},
 				&mockNewEmailCodeStore{
 					NewEmailCode: newEmailCode,
-					NewEmailKey:  newEmailKey,
+					NewEmailKey:  key,
 					OldEmailCode: oldEmailCode,
-					OldEmailKey:  oldEmailKey,
 				},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordComparer{},
 			),
@@ -352,30 +361,6 @@ func TestNewEmailUseCase_Execute(t *testing.T) {
 				Password:     password,
 			},
 		},
-		{
-			TestName: "test_new_email_use_case_del_code_error",
-			Expected: ErrInternal,
-			UC: MustNewEmailUseCase(
-				&mockNewEmailRepository{User: user},
-				&mockNewEmailCodeStore{
-					ErrDel:       ErrInternal,
-					NewEmailCode: newEmailCode,
-					NewEmailKey:  newEmailKey,
-					OldEmailCode: oldEmailCode,
-					OldEmailKey:  oldEmailKey,
-				},
-				&mockEmailValidator{},
-				&mockPasswordComparer{},
-			),
-			Command: &NewEmailCommand{
-				InitiatorID:  user.ID,
-				UserID:       user.ID,
-				NewEmail:     newEmail,
-				NewEmailCode: newEmailCode,
-				OldEmailCode: oldEmailCode,
-				Password:     password,
-			},
-		},
 	}
 	for _, c := range cases {
 		t.Run(c.TestName, func(t *testing.T) {
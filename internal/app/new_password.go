@@ -2,7 +2,6 @@ package app
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/google/uuid"
 )
@@ -10,6 +9,9 @@ import (
 type NewPasswordUseCase struct {
 	repo              newPasswordRepository
 	store             newPasswordCodeStore
+	codeNormalizer    codeNormalizer
+	limiter           attemptLimiter
+	sessions          sessionRevoker
 	passwordComparer  passwordComparer
 	passwordValidator passwordValidator
 	passwordHasher    passwordHasher
@@ -21,6 +23,7 @@ type NewPasswordCommand struct {
 	OldPassword string
 	NewPassword string
 	Code        string
+	ClientIP    string
 }
 
 type newPasswordRepository interface {
@@ -29,13 +32,15 @@ type newPasswordRepository interface {
 }
 
 type newPasswordCodeStore interface {
-	GetNewPassword(ctx context.Context, key string) (string, error)
-	DelNewPassword(ctx context.Context, key string) error
+	ConsumeNewPassword(ctx context.Context, key, code string) error
 }
 
 func MustNewPasswordUseCase(
 	repo newPasswordRepository,
 	store newPasswordCodeStore,
+	codeNormalizer codeNormalizer,
+	limiter attemptLimiter,
+	sessions sessionRevoker,
 	passwordComparer passwordComparer,
 	passwordValidator passwordValidator,
 	passwordHasher passwordHasher,
@@ -46,6 +51,15 @@ func MustNewPasswordUseCase(
 	if store == nil {
 		panic("new password use case did not get code store")
 	}
+	if codeNormalizer == nil {
+		panic("new password use case did not get code normalizer")
+	}
+	if limiter == nil {
+		panic("new password use case did not get attempt limiter")
+	}
+	if sessions == nil {
+		panic("new password use case did not get session revoker")
+	}
 	if passwordComparer == nil {
 		panic("new password use case did not get password comparer")
 	}
@@ -58,6 +72,9 @@ func MustNewPasswordUseCase(
 	return &NewPasswordUseCase{
 		repo:              repo,
 		store:             store,
+		codeNormalizer:    codeNormalizer,
+		limiter:           limiter,
+		sessions:          sessions,
 		passwordComparer:  passwordComparer,
 		passwordValidator: passwordValidator,
 		passwordHasher:    passwordHasher,
@@ -66,44 +83,51 @@ func MustNewPasswordUseCase(
 
 func (u *NewPasswordUseCase) Execute(ctx context.Context, command *NewPasswordCommand) error {
 	if command.InitiatorID != command.UserID {
-		return fmt.Errorf(
-			"%w: вы не можете изменять пароль другим пользователям",
-			ErrNotAllowed,
-		)
+		return accessDenied("вы не можете изменять пароль другим пользователям")
 	}
 
 	if command.NewPassword == command.OldPassword {
-		return fmt.Errorf(
-			"%w: новый пароль не должен совпадать со старым",
+		return NewError(
 			ErrInvalidData,
-		)
+			CodePasswordReused,
+			"новый пароль не должен совпадать со старым",
+		).WithField("new_password")
 	}
 
-	appUser, err := u.repo.ByID(ctx, command.UserID)
-	if err != nil {
+	keys := newAttemptKeys(newPasswordLimit, "user:"+command.UserID.String(), command.ClientIP)
+	if err := allowAttempt(ctx, u.limiter, keys); err != nil {
 		return err
 	}
 
-	code, err := u.store.GetNewPassword(ctx, appUser.ID.String()+appUser.Email)
+	appUser, err := u.repo.ByID(ctx, command.UserID)
 	if err != nil {
 		return err
 	}
-	if code != command.Code {
-		return fmt.Errorf("%w: неверный код для смены пароля", ErrInvalidData)
-	}
 
 	compare, err := u.passwordComparer.Compare(command.OldPassword, appUser.PasswordHash)
 	if err != nil {
 		return err
 	}
 	if !compare {
-		return fmt.Errorf("%w: неверный пароль", ErrInvalidData)
+		return failAttempt(ctx, u.limiter, keys, wrongPassword("old_password"))
 	}
 
 	if err = u.passwordValidator.Validate(command.NewPassword, appUser.Email); err != nil {
 		return err
 	}
 
+	err = u.store.ConsumeNewPassword(
+		ctx,
+		newPasswordKey(appUser.ID, appUser.Email),
+		u.codeNormalizer.Normalize(command.Code),
+	)
+	if err != nil {
+		return failAttempt(ctx, u.limiter, keys, err)
+	}
+	if err = resetAttempts(ctx, u.limiter, keys); err != nil {
+		return err
+	}
+
 	hashedPassword, err := u.passwordHasher.Hash(command.NewPassword)
 	if err != nil {
 		return err
@@ -123,11 +147,11 @@ func (u *NewPasswordUseCase) Execute(ctx context.Context, command *NewPasswordCo
 		return err
 	}
 
-	if err = u.store.DelNewPassword(ctx, appUser.ID.String()+appUser.Email); err != nil {
+	if err = u.repo.Save(ctx, newAppUser); err != nil {
 		return err
 	}
 
-	if err = u.repo.Save(ctx, newAppUser); err != nil {
+	if err = u.sessions.RevokeUser(ctx, newAppUser.ID); err != nil {
 		return err
 	}
 
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/Nemagu/dnd_users/internal/domain"
@@ -24,17 +25,18 @@ func (m *mockNewPasswordRepository) Save(ctx context.Context, user *User) error
 }
 
 type mockNewPasswordCodeStore struct {
-	Code   string
-	ErrGet error
-	ErrDel error
+	Code       string
+	ErrConsume error
 }
 
-func (m *mockNewPasswordCodeStore) GetNewPassword(ctx context.Context, key string) (string, error) {
-	return m.Code, m.ErrGet
-}
-
-func (m *mockNewPasswordCodeStore) DelNewPassword(ctx context.Context, key string) error {
-	return m.ErrDel
+func (m *mockNewPasswordCodeStore) ConsumeNewPassword(ctx context.Context, key, code string) error {
+	if m.ErrConsume != nil {
+		return m.ErrConsume
+	}
+	if code != m.Code {
+		return fmt.Errorf("%w: invalid code", ErrInvalidData)
+	}
+	return nil
 }
 
 func TestNewPasswordUseCase_Execute(t *testing.T) {
@@ -43,6 +45,7 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 		Email:        "test@mail.com",
 		State:        domain.ACTIVE,
 		Status:       domain.USER,
+		Locale:       domain.RU,
 		PasswordHash: "test",
 		Version:      1,
 	}
@@ -51,6 +54,7 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 		Email:        "test@mail.com",
 		State:        domain.FROZEN,
 		Status:       domain.USER,
+		Locale:       domain.RU,
 		PasswordHash: "test",
 		Version:      1,
 	}
@@ -68,6 +72,9 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 			UC: MustNewPasswordUseCase(
 				&mockNewPasswordRepository{User: activeUser},
 				&mockNewPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
 				&mockPasswordComparer{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -86,6 +93,9 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 			UC: MustNewPasswordUseCase(
 				&mockNewPasswordRepository{User: activeUser},
 				&mockNewPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
 				&mockPasswordComparer{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -104,6 +114,9 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 			UC: MustNewPasswordUseCase(
 				&mockNewPasswordRepository{User: notActiveUser},
 				&mockNewPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
 				&mockPasswordComparer{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -122,6 +135,9 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 			UC: MustNewPasswordUseCase(
 				&mockNewPasswordRepository{User: activeUser, ErrByID: ErrInternal},
 				&mockNewPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
 				&mockPasswordComparer{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -140,6 +156,9 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 			UC: MustNewPasswordUseCase(
 				&mockNewPasswordRepository{User: activeUser, ErrSave: ErrInternal},
 				&mockNewPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
 				&mockPasswordComparer{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -153,11 +172,14 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 			},
 		},
 		{
-			TestName: "test_new_password_use_case_get_code_error",
+			TestName: "test_new_password_use_case_consume_code_error",
 			Expected: ErrInternal,
 			UC: MustNewPasswordUseCase(
 				&mockNewPasswordRepository{User: activeUser},
-				&mockNewPasswordCodeStore{Code: validCode, ErrGet: ErrInternal},
+				&mockNewPasswordCodeStore{Code: validCode, ErrConsume: ErrInternal},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
 				&mockPasswordComparer{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -171,11 +193,14 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 			},
 		},
 		{
-			TestName: "test_new_password_use_case_del_code_error",
+			TestName: "test_new_password_use_case_revoking_sessions_error",
 			Expected: ErrInternal,
 			UC: MustNewPasswordUseCase(
 				&mockNewPasswordRepository{User: activeUser},
-				&mockNewPasswordCodeStore{Code: validCode, ErrDel: ErrInternal},
+				&mockNewPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{Err: ErrInternal},
 				&mockPasswordComparer{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -194,6 +219,9 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 			UC: MustNewPasswordUseCase(
 				&mockNewPasswordRepository{User: activeUser},
 				&mockNewPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
 				&mockPasswordComparer{InvalidPassword: []string{"old_password"}},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -212,6 +240,9 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 			UC: MustNewPasswordUseCase(
 				&mockNewPasswordRepository{User: activeUser},
 				&mockNewPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
 				&mockPasswordComparer{Err: ErrInternal},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -230,6 +261,9 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 			UC: MustNewPasswordUseCase(
 				&mockNewPasswordRepository{User: activeUser},
 				&mockNewPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
 				&mockPasswordComparer{},
 				&mockPasswordValidator{InvalidPasswords: []string{"new_password"}},
 				&mockPasswordHasher{},
@@ -248,6 +282,9 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 			UC: MustNewPasswordUseCase(
 				&mockNewPasswordRepository{User: activeUser},
 				&mockNewPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
 				&mockPasswordComparer{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{Err: ErrInternal},
@@ -266,6 +303,9 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 			UC: MustNewPasswordUseCase(
 				&mockNewPasswordRepository{User: activeUser},
 				&mockNewPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
 				&mockPasswordComparer{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -284,6 +324,9 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 			UC: MustNewPasswordUseCase(
 				&mockNewPasswordRepository{User: activeUser},
 				&mockNewPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
 				&mockPasswordComparer{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -302,6 +345,9 @@ func TestNewPasswordUseCase_Execute(t *testing.T) {
 			UC: MustNewPasswordUseCase(
 				&mockNewPasswordRepository{User: activeUser},
 				&mockNewPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
 				&mockPasswordComparer{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"context"
+	"errors"
+)
+
+type attemptLimiter interface {
+	Allow(ctx context.Context, keys ...string) error
+	Fail(ctx context.Context, keys ...string) error
+	Reset(ctx context.Context, keys ...string) error
+}
+
+type limitScope string
+
+const (
+	loginLimit         limitScope = "login"
+	registrationLimit  limitScope = "registration"
+	resetPasswordLimit limitScope = "reset_password"
+	newEmailLimit      limitScope = "new_email"
+	newPasswordLimit   limitScope = "new_password"
+	twoFactorLimit     limitScope = "two_factor"
+)
+
+type attemptKeys struct {
+	subject string
+	client  string
+}
+
+func newAttemptKeys(scope limitScope, subject, clientIP string) attemptKeys {
+	keys := attemptKeys{subject: string(scope) + ":" + subject}
+	if clientIP != "" {
+		keys.client = string(scope) + ":ip:" + clientIP
+	}
+	return keys
+}
+
+func (k attemptKeys) all() []string {
+	if k.client == "" {
+		return []string{k.subject}
+	}
+	return []string{k.subject, k.client}
+}
+
+func allowAttempt(ctx context.Context, limiter attemptLimiter, keys attemptKeys) error {
+	return limiter.Allow(ctx, keys.all()...)
+}
+
+func failAttempt(ctx context.Context, limiter attemptLimiter, keys attemptKeys, err error) error {
+	if !errors.Is(err, ErrInvalidData) && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if limitErr := limiter.Fail(ctx, keys.all()...); limitErr != nil {
+		return limitErr
+	}
+	return err
+}
+
+// rejectAttempt counts the attempt as failed whatever the rejection is, for
+// flows where any rejection tells the caller something about the account.
+func rejectAttempt(ctx context.Context, limiter attemptLimiter, keys attemptKeys, err error) error {
+	if limitErr := limiter.Fail(ctx, keys.all()...); limitErr != nil {
+		return limitErr
+	}
+	return err
+}
+
+func resetAttempts(ctx context.Context, limiter attemptLimiter, keys attemptKeys) error {
+	return limiter.Reset(ctx, keys.subject)
+}
@@ -0,0 +1,307 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestTooManyRequestsError(t *testing.T) {
+	err := TooManyRequestsError(1500 * time.Millisecond)
+	if !errors.Is(err, ErrTooManyRequests) {
+		t.Errorf("expected %v, but got %v", ErrTooManyRequests, err)
+	}
+	if err.Code != CodeTooManyRequests || err.Params["retry_after"] != "2" {
+		t.Errorf("expected retry_after 2, but got %s %v", err.Code, err.Params)
+	}
+	if err.RetryAfter != 1500*time.Millisecond {
+		t.Errorf("expected 1.5s retry after, but got %v", err.RetryAfter)
+	}
+}
+
+func TestRegistrationUseCase_RateLimit(t *testing.T) {
+	email := "test@mail.com"
+	emailKey := "registration:email:" + email
+	ipKey := "registration:ip:192.0.2.1"
+	cases := []struct {
+		TestName string
+		Expected error
+		Limiter  *mockAttemptLimiter
+		Code     string
+		Failed   [][]string
+		Cleared  [][]string
+		Consumed bool
+	}{
+		{
+			TestName: "test_registration_rate_limit_blocked",
+			Expected: ErrTooManyRequests,
+			Limiter:  &mockAttemptLimiter{Err: TooManyRequestsError(time.Minute)},
+			Code:     "123456",
+		},
+		{
+			TestName: "test_registration_rate_limit_failed",
+			Expected: ErrInvalidData,
+			Limiter:  &mockAttemptLimiter{},
+			Code:     "654321",
+			Failed:   [][]string{{emailKey, ipKey}},
+		},
+		{
+			TestName: "test_registration_rate_limit_cleared",
+			Expected: nil,
+			Limiter:  &mockAttemptLimiter{},
+			Code:     "123456",
+			Cleared:  [][]string{{emailKey}},
+			Consumed: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			store := &mockRegistrationCodeStore{
+				Codes: map[string]string{confirmEmailKey(email): "123456"},
+			}
+			uc := MustRegistrationUseCase(
+				&mockRegistrationRepository{},
+				store,
+				&mockCodeGenerator{},
+				c.Limiter,
+				&mockEmailValidator{},
+				&mockPasswordValidator{},
+				&mockPasswordHasher{},
+			)
+			_, err := uc.Execute(context.Background(), &RegistrationCommand{
+				Email:    email,
+				Password: "password",
+				Code:     c.Code,
+				ClientIP: "192.0.2.1",
+			})
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, err)
+			}
+			if _, ok := store.Codes[confirmEmailKey(email)]; ok == c.Consumed {
+				t.Errorf("expected code consumed %t", c.Consumed)
+			}
+			if !slices.EqualFunc(c.Limiter.Failed, c.Failed, slices.Equal) {
+				t.Errorf("expected failed %v, but got %v", c.Failed, c.Limiter.Failed)
+			}
+			if !slices.EqualFunc(c.Limiter.Cleared, c.Cleared, slices.Equal) {
+				t.Errorf("expected cleared %v, but got %v", c.Cleared, c.Limiter.Cleared)
+			}
+		})
+	}
+}
+
+func TestNewPasswordUseCase_RateLimit(t *testing.T) {
+	user := &User{
+		ID:           uuid.New(),
+		Email:        "test@mail.com",
+		State:        domain.ACTIVE,
+		Status:       domain.USER,
+		Locale:       domain.RU,
+		PasswordHash: "test",
+		Version:      1,
+	}
+	userKey := "new_password:user:" + user.ID.String()
+	cases := []struct {
+		TestName string
+		Expected error
+		Limiter  *mockAttemptLimiter
+		Comparer *mockPasswordComparer
+		Failed   [][]string
+	}{
+		{
+			TestName: "test_new_password_rate_limit_blocked",
+			Expected: ErrTooManyRequests,
+			Limiter:  &mockAttemptLimiter{Err: TooManyRequestsError(time.Minute)},
+			Comparer: &mockPasswordComparer{},
+		},
+		{
+			TestName: "test_new_password_rate_limit_wrong_password",
+			Expected: ErrInvalidData,
+			Limiter:  &mockAttemptLimiter{},
+			Comparer: &mockPasswordComparer{InvalidPassword: []string{"old_password"}},
+			Failed:   [][]string{{userKey}},
+		},
+		{
+			TestName: "test_new_password_rate_limit_internal_error",
+			Expected: ErrInternal,
+			Limiter:  &mockAttemptLimiter{},
+			Comparer: &mockPasswordComparer{Err: ErrInternal},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			uc := MustNewPasswordUseCase(
+				&mockNewPasswordRepository{User: user},
+				&mockNewPasswordCodeStore{Code: "123456"},
+				&mockCodeGenerator{},
+				c.Limiter,
+				&mockSessionRevoker{},
+				c.Comparer,
+				&mockPasswordValidator{},
+				&mockPasswordHasher{},
+			)
+			err := uc.Execute(context.Background(), &NewPasswordCommand{
+				InitiatorID: user.ID,
+				UserID:      user.ID,
+				OldPassword: "old_password",
+				NewPassword: "new_password",
+				Code:        "123456",
+			})
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, err)
+			}
+			if !slices.EqualFunc(c.Limiter.Failed, c.Failed, slices.Equal) {
+				t.Errorf("expected failed %v, but got %v", c.Failed, c.Limiter.Failed)
+			}
+		})
+	}
+}
+
+func TestLoginUseCase_RateLimit(t *testing.T) {
+	user := &User{
+		ID:           uuid.New(),
+		Email:        "test@mail.com",
+		State:        domain.ACTIVE,
+		Status:       domain.USER,
+		Locale:       domain.RU,
+		PasswordHash: "password",
+		Version:      1,
+	}
+	emailKey := "login:email:" + user.Email
+	ipKey := "login:ip:192.0.2.1"
+	cases := []struct {
+		TestName string
+		Expected error
+		Limiter  *mockAttemptLimiter
+		Repo     *mockLoginRepository
+		Password string
+		Failed   [][]string
+		Cleared  [][]string
+	}{
+		{
+			TestName: "test_login_rate_limit_blocked",
+			Expected: ErrTooManyRequests,
+			Limiter:  &mockAttemptLimiter{Err: TooManyRequestsError(time.Minute)},
+			Repo:     &mockLoginRepository{User: user},
+			Password: "password",
+		},
+		{
+			TestName: "test_login_rate_limit_wrong_password",
+			Expected: ErrInvalidData,
+			Limiter:  &mockAttemptLimiter{},
+			Repo:     &mockLoginRepository{User: user},
+			Password: "wrong_password",
+			Failed:   [][]string{{emailKey, ipKey}},
+		},
+		{
+			TestName: "test_login_rate_limit_unknown_email",
+			Expected: ErrInvalidData,
+			Limiter:  &mockAttemptLimiter{},
+			Repo:     &mockLoginRepository{ErrByEmail: ErrNotFound},
+			Password: "password",
+			Failed:   [][]string{{emailKey, ipKey}},
+		},
+		{
+			TestName: "test_login_rate_limit_cleared",
+			Expected: nil,
+			Limiter:  &mockAttemptLimiter{},
+			Repo:     &mockLoginRepository{User: user},
+			Password: "password",
+			Cleared:  [][]string{{emailKey}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			uc := MustLoginUseCase(
+				c.Repo,
+				&mockLoginSessionRepository{},
+				c.Limiter,
+				&mockEmailValidator{},
+				&mockPasswordComparer{InvalidPassword: []string{"wrong_password"}},
+				&mockPasswordRehashChecker{},
+				&mockPasswordHasher{},
+				&mockTOTPVerifier{},
+				&mockAccessTokenIssuer{},
+				&mockTokenGenerator{},
+				time.Hour,
+			)
+			_, err := uc.Execute(context.Background(), &LoginCommand{
+				Email:    user.Email,
+				Password: c.Password,
+				ClientIP: "192.0.2.1",
+			})
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, err)
+			}
+			if !slices.EqualFunc(c.Limiter.Failed, c.Failed, slices.Equal) {
+				t.Errorf("expected failed %v, but got %v", c.Failed, c.Limiter.Failed)
+			}
+			if !slices.EqualFunc(c.Limiter.Cleared, c.Cleared, slices.Equal) {
+				t.Errorf("expected cleared %v, but got %v", c.Cleared, c.Limiter.Cleared)
+			}
+		})
+	}
+}
+
+func TestNewEmailUseCase_RateLimit(t *testing.T) {
+	user := &User{
+		ID:           uuid.New(),
+		Email:        "test@mail.com",
+		State:        domain.ACTIVE,
+		Status:       domain.USER,
+		Locale:       domain.RU,
+		PasswordHash: "test",
+		Version:      1,
+	}
+	takenEmail := "taken@mail.com"
+	userKey := "new_email:user:" + user.ID.String()
+	cases := []struct {
+		TestName string
+		Expected error
+		Password string
+		Failed   [][]string
+	}{
+		{
+			TestName: "test_new_email_rate_limit_wrong_password_before_taken_email",
+			Expected: ErrInvalidData,
+			Password: "wrong_password",
+			Failed:   [][]string{{userKey}},
+		},
+		{
+			TestName: "test_new_email_rate_limit_taken_email",
+			Expected: ErrAlreadyExists,
+			Password: "password",
+			Failed:   [][]string{{userKey}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			limiter := &mockAttemptLimiter{}
+			uc := MustNewEmailUseCase(
+				&mockNewEmailRepository{User: user, ExistsEmails: []string{takenEmail}},
+				&mockNewEmailCodeStore{},
+				&mockCodeGenerator{},
+				limiter,
+				&mockEmailValidator{},
+				&mockPasswordComparer{InvalidPassword: []string{"wrong_password"}},
+			)
+			err := uc.Execute(context.Background(), &NewEmailCommand{
+				InitiatorID: user.ID,
+				UserID:      user.ID,
+				NewEmail:    takenEmail,
+				Password:    c.Password,
+			})
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, err)
+			}
+			if !slices.EqualFunc(limiter.Failed, c.Failed, slices.Equal) {
+				t.Errorf("expected failed %v, but got %v", c.Failed, limiter.Failed)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RefreshUseCase struct {
+	repo              refreshRepository
+	sessions          refreshSessionRepository
+	accessTokenIssuer accessTokenIssuer
+	tokenGenerator    tokenGenerator
+	sessionTTL        time.Duration
+}
+
+type RefreshCommand struct {
+	RefreshToken string
+}
+
+type refreshRepository interface {
+	ByID(ctx context.Context, id uuid.UUID) (*User, error)
+}
+
+type refreshSessionRepository interface {
+	ByTokenHash(ctx context.Context, tokenHash string) (*Session, error)
+	Rotate(ctx context.Context, usedID uuid.UUID, next *Session) error
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+}
+
+func MustRefreshUseCase(
+	repo refreshRepository,
+	sessions refreshSessionRepository,
+	accessTokenIssuer accessTokenIssuer,
+	tokenGenerator tokenGenerator,
+	sessionTTL time.Duration,
+) *RefreshUseCase {
+	if repo == nil {
+		panic("refresh use case did not get user repository")
+	}
+	if sessions == nil {
+		panic("refresh use case did not get session repository")
+	}
+	if accessTokenIssuer == nil {
+		panic("refresh use case did not get access token issuer")
+	}
+	if tokenGenerator == nil {
+		panic("refresh use case did not get token generator")
+	}
+	if sessionTTL <= 0 {
+		panic("refresh use case did not get session ttl")
+	}
+	return &RefreshUseCase{
+		repo:              repo,
+		sessions:          sessions,
+		accessTokenIssuer: accessTokenIssuer,
+		tokenGenerator:    tokenGenerator,
+		sessionTTL:        sessionTTL,
+	}
+}
+
+func (u *RefreshUseCase) Execute(ctx context.Context, command *RefreshCommand) (*Tokens, error) {
+	session, err := u.sessions.ByTokenHash(ctx, hashRefreshToken(command.RefreshToken))
+	if errors.Is(err, ErrNotFound) {
+		return nil, NewError(ErrUnauthenticated, CodeSessionInvalid, "неизвестный refresh токен")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Revoked {
+		return nil, NewError(
+			ErrUnauthenticated,
+			CodeSessionRevoked,
+			fmt.Sprintf("сессия %s отозвана", session.ID),
+		)
+	}
+	if session.Used {
+		if err = u.sessions.RevokeFamily(ctx, session.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, NewError(
+			ErrUnauthenticated,
+			CodeSessionReused,
+			fmt.Sprintf("повторное использование refresh токена сессии %s", session.ID),
+		)
+	}
+	if !time.Now().Before(session.ExpiresAt) {
+		return nil, NewError(
+			ErrUnauthenticated,
+			CodeSessionExpired,
+			fmt.Sprintf("срок действия сессии %s истек", session.ID),
+		)
+	}
+
+	appUser, err := u.repo.ByID(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	domainUser, err := domainUser(appUser)
+	if err != nil {
+		return nil, err
+	}
+	if !domainUser.State().IsActive() {
+		if err = u.sessions.RevokeFamily(ctx, session.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, userNotActive(domainUser)
+	}
+
+	refreshToken := u.tokenGenerator.Generate()
+	next := newSession(session.UserID, session.FamilyID, refreshToken, u.sessionTTL)
+	err = u.sessions.Rotate(ctx, session.ID, next)
+	if errors.Is(err, ErrConflict) {
+		if err = u.sessions.RevokeFamily(ctx, session.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, NewError(
+			ErrUnauthenticated,
+			CodeSessionReused,
+			fmt.Sprintf("повторное использование refresh токена сессии %s", session.ID),
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := u.accessTokenIssuer.Issue(appUser)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
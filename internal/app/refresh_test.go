@@ -0,0 +1,219 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+type mockRefreshRepository struct {
+	User    *User
+	ErrByID error
+}
+
+func (m *mockRefreshRepository) ByID(ctx context.Context, id uuid.UUID) (*User, error) {
+	return m.User, m.ErrByID
+}
+
+type mockRefreshSessionRepository struct {
+	Session         *Session
+	ErrByTokenHash  error
+	ErrRotate       error
+	ErrRevokeFamily error
+	RevokedFamilies []uuid.UUID
+}
+
+func (m *mockRefreshSessionRepository) ByTokenHash(
+	ctx context.Context,
+	tokenHash string,
+) (*Session, error) {
+	return m.Session, m.ErrByTokenHash
+}
+
+func (m *mockRefreshSessionRepository) Rotate(
+	ctx context.Context,
+	usedID uuid.UUID,
+	next *Session,
+) error {
+	return m.ErrRotate
+}
+
+func (m *mockRefreshSessionRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	m.RevokedFamilies = append(m.RevokedFamilies, familyID)
+	return m.ErrRevokeFamily
+}
+
+func TestRefreshUseCase_Execute(t *testing.T) {
+	activeUser := &User{
+		ID:           uuid.New(),
+		Email:        "test@mail.com",
+		State:        domain.ACTIVE,
+		Status:       domain.USER,
+		Locale:       domain.RU,
+		PasswordHash: "password",
+		Version:      1,
+	}
+	frozenUser := &User{
+		ID:           uuid.New(),
+		Email:        "test@mail.com",
+		State:        domain.FROZEN,
+		Status:       domain.USER,
+		Locale:       domain.RU,
+		PasswordHash: "password",
+		Version:      1,
+	}
+	refreshToken := "refresh_token"
+	session := func(used, revoked bool, expiresAt time.Time) *Session {
+		return &Session{
+			ID:        uuid.New(),
+			FamilyID:  uuid.New(),
+			UserID:    activeUser.ID,
+			TokenHash: hashRefreshToken(refreshToken),
+			Used:      used,
+			Revoked:   revoked,
+			CreatedAt: time.Now().Add(-time.Minute),
+			ExpiresAt: expiresAt,
+		}
+	}
+	validSession := session(false, false, time.Now().Add(time.Hour))
+	cases := []struct {
+		TestName      string
+		Expected      error
+		FamilyRevoked bool
+		Repo          *mockRefreshRepository
+		Sessions      *mockRefreshSessionRepository
+		Issuer        *mockAccessTokenIssuer
+	}{
+		{
+			TestName: "test_refresh_use_case_ok",
+			Expected: nil,
+			Repo:     &mockRefreshRepository{User: activeUser},
+			Sessions: &mockRefreshSessionRepository{Session: validSession},
+			Issuer:   &mockAccessTokenIssuer{},
+		},
+		{
+			TestName: "test_refresh_use_case_unknown_token",
+			Expected: ErrUnauthenticated,
+			Repo:     &mockRefreshRepository{User: activeUser},
+			Sessions: &mockRefreshSessionRepository{ErrByTokenHash: ErrNotFound},
+			Issuer:   &mockAccessTokenIssuer{},
+		},
+		{
+			TestName: "test_refresh_use_case_getting_session_error",
+			Expected: ErrInternal,
+			Repo:     &mockRefreshRepository{User: activeUser},
+			Sessions: &mockRefreshSessionRepository{ErrByTokenHash: ErrInternal},
+			Issuer:   &mockAccessTokenIssuer{},
+		},
+		{
+			TestName: "test_refresh_use_case_revoked_session",
+			Expected: ErrUnauthenticated,
+			Repo:     &mockRefreshRepository{User: activeUser},
+			Sessions: &mockRefreshSessionRepository{
+				Session: session(false, true, time.Now().Add(time.Hour)),
+			},
+			Issuer: &mockAccessTokenIssuer{},
+		},
+		{
+			TestName:      "test_refresh_use_case_reused_token",
+			Expected:      ErrUnauthenticated,
+			FamilyRevoked: true,
+			Repo:          &mockRefreshRepository{User: activeUser},
+			Sessions: &mockRefreshSessionRepository{
+				Session: session(true, false, time.Now().Add(time.Hour)),
+			},
+			Issuer: &mockAccessTokenIssuer{},
+		},
+		{
+			TestName:      "test_refresh_use_case_reused_token_revoking_error",
+			Expected:      ErrInternal,
+			FamilyRevoked: true,
+			Repo:          &mockRefreshRepository{User: activeUser},
+			Sessions: &mockRefreshSessionRepository{
+				Session:         session(true, false, time.Now().Add(time.Hour)),
+				ErrRevokeFamily: ErrInternal,
+			},
+			Issuer: &mockAccessTokenIssuer{},
+		},
+		{
+			TestName: "test_refresh_use_case_expired_session",
+			Expected: ErrUnauthenticated,
+			Repo:     &mockRefreshRepository{User: activeUser},
+			Sessions: &mockRefreshSessionRepository{
+				Session: session(false, false, time.Now().Add(-time.Second)),
+			},
+			Issuer: &mockAccessTokenIssuer{},
+		},
+		{
+			TestName: "test_refresh_use_case_getting_user_error",
+			Expected: ErrNotFound,
+			Repo:     &mockRefreshRepository{ErrByID: ErrNotFound},
+			Sessions: &mockRefreshSessionRepository{Session: validSession},
+			Issuer:   &mockAccessTokenIssuer{},
+		},
+		{
+			TestName:      "test_refresh_use_case_not_active_user",
+			Expected:      ErrUserNotActive,
+			FamilyRevoked: true,
+			Repo:          &mockRefreshRepository{User: frozenUser},
+			Sessions:      &mockRefreshSessionRepository{Session: validSession},
+			Issuer:        &mockAccessTokenIssuer{},
+		},
+		{
+			TestName:      "test_refresh_use_case_concurrent_rotation",
+			Expected:      ErrUnauthenticated,
+			FamilyRevoked: true,
+			Repo:          &mockRefreshRepository{User: activeUser},
+			Sessions: &mockRefreshSessionRepository{
+				Session:   validSession,
+				ErrRotate: ErrConflict,
+			},
+			Issuer: &mockAccessTokenIssuer{},
+		},
+		{
+			TestName: "test_refresh_use_case_rotation_error",
+			Expected: ErrInternal,
+			Repo:     &mockRefreshRepository{User: activeUser},
+			Sessions: &mockRefreshSessionRepository{
+				Session:   validSession,
+				ErrRotate: ErrInternal,
+			},
+			Issuer: &mockAccessTokenIssuer{},
+		},
+		{
+			TestName: "test_refresh_use_case_access_token_error",
+			Expected: ErrInternal,
+			Repo:     &mockRefreshRepository{User: activeUser},
+			Sessions: &mockRefreshSessionRepository{Session: validSession},
+			Issuer:   &mockAccessTokenIssuer{Err: ErrInternal},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			uc := MustRefreshUseCase(c.Repo, c.Sessions, c.Issuer, &mockTokenGenerator{}, time.Hour)
+			tokens, err := uc.Execute(context.Background(), &RefreshCommand{RefreshToken: refreshToken})
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected nil, but got %v", err)
+				}
+				if tokens == nil || tokens.RefreshToken == refreshToken {
+					t.Errorf("expected rotated refresh token, but got %v", tokens)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+			if (len(c.Sessions.RevokedFamilies) > 0) != c.FamilyRevoked {
+				t.Errorf(
+					"expected family revoked %v, but got %v",
+					c.FamilyRevoked,
+					c.Sessions.RevokedFamilies,
+				)
+			}
+		})
+	}
+}
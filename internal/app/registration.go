@@ -2,7 +2,6 @@ package app
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/Nemagu/dnd_users/internal/domain"
 	"github.com/google/uuid"
@@ -15,19 +14,22 @@ type registrationRepository interface {
 }
 
 type registrationCodeStore interface {
-	GetConfirmEmail(ctx context.Context, key string) (string, error)
-	DelConfirmEmail(ctx context.Context, key string) error
+	ConsumeConfirmEmail(ctx context.Context, key, code string) error
 }
 
 type RegistrationCommand struct {
 	Email    string
 	Password string
 	Code     string
+	Locale   string
+	ClientIP string
 }
 
 type RegistrationUseCase struct {
 	repo              registrationRepository
 	store             registrationCodeStore
+	codeNormalizer    codeNormalizer
+	limiter           attemptLimiter
 	emailValidator    emailValidator
 	passwordValidator passwordValidator
 	passwordHasher    passwordHasher
@@ -36,6 +38,8 @@ type RegistrationUseCase struct {
 func MustRegistrationUseCase(
 	repo registrationRepository,
 	store registrationCodeStore,
+	codeNormalizer codeNormalizer,
+	limiter attemptLimiter,
 	emailValidator emailValidator,
 	passwordValidator passwordValidator,
 	passwordHasher passwordHasher,
@@ -46,6 +50,12 @@ func MustRegistrationUseCase(
 	if store == nil {
 		panic("registration use case did not get code store")
 	}
+	if codeNormalizer == nil {
+		panic("registration use case did not get code normalizer")
+	}
+	if limiter == nil {
+		panic("registration use case did not get attempt limiter")
+	}
 	if emailValidator == nil {
 		panic("registration use case did not get email validator")
 	}
@@ -58,6 +68,8 @@ func MustRegistrationUseCase(
 	return &RegistrationUseCase{
 		repo:              repo,
 		store:             store,
+		codeNormalizer:    codeNormalizer,
+		limiter:           limiter,
 		emailValidator:    emailValidator,
 		passwordValidator: passwordValidator,
 		passwordHasher:    passwordHasher,
@@ -68,34 +80,42 @@ func (u *RegistrationUseCase) Execute(
 	ctx context.Context,
 	command *RegistrationCommand,
 ) (uuid.UUID, error) {
-	code, err := u.store.GetConfirmEmail(ctx, command.Code)
+	email, err := u.emailValidator.Validate(command.Email)
 	if err != nil {
 		return uuid.Nil, err
 	}
 
-	if code != command.Code {
-		return uuid.Nil, fmt.Errorf("%w: не верный код подтверждения", ErrInvalidData)
+	locale, err := requestedLocale(command.Locale)
+	if err != nil {
+		return uuid.Nil, err
 	}
 
-	if err = u.emailValidator.Validate(command.Email); err != nil {
+	if err = u.passwordValidator.Validate(command.Password, email); err != nil {
 		return uuid.Nil, err
 	}
 
-	exists, err := u.repo.EmailExists(ctx, command.Email)
-	if err != nil {
+	keys := newAttemptKeys(registrationLimit, "email:"+email, command.ClientIP)
+	if err = allowAttempt(ctx, u.limiter, keys); err != nil {
 		return uuid.Nil, err
 	}
-	if exists {
-		return uuid.Nil, fmt.Errorf(
-			"%w: пользователь с email %s уже существует",
-			ErrInvalidData,
-			command.Email,
-		)
+	if err = u.store.ConsumeConfirmEmail(
+		ctx,
+		confirmEmailKey(email),
+		u.codeNormalizer.Normalize(command.Code),
+	); err != nil {
+		return uuid.Nil, failAttempt(ctx, u.limiter, keys, err)
+	}
+	if err = resetAttempts(ctx, u.limiter, keys); err != nil {
+		return uuid.Nil, err
 	}
 
-	if err = u.passwordValidator.Validate(command.Password, command.Email); err != nil {
+	exists, err := u.repo.EmailExists(ctx, email)
+	if err != nil {
 		return uuid.Nil, err
 	}
+	if exists {
+		return uuid.Nil, emailTaken(ErrInvalidData, "email", email)
+	}
 
 	hashedPassword, err := u.passwordHasher.Hash(command.Password)
 	if err != nil {
@@ -107,15 +127,11 @@ func (u *RegistrationUseCase) Execute(
 		return uuid.Nil, err
 	}
 
-	domainUser, err := domain.NewUser(id, command.Email, hashedPassword)
+	domainUser, err := domain.NewUser(id, email, hashedPassword, locale)
 	if err != nil {
 		return uuid.Nil, handleDomainError(err)
 	}
 
-	if err = u.store.DelConfirmEmail(ctx, command.Code); err != nil {
-		return uuid.Nil, err
-	}
-
 	appUser, err := modifiedUser(domainUser)
 	if err != nil {
 		return uuid.Nil, err
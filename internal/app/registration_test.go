@@ -29,17 +29,26 @@ func (m *mockRegistrationRepository) Save(ctx context.Context, user *User) error
 }
 
 type mockRegistrationCodeStore struct {
-	Value       string
-	ErrGetting  error
-	ErrDeleting error
+	Codes      map[string]string
+	ErrConsume error
 }
 
-func (m *mockRegistrationCodeStore) GetConfirmEmail(ctx context.Context, key string) (string, error) {
-	return m.Value, m.ErrGetting
-}
-
-func (m *mockRegistrationCodeStore) DelConfirmEmail(ctx context.Context, key string) error {
-	return m.ErrDeleting
+func (m *mockRegistrationCodeStore) ConsumeConfirmEmail(
+	ctx context.Context,
+	key, code string,
+) error {
+	if m.ErrConsume != nil {
+		return m.ErrConsume
+	}
+	stored, ok := m.Codes[key]
+	if !ok {
+		return CodeExpiredError()
+	}
+	if code != stored {
+		return CodeInvalidError()
+	}
+	delete(m.Codes, key)
+	return nil
 }
 
 func TestRegistrationUseCase_Execute(t *testing.T) {
@@ -57,7 +66,46 @@ func TestRegistrationUseCase_Execute(t *testing.T) {
 			Expected: nil,
 			UC: MustRegistrationUseCase(
 				&mockRegistrationRepository{},
-				&mockRegistrationCodeStore{Value: validCode},
+				&mockRegistrationCodeStore{Codes: map[string]string{confirmEmailKey(validEmail): validCode}},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordValidator{},
+				&mockPasswordHasher{},
+			),
+			Command: &RegistrationCommand{
+				Email:    validEmail,
+				Password: validPassword,
+				Code:     validCode,
+			},
+		},
+		{
+			TestName: "test_registration_use_case_english_locale_ok",
+			Expected: nil,
+			UC: MustRegistrationUseCase(
+				&mockRegistrationRepository{},
+				&mockRegistrationCodeStore{Codes: map[string]string{confirmEmailKey(validEmail): validCode}},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordValidator{},
+				&mockPasswordHasher{},
+			),
+			Command: &RegistrationCommand{
+				Email:    validEmail,
+				Password: validPassword,
+				Code:     validCode,
+				Locale:   "en",
+			},
+		},
+		{
+			TestName: "test_registration_use_case_unsupported_locale",
+			Expected: ErrInvalidData,
+			UC: MustRegistrationUseCase(
+				&mockRegistrationRepository{},
+				&mockRegistrationCodeStore{Codes: map[string]string{confirmEmailKey(validEmail): validCode}},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -66,6 +114,7 @@ func TestRegistrationUseCase_Execute(t *testing.T) {
 				Email:    validEmail,
 				Password: validPassword,
 				Code:     validCode,
+				Locale:   "de",
 			},
 		},
 		{
@@ -73,7 +122,9 @@ func TestRegistrationUseCase_Execute(t *testing.T) {
 			Expected: ErrInternal,
 			UC: MustRegistrationUseCase(
 				&mockRegistrationRepository{ErrNextID: ErrInternal},
-				&mockRegistrationCodeStore{Value: validCode},
+				&mockRegistrationCodeStore{Codes: map[string]string{confirmEmailKey(validEmail): validCode}},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -89,7 +140,9 @@ func TestRegistrationUseCase_Execute(t *testing.T) {
 			Expected: ErrInternal,
 			UC: MustRegistrationUseCase(
 				&mockRegistrationRepository{ErrExists: ErrInternal},
-				&mockRegistrationCodeStore{Value: validCode},
+				&mockRegistrationCodeStore{Codes: map[string]string{confirmEmailKey(validEmail): validCode}},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -105,7 +158,9 @@ func TestRegistrationUseCase_Execute(t *testing.T) {
 			Expected: ErrInternal,
 			UC: MustRegistrationUseCase(
 				&mockRegistrationRepository{ErrSave: ErrInternal},
-				&mockRegistrationCodeStore{Value: validCode},
+				&mockRegistrationCodeStore{Codes: map[string]string{confirmEmailKey(validEmail): validCode}},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -124,7 +179,9 @@ func TestRegistrationUseCase_Execute(t *testing.T) {
 					ExistsEmails: []string{validEmail},
 					ErrExists:    ErrAlreadyExists,
 				},
-				&mockRegistrationCodeStore{Value: validCode},
+				&mockRegistrationCodeStore{Codes: map[string]string{confirmEmailKey(validEmail): validCode}},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -136,11 +193,13 @@ func TestRegistrationUseCase_Execute(t *testing.T) {
 			},
 		},
 		{
-			TestName: "test_registration_use_case_getting_code_error",
+			TestName: "test_registration_use_case_consuming_code_error",
 			Expected: ErrInternal,
 			UC: MustRegistrationUseCase(
 				&mockRegistrationRepository{},
-				&mockRegistrationCodeStore{Value: validCode, ErrGetting: ErrInternal},
+				&mockRegistrationCodeStore{ErrConsume: ErrInternal},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -156,7 +215,9 @@ func TestRegistrationUseCase_Execute(t *testing.T) {
 			Expected: ErrInvalidData,
 			UC: MustRegistrationUseCase(
 				&mockRegistrationRepository{},
-				&mockRegistrationCodeStore{Value: "validCode"},
+				&mockRegistrationCodeStore{Codes: map[string]string{confirmEmailKey(validEmail): "654321"}},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -172,7 +233,9 @@ func TestRegistrationUseCase_Execute(t *testing.T) {
 			Expected: ErrInvalidData,
 			UC: MustRegistrationUseCase(
 				&mockRegistrationRepository{},
-				&mockRegistrationCodeStore{Value: validCode},
+				&mockRegistrationCodeStore{Codes: map[string]string{confirmEmailKey(validEmail): validCode}},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{InvalidEmails: []string{validEmail}},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
@@ -188,7 +251,9 @@ func TestRegistrationUseCase_Execute(t *testing.T) {
 			Expected: ErrInvalidData,
 			UC: MustRegistrationUseCase(
 				&mockRegistrationRepository{},
-				&mockRegistrationCodeStore{Value: validCode},
+				&mockRegistrationCodeStore{Codes: map[string]string{confirmEmailKey(validEmail): validCode}},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{InvalidPasswords: []string{validPassword}},
 				&mockPasswordHasher{},
@@ -204,7 +269,9 @@ func TestRegistrationUseCase_Execute(t *testing.T) {
 			Expected: ErrInternal,
 			UC: MustRegistrationUseCase(
 				&mockRegistrationRepository{},
-				&mockRegistrationCodeStore{Value: validCode},
+				&mockRegistrationCodeStore{Codes: map[string]string{confirmEmailKey(validEmail): validCode}},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
 				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{Err: ErrInternal},
@@ -215,22 +282,6 @@ func TestRegistrationUseCase_Execute(t *testing.T) {
 				Code:     validCode,
 			},
 		},
-		{
-			TestName: "test_registration_use_case_code_delete_error",
-			Expected: ErrInternal,
-			UC: MustRegistrationUseCase(
-				&mockRegistrationRepository{},
-				&mockRegistrationCodeStore{Value: validCode, ErrDeleting: ErrInternal},
-				&mockEmailValidator{},
-				&mockPasswordValidator{},
-				&mockPasswordHasher{},
-			),
-			Command: &RegistrationCommand{
-				Email:    validEmail,
-				Password: validPassword,
-				Code:     validCode,
-			},
-		},
 	}
 	for _, c := range cases {
 		t.Run(c.TestName, func(t *testing.T) {
@@ -246,3 +297,98 @@ func TestRegistrationUseCase_Execute(t *testing.T) {
 		})
 	}
 }
+
+func TestRegistrationUseCase_CodeBoundToEmail(t *testing.T) {
+	issuedEmail := "issued@mail.com"
+	otherEmail := "other@mail.com"
+	code := "123456"
+	cases := []struct {
+		TestName string
+		Expected error
+		Command  *RegistrationCommand
+		Codes    map[string]string
+	}{
+		{
+			TestName: "test_registration_use_case_code_for_other_email",
+			Expected: ErrNotFound,
+			Command: &RegistrationCommand{
+				Email:    otherEmail,
+				Password: "password",
+				Code:     code,
+			},
+			Codes: map[string]string{confirmEmailKey(issuedEmail): code},
+		},
+		{
+			TestName: "test_registration_use_case_code_of_other_email",
+			Expected: ErrInvalidData,
+			Command: &RegistrationCommand{
+				Email:    otherEmail,
+				Password: "password",
+				Code:     code,
+			},
+			Codes: map[string]string{
+				confirmEmailKey(issuedEmail): code,
+				confirmEmailKey(otherEmail):  "654321",
+			},
+		},
+		{
+			TestName: "test_registration_use_case_code_normalized_email",
+			Expected: nil,
+			Command: &RegistrationCommand{
+				Email:    "Issued@Mail.com",
+				Password: "password",
+				Code:     code,
+			},
+			Codes: map[string]string{confirmEmailKey(issuedEmail): code},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			store := &mockRegistrationCodeStore{Codes: c.Codes}
+			uc := MustRegistrationUseCase(
+				&mockRegistrationRepository{},
+				store,
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockEmailValidator{},
+				&mockPasswordValidator{},
+				&mockPasswordHasher{},
+			)
+			_, err := uc.Execute(context.Background(), c.Command)
+			if c.Expected == nil && err != nil {
+				t.Errorf("expected nil, but got %v", err)
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+			if c.Expected != nil && store.Codes[confirmEmailKey(issuedEmail)] != code {
+				t.Errorf("expected code of %s to stay unused, but got %v", issuedEmail, store.Codes)
+			}
+		})
+	}
+}
+
+func TestRegistrationUseCase_InvalidPasswordKeepsCode(t *testing.T) {
+	email := "test@mail.com"
+	store := &mockRegistrationCodeStore{Codes: map[string]string{confirmEmailKey(email): "123456"}}
+	uc := MustRegistrationUseCase(
+		&mockRegistrationRepository{},
+		store,
+		&mockCodeGenerator{},
+		&mockAttemptLimiter{},
+		&mockEmailValidator{},
+		&mockPasswordValidator{InvalidPasswords: []string{"weak"}},
+		&mockPasswordHasher{},
+	)
+	command := &RegistrationCommand{Email: email, Password: "weak", Code: "123456"}
+	if _, err := uc.Execute(context.Background(), command); !errors.Is(err, ErrInvalidData) {
+		t.Fatalf("expected %v, but got %v", ErrInvalidData, err)
+	}
+	command.Password = "password"
+	if _, err := uc.Execute(context.Background(), command); err != nil {
+		t.Fatalf("expected registration with the same code, but got %v", err)
+	}
+	if _, err := uc.Execute(context.Background(), command); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected consumed code to be rejected, but got %v", err)
+	}
+}
@@ -2,20 +2,27 @@ package app
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"time"
 )
 
 type ResetPasswordUseCase struct {
 	repo              resetPasswordRepository
 	store             resetPasswordCodeStore
+	codeNormalizer    codeNormalizer
+	limiter           attemptLimiter
+	sessions          sessionRevoker
+	emailValidator    emailValidator
 	passwordValidator passwordValidator
 	passwordHasher    passwordHasher
+	enumeration       EnumerationProtection
 }
 
 type ResetPasswordCommand struct {
 	Email       string
 	Code        string
 	NewPassword string
+	ClientIP    string
 }
 
 type resetPasswordRepository interface {
@@ -24,15 +31,19 @@ type resetPasswordRepository interface {
 }
 
 type resetPasswordCodeStore interface {
-	GetResetPassword(ctx context.Context, key string) (string, error)
-	DelResetPassword(ctx context.Context, key string) error
+	ConsumeResetPassword(ctx context.Context, key, code string) error
 }
 
 func MustResetPasswordUseCase(
 	repo resetPasswordRepository,
 	store resetPasswordCodeStore,
+	codeNormalizer codeNormalizer,
+	limiter attemptLimiter,
+	sessions sessionRevoker,
+	emailValidator emailValidator,
 	passwordValidator passwordValidator,
 	passwordHasher passwordHasher,
+	enumeration EnumerationProtection,
 ) *ResetPasswordUseCase {
 	if repo == nil {
 		panic("reset password use case did not get user repository")
@@ -40,36 +51,77 @@ func MustResetPasswordUseCase(
 	if store == nil {
 		panic("reset password use case did not get code store")
 	}
+	if codeNormalizer == nil {
+		panic("reset password use case did not get code normalizer")
+	}
+	if limiter == nil {
+		panic("reset password use case did not get attempt limiter")
+	}
+	if sessions == nil {
+		panic("reset password use case did not get session revoker")
+	}
+	if emailValidator == nil {
+		panic("reset password use case did not get email validator")
+	}
 	if passwordValidator == nil {
 		panic("reset password use case did not get password validator")
 	}
 	if passwordHasher == nil {
 		panic("reset password use case did not get password hasher")
 	}
+	if enumeration.MinDuration < 0 {
+		panic("reset password use case got negative enumeration min duration")
+	}
 	return &ResetPasswordUseCase{
 		repo:              repo,
 		store:             store,
+		codeNormalizer:    codeNormalizer,
+		limiter:           limiter,
+		sessions:          sessions,
+		emailValidator:    emailValidator,
 		passwordValidator: passwordValidator,
 		passwordHasher:    passwordHasher,
+		enumeration:       enumeration,
 	}
 }
 
 func (u *ResetPasswordUseCase) Execute(ctx context.Context, command *ResetPasswordCommand) error {
-	user, err := u.repo.ByEmail(ctx, command.Email)
+	if u.enumeration.Enabled {
+		defer u.enumeration.wait(ctx, time.Now())
+	}
+
+	email, err := u.emailValidator.Validate(command.Email)
 	if err != nil {
 		return err
 	}
 
-	if err = u.passwordValidator.Validate(command.NewPassword, user.Email); err != nil {
+	keys := newAttemptKeys(resetPasswordLimit, "email:"+email, command.ClientIP)
+	if err = allowAttempt(ctx, u.limiter, keys); err != nil {
+		return err
+	}
+
+	if err = u.passwordValidator.Validate(command.NewPassword, email); err != nil {
 		return err
 	}
 
-	code, err := u.store.GetResetPassword(ctx, user.ID.String()+user.Email)
+	user, err := u.repo.ByEmail(ctx, email)
 	if err != nil {
+		if u.enumeration.Enabled && errors.Is(err, ErrNotFound) {
+			return failAttempt(ctx, u.limiter, keys, CodeExpiredError())
+		}
 		return err
 	}
-	if code != command.Code {
-		return fmt.Errorf("%w: не верный код сброса пароля", ErrInvalidData)
+
+	err = u.store.ConsumeResetPassword(
+		ctx,
+		resetPasswordKey(user.Email),
+		u.codeNormalizer.Normalize(command.Code),
+	)
+	if err != nil {
+		return failAttempt(ctx, u.limiter, keys, err)
+	}
+	if err = resetAttempts(ctx, u.limiter, keys); err != nil {
+		return err
 	}
 
 	hashedPassword, err := u.passwordHasher.Hash(command.NewPassword)
@@ -86,10 +138,6 @@ func (u *ResetPasswordUseCase) Execute(ctx context.Context, command *ResetPasswo
 		return handleDomainError(err)
 	}
 
-	if err = u.store.DelResetPassword(ctx, user.ID.String()+user.Email); err != nil {
-		return err
-	}
-
 	newUser, err := modifiedUser(domainUser)
 	if err != nil {
 		return err
@@ -98,5 +146,9 @@ func (u *ResetPasswordUseCase) Execute(ctx context.Context, command *ResetPasswo
 		return err
 	}
 
+	if err = u.sessions.RevokeUser(ctx, newUser.ID); err != nil {
+		return err
+	}
+
 	return nil
 }
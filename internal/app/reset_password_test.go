@@ -3,7 +3,9 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/Nemagu/dnd_users/internal/domain"
 	"github.com/google/uuid"
@@ -24,20 +26,21 @@ func (m *mockResetPasswordRepository) Save(ctx context.Context, user *User) erro
 }
 
 type mockResetPasswordCodeStore struct {
-	ErrGet error
-	ErrDel error
-	Code   string
+	Code       string
+	ErrConsume error
 }
 
-func (m *mockResetPasswordCodeStore) GetResetPassword(
+func (m *mockResetPasswordCodeStore) ConsumeResetPassword(
 	ctx context.Context,
-	key string,
-) (string, error) {
-	return m.Code, m.ErrGet
-}
-
-func (m *mockResetPasswordCodeStore) DelResetPassword(ctx context.Context, key string) error {
-	return m.ErrDel
+	key, code string,
+) error {
+	if m.ErrConsume != nil {
+		return m.ErrConsume
+	}
+	if code != m.Code {
+		return fmt.Errorf("%w: invalid code", ErrInvalidData)
+	}
+	return nil
 }
 
 func TestResetPasswordUseCase_Execute(t *testing.T) {
@@ -46,6 +49,7 @@ func TestResetPasswordUseCase_Execute(t *testing.T) {
 		Email:        "test@example.com",
 		State:        domain.ACTIVE,
 		Status:       domain.USER,
+		Locale:       domain.RU,
 		PasswordHash: "test",
 		Version:      1,
 	}
@@ -54,6 +58,7 @@ func TestResetPasswordUseCase_Execute(t *testing.T) {
 		Email:        "test@example.com",
 		State:        domain.FROZEN,
 		Status:       domain.USER,
+		Locale:       domain.RU,
 		PasswordHash: "test",
 		Version:      1,
 	}
@@ -71,8 +76,33 @@ func TestResetPasswordUseCase_Execute(t *testing.T) {
 			UC: MustResetPasswordUseCase(
 				&mockResetPasswordRepository{User: activeUser},
 				&mockResetPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
+				&mockEmailValidator{},
+				&mockPasswordValidator{},
+				&mockPasswordHasher{},
+				EnumerationProtection{},
+			),
+			Command: &ResetPasswordCommand{
+				NewPassword: newPassword,
+				Code:        validCode,
+				Email:       activeUser.Email,
+			},
+		},
+		{
+			TestName: "test_reset_password_use_case_invalid_email",
+			Expected: ErrInvalidData,
+			UC: MustResetPasswordUseCase(
+				&mockResetPasswordRepository{User: activeUser},
+				&mockResetPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
+				&mockEmailValidator{InvalidEmails: []string{activeUser.Email}},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
+				EnumerationProtection{},
 			),
 			Command: &ResetPasswordCommand{
 				NewPassword: newPassword,
@@ -86,8 +116,13 @@ func TestResetPasswordUseCase_Execute(t *testing.T) {
 			UC: MustResetPasswordUseCase(
 				&mockResetPasswordRepository{User: activeUser, ErrByEmail: ErrNotFound},
 				&mockResetPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
+				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
+				EnumerationProtection{},
 			),
 			Command: &ResetPasswordCommand{
 				NewPassword: newPassword,
@@ -101,8 +136,13 @@ func TestResetPasswordUseCase_Execute(t *testing.T) {
 			UC: MustResetPasswordUseCase(
 				&mockResetPasswordRepository{User: activeUser, ErrSave: ErrInternal},
 				&mockResetPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
+				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
+				EnumerationProtection{},
 			),
 			Command: &ResetPasswordCommand{
 				NewPassword: newPassword,
@@ -116,8 +156,13 @@ func TestResetPasswordUseCase_Execute(t *testing.T) {
 			UC: MustResetPasswordUseCase(
 				&mockResetPasswordRepository{User: activeUser},
 				&mockResetPasswordCodeStore{Code: "validCode"},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
+				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
+				EnumerationProtection{},
 			),
 			Command: &ResetPasswordCommand{
 				NewPassword: newPassword,
@@ -126,13 +171,18 @@ func TestResetPasswordUseCase_Execute(t *testing.T) {
 			},
 		},
 		{
-			TestName: "test_reset_password_use_case_code_get_error",
+			TestName: "test_reset_password_use_case_code_consume_error",
 			Expected: ErrInternal,
 			UC: MustResetPasswordUseCase(
 				&mockResetPasswordRepository{User: activeUser},
-				&mockResetPasswordCodeStore{Code: validCode, ErrGet: ErrInternal},
+				&mockResetPasswordCodeStore{Code: validCode, ErrConsume: ErrInternal},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
+				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
+				EnumerationProtection{},
 			),
 			Command: &ResetPasswordCommand{
 				NewPassword: newPassword,
@@ -141,13 +191,18 @@ func TestResetPasswordUseCase_Execute(t *testing.T) {
 			},
 		},
 		{
-			TestName: "test_reset_password_use_case_code_delete_error",
+			TestName: "test_reset_password_use_case_revoking_sessions_error",
 			Expected: ErrInternal,
 			UC: MustResetPasswordUseCase(
 				&mockResetPasswordRepository{User: activeUser},
-				&mockResetPasswordCodeStore{Code: validCode, ErrDel: ErrInternal},
+				&mockResetPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{Err: ErrInternal},
+				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
+				EnumerationProtection{},
 			),
 			Command: &ResetPasswordCommand{
 				NewPassword: newPassword,
@@ -161,8 +216,13 @@ func TestResetPasswordUseCase_Execute(t *testing.T) {
 			UC: MustResetPasswordUseCase(
 				&mockResetPasswordRepository{User: notActiveUser},
 				&mockResetPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
+				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{},
+				EnumerationProtection{},
 			),
 			Command: &ResetPasswordCommand{
 				NewPassword: newPassword,
@@ -176,8 +236,13 @@ func TestResetPasswordUseCase_Execute(t *testing.T) {
 			UC: MustResetPasswordUseCase(
 				&mockResetPasswordRepository{User: activeUser},
 				&mockResetPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
+				&mockEmailValidator{},
 				&mockPasswordValidator{InvalidPasswords: []string{newPassword}},
 				&mockPasswordHasher{},
+				EnumerationProtection{},
 			),
 			Command: &ResetPasswordCommand{
 				NewPassword: newPassword,
@@ -191,8 +256,13 @@ func TestResetPasswordUseCase_Execute(t *testing.T) {
 			UC: MustResetPasswordUseCase(
 				&mockResetPasswordRepository{User: activeUser},
 				&mockResetPasswordCodeStore{Code: validCode},
+				&mockCodeGenerator{},
+				&mockAttemptLimiter{},
+				&mockSessionRevoker{},
+				&mockEmailValidator{},
 				&mockPasswordValidator{},
 				&mockPasswordHasher{Err: ErrInternal},
+				EnumerationProtection{},
 			),
 			Command: &ResetPasswordCommand{
 				NewPassword: newPassword,
@@ -215,3 +285,63 @@ func TestResetPasswordUseCase_Execute(t *testing.T) {
 		})
 	}
 }
+
+func TestResetPasswordUseCase_EnumerationProtection(t *testing.T) {
+	user := &User{
+		ID:           uuid.New(),
+		Email:        "test@mail.com",
+		State:        domain.ACTIVE,
+		Status:       domain.USER,
+		Locale:       domain.RU,
+		PasswordHash: "test",
+		Version:      1,
+	}
+	cases := []struct {
+		TestName string
+		Repo     *mockResetPasswordRepository
+		Store    *mockResetPasswordCodeStore
+	}{
+		{
+			TestName: "test_reset_password_use_case_protected_existing_email",
+			Repo:     &mockResetPasswordRepository{User: user},
+			Store:    &mockResetPasswordCodeStore{ErrConsume: CodeExpiredError()},
+		},
+		{
+			TestName: "test_reset_password_use_case_protected_unknown_email",
+			Repo:     &mockResetPasswordRepository{ErrByEmail: ErrNotFound},
+			Store:    &mockResetPasswordCodeStore{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			limiter := &mockAttemptLimiter{}
+			uc := MustResetPasswordUseCase(
+				c.Repo,
+				c.Store,
+				&mockCodeGenerator{},
+				limiter,
+				&mockSessionRevoker{},
+				&mockEmailValidator{},
+				&mockPasswordValidator{},
+				&mockPasswordHasher{},
+				EnumerationProtection{Enabled: true, MinDuration: 20 * time.Millisecond},
+			)
+			started := time.Now()
+			err := uc.Execute(context.Background(), &ResetPasswordCommand{
+				Email:       user.Email,
+				Code:        "123456",
+				NewPassword: "new_password",
+			})
+			var appErr *Error
+			if !errors.As(err, &appErr) || appErr.Code != CodeCodeExpired {
+				t.Errorf("expected %s, but got %v", CodeCodeExpired, err)
+			}
+			if elapsed := time.Since(started); elapsed < 20*time.Millisecond {
+				t.Errorf("expected response to take at least 20ms, but got %v", elapsed)
+			}
+			if len(limiter.Failed) != 1 {
+				t.Errorf("expected one failed attempt, but got %v", limiter.Failed)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newSession(userID, familyID uuid.UUID, token string, ttl time.Duration) *Session {
+	now := time.Now()
+	return &Session{
+		ID:        uuid.New(),
+		FamilyID:  familyID,
+		UserID:    userID,
+		TokenHash: hashRefreshToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+}
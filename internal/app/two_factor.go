@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+
+	"github.com/Nemagu/dnd_users/internal/domain"
+)
+
+func twoFactorKeys(user *domain.User, clientIP string) attemptKeys {
+	return newAttemptKeys(twoFactorLimit, "user:"+user.ID().String(), clientIP)
+}
+
+func verifyTwoFactor(
+	ctx context.Context,
+	verifier totpVerifier,
+	limiter attemptLimiter,
+	keys attemptKeys,
+	secret, code string,
+) (int64, error) {
+	step, ok, err := verifier.Verify(secret, code)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, failAttempt(ctx, limiter, keys, twoFactorInvalid())
+	}
+	return step, nil
+}
+
+func useTwoFactor(
+	ctx context.Context,
+	verifier totpVerifier,
+	limiter attemptLimiter,
+	keys attemptKeys,
+	user *domain.User,
+	code string,
+) error {
+	step, err := verifyTwoFactor(ctx, verifier, limiter, keys, user.TwoFactor().Secret(), code)
+	if err != nil {
+		return err
+	}
+	if err = user.UseTwoFactorStep(step); err != nil {
+		return failAttempt(ctx, limiter, keys, handleDomainError(err))
+	}
+	return resetAttempts(ctx, limiter, keys)
+}
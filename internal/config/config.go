@@ -0,0 +1,760 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/infrastructure/random"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/smtp"
+)
+
+const envPrefix = "DND_USERS_"
+
+var ErrInvalidConfig = errors.New("некорректная конфигурация")
+
+const (
+	CodeStorePostgres = "postgres"
+	CodeStoreRedis    = "redis"
+)
+
+const (
+	RateLimitStoreMemory = "memory"
+	RateLimitStoreRedis  = "redis"
+)
+
+var grpcMethods = []string{"GetUser", "ValidateToken", "ChangeUser"}
+
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("длительность должна быть строкой вида \"15m\": %w", err)
+	}
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+type Config struct {
+	HTTP            HTTP        `json:"http"`
+	GRPC            GRPC        `json:"grpc"`
+	Postgres        Postgres    `json:"postgres"`
+	Token           Token       `json:"token"`
+	Email           Email       `json:"email"`
+	Password        Password    `json:"password"`
+	Codes           Codes       `json:"codes"`
+	Redis           Redis       `json:"redis"`
+	SMTP            SMTP        `json:"smtp"`
+	Outbox          Outbox      `json:"outbox"`
+	Enumeration     Enumeration `json:"enumeration"`
+	RateLimit       RateLimit   `json:"rate_limit"`
+	TwoFactor       TwoFactor   `json:"two_factor"`
+	ShutdownTimeout Duration    `json:"shutdown_timeout"`
+}
+
+type HTTP struct {
+	Addr              string   `json:"addr"`
+	ReadHeaderTimeout Duration `json:"read_header_timeout"`
+	TrustedProxies    []string `json:"trusted_proxies"`
+}
+
+func (h HTTP) TrustedProxyPrefixes() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(h.TrustedProxies))
+	for _, proxy := range h.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+type GRPC struct {
+	Addr         string       `json:"addr"`
+	CertFile     string       `json:"cert_file"`
+	KeyFile      string       `json:"key_file"`
+	ClientCAFile string       `json:"client_ca_file"`
+	Clients      []GRPCClient `json:"clients"`
+}
+
+type GRPCClient struct {
+	Name        string   `json:"name"`
+	TokenSHA256 string   `json:"token_sha256"`
+	Methods     []string `json:"methods"`
+}
+
+type Postgres struct {
+	DSN string `json:"dsn"`
+}
+
+type Token struct {
+	Issuer      string     `json:"issuer"`
+	AccessTTL   Duration   `json:"access_ttl"`
+	SessionTTL  Duration   `json:"session_ttl"`
+	ActiveKeyID string     `json:"active_key_id"`
+	Keys        []TokenKey `json:"keys"`
+}
+
+type TokenKey struct {
+	ID             string `json:"id"`
+	PrivateKeyFile string `json:"private_key_file"`
+	PublicKeyFile  string `json:"public_key_file"`
+}
+
+type Email struct {
+	LowercaseLocalPart    bool   `json:"lowercase_local_part"`
+	StripPlusTag          bool   `json:"strip_plus_tag"`
+	DisposableDomainsFile string `json:"disposable_domains_file"`
+}
+
+type Password struct {
+	MinLength            int    `json:"min_length"`
+	MaxLength            int    `json:"max_length"`
+	RequireLower         bool   `json:"require_lower"`
+	RequireUpper         bool   `json:"require_upper"`
+	RequireDigit         bool   `json:"require_digit"`
+	RequireSymbol        bool   `json:"require_symbol"`
+	ForbidEmailLocalPart bool   `json:"forbid_email_local_part"`
+	BreachedListFile     string `json:"breached_list_file"`
+	Argon2MemoryKiB      int    `json:"argon2_memory_kib"`
+	Argon2Iterations     int    `json:"argon2_iterations"`
+	Argon2Parallelism    int    `json:"argon2_parallelism"`
+}
+
+type Codes struct {
+	Store                string      `json:"store"`
+	ConfirmEmail         Duration    `json:"confirm_email_ttl"`
+	NewEmail             Duration    `json:"new_email_ttl"`
+	ResetPassword        Duration    `json:"reset_password_ttl"`
+	NewPassword          Duration    `json:"new_password_ttl"`
+	ConfirmEmailProfile  CodeProfile `json:"confirm_email_profile"`
+	NewEmailProfile      CodeProfile `json:"new_email_profile"`
+	ResetPasswordProfile CodeProfile `json:"reset_password_profile"`
+	NewPasswordProfile   CodeProfile `json:"new_password_profile"`
+	MaxAttempts          int         `json:"max_attempts"`
+	CleanupInterval      Duration    `json:"cleanup_interval"`
+}
+
+type CodeProfile struct {
+	Kind   string `json:"kind"`
+	Length int    `json:"length"`
+}
+
+func (p CodeProfile) Random() random.Profile {
+	return random.Profile{Kind: random.Kind(p.Kind), Length: p.Length}
+}
+
+type Redis struct {
+	Addr      string `json:"addr"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	DB        int    `json:"db"`
+	KeyPrefix string `json:"key_prefix"`
+}
+
+type SMTP struct {
+	Host         string   `json:"host"`
+	Port         int      `json:"port"`
+	Username     string   `json:"username"`
+	Password     string   `json:"password"`
+	From         string   `json:"from"`
+	Security     string   `json:"security"`
+	Timeout      Duration `json:"timeout"`
+	TemplatesDir string   `json:"templates_dir"`
+}
+
+type Outbox struct {
+	PollInterval  Duration `json:"poll_interval"`
+	BatchSize     int      `json:"batch_size"`
+	MaxAttempts   int      `json:"max_attempts"`
+	BaseBackoff   Duration `json:"base_backoff"`
+	MaxBackoff    Duration `json:"max_backoff"`
+	Lease         Duration `json:"lease"`
+	DeadRetention Duration `json:"dead_retention"`
+}
+
+type Enumeration struct {
+	Protection      bool     `json:"protection"`
+	MinResponseTime Duration `json:"min_response_time"`
+}
+
+type RateLimit struct {
+	Store       string   `json:"store"`
+	KeyPrefix   string   `json:"key_prefix"`
+	Window      Duration `json:"window"`
+	MaxAttempts int      `json:"max_attempts"`
+	MaxFailures int      `json:"max_failures"`
+	Lockout     Duration `json:"lockout"`
+	MaxLockout  Duration `json:"max_lockout"`
+}
+
+type TwoFactor struct {
+	Issuer string   `json:"issuer"`
+	Digits int      `json:"digits"`
+	Period Duration `json:"period"`
+	Skew   int      `json:"skew"`
+}
+
+func Default() *Config {
+	return &Config{
+		HTTP: HTTP{
+			Addr:              ":8080",
+			ReadHeaderTimeout: Duration(5 * time.Second),
+		},
+		GRPC: GRPC{Addr: ":9090"},
+		Token: Token{
+			Issuer:     "dnd_users",
+			AccessTTL:  Duration(15 * time.Minute),
+			SessionTTL: Duration(30 * 24 * time.Hour),
+		},
+		Email: Email{LowercaseLocalPart: true},
+		Password: Password{
+			MinLength:            8,
+			MaxLength:            128,
+			RequireLower:         true,
+			RequireDigit:         true,
+			ForbidEmailLocalPart: true,
+			Argon2MemoryKiB:      64 * 1024,
+			Argon2Iterations:     3,
+			Argon2Parallelism:    4,
+		},
+		Codes: Codes{
+			Store:                CodeStorePostgres,
+			ConfirmEmail:         Duration(15 * time.Minute),
+			NewEmail:             Duration(15 * time.Minute),
+			ResetPassword:        Duration(15 * time.Minute),
+			NewPassword:          Duration(15 * time.Minute),
+			ConfirmEmailProfile:  CodeProfile{Kind: string(random.Numeric), Length: 6},
+			NewEmailProfile:      CodeProfile{Kind: string(random.Numeric), Length: 6},
+			ResetPasswordProfile: CodeProfile{Kind: string(random.Alphanumeric), Length: 8},
+			NewPasswordProfile:   CodeProfile{Kind: string(random.Numeric), Length: 6},
+			MaxAttempts:          5,
+			CleanupInterval:      Duration(10 * time.Minute),
+		},
+		Redis: Redis{KeyPrefix: "dnd_users:code:"},
+		SMTP: SMTP{
+			Port:     587,
+			Security: string(smtp.SecurityStartTLS),
+			Timeout:  Duration(30 * time.Second),
+		},
+		Outbox: Outbox{
+			PollInterval:  Duration(time.Second),
+			BatchSize:     20,
+			MaxAttempts:   8,
+			BaseBackoff:   Duration(10 * time.Second),
+			MaxBackoff:    Duration(time.Hour),
+			Lease:         Duration(time.Minute),
+			DeadRetention: Duration(24 * time.Hour),
+		},
+		Enumeration: Enumeration{MinResponseTime: Duration(500 * time.Millisecond)},
+		RateLimit: RateLimit{
+			Store:       RateLimitStoreMemory,
+			KeyPrefix:   "dnd_users:limit:",
+			Window:      Duration(time.Minute),
+			MaxAttempts: 10,
+			MaxFailures: 5,
+			Lockout:     Duration(time.Minute),
+			MaxLockout:  Duration(time.Hour),
+		},
+		TwoFactor: TwoFactor{
+			Issuer: "dnd_users",
+			Digits: 6,
+			Period: Duration(30 * time.Second),
+			Skew:   1,
+		},
+		ShutdownTimeout: Duration(15 * time.Second),
+	}
+}
+
+func Load(path string, getenv func(string) string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: чтение файла %s: %s", ErrInvalidConfig, path, err)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err = decoder.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("%w: разбор файла %s: %s", ErrInvalidConfig, path, err)
+		}
+	}
+	if err := cfg.applyEnv(getenv); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) applyEnv(getenv func(string) string) error {
+	var errs []error
+	setString := func(name string, target *string) {
+		if value := getenv(envPrefix + name); value != "" {
+			*target = value
+		}
+	}
+	setInt := func(name string, target *int) {
+		value := getenv(envPrefix + name)
+		if value == "" {
+			return
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s%s: %s", ErrInvalidConfig, envPrefix, name, err))
+			return
+		}
+		*target = parsed
+	}
+	setBool := func(name string, target *bool) {
+		value := getenv(envPrefix + name)
+		if value == "" {
+			return
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s%s: %s", ErrInvalidConfig, envPrefix, name, err))
+			return
+		}
+		*target = parsed
+	}
+	setDuration := func(name string, target *Duration) {
+		value := getenv(envPrefix + name)
+		if value == "" {
+			return
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s%s: %s", ErrInvalidConfig, envPrefix, name, err))
+			return
+		}
+		*target = Duration(parsed)
+	}
+
+	setString("HTTP_ADDR", &c.HTTP.Addr)
+	setDuration("HTTP_READ_HEADER_TIMEOUT", &c.HTTP.ReadHeaderTimeout)
+	if value := getenv(envPrefix + "HTTP_TRUSTED_PROXIES"); value != "" {
+		c.HTTP.TrustedProxies = nil
+		for proxy := range strings.SplitSeq(value, ",") {
+			c.HTTP.TrustedProxies = append(c.HTTP.TrustedProxies, strings.TrimSpace(proxy))
+		}
+	}
+	setString("GRPC_ADDR", &c.GRPC.Addr)
+	setString("GRPC_CERT_FILE", &c.GRPC.CertFile)
+	setString("GRPC_KEY_FILE", &c.GRPC.KeyFile)
+	setString("GRPC_CLIENT_CA_FILE", &c.GRPC.ClientCAFile)
+	setString("POSTGRES_DSN", &c.Postgres.DSN)
+	setString("TOKEN_ISSUER", &c.Token.Issuer)
+	setDuration("TOKEN_ACCESS_TTL", &c.Token.AccessTTL)
+	setDuration("TOKEN_SESSION_TTL", &c.Token.SessionTTL)
+	setString("TOKEN_ACTIVE_KEY_ID", &c.Token.ActiveKeyID)
+	setBool("EMAIL_LOWERCASE_LOCAL_PART", &c.Email.LowercaseLocalPart)
+	setBool("EMAIL_STRIP_PLUS_TAG", &c.Email.StripPlusTag)
+	setString("EMAIL_DISPOSABLE_DOMAINS_FILE", &c.Email.DisposableDomainsFile)
+	setInt("PASSWORD_MIN_LENGTH", &c.Password.MinLength)
+	setInt("PASSWORD_MAX_LENGTH", &c.Password.MaxLength)
+	setBool("PASSWORD_REQUIRE_LOWER", &c.Password.RequireLower)
+	setBool("PASSWORD_REQUIRE_UPPER", &c.Password.RequireUpper)
+	setBool("PASSWORD_REQUIRE_DIGIT", &c.Password.RequireDigit)
+	setBool("PASSWORD_REQUIRE_SYMBOL", &c.Password.RequireSymbol)
+	setBool("PASSWORD_FORBID_EMAIL_LOCAL_PART", &c.Password.ForbidEmailLocalPart)
+	setString("PASSWORD_BREACHED_LIST_FILE", &c.Password.BreachedListFile)
+	setInt("PASSWORD_ARGON2_MEMORY_KIB", &c.Password.Argon2MemoryKiB)
+	setInt("PASSWORD_ARGON2_ITERATIONS", &c.Password.Argon2Iterations)
+	setInt("PASSWORD_ARGON2_PARALLELISM", &c.Password.Argon2Parallelism)
+	setString("CODES_STORE", &c.Codes.Store)
+	setDuration("CODES_CONFIRM_EMAIL_TTL", &c.Codes.ConfirmEmail)
+	setDuration("CODES_NEW_EMAIL_TTL", &c.Codes.NewEmail)
+	setDuration("CODES_RESET_PASSWORD_TTL", &c.Codes.ResetPassword)
+	setDuration("CODES_NEW_PASSWORD_TTL", &c.Codes.NewPassword)
+	setString("CODES_CONFIRM_EMAIL_KIND", &c.Codes.ConfirmEmailProfile.Kind)
+	setInt("CODES_CONFIRM_EMAIL_LENGTH", &c.Codes.ConfirmEmailProfile.Length)
+	setString("CODES_NEW_EMAIL_KIND", &c.Codes.NewEmailProfile.Kind)
+	setInt("CODES_NEW_EMAIL_LENGTH", &c.Codes.NewEmailProfile.Length)
+	setString("CODES_RESET_PASSWORD_KIND", &c.Codes.ResetPasswordProfile.Kind)
+	setInt("CODES_RESET_PASSWORD_LENGTH", &c.Codes.ResetPasswordProfile.Length)
+	setString("CODES_NEW_PASSWORD_KIND", &c.Codes.NewPasswordProfile.Kind)
+	setInt("CODES_NEW_PASSWORD_LENGTH", &c.Codes.NewPasswordProfile.Length)
+	setInt("CODES_MAX_ATTEMPTS", &c.Codes.MaxAttempts)
+	setDuration("CODES_CLEANUP_INTERVAL", &c.Codes.CleanupInterval)
+	setString("REDIS_ADDR", &c.Redis.Addr)
+	setString("REDIS_USERNAME", &c.Redis.Username)
+	setString("REDIS_PASSWORD", &c.Redis.Password)
+	setInt("REDIS_DB", &c.Redis.DB)
+	setString("REDIS_KEY_PREFIX", &c.Redis.KeyPrefix)
+	setString("SMTP_HOST", &c.SMTP.Host)
+	setInt("SMTP_PORT", &c.SMTP.Port)
+	setString("SMTP_USERNAME", &c.SMTP.Username)
+	setString("SMTP_PASSWORD", &c.SMTP.Password)
+	setString("SMTP_FROM", &c.SMTP.From)
+	setString("SMTP_SECURITY", &c.SMTP.Security)
+	setDuration("SMTP_TIMEOUT", &c.SMTP.Timeout)
+	setString("SMTP_TEMPLATES_DIR", &c.SMTP.TemplatesDir)
+	setDuration("OUTBOX_POLL_INTERVAL", &c.Outbox.PollInterval)
+	setInt("OUTBOX_BATCH_SIZE", &c.Outbox.BatchSize)
+	setInt("OUTBOX_MAX_ATTEMPTS", &c.Outbox.MaxAttempts)
+	setDuration("OUTBOX_BASE_BACKOFF", &c.Outbox.BaseBackoff)
+	setDuration("OUTBOX_MAX_BACKOFF", &c.Outbox.MaxBackoff)
+	setDuration("OUTBOX_LEASE", &c.Outbox.Lease)
+	setDuration("OUTBOX_DEAD_RETENTION", &c.Outbox.DeadRetention)
+	setBool("ENUMERATION_PROTECTION", &c.Enumeration.Protection)
+	setDuration("ENUMERATION_MIN_RESPONSE_TIME", &c.Enumeration.MinResponseTime)
+	setString("RATE_LIMIT_STORE", &c.RateLimit.Store)
+	setString("RATE_LIMIT_KEY_PREFIX", &c.RateLimit.KeyPrefix)
+	setDuration("RATE_LIMIT_WINDOW", &c.RateLimit.Window)
+	setInt("RATE_LIMIT_MAX_ATTEMPTS", &c.RateLimit.MaxAttempts)
+	setInt("RATE_LIMIT_MAX_FAILURES", &c.RateLimit.MaxFailures)
+	setDuration("RATE_LIMIT_LOCKOUT", &c.RateLimit.Lockout)
+	setDuration("RATE_LIMIT_MAX_LOCKOUT", &c.RateLimit.MaxLockout)
+	setString("TWO_FACTOR_ISSUER", &c.TwoFactor.Issuer)
+	setInt("TWO_FACTOR_DIGITS", &c.TwoFactor.Digits)
+	setDuration("TWO_FACTOR_PERIOD", &c.TwoFactor.Period)
+	setInt("TWO_FACTOR_SKEW", &c.TwoFactor.Skew)
+	setDuration("SHUTDOWN_TIMEOUT", &c.ShutdownTimeout)
+
+	if value := getenv(envPrefix + "TOKEN_KEYS"); value != "" {
+		keys, err := parseTokenKeys(value)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			c.Token.Keys = keys
+		}
+	}
+	if value := getenv(envPrefix + "GRPC_CLIENTS"); value != "" {
+		clients, err := parseGRPCClients(value)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			c.GRPC.Clients = clients
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func parseGRPCClients(value string) ([]GRPCClient, error) {
+	var clients []GRPCClient
+	for item := range strings.SplitSeq(value, ",") {
+		name, rest, _ := strings.Cut(strings.TrimSpace(item), "=")
+		hash, methods, ok := strings.Cut(rest, ":")
+		if !ok || name == "" || hash == "" || methods == "" {
+			return nil, fmt.Errorf(
+				"%w: %sGRPC_CLIENTS должен иметь вид имя=sha256_токена:Метод|Метод[,...], получено %q",
+				ErrInvalidConfig,
+				envPrefix,
+				item,
+			)
+		}
+		clients = append(clients, GRPCClient{
+			Name:        name,
+			TokenSHA256: hash,
+			Methods:     strings.Split(methods, "|"),
+		})
+	}
+	return clients, nil
+}
+
+func parseTokenKeys(value string) ([]TokenKey, error) {
+	var keys []TokenKey
+	for item := range strings.SplitSeq(value, ",") {
+		id, file, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok || id == "" || file == "" {
+			return nil, fmt.Errorf(
+				"%w: %sTOKEN_KEYS должен иметь вид id=путь_к_закрытому_ключу[,...], получено %q",
+				ErrInvalidConfig,
+				envPrefix,
+				item,
+			)
+		}
+		keys = append(keys, TokenKey{ID: id, PrivateKeyFile: file})
+	}
+	return keys, nil
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrInvalidConfig}, args...)...))
+	}
+
+	if c.HTTP.Addr == "" {
+		invalid("не задан http.addr")
+	}
+	if c.HTTP.ReadHeaderTimeout <= 0 {
+		invalid("http.read_header_timeout должен быть больше 0")
+	}
+	if _, err := c.HTTP.TrustedProxyPrefixes(); err != nil {
+		invalid("http.trusted_proxies должен содержать IP-адреса или CIDR: %s", err)
+	}
+	if c.GRPC.Addr == "" {
+		invalid("не задан grpc.addr")
+	}
+	if c.GRPC.CertFile == "" || c.GRPC.KeyFile == "" {
+		invalid("не заданы grpc.cert_file и grpc.key_file")
+	}
+	if len(c.GRPC.Clients) == 0 {
+		invalid("не заданы grpc.clients")
+	}
+	clientNames := make(map[string]bool)
+	for i, client := range c.GRPC.Clients {
+		switch {
+		case client.Name == "":
+			invalid("не задано name у grpc.clients[%d]", i)
+		case clientNames[client.Name]:
+			invalid("повторяющееся имя %s в grpc.clients", client.Name)
+		}
+		clientNames[client.Name] = true
+		if hash, err := hex.DecodeString(client.TokenSHA256); err != nil || len(hash) != sha256.Size {
+			invalid("token_sha256 у grpc.clients[%d] должен быть sha256 в hex", i)
+		}
+		if len(client.Methods) == 0 {
+			invalid("не заданы methods у grpc.clients[%d]", i)
+		}
+		for _, method := range client.Methods {
+			if !slices.Contains(grpcMethods, method) {
+				invalid("неизвестный метод %q у grpc.clients[%d]", method, i)
+			}
+		}
+	}
+	if c.Postgres.DSN == "" {
+		invalid("не задан postgres.dsn")
+	}
+
+	if c.Token.Issuer == "" {
+		invalid("не задан token.issuer")
+	}
+	if c.Token.AccessTTL <= 0 {
+		invalid("token.access_ttl должен быть больше 0")
+	}
+	if c.Token.SessionTTL <= 0 {
+		invalid("token.session_ttl должен быть больше 0")
+	}
+	if len(c.Token.Keys) == 0 {
+		invalid("не заданы token.keys")
+	}
+	activeFound := false
+	seen := make(map[string]bool)
+	for i, key := range c.Token.Keys {
+		switch {
+		case key.ID == "":
+			invalid("не задан id у token.keys[%d]", i)
+		case seen[key.ID]:
+			invalid("повторяющийся id %s в token.keys", key.ID)
+		}
+		seen[key.ID] = true
+		if (key.PrivateKeyFile == "") == (key.PublicKeyFile == "") {
+			invalid(
+				"у token.keys[%d] должен быть задан ровно один из private_key_file и public_key_file",
+				i,
+			)
+		}
+		if key.ID == c.Token.ActiveKeyID {
+			activeFound = true
+			if key.PrivateKeyFile == "" {
+				invalid("у активного ключа %s не задан private_key_file", key.ID)
+			}
+		}
+	}
+	if c.Token.ActiveKeyID == "" {
+		invalid("не задан token.active_key_id")
+	} else if !activeFound {
+		invalid("активный ключ %s отсутствует в token.keys", c.Token.ActiveKeyID)
+	}
+
+	if c.Password.MinLength < 1 {
+		invalid("password.min_length должен быть больше 0")
+	}
+	if c.Password.MaxLength < c.Password.MinLength {
+		invalid(
+			"password.max_length (%d) не может быть меньше password.min_length (%d)",
+			c.Password.MaxLength,
+			c.Password.MinLength,
+		)
+	}
+	if c.Password.Argon2Parallelism < 1 || c.Password.Argon2Parallelism > 255 {
+		invalid(
+			"password.argon2_parallelism должен быть в диапазоне 1-255, получено %d",
+			c.Password.Argon2Parallelism,
+		)
+	}
+	if c.Password.Argon2Iterations < 1 {
+		invalid("password.argon2_iterations должен быть больше 0")
+	}
+	if c.Password.Argon2MemoryKiB < 8*max(c.Password.Argon2Parallelism, 1) ||
+		c.Password.Argon2MemoryKiB > 4*1024*1024 {
+		invalid(
+			"password.argon2_memory_kib должен быть не меньше 8*argon2_parallelism и не больше 4194304, получено %d",
+			c.Password.Argon2MemoryKiB,
+		)
+	}
+
+	codes := []struct {
+		name    string
+		ttl     Duration
+		profile CodeProfile
+	}{
+		{"confirm_email", c.Codes.ConfirmEmail, c.Codes.ConfirmEmailProfile},
+		{"new_email", c.Codes.NewEmail, c.Codes.NewEmailProfile},
+		{"reset_password", c.Codes.ResetPassword, c.Codes.ResetPasswordProfile},
+		{"new_password", c.Codes.NewPassword, c.Codes.NewPasswordProfile},
+	}
+	for _, code := range codes {
+		if code.ttl <= 0 {
+			invalid("codes.%s_ttl должен быть больше 0", code.name)
+		}
+		if err := code.profile.Random().Validate(); err != nil {
+			invalid("codes.%s_profile: %s", code.name, err)
+		}
+	}
+	if c.Codes.MaxAttempts <= 0 {
+		invalid("codes.max_attempts должен быть больше 0, получено %d", c.Codes.MaxAttempts)
+	}
+	if c.Codes.CleanupInterval <= 0 {
+		invalid("codes.cleanup_interval должен быть больше 0")
+	}
+	switch c.Codes.Store {
+	case CodeStorePostgres:
+	case CodeStoreRedis:
+		if c.Redis.Addr == "" {
+			invalid("не задан redis.addr для codes.store %q", CodeStoreRedis)
+		}
+	default:
+		invalid(
+			"codes.store должен быть %q или %q, получено %q",
+			CodeStorePostgres,
+			CodeStoreRedis,
+			c.Codes.Store,
+		)
+	}
+
+	if c.SMTP.Host == "" {
+		invalid("не задан smtp.host")
+	}
+	if c.SMTP.Port <= 0 || c.SMTP.Port > 65535 {
+		invalid("smtp.port должен быть в диапазоне 1-65535, получено %d", c.SMTP.Port)
+	}
+	if c.SMTP.From == "" {
+		invalid("не задан smtp.from")
+	}
+	if (c.SMTP.Username == "") != (c.SMTP.Password == "") {
+		invalid("smtp.username и smtp.password должны быть заданы вместе")
+	}
+	switch smtp.Security(c.SMTP.Security) {
+	case smtp.SecurityNone, smtp.SecurityStartTLS, smtp.SecurityTLS:
+	default:
+		invalid(
+			"smtp.security должен быть %q, %q или %q, получено %q",
+			smtp.SecurityNone,
+			smtp.SecurityStartTLS,
+			smtp.SecurityTLS,
+			c.SMTP.Security,
+		)
+	}
+	if c.SMTP.Timeout <= 0 {
+		invalid("smtp.timeout должен быть больше 0")
+	}
+
+	if c.Outbox.PollInterval <= 0 {
+		invalid("outbox.poll_interval должен быть больше 0")
+	}
+	if c.Outbox.BatchSize <= 0 {
+		invalid("outbox.batch_size должен быть больше 0, получено %d", c.Outbox.BatchSize)
+	}
+	if c.Outbox.MaxAttempts <= 0 {
+		invalid("outbox.max_attempts должен быть больше 0, получено %d", c.Outbox.MaxAttempts)
+	}
+	if c.Outbox.BaseBackoff <= 0 {
+		invalid("outbox.base_backoff должен быть больше 0")
+	}
+	if c.Outbox.MaxBackoff < c.Outbox.BaseBackoff {
+		invalid("outbox.max_backoff не может быть меньше outbox.base_backoff")
+	}
+	if c.Outbox.Lease <= c.SMTP.Timeout {
+		invalid("outbox.lease должен быть больше smtp.timeout")
+	}
+	if c.Outbox.DeadRetention <= 0 {
+		invalid("outbox.dead_retention должен быть больше 0")
+	}
+
+	if c.Enumeration.MinResponseTime < 0 {
+		invalid("enumeration.min_response_time не может быть отрицательным")
+	}
+
+	switch c.RateLimit.Store {
+	case RateLimitStoreMemory:
+	case RateLimitStoreRedis:
+		if c.Redis.Addr == "" {
+			invalid("не задан redis.addr для rate_limit.store %q", RateLimitStoreRedis)
+		}
+	default:
+		invalid(
+			"rate_limit.store должен быть %q или %q, получено %q",
+			RateLimitStoreMemory,
+			RateLimitStoreRedis,
+			c.RateLimit.Store,
+		)
+	}
+	if c.Redis.DB < 0 {
+		invalid("redis.db не может быть отрицательным, получено %d", c.Redis.DB)
+	}
+	if c.RateLimit.Window <= 0 {
+		invalid("rate_limit.window должен быть больше 0")
+	}
+	if c.RateLimit.MaxAttempts <= 0 {
+		invalid("rate_limit.max_attempts должен быть больше 0, получено %d", c.RateLimit.MaxAttempts)
+	}
+	if c.RateLimit.MaxFailures <= 0 {
+		invalid("rate_limit.max_failures должен быть больше 0, получено %d", c.RateLimit.MaxFailures)
+	}
+	if c.RateLimit.Lockout <= 0 {
+		invalid("rate_limit.lockout должен быть больше 0")
+	}
+	if c.RateLimit.MaxLockout < c.RateLimit.Lockout {
+		invalid("rate_limit.max_lockout не может быть меньше rate_limit.lockout")
+	}
+
+	if c.TwoFactor.Issuer == "" {
+		invalid("two_factor.issuer не может быть пустым")
+	}
+	if c.TwoFactor.Digits != 6 && c.TwoFactor.Digits != 8 {
+		invalid("two_factor.digits должен быть 6 или 8, получено %d", c.TwoFactor.Digits)
+	}
+	if period := time.Duration(c.TwoFactor.Period); period < time.Second || period%time.Second != 0 {
+		invalid("two_factor.period должен быть целым числом секунд не меньше 1s")
+	}
+	if c.TwoFactor.Skew < 0 {
+		invalid("two_factor.skew не может быть отрицательным, получено %d", c.TwoFactor.Skew)
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		invalid("shutdown_timeout должен быть больше 0")
+	}
+	return errors.Join(errs...)
+}
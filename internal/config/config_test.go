@@ -0,0 +1,421 @@
+package config
+
+import (
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+const serviceTokenSHA256 = "784c8e01994654a577f492116789bb8d9153c8774836fc8cb6bfa2cc773ae549"
+
+func testEnv(env map[string]string) func(string) string {
+	return func(name string) string {
+		return env[name]
+	}
+}
+
+func validEnv() map[string]string {
+	return map[string]string{
+		"DND_USERS_POSTGRES_DSN":        "postgres://localhost/dnd_users",
+		"DND_USERS_TOKEN_ACTIVE_KEY_ID": "current",
+		"DND_USERS_TOKEN_KEYS":          "current=/keys/current.pem,previous=/keys/previous.pem",
+		"DND_USERS_SMTP_HOST":           "smtp.mail.com",
+		"DND_USERS_SMTP_FROM":           "noreply@mail.com",
+		"DND_USERS_GRPC_CERT_FILE":      "/tls/server.pem",
+		"DND_USERS_GRPC_KEY_FILE":       "/tls/server.key",
+		"DND_USERS_GRPC_CLIENTS":        "games=" + serviceTokenSHA256 + ":GetUser|ValidateToken",
+	}
+}
+
+func withEnv(env map[string]string, name, value string) map[string]string {
+	env[name] = value
+	return env
+}
+
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestConfig_Load(t *testing.T) {
+	validFile := `{
+		"grpc": {
+			"cert_file": "/tls/server.pem",
+			"key_file": "/tls/server.key",
+			"clients": [
+				{"name": "games", "token_sha256": "` + serviceTokenSHA256 + `", "methods": ["GetUser"]}
+			]
+		},
+		"postgres": {"dsn": "postgres://file/dnd_users"},
+		"token": {
+			"active_key_id": "current",
+			"access_ttl": "5m",
+			"keys": [
+				{"id": "current", "private_key_file": "/keys/current.pem"},
+				{"id": "previous", "public_key_file": "/keys/previous.pub.pem"}
+			]
+		},
+		"smtp": {"host": "smtp.mail.com", "from": "noreply@mail.com"}
+	}`
+	cases := []struct {
+		TestName string
+		Expected error
+		File     string
+		Env      map[string]string
+	}{
+		{TestName: "test_config_load_env_ok", Expected: nil, Env: validEnv()},
+		{TestName: "test_config_load_file_ok", Expected: nil, File: validFile},
+		{
+			TestName: "test_config_load_empty",
+			Expected: ErrInvalidConfig,
+			Env:      map[string]string{},
+		},
+		{
+			TestName: "test_config_load_unknown_field",
+			Expected: ErrInvalidConfig,
+			File:     `{"htp": {"addr": ":8080"}}`,
+		},
+		{
+			TestName: "test_config_load_invalid_duration",
+			Expected: ErrInvalidConfig,
+			File:     `{"shutdown_timeout": 15}`,
+		},
+		{
+			TestName: "test_config_load_invalid_env_duration",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_TOKEN_ACCESS_TTL": "five minutes"},
+		},
+		{
+			TestName: "test_config_load_invalid_env_port",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_SMTP_PORT": "smtp"},
+		},
+		{
+			TestName: "test_config_load_invalid_env_keys",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_TOKEN_KEYS": "current"},
+		},
+		{
+			TestName: "test_config_load_unknown_active_key",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_TOKEN_ACTIVE_KEY_ID": "next"},
+		},
+		{
+			TestName: "test_config_load_active_public_key",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_TOKEN_ACTIVE_KEY_ID": "previous"},
+		},
+		{
+			TestName: "test_config_load_smtp_username_without_password",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_SMTP_USERNAME": "user"},
+		},
+		{
+			TestName: "test_config_load_password_max_less_than_min",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_PASSWORD_MAX_LENGTH": "6"},
+		},
+		{
+			TestName: "test_config_load_invalid_env_bool",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_PASSWORD_REQUIRE_UPPER": "sometimes"},
+		},
+		{
+			TestName: "test_config_load_argon2_memory_too_low",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_PASSWORD_ARGON2_MEMORY_KIB": "16"},
+		},
+		{
+			TestName: "test_config_load_argon2_zero_iterations",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_PASSWORD_ARGON2_ITERATIONS": "0"},
+		},
+		{
+			TestName: "test_config_load_unknown_code_kind",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_CODES_RESET_PASSWORD_KIND": "emoji"},
+		},
+		{
+			TestName: "test_config_load_short_code",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_CODES_CONFIRM_EMAIL_LENGTH": "3"},
+		},
+		{
+			TestName: "test_config_load_zero_code_ttl",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_CODES_NEW_EMAIL_TTL": "0s"},
+		},
+		{
+			TestName: "test_config_load_zero_code_max_attempts",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_CODES_MAX_ATTEMPTS": "0"},
+		},
+		{
+			TestName: "test_config_load_zero_code_cleanup_interval",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_CODES_CLEANUP_INTERVAL": "0s"},
+		},
+		{
+			TestName: "test_config_load_unknown_code_store",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_CODES_STORE": "memcached"},
+		},
+		{
+			TestName: "test_config_load_redis_without_addr",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_CODES_STORE": "redis"},
+		},
+		{
+			TestName: "test_config_load_unknown_smtp_security",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_SMTP_SECURITY": "ssl"},
+		},
+		{
+			TestName: "test_config_load_outbox_max_backoff_less_than_base",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_OUTBOX_MAX_BACKOFF": "1s"},
+		},
+		{
+			TestName: "test_config_load_outbox_lease_not_above_smtp_timeout",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_OUTBOX_LEASE": "30s"},
+		},
+		{
+			TestName: "test_config_load_zero_outbox_dead_retention",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_OUTBOX_DEAD_RETENTION": "0s"},
+		},
+		{
+			TestName: "test_config_load_zero_outbox_batch_size",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_OUTBOX_BATCH_SIZE": "0"},
+		},
+		{
+			TestName: "test_config_load_negative_enumeration_min_response_time",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_ENUMERATION_MIN_RESPONSE_TIME": "-1s"},
+		},
+		{
+			TestName: "test_config_load_enumeration_protection_ok",
+			Expected: nil,
+			File:     validFile,
+			Env: map[string]string{
+				"DND_USERS_ENUMERATION_PROTECTION":        "true",
+				"DND_USERS_ENUMERATION_MIN_RESPONSE_TIME": "300ms",
+			},
+		},
+		{
+			TestName: "test_config_load_zero_rate_limit_max_failures",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_RATE_LIMIT_MAX_FAILURES": "0"},
+		},
+		{
+			TestName: "test_config_load_rate_limit_max_lockout_below_lockout",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env: map[string]string{
+				"DND_USERS_RATE_LIMIT_LOCKOUT":     "10m",
+				"DND_USERS_RATE_LIMIT_MAX_LOCKOUT": "5m",
+			},
+		},
+		{
+			TestName: "test_config_load_two_factor_invalid_digits",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_TWO_FACTOR_DIGITS": "7"},
+		},
+		{
+			TestName: "test_config_load_two_factor_fractional_period",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_TWO_FACTOR_PERIOD": "1500ms"},
+		},
+		{
+			TestName: "test_config_load_two_factor_ok",
+			Expected: nil,
+			File:     validFile,
+			Env: map[string]string{
+				"DND_USERS_TWO_FACTOR_DIGITS": "8",
+				"DND_USERS_TWO_FACTOR_PERIOD": "60s",
+				"DND_USERS_TWO_FACTOR_SKEW":   "0",
+			},
+		},
+		{
+			TestName: "test_config_load_trusted_proxies_ok",
+			Expected: nil,
+			Env: withEnv(
+				validEnv(),
+				"DND_USERS_HTTP_TRUSTED_PROXIES",
+				"10.0.0.0/8, 192.0.2.10",
+			),
+		},
+		{
+			TestName: "test_config_load_invalid_trusted_proxies",
+			Expected: ErrInvalidConfig,
+			Env:      withEnv(validEnv(), "DND_USERS_HTTP_TRUSTED_PROXIES", "10.0.0.0/40"),
+		},
+		{
+			TestName: "test_config_load_grpc_without_tls",
+			Expected: ErrInvalidConfig,
+			Env:      withEnv(validEnv(), "DND_USERS_GRPC_KEY_FILE", ""),
+		},
+		{
+			TestName: "test_config_load_grpc_without_clients",
+			Expected: ErrInvalidConfig,
+			Env:      withEnv(validEnv(), "DND_USERS_GRPC_CLIENTS", ""),
+		},
+		{
+			TestName: "test_config_load_grpc_invalid_clients",
+			Expected: ErrInvalidConfig,
+			Env:      withEnv(validEnv(), "DND_USERS_GRPC_CLIENTS", "games=abc"),
+		},
+		{
+			TestName: "test_config_load_grpc_client_invalid_hash",
+			Expected: ErrInvalidConfig,
+			Env:      withEnv(validEnv(), "DND_USERS_GRPC_CLIENTS", "games=abc:GetUser"),
+		},
+		{
+			TestName: "test_config_load_grpc_client_unknown_method",
+			Expected: ErrInvalidConfig,
+			Env: withEnv(
+				validEnv(),
+				"DND_USERS_GRPC_CLIENTS",
+				"games="+serviceTokenSHA256+":DeleteUser",
+			),
+		},
+		{
+			TestName: "test_config_load_redis_ok",
+			Expected: nil,
+			File:     validFile,
+			Env: map[string]string{
+				"DND_USERS_CODES_STORE": "redis",
+				"DND_USERS_REDIS_ADDR":  "localhost:6379",
+			},
+		},
+		{
+			TestName: "test_config_load_rate_limit_redis_ok",
+			Expected: nil,
+			File:     validFile,
+			Env: map[string]string{
+				"DND_USERS_RATE_LIMIT_STORE": "redis",
+				"DND_USERS_REDIS_ADDR":       "localhost:6379",
+			},
+		},
+		{
+			TestName: "test_config_load_rate_limit_redis_without_addr",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_RATE_LIMIT_STORE": "redis"},
+		},
+		{
+			TestName: "test_config_load_unknown_rate_limit_store",
+			Expected: ErrInvalidConfig,
+			File:     validFile,
+			Env:      map[string]string{"DND_USERS_RATE_LIMIT_STORE": "postgres"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			path := ""
+			if c.File != "" {
+				path = writeConfig(t, c.File)
+			}
+			cfg, err := Load(path, testEnv(c.Env))
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected nil, but got %v", err)
+				}
+				if cfg == nil {
+					t.Fatal("expected config, but got nil")
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+}
+
+func TestConfig_LoadPrecedence(t *testing.T) {
+	path := writeConfig(t, `{
+		"http": {"addr": ":8000"},
+		"postgres": {"dsn": "postgres://file/dnd_users"},
+		"token": {"access_ttl": "5m"}
+	}`)
+	env := validEnv()
+	env["DND_USERS_POSTGRES_DSN"] = "postgres://env/dnd_users"
+	cfg, err := Load(path, testEnv(env))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Postgres.DSN != "postgres://env/dnd_users" {
+		t.Errorf("expected env to override file, but got %s", cfg.Postgres.DSN)
+	}
+	if cfg.HTTP.Addr != ":8000" {
+		t.Errorf("expected file to override default, but got %s", cfg.HTTP.Addr)
+	}
+	if time.Duration(cfg.Token.AccessTTL) != 5*time.Minute {
+		t.Errorf("expected access ttl from file, but got %v", time.Duration(cfg.Token.AccessTTL))
+	}
+	if cfg.GRPC.Addr != ":9090" {
+		t.Errorf("expected default grpc addr, but got %s", cfg.GRPC.Addr)
+	}
+	if len(cfg.Token.Keys) != 2 || cfg.Token.Keys[1].PrivateKeyFile != "/keys/previous.pem" {
+		t.Errorf("expected keys from env, but got %v", cfg.Token.Keys)
+	}
+}
+
+func TestConfig_LoadExample(t *testing.T) {
+	env := map[string]string{"DND_USERS_SMTP_PASSWORD": "secret"}
+	if _, err := Load("../../cmd/server/config.example.json", testEnv(env)); err != nil {
+		t.Errorf("expected example config to be valid, but got %v", err)
+	}
+}
+
+func TestHTTP_TrustedProxyPrefixes(t *testing.T) {
+	cfg := HTTP{TrustedProxies: []string{"10.1.2.3/8", "192.0.2.10", "::ffff:198.51.100.1"}}
+	prefixes, err := cfg.TrustedProxyPrefixes()
+	if err != nil {
+		t.Fatalf("parse trusted proxies: %v", err)
+	}
+	expected := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.10/32"),
+		netip.MustParsePrefix("198.51.100.1/32"),
+	}
+	if !slices.Equal(prefixes, expected) {
+		t.Errorf("expected %v, but got %v", expected, prefixes)
+	}
+}
@@ -1,9 +1,56 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrInvalidData   = errors.New("не корректные данные")
 	ErrIdempotent    = errors.New("попытка изменения пользователя без изменения данных")
 	ErrUserNotActive = errors.New("пользователь имеет не активный статус")
 )
+
+const (
+	CodeRequired      = "value.required"
+	CodeUnsupported   = "value.unsupported"
+	CodeUnchanged     = "value.unchanged"
+	CodeUserNotActive = "user.not_active"
+)
+
+type Error struct {
+	Kind    error
+	Code    string
+	Field   string
+	Params  map[string]string
+	Message string
+}
+
+func newError(kind error, code, field, message string) *Error {
+	return &Error{Kind: kind, Code: code, Field: field, Message: message}
+}
+
+func required(field, message string) *Error {
+	return newError(ErrInvalidData, CodeRequired, field, message)
+}
+
+func unchanged(field, format, value string) *Error {
+	return newError(ErrIdempotent, CodeUnchanged, field, fmt.Sprintf(format, value)).
+		with("value", value)
+}
+
+func (e *Error) with(key, value string) *Error {
+	if e.Params == nil {
+		e.Params = make(map[string]string)
+	}
+	e.Params[key] = value
+	return e
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Kind, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestError_Code(t *testing.T) {
+	active, err := RestoreUser(uuid.New(), "test@mail.com", "hash", ACTIVE, USER, RU, TwoFactor{}, 1)
+	if err != nil {
+		t.Fatalf("restore user: %v", err)
+	}
+	frozen, err := RestoreUser(uuid.New(), "test@mail.com", "hash", FROZEN, USER, RU, TwoFactor{}, 1)
+	if err != nil {
+		t.Fatalf("restore user: %v", err)
+	}
+	_, unsupported := NewStatus("other")
+	cases := []struct {
+		TestName string
+		Expected error
+		Code     string
+		Field    string
+		Value    string
+		Err      error
+	}{
+		{
+			TestName: "test_error_required",
+			Expected: ErrInvalidData,
+			Code:     CodeRequired,
+			Field:    "email",
+			Err:      active.NewEmail(""),
+		},
+		{
+			TestName: "test_error_unsupported",
+			Expected: ErrInvalidData,
+			Code:     CodeUnsupported,
+			Field:    "status",
+			Value:    "other",
+			Err:      unsupported,
+		},
+		{
+			TestName: "test_error_unchanged",
+			Expected: ErrIdempotent,
+			Code:     CodeUnchanged,
+			Field:    "email",
+			Value:    "test@mail.com",
+			Err:      active.NewEmail("test@mail.com"),
+		},
+		{
+			TestName: "test_error_user_not_active",
+			Expected: ErrUserNotActive,
+			Code:     CodeUserNotActive,
+			Field:    "state",
+			Err:      frozen.NewEmail("new@mail.com"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			if !errors.Is(c.Err, c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, c.Err)
+			}
+			var domainErr *Error
+			if !errors.As(c.Err, &domainErr) {
+				t.Fatalf("expected *Error, but got %T", c.Err)
+			}
+			if domainErr.Code != c.Code || domainErr.Field != c.Field {
+				t.Errorf(
+					"expected %s on %s, but got %s on %s",
+					c.Code,
+					c.Field,
+					domainErr.Code,
+					domainErr.Field,
+				)
+			}
+			if domainErr.Params["value"] != c.Value {
+				t.Errorf("expected value %q, but got %v", c.Value, domainErr.Params)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package domain
+
+const (
+	RU = "ru"
+	EN = "en"
+)
+
+var NilLocale = Locale("")
+
+type Locale string
+
+func NewLocale(locale string) (Locale, error) {
+	switch locale {
+	case RU:
+		return RU, nil
+	case EN:
+		return EN, nil
+	default:
+		return "", newError(
+			ErrInvalidData,
+			CodeUnsupported,
+			"locale",
+			"язык "+locale+" не поддерживается",
+		).with("value", locale)
+	}
+}
+
+func DefaultLocale() Locale {
+	return Locale(RU)
+}
+
+func (l Locale) String() string {
+	return string(l)
+}
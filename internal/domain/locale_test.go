@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLocale_NewLocale(t *testing.T) {
+	cases := []struct {
+		TestName   string
+		LocaleName string
+		Expected   error
+	}{
+		{TestName: "test_new_ru_locale", LocaleName: RU, Expected: nil},
+		{TestName: "test_new_en_locale", LocaleName: EN, Expected: nil},
+		{TestName: "test_new_other_locale", LocaleName: "de", Expected: ErrInvalidData},
+		{TestName: "test_new_empty_locale", LocaleName: "", Expected: ErrInvalidData},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			_, err := NewLocale(c.LocaleName)
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %T", c.Expected, err)
+			}
+		})
+	}
+}
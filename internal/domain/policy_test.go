@@ -125,6 +125,7 @@ func activeUser() *User {
 		email:        "test@test.ru",
 		state:        newActiveState(),
 		status:       newUserStatus(),
+		locale:       DefaultLocale(),
 		passwordHash: "test",
 		version:      1,
 	}
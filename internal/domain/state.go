@@ -1,7 +1,5 @@
 package domain
 
-import "fmt"
-
 const (
 	ACTIVE  = "active"
 	FROZEN  = "frozen"
@@ -21,11 +19,12 @@ func NewState(state string) (State, error) {
 	case DELETED:
 		return DELETED, nil
 	default:
-		return "", fmt.Errorf(
-			"%w: состояния пользователя с названием %s не существует",
+		return "", newError(
 			ErrInvalidData,
-			state,
-		)
+			CodeUnsupported,
+			"state",
+			"состояния пользователя с названием "+state+" не существует",
+		).with("value", state)
 	}
 }
 
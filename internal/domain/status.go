@@ -1,7 +1,5 @@
 package domain
 
-import "fmt"
-
 const (
 	ADMIN = "admin"
 	USER  = "user"
@@ -18,11 +16,12 @@ func NewStatus(status string) (Status, error) {
 	case USER:
 		return USER, nil
 	default:
-		return "", fmt.Errorf(
-			"%w: статуса пользователя с названием %s не существует",
+		return "", newError(
 			ErrInvalidData,
-			status,
-		)
+			CodeUnsupported,
+			"status",
+			"статуса пользователя с названием "+status+" не существует",
+		).with("value", status)
 	}
 }
 
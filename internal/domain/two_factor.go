@@ -0,0 +1,142 @@
+package domain
+
+const (
+	CodeTwoFactorEnabled     = "two_factor.enabled"
+	CodeTwoFactorNotEnrolled = "two_factor.not_enrolled"
+	CodeTwoFactorNotEnabled  = "two_factor.not_enabled"
+	CodeTwoFactorReplayed    = "two_factor.replayed"
+)
+
+type TwoFactor struct {
+	secret   string
+	enabled  bool
+	lastStep int64
+}
+
+func RestoreTwoFactor(secret string, enabled bool, lastStep int64) (TwoFactor, error) {
+	if enabled && secret == "" {
+		return TwoFactor{}, required(
+			"two_factor",
+			"секрет двухфакторной аутентификации не может быть пустым",
+		)
+	}
+	if lastStep < 0 {
+		return TwoFactor{}, newError(
+			ErrInvalidData,
+			CodeUnsupported,
+			"two_factor",
+			"шаг двухфакторной аутентификации не может быть отрицательным",
+		)
+	}
+	return TwoFactor{secret: secret, enabled: enabled, lastStep: lastStep}, nil
+}
+
+func (t TwoFactor) Secret() string {
+	return t.secret
+}
+
+func (t TwoFactor) Enabled() bool {
+	return t.enabled
+}
+
+func (t TwoFactor) LastStep() int64 {
+	return t.lastStep
+}
+
+func (u *User) TwoFactor() TwoFactor {
+	return u.twoFactor
+}
+
+func (u *User) EnrollTwoFactor(secret string) error {
+	if err := u.checkState(); err != nil {
+		return err
+	}
+	if secret == "" {
+		return required("two_factor", "секрет двухфакторной аутентификации не может быть пустым")
+	}
+	if u.twoFactor.enabled {
+		return newError(
+			ErrInvalidData,
+			CodeTwoFactorEnabled,
+			"two_factor",
+			"двухфакторная аутентификация уже включена",
+		)
+	}
+	u.twoFactor = TwoFactor{secret: secret}
+	return nil
+}
+
+func (u *User) EnableTwoFactor(step int64) error {
+	if err := u.CanEnableTwoFactor(); err != nil {
+		return err
+	}
+	u.twoFactor.enabled = true
+	u.twoFactor.lastStep = step
+	return nil
+}
+
+func (u *User) CanEnableTwoFactor() error {
+	if err := u.checkState(); err != nil {
+		return err
+	}
+	if u.twoFactor.enabled {
+		return newError(
+			ErrIdempotent,
+			CodeTwoFactorEnabled,
+			"two_factor",
+			"двухфакторная аутентификация уже включена",
+		)
+	}
+	if u.twoFactor.secret == "" {
+		return newError(
+			ErrInvalidData,
+			CodeTwoFactorNotEnrolled,
+			"two_factor",
+			"двухфакторная аутентификация не подключена",
+		)
+	}
+	return nil
+}
+
+func (u *User) DisableTwoFactor() error {
+	if err := u.CanDisableTwoFactor(); err != nil {
+		return err
+	}
+	u.twoFactor = TwoFactor{}
+	return nil
+}
+
+func (u *User) CanDisableTwoFactor() error {
+	if err := u.checkState(); err != nil {
+		return err
+	}
+	if !u.twoFactor.enabled {
+		return u.twoFactorNotEnabled()
+	}
+	return nil
+}
+
+func (u *User) UseTwoFactorStep(step int64) error {
+	if !u.twoFactor.enabled {
+		return u.twoFactorNotEnabled()
+	}
+	if step <= u.twoFactor.lastStep {
+		return newError(
+			ErrInvalidData,
+			CodeTwoFactorReplayed,
+			"totp_code",
+			"код двухфакторной аутентификации уже использован",
+		)
+	}
+	u.twoFactor.lastStep = step
+	return nil
+}
+
+func (u *User) twoFactorNotEnabled() *Error {
+	return newError(
+		ErrInvalidData,
+		CodeTwoFactorNotEnabled,
+		"two_factor",
+		"двухфакторная аутентификация не включена",
+	)
+}
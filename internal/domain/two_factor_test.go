@@ -0,0 +1,218 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func twoFactorUser(enabled bool, lastStep int64) *User {
+	user := activeUser()
+	user.twoFactor = TwoFactor{secret: "secret", enabled: enabled, lastStep: lastStep}
+	return user
+}
+
+func TestRestoreTwoFactor(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Expected error
+		Secret   string
+		Enabled  bool
+		LastStep int64
+	}{
+		{TestName: "test_restore_two_factor_disabled", Expected: nil},
+		{TestName: "test_restore_two_factor_pending", Expected: nil, Secret: "secret"},
+		{
+			TestName: "test_restore_two_factor_enabled",
+			Expected: nil,
+			Secret:   "secret",
+			Enabled:  true,
+			LastStep: 10,
+		},
+		{
+			TestName: "test_restore_two_factor_enabled_without_secret",
+			Expected: ErrInvalidData,
+			Enabled:  true,
+		},
+		{
+			TestName: "test_restore_two_factor_negative_step",
+			Expected: ErrInvalidData,
+			Secret:   "secret",
+			LastStep: -1,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			_, err := RestoreTwoFactor(c.Secret, c.Enabled, c.LastStep)
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, err)
+			}
+		})
+	}
+}
+
+func TestUser_EnrollTwoFactor(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Expected error
+		User     *User
+		Secret   string
+	}{
+		{
+			TestName: "test_user_enroll_two_factor_ok",
+			Expected: nil,
+			User:     activeUser(),
+			Secret:   "new_secret",
+		},
+		{
+			TestName: "test_user_enroll_two_factor_replaces_pending",
+			Expected: nil,
+			User:     twoFactorUser(false, 0),
+			Secret:   "new_secret",
+		},
+		{
+			TestName: "test_user_enroll_two_factor_already_enabled",
+			Expected: ErrInvalidData,
+			User:     twoFactorUser(true, 0),
+			Secret:   "new_secret",
+		},
+		{
+			TestName: "test_user_enroll_two_factor_empty_secret",
+			Expected: ErrInvalidData,
+			User:     activeUser(),
+			Secret:   "",
+		},
+		{
+			TestName: "test_user_enroll_two_factor_he_is_frozen",
+			Expected: ErrUserNotActive,
+			User:     frozenUser(),
+			Secret:   "new_secret",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := c.User.EnrollTwoFactor(c.Secret)
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, err)
+			}
+			if c.Expected == nil && c.User.TwoFactor() != (TwoFactor{secret: c.Secret}) {
+				t.Errorf("expected pending secret, but got %+v", c.User.TwoFactor())
+			}
+		})
+	}
+}
+
+func TestUser_EnableTwoFactor(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Expected error
+		User     *User
+	}{
+		{
+			TestName: "test_user_enable_two_factor_ok",
+			Expected: nil,
+			User:     twoFactorUser(false, 0),
+		},
+		{
+			TestName: "test_user_enable_two_factor_not_enrolled",
+			Expected: ErrInvalidData,
+			User:     activeUser(),
+		},
+		{
+			TestName: "test_user_enable_two_factor_already_enabled",
+			Expected: ErrIdempotent,
+			User:     twoFactorUser(true, 5),
+		},
+		{
+			TestName: "test_user_enable_two_factor_he_is_deleted",
+			Expected: ErrUserNotActive,
+			User:     deletedUser(),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := c.User.EnableTwoFactor(10)
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, err)
+			}
+			if c.Expected == nil &&
+				(!c.User.TwoFactor().Enabled() || c.User.TwoFactor().LastStep() != 10) {
+				t.Errorf("expected enabled two factor at step 10, but got %+v", c.User.TwoFactor())
+			}
+		})
+	}
+}
+
+func TestUser_DisableTwoFactor(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Expected error
+		User     *User
+	}{
+		{
+			TestName: "test_user_disable_two_factor_ok",
+			Expected: nil,
+			User:     twoFactorUser(true, 5),
+		},
+		{
+			TestName: "test_user_disable_two_factor_not_enabled",
+			Expected: ErrInvalidData,
+			User:     twoFactorUser(false, 0),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := c.User.DisableTwoFactor()
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, err)
+			}
+			if c.Expected == nil && c.User.TwoFactor() != (TwoFactor{}) {
+				t.Errorf("expected two factor to be cleared, but got %+v", c.User.TwoFactor())
+			}
+		})
+	}
+}
+
+func TestUser_UseTwoFactorStep(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Expected error
+		User     *User
+		Step     int64
+	}{
+		{
+			TestName: "test_user_use_two_factor_step_ok",
+			Expected: nil,
+			User:     twoFactorUser(true, 5),
+			Step:     6,
+		},
+		{
+			TestName: "test_user_use_two_factor_step_replayed",
+			Expected: ErrInvalidData,
+			User:     twoFactorUser(true, 5),
+			Step:     5,
+		},
+		{
+			TestName: "test_user_use_two_factor_step_older",
+			Expected: ErrInvalidData,
+			User:     twoFactorUser(true, 5),
+			Step:     4,
+		},
+		{
+			TestName: "test_user_use_two_factor_step_not_enabled",
+			Expected: ErrInvalidData,
+			User:     twoFactorUser(false, 0),
+			Step:     6,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := c.User.UseTwoFactorStep(c.Step)
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, err)
+			}
+			if c.Expected == nil && c.User.TwoFactor().LastStep() != c.Step {
+				t.Errorf("expected last step %d, but got %d", c.Step, c.User.TwoFactor().LastStep())
+			}
+		})
+	}
+}
@@ -1,35 +1,37 @@
 package domain
 
-import (
-	"fmt"
-
-	"github.com/google/uuid"
-)
+import "github.com/google/uuid"
 
 type User struct {
 	id           uuid.UUID
 	email        string
 	state        State
 	status       Status
+	locale       Locale
 	passwordHash string
+	twoFactor    TwoFactor
 	version      uint
 }
 
-func NewUser(id uuid.UUID, email, passwordHash string) (*User, error) {
+func NewUser(id uuid.UUID, email, passwordHash string, locale Locale) (*User, error) {
 	if id == uuid.Nil {
-		return nil, fmt.Errorf("%w: id пользователя не может быть пустым", ErrInvalidData)
+		return nil, required("id", "id пользователя не может быть пустым")
 	}
 	if email == "" {
-		return nil, fmt.Errorf("%w: email пользователя не может быть пустым", ErrInvalidData)
+		return nil, required("email", "email пользователя не может быть пустым")
 	}
 	if passwordHash == "" {
-		return nil, fmt.Errorf("%w: пароль пользователя не может быть пустым", ErrInvalidData)
+		return nil, required("password", "пароль пользователя не может быть пустым")
+	}
+	if locale == NilLocale {
+		return nil, required("locale", "язык пользователя не может быть пустым")
 	}
 	return &User{
 		id:           id,
 		email:        email,
 		state:        newActiveState(),
 		status:       newUserStatus(),
+		locale:       locale,
 		passwordHash: passwordHash,
 		version:      0,
 	}, nil
@@ -40,32 +42,39 @@ func RestoreUser(
 	email, passwordHash string,
 	state State,
 	status Status,
+	locale Locale,
+	twoFactor TwoFactor,
 	version uint,
 ) (*User, error) {
 	if id == uuid.Nil {
-		return nil, fmt.Errorf("%w: id пользователя не может быть пустым", ErrInvalidData)
+		return nil, required("id", "id пользователя не может быть пустым")
 	}
 	if email == "" {
-		return nil, fmt.Errorf("%w: email пользователя не может быть пустым", ErrInvalidData)
+		return nil, required("email", "email пользователя не может быть пустым")
 	}
 	if state == NilState {
-		return nil, fmt.Errorf("%w: состояние пользователя не может быть пустым", ErrInvalidData)
+		return nil, required("state", "состояние пользователя не может быть пустым")
 	}
 	if status == NilStatus {
-		return nil, fmt.Errorf("%w: статус пользователя не может быть пустым", ErrInvalidData)
+		return nil, required("status", "статус пользователя не может быть пустым")
+	}
+	if locale == NilLocale {
+		return nil, required("locale", "язык пользователя не может быть пустым")
 	}
 	if passwordHash == "" {
-		return nil, fmt.Errorf("%w: пароль пользователя не может быть пустым", ErrInvalidData)
+		return nil, required("password", "пароль пользователя не может быть пустым")
 	}
 	if version == 0 {
-		return nil, fmt.Errorf("%w: версия пользователя не может быть равна 0", ErrInvalidData)
+		return nil, required("version", "версия пользователя не может быть равна 0")
 	}
 	return &User{
 		id:           id,
 		email:        email,
 		state:        state,
 		status:       status,
+		locale:       locale,
 		passwordHash: passwordHash,
+		twoFactor:    twoFactor,
 		version:      version,
 	}, nil
 }
@@ -86,6 +95,10 @@ func (u *User) Status() Status {
 	return u.status
 }
 
+func (u *User) Locale() Locale {
+	return u.locale
+}
+
 func (u *User) PasswordHash() string {
 	return u.passwordHash
 }
@@ -103,10 +116,10 @@ func (u *User) NewEmail(email string) error {
 		return err
 	}
 	if email == "" {
-		return fmt.Errorf("%w: email пользователя не может быть пустым", ErrInvalidData)
+		return required("email", "email пользователя не может быть пустым")
 	}
 	if u.email == email {
-		return fmt.Errorf("%w: email пользователя уже %s", ErrIdempotent, email)
+		return unchanged("email", "email пользователя уже %s", email)
 	}
 	u.email = email
 	return nil
@@ -114,10 +127,10 @@ func (u *User) NewEmail(email string) error {
 
 func (u *User) NewState(state State) error {
 	if state == NilState {
-		return fmt.Errorf("%w: состояние пользователя не может быть пустым", ErrInvalidData)
+		return required("state", "состояние пользователя не может быть пустым")
 	}
 	if u.state == state {
-		return fmt.Errorf("%w: состояние пользователя уже %s", ErrIdempotent, state)
+		return unchanged("state", "состояние пользователя уже %s", string(state))
 	}
 	u.state = state
 	return nil
@@ -128,21 +141,35 @@ func (u *User) NewStatus(status Status) error {
 		return err
 	}
 	if status == NilStatus {
-		return fmt.Errorf("%w: статус пользователя не может быть пустым", ErrInvalidData)
+		return required("status", "статус пользователя не может быть пустым")
 	}
 	if u.status == status {
-		return fmt.Errorf("%w: статус пользователя уже %s", ErrIdempotent, status)
+		return unchanged("status", "статус пользователя уже %s", string(status))
 	}
 	u.status = status
 	return nil
 }
 
+func (u *User) NewLocale(locale Locale) error {
+	if err := u.checkState(); err != nil {
+		return err
+	}
+	if locale == NilLocale {
+		return required("locale", "язык пользователя не может быть пустым")
+	}
+	if u.locale == locale {
+		return unchanged("locale", "язык пользователя уже %s", string(locale))
+	}
+	u.locale = locale
+	return nil
+}
+
 func (u *User) NewPasswordHash(passwordHash string) error {
 	if err := u.checkState(); err != nil {
 		return err
 	}
 	if passwordHash == "" {
-		return fmt.Errorf("%w: пароль пользователя не может быть пустым", ErrInvalidData)
+		return required("password", "пароль пользователя не может быть пустым")
 	}
 	u.passwordHash = passwordHash
 	return nil
@@ -150,7 +177,12 @@ func (u *User) NewPasswordHash(passwordHash string) error {
 
 func (u *User) checkState() error {
 	if !u.state.IsActive() {
-		return fmt.Errorf("%w: id пользователя %s", ErrUserNotActive, u.id)
+		return newError(
+			ErrUserNotActive,
+			CodeUserNotActive,
+			"state",
+			"id пользователя "+u.id.String(),
+		).with("id", u.id.String())
 	}
 	return nil
 }
@@ -14,6 +14,7 @@ func TestUser_NewUser(t *testing.T) {
 		ID           uuid.UUID
 		Email        string
 		PasswordHash string
+		Locale       Locale
 	}{
 		{
 			TestName:     "test_new_user_ok",
@@ -21,6 +22,7 @@ func TestUser_NewUser(t *testing.T) {
 			ID:           uuid.New(),
 			Email:        "test@test.ru",
 			PasswordHash: "test",
+			Locale:       DefaultLocale(),
 		},
 		{
 			TestName:     "test_new_user_id_is_empty",
@@ -28,6 +30,7 @@ func TestUser_NewUser(t *testing.T) {
 			ID:           uuid.Nil,
 			Email:        "test@test.ru",
 			PasswordHash: "test",
+			Locale:       DefaultLocale(),
 		},
 		{
 			TestName:     "test_new_user_email_is_empty",
@@ -35,6 +38,7 @@ func TestUser_NewUser(t *testing.T) {
 			ID:           uuid.New(),
 			Email:        "",
 			PasswordHash: "test",
+			Locale:       DefaultLocale(),
 		},
 		{
 			TestName:     "test_new_user_password_hash_is_empty",
@@ -42,11 +46,20 @@ func TestUser_NewUser(t *testing.T) {
 			ID:           uuid.New(),
 			Email:        "test@test.ru",
 			PasswordHash: "",
+			Locale:       DefaultLocale(),
+		},
+		{
+			TestName:     "test_new_user_locale_is_empty",
+			Expected:     ErrInvalidData,
+			ID:           uuid.New(),
+			Email:        "test@test.ru",
+			PasswordHash: "test",
+			Locale:       NilLocale,
 		},
 	}
 	for _, c := range cases {
 		t.Run(c.TestName, func(t *testing.T) {
-			_, err := NewUser(c.ID, c.Email, c.PasswordHash)
+			_, err := NewUser(c.ID, c.Email, c.PasswordHash, c.Locale)
 			if c.Expected == nil {
 				if err != nil {
 					t.Errorf("expected %T, but got nil", c.Expected)
@@ -67,6 +80,7 @@ func TestUser_RestoreUser(t *testing.T) {
 		Email        string
 		State        State
 		Status       Status
+		Locale       Locale
 		PasswordHash string
 		Version      uint
 	}{
@@ -77,6 +91,7 @@ func TestUser_RestoreUser(t *testing.T) {
 			Email:        "test@test.ru",
 			State:        newActiveState(),
 			Status:       newUserStatus(),
+			Locale:       DefaultLocale(),
 			PasswordHash: "test",
 			Version:      1,
 		},
@@ -87,6 +102,7 @@ func TestUser_RestoreUser(t *testing.T) {
 			Email:        "test@test.ru",
 			State:        newActiveState(),
 			Status:       newUserStatus(),
+			Locale:       DefaultLocale(),
 			PasswordHash: "test",
 			Version:      1,
 		},
@@ -97,6 +113,7 @@ func TestUser_RestoreUser(t *testing.T) {
 			Email:        "",
 			State:        newActiveState(),
 			Status:       newUserStatus(),
+			Locale:       DefaultLocale(),
 			PasswordHash: "test",
 			Version:      1,
 		},
@@ -107,6 +124,7 @@ func TestUser_RestoreUser(t *testing.T) {
 			Email:        "test@test.ru",
 			State:        NilState,
 			Status:       newUserStatus(),
+			Locale:       DefaultLocale(),
 			PasswordHash: "test",
 			Version:      1,
 		},
@@ -117,6 +135,7 @@ func TestUser_RestoreUser(t *testing.T) {
 			Email:        "test@test.ru",
 			State:        newActiveState(),
 			Status:       NilStatus,
+			Locale:       DefaultLocale(),
 			PasswordHash: "test",
 			Version:      1,
 		},
@@ -127,6 +146,7 @@ func TestUser_RestoreUser(t *testing.T) {
 			Email:        "test@test.ru",
 			State:        newActiveState(),
 			Status:       newUserStatus(),
+			Locale:       DefaultLocale(),
 			PasswordHash: "",
 			Version:      1,
 		},
@@ -137,13 +157,34 @@ func TestUser_RestoreUser(t *testing.T) {
 			Email:        "test@test.ru",
 			State:        newActiveState(),
 			Status:       newUserStatus(),
+			Locale:       DefaultLocale(),
 			PasswordHash: "test",
 			Version:      0,
 		},
+		{
+			TestName:     "test_restore_user_locale_is_empty",
+			Expected:     ErrInvalidData,
+			ID:           uuid.New(),
+			Email:        "test@test.ru",
+			State:        newActiveState(),
+			Status:       newUserStatus(),
+			Locale:       NilLocale,
+			PasswordHash: "test",
+			Version:      1,
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.TestName, func(t *testing.T) {
-			_, err := RestoreUser(c.ID, c.Email, c.PasswordHash, c.State, c.Status, c.Version)
+			_, err := RestoreUser(
+				c.ID,
+				c.Email,
+				c.PasswordHash,
+				c.State,
+				c.Status,
+				c.Locale,
+				TwoFactor{},
+				c.Version,
+			)
 			if c.Expected == nil {
 				if err != nil {
 					t.Errorf("expected %T, but got nil", c.Expected)
@@ -351,3 +392,45 @@ func TestUser_NewPasswordHash(t *testing.T) {
 		})
 	}
 }
+
+func TestUser_NewLocale(t *testing.T) {
+	cases := []struct {
+		TestName  string
+		Expected  error
+		User      *User
+		NewLocale Locale
+	}{
+		{TestName: "test_user_new_locale_ok", Expected: nil, User: activeUser(), NewLocale: EN},
+		{
+			TestName:  "test_user_new_locale_it_is_empty",
+			Expected:  ErrInvalidData,
+			User:      activeUser(),
+			NewLocale: NilLocale,
+		},
+		{
+			TestName:  "test_user_new_locale_it_is_same",
+			Expected:  ErrIdempotent,
+			User:      activeUser(),
+			NewLocale: activeUser().Locale(),
+		},
+		{
+			TestName:  "test_user_new_locale_he_is_frozen",
+			Expected:  ErrUserNotActive,
+			User:      frozenUser(),
+			NewLocale: EN,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := c.User.NewLocale(c.NewLocale)
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected %T, but got nil", c.Expected)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %T", c.Expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:embed messages/*.json
+var defaultMessages embed.FS
+
+const DefaultLocale = "ru"
+
+var ErrInvalidCatalog = errors.New("некорректный каталог сообщений")
+
+type MessageID string
+
+type Catalog struct {
+	messages map[string]map[MessageID]string
+	locales  []string
+	fallback string
+}
+
+func MustDefaultCatalog() *Catalog {
+	sub, err := fs.Sub(defaultMessages, "messages")
+	if err != nil {
+		panic(fmt.Sprintf("i18n could not open embedded messages: %s", err))
+	}
+	catalog, err := LoadCatalog(sub, DefaultLocale)
+	if err != nil {
+		panic(fmt.Sprintf("i18n could not load embedded messages: %s", err))
+	}
+	return catalog
+}
+
+func LoadCatalog(fsys fs.FS, fallback string) (*Catalog, error) {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCatalog, err)
+	}
+	catalog := &Catalog{messages: make(map[string]map[MessageID]string), fallback: fallback}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("%w: чтение %s: %s", ErrInvalidCatalog, file, err)
+		}
+		var messages map[MessageID]string
+		if err = json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("%w: разбор %s: %s", ErrInvalidCatalog, file, err)
+		}
+		locale := strings.TrimSuffix(path.Base(file), ".json")
+		catalog.messages[locale] = messages
+		catalog.locales = append(catalog.locales, locale)
+	}
+
+	base, ok := catalog.messages[fallback]
+	if !ok {
+		return nil, fmt.Errorf("%w: нет сообщений для языка по умолчанию %s", ErrInvalidCatalog, fallback)
+	}
+	for locale, messages := range catalog.messages {
+		for id := range base {
+			if _, ok := messages[id]; !ok {
+				return nil, fmt.Errorf("%w: в %s нет сообщения %s", ErrInvalidCatalog, locale, id)
+			}
+		}
+		for id := range messages {
+			if _, ok := base[id]; !ok {
+				return nil, fmt.Errorf(
+					"%w: сообщение %s из %s отсутствует в %s",
+					ErrInvalidCatalog,
+					id,
+					locale,
+					fallback,
+				)
+			}
+		}
+	}
+	slices.Sort(catalog.locales)
+	return catalog, nil
+}
+
+func (c *Catalog) Locales() []string {
+	return slices.Clone(c.locales)
+}
+
+func (c *Catalog) Supports(locale string) bool {
+	_, ok := c.messages[locale]
+	return ok
+}
+
+func (c *Catalog) Message(locale string, id MessageID, args ...any) string {
+	messages, ok := c.messages[locale]
+	if !ok {
+		messages = c.messages[c.fallback]
+	}
+	message, ok := messages[id]
+	if !ok {
+		return string(id)
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Reason returns the message for an error reason code. The "rule" param picks a
+// more specific message when there is one, {name} placeholders take params.
+func (c *Catalog) Reason(locale, code string, params map[string]string) (string, bool) {
+	messages, ok := c.messages[locale]
+	if !ok {
+		messages = c.messages[c.fallback]
+	}
+	message, ok := messages[ReasonID(code, params["rule"])]
+	if !ok {
+		message, ok = messages[ReasonID(code, "")]
+	}
+	if !ok {
+		return "", false
+	}
+	// One pass over the message, so a param value is never substituted again.
+	pairs := make([]string, 0, 2*len(params))
+	for _, key := range slices.Sorted(maps.Keys(params)) {
+		pairs = append(pairs, "{"+key+"}", params[key])
+	}
+	return strings.NewReplacer(pairs...).Replace(message), true
+}
+
+func ReasonID(code, rule string) MessageID {
+	if rule == "" {
+		return MessageID("reason." + code)
+	}
+	return MessageID("reason." + code + "." + rule)
+}
+
+func (c *Catalog) Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		locale  string
+		quality float64
+	}
+	var candidates []candidate
+	for item := range strings.SplitSeq(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if tag == "" || quality <= 0 {
+			continue
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		candidates = append(candidates, candidate{locale: primary, quality: quality})
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		switch {
+		case a.quality > b.quality:
+			return -1
+		case a.quality < b.quality:
+			return 1
+		default:
+			return 0
+		}
+	})
+	for _, candidate := range candidates {
+		if c.Supports(candidate.locale) {
+			return candidate.locale
+		}
+	}
+	return c.fallback
+}
@@ -0,0 +1,217 @@
+package i18n
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadCatalog(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Expected error
+		FS       fstest.MapFS
+	}{
+		{
+			TestName: "test_load_catalog_ok",
+			Expected: nil,
+			FS: fstest.MapFS{
+				"ru.json": {Data: []byte(`{"greeting": "Привет"}`)},
+				"en.json": {Data: []byte(`{"greeting": "Hello"}`)},
+			},
+		},
+		{
+			TestName: "test_load_catalog_without_fallback",
+			Expected: ErrInvalidCatalog,
+			FS:       fstest.MapFS{"en.json": {Data: []byte(`{"greeting": "Hello"}`)}},
+		},
+		{
+			TestName: "test_load_catalog_missing_translation",
+			Expected: ErrInvalidCatalog,
+			FS: fstest.MapFS{
+				"ru.json": {Data: []byte(`{"greeting": "Привет", "bye": "Пока"}`)},
+				"en.json": {Data: []byte(`{"greeting": "Hello"}`)},
+			},
+		},
+		{
+			TestName: "test_load_catalog_extra_translation",
+			Expected: ErrInvalidCatalog,
+			FS: fstest.MapFS{
+				"ru.json": {Data: []byte(`{"greeting": "Привет"}`)},
+				"en.json": {Data: []byte(`{"greeting": "Hello", "bye": "Bye"}`)},
+			},
+		},
+		{
+			TestName: "test_load_catalog_invalid_json",
+			Expected: ErrInvalidCatalog,
+			FS:       fstest.MapFS{"ru.json": {Data: []byte(`{"greeting": `)}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			_, err := LoadCatalog(c.FS, "ru")
+			if c.Expected == nil && err != nil {
+				t.Errorf("expected nil, but got %v", err)
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+}
+
+func TestMustDefaultCatalog(t *testing.T) {
+	catalog := MustDefaultCatalog()
+	if !slices.Equal(catalog.Locales(), []string{"en", "ru"}) {
+		t.Errorf("expected en and ru locales, but got %v", catalog.Locales())
+	}
+	if message := catalog.Message("en", "error.not_found"); message != "object not found" {
+		t.Errorf("expected english message, but got %q", message)
+	}
+	if message := catalog.Message("de", "error.not_found"); message != "объект не найден" {
+		t.Errorf("expected fallback message, but got %q", message)
+	}
+	if message := catalog.Message("en", "unknown"); message != "unknown" {
+		t.Errorf("expected message id for unknown message, but got %q", message)
+	}
+	message := catalog.Message("en", "email.new_email.hint", "new@mail.com")
+	if message != "This code confirms changing your address to new@mail.com." {
+		t.Errorf("expected formatted message, but got %q", message)
+	}
+}
+
+func TestMustDefaultCatalog_Reasons(t *testing.T) {
+	catalog := MustDefaultCatalog()
+	codes := reasonCodes(t, "../domain", "../app")
+	rules := passwordRules(t, "../infrastructure/password/policy.go")
+	if len(codes) == 0 || len(rules) == 0 {
+		t.Fatalf("expected reason codes and password rules, but got %v and %v", codes, rules)
+	}
+	for _, locale := range catalog.Locales() {
+		for _, code := range codes {
+			if _, ok := catalog.Reason(locale, code, nil); !ok {
+				t.Errorf("expected %s message for reason %s", locale, code)
+			}
+		}
+		for _, rule := range rules {
+			if _, ok := catalog.messages[locale][ReasonID("password.too_weak", rule)]; !ok {
+				t.Errorf("expected %s message for password rule %s", locale, rule)
+			}
+		}
+	}
+
+	message, ok := catalog.Reason(
+		"en",
+		"password.too_weak",
+		map[string]string{"rule": "min_length", "min": "8"},
+	)
+	if !ok || message != "password must contain at least 8 characters" {
+		t.Errorf("expected rule message with params, but got %q", message)
+	}
+	message, ok = catalog.Reason(
+		"en",
+		"user.email.taken",
+		map[string]string{"email": "{state}@mail.com", "state": "active"},
+	)
+	if !ok || message != "email {state}@mail.com already exists" {
+		t.Errorf("expected param values substituted once, but got %q", message)
+	}
+	if _, ok = catalog.Reason("en", "unknown.reason", nil); ok {
+		t.Errorf("expected no message for unknown reason")
+	}
+}
+
+func reasonCodes(t *testing.T, dirs ...string) []string {
+	t.Helper()
+	var codes []string
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatalf("glob %s: %v", dir, err)
+		}
+		for _, file := range files {
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			parsed, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+			if err != nil {
+				t.Fatalf("parse %s: %v", file, err)
+			}
+			for _, decl := range parsed.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.CONST {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					value := spec.(*ast.ValueSpec)
+					for i, name := range value.Names {
+						if !strings.HasPrefix(name.Name, "Code") || i >= len(value.Values) {
+							continue
+						}
+						if lit, ok := value.Values[i].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+							code, _ := strconv.Unquote(lit.Value)
+							codes = append(codes, code)
+						}
+					}
+				}
+			}
+		}
+	}
+	return codes
+}
+
+func passwordRules(t *testing.T, file string) []string {
+	t.Helper()
+	parsed, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	if err != nil {
+		t.Fatalf("parse %s: %v", file, err)
+	}
+	var rules []string
+	ast.Inspect(parsed, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		if fun, ok := call.Fun.(*ast.Ident); ok && fun.Name == "invalid" {
+			if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				rule, _ := strconv.Unquote(lit.Value)
+				rules = append(rules, rule)
+			}
+		}
+		return true
+	})
+	return rules
+}
+
+func TestCatalog_Negotiate(t *testing.T) {
+	catalog := MustDefaultCatalog()
+	cases := []struct {
+		TestName string
+		Expected string
+		Header   string
+	}{
+		{TestName: "test_negotiate_empty", Expected: "ru", Header: ""},
+		{TestName: "test_negotiate_exact", Expected: "en", Header: "en"},
+		{TestName: "test_negotiate_region", Expected: "en", Header: "en-GB"},
+		{TestName: "test_negotiate_quality", Expected: "en", Header: "ru;q=0.5, en-US;q=0.9"},
+		{TestName: "test_negotiate_order", Expected: "ru", Header: "RU-ru, en"},
+		{TestName: "test_negotiate_unsupported", Expected: "en", Header: "de-DE, fr;q=0.9, en;q=0.1"},
+		{TestName: "test_negotiate_rejected", Expected: "ru", Header: "en;q=0, de"},
+		{TestName: "test_negotiate_wildcard", Expected: "ru", Header: "*"},
+		{TestName: "test_negotiate_invalid_quality", Expected: "ru", Header: "en;q=high"},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			if locale := catalog.Negotiate(c.Header); locale != c.Expected {
+				t.Errorf("expected %s, but got %s", c.Expected, locale)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type expiredCodeStore interface {
+	PurgeExpired(ctx context.Context) (int, error)
+}
+
+// CodeCleaner removes expired codes on a tick, stores only skip them on read
+// and would otherwise keep codes nobody came back for.
+type CodeCleaner struct {
+	store    expiredCodeStore
+	interval time.Duration
+	logger   *slog.Logger
+	once     sync.Once
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func MustCodeCleaner(
+	store expiredCodeStore,
+	interval time.Duration,
+	logger *slog.Logger,
+) *CodeCleaner {
+	if store == nil {
+		panic("code cleaner did not get code store")
+	}
+	if interval <= 0 {
+		panic("code cleaner did not get interval")
+	}
+	if logger == nil {
+		panic("code cleaner did not get logger")
+	}
+	return &CodeCleaner{
+		store:    store,
+		interval: interval,
+		logger:   logger,
+		done:     make(chan struct{}),
+	}
+}
+
+func (c *CodeCleaner) Start() {
+	c.once.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		c.cancel = cancel
+		go c.run(ctx)
+	})
+}
+
+func (c *CodeCleaner) Shutdown(ctx context.Context) error {
+	c.once.Do(func() { close(c.done) })
+	if c.cancel == nil {
+		return nil
+	}
+	c.cancel()
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("ожидание остановки очистки кодов: %w", ctx.Err())
+	}
+}
+
+func (c *CodeCleaner) run(ctx context.Context) {
+	defer close(c.done)
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		purged, err := c.store.PurgeExpired(ctx)
+		if err != nil {
+			c.logger.Error("purge expired codes", "error", err)
+			continue
+		}
+		if purged > 0 {
+			c.logger.Info("expired codes purged", "count", purged)
+		}
+	}
+}
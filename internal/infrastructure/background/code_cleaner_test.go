@@ -0,0 +1,45 @@
+package background
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type mockExpiredCodeStore struct {
+	Purges atomic.Int32
+}
+
+func (m *mockExpiredCodeStore) PurgeExpired(ctx context.Context) (int, error) {
+	m.Purges.Add(1)
+	return 1, nil
+}
+
+func TestCodeCleaner_PurgesOnTick(t *testing.T) {
+	store := &mockExpiredCodeStore{}
+	cleaner := MustCodeCleaner(
+		store,
+		time.Millisecond,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	cleaner.Start()
+
+	deadline := time.Now().Add(time.Second)
+	for store.Purges.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected expired codes to be purged on every tick")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := cleaner.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	purges := store.Purges.Load()
+	time.Sleep(5 * time.Millisecond)
+	if store.Purges.Load() != purges {
+		t.Errorf("expected no purges after shutdown")
+	}
+}
@@ -0,0 +1,210 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+)
+
+type emailOutbox interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]app.OutboxEmail, error)
+	MarkDelivered(ctx context.Context, id uuid.UUID) error
+	Retry(ctx context.Context, id uuid.UUID, lastError string, delay time.Duration) error
+	Bury(ctx context.Context, id uuid.UUID, lastError string) error
+	PurgeDead(ctx context.Context, retention time.Duration) (int, error)
+}
+
+type emailSender interface {
+	Send(ctx context.Context, kind app.EmailKind, data app.EmailCode) error
+}
+
+type DispatcherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	Lease        time.Duration
+	// DeadRetention bounds how long undelivered emails stay in the outbox,
+	// their codes are already blanked when they are buried.
+	DeadRetention time.Duration
+}
+
+type EmailDispatcher struct {
+	outbox emailOutbox
+	sender emailSender
+	cfg    DispatcherConfig
+	logger *slog.Logger
+	once   sync.Once
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func MustEmailDispatcher(
+	outbox emailOutbox,
+	sender emailSender,
+	cfg DispatcherConfig,
+	logger *slog.Logger,
+) *EmailDispatcher {
+	if outbox == nil {
+		panic("email dispatcher did not get outbox")
+	}
+	if sender == nil {
+		panic("email dispatcher did not get sender")
+	}
+	if cfg.PollInterval <= 0 {
+		panic("email dispatcher did not get poll interval")
+	}
+	if cfg.BatchSize <= 0 {
+		panic("email dispatcher did not get batch size")
+	}
+	if cfg.MaxAttempts <= 0 {
+		panic("email dispatcher did not get max attempts")
+	}
+	if cfg.BaseBackoff <= 0 || cfg.MaxBackoff < cfg.BaseBackoff {
+		panic("email dispatcher got invalid backoff")
+	}
+	if cfg.Lease <= 0 {
+		panic("email dispatcher did not get lease")
+	}
+	if cfg.DeadRetention <= 0 {
+		panic("email dispatcher did not get dead letter retention")
+	}
+	if logger == nil {
+		panic("email dispatcher did not get logger")
+	}
+	return &EmailDispatcher{
+		outbox: outbox,
+		sender: sender,
+		cfg:    cfg,
+		logger: logger,
+		done:   make(chan struct{}),
+	}
+}
+
+func (d *EmailDispatcher) Start() {
+	d.once.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		d.cancel = cancel
+		go d.run(ctx)
+	})
+}
+
+func (d *EmailDispatcher) Shutdown(ctx context.Context) error {
+	d.once.Do(func() { close(d.done) })
+	if d.cancel == nil {
+		return nil
+	}
+	d.cancel()
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("ожидание остановки отправки писем: %w", ctx.Err())
+	}
+}
+
+func (d *EmailDispatcher) run(ctx context.Context) {
+	defer close(d.done)
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := d.dispatch(ctx)
+			if err != nil {
+				d.logger.Error("dispatch emails", "error", err)
+			}
+			if err != nil || n < d.cfg.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+		d.purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *EmailDispatcher) dispatch(ctx context.Context) (int, error) {
+	emails, err := d.outbox.Claim(ctx, d.cfg.BatchSize, d.cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+	for _, email := range emails {
+		if ctx.Err() != nil {
+			break
+		}
+		d.deliver(ctx, email)
+	}
+	return len(emails), nil
+}
+
+func (d *EmailDispatcher) purge(ctx context.Context) {
+	purged, err := d.outbox.PurgeDead(ctx, d.cfg.DeadRetention)
+	if err != nil {
+		d.logger.Error("purge dead emails", "error", err)
+		return
+	}
+	if purged > 0 {
+		d.logger.Info("dead emails purged", "count", purged)
+	}
+}
+
+func (d *EmailDispatcher) deliver(ctx context.Context, email app.OutboxEmail) {
+	sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), d.cfg.Lease)
+	defer cancel()
+	sendErr := d.sender.Send(
+		sendCtx,
+		email.Kind,
+		app.EmailCode{To: email.To, Code: email.Code, Locale: email.Locale},
+	)
+
+	ctx = context.WithoutCancel(ctx)
+	attempts := email.Attempts + 1
+	var err error
+	switch {
+	case sendErr == nil:
+		err = d.outbox.MarkDelivered(ctx, email.ID)
+	case attempts >= d.cfg.MaxAttempts:
+		d.logger.Error(
+			"email moved to dead letter",
+			"id", email.ID,
+			"kind", email.Kind,
+			"attempts", attempts,
+			"error", sendErr,
+		)
+		err = d.outbox.Bury(ctx, email.ID, sendErr.Error())
+	default:
+		delay := d.backoff(attempts)
+		d.logger.Warn(
+			"email delivery failed",
+			"id", email.ID,
+			"kind", email.Kind,
+			"attempts", attempts,
+			"retry_in", delay,
+			"error", sendErr,
+		)
+		err = d.outbox.Retry(ctx, email.ID, sendErr.Error(), delay)
+	}
+	if err != nil {
+		d.logger.Error("update outbox email", "id", email.ID, "error", err)
+	}
+}
+
+func (d *EmailDispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.BaseBackoff
+	for range attempts - 1 {
+		if delay >= d.cfg.MaxBackoff/2 {
+			return d.cfg.MaxBackoff
+		}
+		delay *= 2
+	}
+	return min(delay, d.cfg.MaxBackoff)
+}
@@ -0,0 +1,227 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+)
+
+type mockEmailOutbox struct {
+	mu        sync.Mutex
+	Pending   []app.OutboxEmail
+	Delivered []uuid.UUID
+	Retried   map[uuid.UUID]time.Duration
+	Buried    []uuid.UUID
+	Purged    []time.Duration
+}
+
+func (m *mockEmailOutbox) Claim(
+	ctx context.Context,
+	limit int,
+	lease time.Duration,
+) ([]app.OutboxEmail, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := min(limit, len(m.Pending))
+	claimed := m.Pending[:n]
+	m.Pending = m.Pending[n:]
+	return claimed, nil
+}
+
+func (m *mockEmailOutbox) MarkDelivered(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Delivered = append(m.Delivered, id)
+	return nil
+}
+
+func (m *mockEmailOutbox) Retry(
+	ctx context.Context,
+	id uuid.UUID,
+	lastError string,
+	delay time.Duration,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Retried[id] = delay
+	return nil
+}
+
+func (m *mockEmailOutbox) Bury(ctx context.Context, id uuid.UUID, lastError string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Buried = append(m.Buried, id)
+	return nil
+}
+
+func (m *mockEmailOutbox) PurgeDead(ctx context.Context, retention time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Purged = append(m.Purged, retention)
+	return 0, nil
+}
+
+type mockEmailSender struct {
+	FailTo  string
+	Sending chan struct{}
+	Release chan struct{}
+}
+
+func (m *mockEmailSender) Send(ctx context.Context, kind app.EmailKind, data app.EmailCode) error {
+	if m.Release != nil {
+		m.Sending <- struct{}{}
+		<-m.Release
+	}
+	if data.To == m.FailTo {
+		return errors.New("smtp unavailable")
+	}
+	return nil
+}
+
+func testDispatcherConfig() DispatcherConfig {
+	return DispatcherConfig{
+		PollInterval:  time.Millisecond,
+		BatchSize:     2,
+		MaxAttempts:   3,
+		BaseBackoff:   time.Second,
+		MaxBackoff:    5 * time.Second,
+		Lease:         time.Minute,
+		DeadRetention: time.Hour,
+	}
+}
+
+func testDispatcher(outbox *mockEmailOutbox, sender *mockEmailSender) *EmailDispatcher {
+	return MustEmailDispatcher(
+		outbox,
+		sender,
+		testDispatcherConfig(),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+}
+
+func TestEmailDispatcher_Dispatch(t *testing.T) {
+	delivered := app.OutboxEmail{ID: uuid.New(), To: "ok@mail.com"}
+	retried := app.OutboxEmail{ID: uuid.New(), To: "fail@mail.com", Attempts: 1}
+	buried := app.OutboxEmail{ID: uuid.New(), To: "fail@mail.com", Attempts: 2}
+	outbox := &mockEmailOutbox{
+		Pending: []app.OutboxEmail{delivered, retried, buried},
+		Retried: make(map[uuid.UUID]time.Duration),
+	}
+	dispatcher := testDispatcher(outbox, &mockEmailSender{FailTo: "fail@mail.com"})
+	ctx := context.Background()
+
+	n, err := dispatcher.dispatch(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("expected full batch, but got %d %v", n, err)
+	}
+	if n, err = dispatcher.dispatch(ctx); err != nil || n != 1 {
+		t.Fatalf("expected last email, but got %d %v", n, err)
+	}
+	if len(outbox.Delivered) != 1 || outbox.Delivered[0] != delivered.ID {
+		t.Errorf("expected %s to be delivered, but got %v", delivered.ID, outbox.Delivered)
+	}
+	if delay, ok := outbox.Retried[retried.ID]; !ok || delay != 2*time.Second {
+		t.Errorf("expected %s to be retried in 2s, but got %v", retried.ID, outbox.Retried)
+	}
+	if len(outbox.Buried) != 1 || outbox.Buried[0] != buried.ID {
+		t.Errorf("expected %s to be dead, but got %v", buried.ID, outbox.Buried)
+	}
+}
+
+func TestEmailDispatcher_Backoff(t *testing.T) {
+	dispatcher := testDispatcher(&mockEmailOutbox{}, &mockEmailSender{})
+	cases := []struct {
+		TestName string
+		Expected time.Duration
+		Attempts int
+	}{
+		{TestName: "test_email_dispatcher_backoff_first", Expected: time.Second, Attempts: 1},
+		{TestName: "test_email_dispatcher_backoff_second", Expected: 2 * time.Second, Attempts: 2},
+		{TestName: "test_email_dispatcher_backoff_third", Expected: 4 * time.Second, Attempts: 3},
+		{TestName: "test_email_dispatcher_backoff_capped", Expected: 5 * time.Second, Attempts: 4},
+		{TestName: "test_email_dispatcher_backoff_overflow", Expected: 5 * time.Second, Attempts: 100},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			if delay := dispatcher.backoff(c.Attempts); delay != c.Expected {
+				t.Errorf("expected %v, but got %v", c.Expected, delay)
+			}
+		})
+	}
+}
+
+func TestEmailDispatcher_ShutdownWaitsForSending(t *testing.T) {
+	email := app.OutboxEmail{ID: uuid.New(), To: "ok@mail.com"}
+	outbox := &mockEmailOutbox{Pending: []app.OutboxEmail{email}}
+	sender := &mockEmailSender{Sending: make(chan struct{}), Release: make(chan struct{})}
+	dispatcher := testDispatcher(outbox, sender)
+	dispatcher.Start()
+	<-sender.Sending
+
+	time.AfterFunc(10*time.Millisecond, func() { close(sender.Release) })
+	if err := dispatcher.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+	if len(outbox.Delivered) != 1 {
+		t.Errorf("expected in-flight email to be delivered, but got %v", outbox.Delivered)
+	}
+}
+
+func TestEmailDispatcher_ShutdownTimeout(t *testing.T) {
+	outbox := &mockEmailOutbox{Pending: []app.OutboxEmail{{ID: uuid.New(), To: "ok@mail.com"}}}
+	sender := &mockEmailSender{Sending: make(chan struct{}), Release: make(chan struct{})}
+	defer close(sender.Release)
+	dispatcher := testDispatcher(outbox, sender)
+	dispatcher.Start()
+	<-sender.Sending
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := dispatcher.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, but got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestEmailDispatcher_PurgesDeadEmails(t *testing.T) {
+	outbox := &mockEmailOutbox{}
+	dispatcher := testDispatcher(outbox, &mockEmailSender{})
+	dispatcher.Start()
+	defer dispatcher.Shutdown(context.Background())
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		outbox.mu.Lock()
+		purged := append([]time.Duration(nil), outbox.Purged...)
+		outbox.mu.Unlock()
+		if len(purged) > 0 {
+			if purged[0] != time.Hour {
+				t.Errorf("expected purge with %v retention, but got %v", time.Hour, purged[0])
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected dead emails to be purged")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEmailDispatcher_ShutdownWithoutStart(t *testing.T) {
+	dispatcher := testDispatcher(&mockEmailOutbox{}, &mockEmailSender{})
+	if err := dispatcher.Shutdown(context.Background()); err != nil {
+		t.Errorf("expected nil, but got %v", err)
+	}
+	dispatcher.Start()
+	if err := dispatcher.Shutdown(context.Background()); err != nil {
+		t.Errorf("expected start after shutdown to be ignored, but got %v", err)
+	}
+}
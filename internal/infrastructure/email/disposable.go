@@ -0,0 +1,69 @@
+package email
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+type DomainList struct {
+	domains map[string]struct{}
+}
+
+func MustDomainList(domains ...string) *DomainList {
+	list := &DomainList{domains: make(map[string]struct{}, len(domains))}
+	for _, domain := range domains {
+		if err := list.add(domain); err != nil {
+			panic(err.Error())
+		}
+	}
+	return list
+}
+
+func LoadDomainList(r io.Reader) (*DomainList, error) {
+	list := &DomainList{domains: make(map[string]struct{})}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		domain, _, _ := strings.Cut(scanner.Text(), "#")
+		domain = strings.TrimSpace(domain)
+		if domain == "" {
+			continue
+		}
+		if err := list.add(domain); err != nil {
+			return nil, fmt.Errorf("строка %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("чтение списка доменов: %w", err)
+	}
+	return list, nil
+}
+
+func (l *DomainList) Len() int {
+	return len(l.domains)
+}
+
+func (l *DomainList) Contains(domain string) bool {
+	for {
+		if _, ok := l.domains[domain]; ok {
+			return true
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			return false
+		}
+		domain = parent
+	}
+}
+
+func (l *DomainList) add(domain string) error {
+	ascii, err := idna.Lookup.ToASCII(strings.TrimPrefix(domain, "."))
+	if err != nil {
+		return fmt.Errorf("некорректный домен %s: %w", domain, err)
+	}
+	l.domains[strings.ToLower(ascii)] = struct{}{}
+	return nil
+}
@@ -0,0 +1,44 @@
+package email
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDomainList_Load(t *testing.T) {
+	list, err := LoadDomainList(strings.NewReader(
+		"# disposable domains\nmailinator.com\n  Guerrillamail.com  # comment\n\n.tempmail.org\nпочта.рф\n",
+	))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if list.Len() != 4 {
+		t.Errorf("expected 4 domains, but got %d", list.Len())
+	}
+	cases := []struct {
+		TestName string
+		Expected bool
+		Domain   string
+	}{
+		{TestName: "test_domain_list_contains", Expected: true, Domain: "mailinator.com"},
+		{TestName: "test_domain_list_lowercased", Expected: true, Domain: "guerrillamail.com"},
+		{TestName: "test_domain_list_leading_dot", Expected: true, Domain: "tempmail.org"},
+		{TestName: "test_domain_list_subdomain", Expected: true, Domain: "a.b.mailinator.com"},
+		{TestName: "test_domain_list_punycode", Expected: true, Domain: "xn--80a1acny.xn--p1ai"},
+		{TestName: "test_domain_list_parent_not_listed", Expected: false, Domain: "com"},
+		{TestName: "test_domain_list_suffix_only", Expected: false, Domain: "notmailinator.com"},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			if contains := list.Contains(c.Domain); contains != c.Expected {
+				t.Errorf("expected %v, but got %v", c.Expected, contains)
+			}
+		})
+	}
+}
+
+func TestDomainList_LoadInvalid(t *testing.T) {
+	if _, err := LoadDomainList(strings.NewReader("mail.com\nbad domain.com\n")); err == nil {
+		t.Error("expected error for invalid domain, but got nil")
+	}
+}
@@ -0,0 +1,144 @@
+package email
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"golang.org/x/net/idna"
+)
+
+const (
+	maxAddressLength   = 254
+	maxLocalPartLength = 64
+	maxDomainLength    = 253
+)
+
+type Options struct {
+	LowercaseLocalPart bool
+	StripPlusTag       bool
+}
+
+type disposableChecker interface {
+	Contains(domain string) bool
+}
+
+type Validator struct {
+	options    Options
+	disposable disposableChecker
+}
+
+func MustValidator(options Options, disposable disposableChecker) *Validator {
+	if disposable == nil {
+		panic("email validator did not get disposable domain list")
+	}
+	return &Validator{options: options, disposable: disposable}
+}
+
+func (v *Validator) Validate(email string) (string, error) {
+	invalid := func(reason string) (string, error) {
+		return "", app.NewError(
+			app.ErrInvalidData,
+			app.CodeEmailInvalid,
+			fmt.Sprintf("некорректный email %s: %s", email, reason),
+		).WithField("email")
+	}
+
+	if strings.TrimSpace(email) != email || strings.ContainsAny(email, "<>") {
+		return invalid("ожидается только адрес без имени и пробелов")
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Name != "" {
+		return invalid("адрес не соответствует RFC 5322")
+	}
+
+	at := strings.LastIndexByte(address.Address, '@')
+	local, domain := address.Address[:at], address.Address[at+1:]
+
+	domain, err = canonicalDomain(domain)
+	if err != nil {
+		return invalid(err.Error())
+	}
+	if v.disposable.Contains(domain) {
+		return invalid(fmt.Sprintf("домен %s относится к одноразовой почте", domain))
+	}
+
+	if isDotAtom(local) {
+		if v.options.StripPlusTag {
+			if base, _, found := strings.Cut(local, "+"); found && base != "" {
+				local = base
+			}
+		}
+		if v.options.LowercaseLocalPart {
+			local = strings.ToLower(local)
+		}
+	} else {
+		local = quoteLocalPart(local)
+	}
+	if len(local) > maxLocalPartLength {
+		return invalid(fmt.Sprintf("локальная часть длиннее %d байт", maxLocalPartLength))
+	}
+
+	canonical := local + "@" + domain
+	if len(canonical) > maxAddressLength {
+		return invalid(fmt.Sprintf("адрес длиннее %d байт", maxAddressLength))
+	}
+	return canonical, nil
+}
+
+func canonicalDomain(domain string) (string, error) {
+	if strings.HasPrefix(domain, "[") {
+		return "", fmt.Errorf("IP-адрес вместо домена не поддерживается")
+	}
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return "", fmt.Errorf("некорректный домен %s", domain)
+	}
+	ascii = strings.ToLower(ascii)
+	if len(ascii) > maxDomainLength {
+		return "", fmt.Errorf("домен длиннее %d байт", maxDomainLength)
+	}
+	if !strings.Contains(ascii, ".") {
+		return "", fmt.Errorf("домен %s не содержит зону", ascii)
+	}
+	return ascii, nil
+}
+
+func isDotAtom(local string) bool {
+	if local == "" || strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") ||
+		strings.Contains(local, "..") {
+		return false
+	}
+	for _, r := range local {
+		if r != '.' && !isAtext(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAtext(r rune) bool {
+	switch {
+	case r >= utf8.RuneSelf:
+		return true
+	case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		return true
+	default:
+		return strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", r)
+	}
+}
+
+func quoteLocalPart(local string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range local {
+		if r == '"' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
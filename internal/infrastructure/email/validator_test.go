@@ -0,0 +1,186 @@
+package email
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+func TestValidator_Validate(t *testing.T) {
+	disposable := MustDomainList("mailinator.com", "одноразовая.рф")
+	strict := MustValidator(Options{LowercaseLocalPart: true, StripPlusTag: true}, disposable)
+	preserving := MustValidator(Options{}, disposable)
+	cases := []struct {
+		TestName  string
+		Expected  error
+		Canonical string
+		Validator *Validator
+		Email     string
+	}{
+		{
+			TestName:  "test_email_validator_ok",
+			Expected:  nil,
+			Canonical: "test@mail.com",
+			Validator: strict,
+			Email:     "test@mail.com",
+		},
+		{
+			TestName:  "test_email_validator_lowercase_domain",
+			Expected:  nil,
+			Canonical: "Foo@mail.ru",
+			Validator: preserving,
+			Email:     "Foo@Mail.RU",
+		},
+		{
+			TestName:  "test_email_validator_lowercase_local_part",
+			Expected:  nil,
+			Canonical: "foo@mail.ru",
+			Validator: strict,
+			Email:     "Foo@Mail.ru",
+		},
+		{
+			TestName:  "test_email_validator_strip_plus_tag",
+			Expected:  nil,
+			Canonical: "foo@mail.ru",
+			Validator: strict,
+			Email:     "foo+dnd@mail.ru",
+		},
+		{
+			TestName:  "test_email_validator_keep_plus_tag",
+			Expected:  nil,
+			Canonical: "foo+dnd@mail.ru",
+			Validator: preserving,
+			Email:     "foo+dnd@mail.ru",
+		},
+		{
+			TestName:  "test_email_validator_plus_only_local_part",
+			Expected:  nil,
+			Canonical: "+dnd@mail.ru",
+			Validator: strict,
+			Email:     "+dnd@mail.ru",
+		},
+		{
+			TestName:  "test_email_validator_idn_domain",
+			Expected:  nil,
+			Canonical: "test@xn--80a1acny.xn--p1ai",
+			Validator: strict,
+			Email:     "test@Почта.рф",
+		},
+		{
+			TestName:  "test_email_validator_utf8_local_part",
+			Expected:  nil,
+			Canonical: "тест@xn--80a1acny.xn--p1ai",
+			Validator: strict,
+			Email:     "Тест@почта.рф",
+		},
+		{
+			TestName:  "test_email_validator_quoted_local_part",
+			Expected:  nil,
+			Canonical: `"John Doe"@mail.com`,
+			Validator: strict,
+			Email:     `"John Doe"@mail.com`,
+		},
+		{
+			TestName:  "test_email_validator_without_at",
+			Expected:  app.ErrInvalidData,
+			Validator: strict,
+			Email:     "mail.com",
+		},
+		{
+			TestName:  "test_email_validator_display_name",
+			Expected:  app.ErrInvalidData,
+			Validator: strict,
+			Email:     "Test <test@mail.com>",
+		},
+		{
+			TestName:  "test_email_validator_comment",
+			Expected:  app.ErrInvalidData,
+			Validator: strict,
+			Email:     "test@mail.com (Test)",
+		},
+		{
+			TestName:  "test_email_validator_spaces",
+			Expected:  app.ErrInvalidData,
+			Validator: strict,
+			Email:     " test@mail.com",
+		},
+		{
+			TestName:  "test_email_validator_double_dot",
+			Expected:  app.ErrInvalidData,
+			Validator: strict,
+			Email:     "te..st@mail.com",
+		},
+		{
+			TestName:  "test_email_validator_domain_literal",
+			Expected:  app.ErrInvalidData,
+			Validator: strict,
+			Email:     "test@[127.0.0.1]",
+		},
+		{
+			TestName:  "test_email_validator_dotless_domain",
+			Expected:  app.ErrInvalidData,
+			Validator: strict,
+			Email:     "test@localhost",
+		},
+		{
+			TestName:  "test_email_validator_invalid_domain",
+			Expected:  app.ErrInvalidData,
+			Validator: strict,
+			Email:     "test@-mail.com",
+		},
+		{
+			TestName:  "test_email_validator_long_local_part",
+			Expected:  app.ErrInvalidData,
+			Validator: strict,
+			Email:     strings.Repeat("a", 65) + "@mail.com",
+		},
+		{
+			TestName:  "test_email_validator_long_address",
+			Expected:  app.ErrInvalidData,
+			Validator: strict,
+			Email:     strings.Repeat("a", 64) + "@" + strings.Repeat(strings.Repeat("a", 49)+".", 4) + "com",
+		},
+		{
+			TestName:  "test_email_validator_disposable",
+			Expected:  app.ErrInvalidData,
+			Validator: strict,
+			Email:     "test@Mailinator.com",
+		},
+		{
+			TestName:  "test_email_validator_disposable_subdomain",
+			Expected:  app.ErrInvalidData,
+			Validator: strict,
+			Email:     "test@eu.mailinator.com",
+		},
+		{
+			TestName:  "test_email_validator_disposable_idn",
+			Expected:  app.ErrInvalidData,
+			Validator: strict,
+			Email:     "test@одноразовая.рф",
+		},
+		{
+			TestName:  "test_email_validator_empty",
+			Expected:  app.ErrInvalidData,
+			Validator: strict,
+			Email:     "",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			canonical, err := c.Validator.Validate(c.Email)
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected nil, but got %v", err)
+				}
+				if canonical != c.Canonical {
+					t.Errorf("expected canonical %s, but got %s", c.Canonical, canonical)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"crypto/subtle"
+	"sync"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+const (
+	confirmEmailFlow  = "confirm_email"
+	newEmailFlow      = "new_email"
+	resetPasswordFlow = "reset_password"
+	newPasswordFlow   = "new_password"
+)
+
+type CodeTTL struct {
+	ConfirmEmail  time.Duration
+	NewEmail      time.Duration
+	ResetPassword time.Duration
+	NewPassword   time.Duration
+}
+
+type codeEntry struct {
+	code      string
+	attempts  int
+	expiresAt time.Time
+}
+
+type CodeStore struct {
+	mu          sync.Mutex
+	ttl         map[string]time.Duration
+	maxAttempts int
+	codes       map[string]codeEntry
+	now         func() time.Time
+}
+
+func MustCodeStore(ttl CodeTTL, maxAttempts int) *CodeStore {
+	if ttl.ConfirmEmail <= 0 {
+		panic("code store did not get confirm email ttl")
+	}
+	if ttl.NewEmail <= 0 {
+		panic("code store did not get new email ttl")
+	}
+	if ttl.ResetPassword <= 0 {
+		panic("code store did not get reset password ttl")
+	}
+	if ttl.NewPassword <= 0 {
+		panic("code store did not get new password ttl")
+	}
+	if maxAttempts <= 0 {
+		panic("code store did not get max attempts")
+	}
+	return &CodeStore{
+		ttl: map[string]time.Duration{
+			confirmEmailFlow:  ttl.ConfirmEmail,
+			newEmailFlow:      ttl.NewEmail,
+			resetPasswordFlow: ttl.ResetPassword,
+			newPasswordFlow:   ttl.NewPassword,
+		},
+		maxAttempts: maxAttempts,
+		codes:       make(map[string]codeEntry),
+		now:         time.Now,
+	}
+}
+
+func (s *CodeStore) SetConfirmEmail(ctx context.Context, key, code string) error {
+	return s.set(confirmEmailFlow, key, code)
+}
+
+func (s *CodeStore) ConsumeConfirmEmail(ctx context.Context, key, code string) error {
+	return s.consume(confirmEmailFlow, key, code)
+}
+
+func (s *CodeStore) SetNewEmail(ctx context.Context, key, code string) error {
+	return s.set(newEmailFlow, key, code)
+}
+
+func (s *CodeStore) ConsumeNewEmail(ctx context.Context, key, code string) error {
+	return s.consume(newEmailFlow, key, code)
+}
+
+func (s *CodeStore) SetResetPassword(ctx context.Context, key, code string) error {
+	return s.set(resetPasswordFlow, key, code)
+}
+
+func (s *CodeStore) ConsumeResetPassword(ctx context.Context, key, code string) error {
+	return s.consume(resetPasswordFlow, key, code)
+}
+
+func (s *CodeStore) SetNewPassword(ctx context.Context, key, code string) error {
+	return s.set(newPasswordFlow, key, code)
+}
+
+func (s *CodeStore) ConsumeNewPassword(ctx context.Context, key, code string) error {
+	return s.consume(newPasswordFlow, key, code)
+}
+
+func (s *CodeStore) set(flow, key, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes[flow+":"+key] = codeEntry{code: code, expiresAt: s.now().Add(s.ttl[flow])}
+	return nil
+}
+
+func (s *CodeStore) PurgeExpired(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	purged := 0
+	for key, entry := range s.codes {
+		if !now.Before(entry.expiresAt) {
+			delete(s.codes, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (s *CodeStore) consume(flow, key, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.codes[flow+":"+key]
+	if ok && !s.now().Before(entry.expiresAt) {
+		delete(s.codes, flow+":"+key)
+		ok = false
+	}
+	if !ok {
+		return app.CodeExpiredError()
+	}
+	if subtle.ConstantTimeCompare([]byte(entry.code), []byte(code)) == 1 {
+		delete(s.codes, flow+":"+key)
+		return nil
+	}
+	entry.attempts++
+	if entry.attempts >= s.maxAttempts {
+		delete(s.codes, flow+":"+key)
+		return app.CodeExhaustedError()
+	}
+	s.codes[flow+":"+key] = entry
+	return app.CodeInvalidError()
+}
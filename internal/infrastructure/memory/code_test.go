@@ -0,0 +1,236 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+func testCodeStore(now *time.Time) *CodeStore {
+	store := MustCodeStore(CodeTTL{
+		ConfirmEmail:  time.Minute,
+		NewEmail:      2 * time.Minute,
+		ResetPassword: 3 * time.Minute,
+		NewPassword:   4 * time.Minute,
+	}, 3)
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func TestCodeStore_Consume(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := testCodeStore(&now)
+	key := "test@mail.com"
+	flows := []struct {
+		Name    string
+		TTL     time.Duration
+		Set     func(key, code string) error
+		Consume func(key, code string) error
+	}{
+		{
+			Name:    "confirm_email",
+			TTL:     time.Minute,
+			Set:     func(k, c string) error { return store.SetConfirmEmail(ctx, k, c) },
+			Consume: func(k, c string) error { return store.ConsumeConfirmEmail(ctx, k, c) },
+		},
+		{
+			Name:    "new_email",
+			TTL:     2 * time.Minute,
+			Set:     func(k, c string) error { return store.SetNewEmail(ctx, k, c) },
+			Consume: func(k, c string) error { return store.ConsumeNewEmail(ctx, k, c) },
+		},
+		{
+			Name:    "reset_password",
+			TTL:     3 * time.Minute,
+			Set:     func(k, c string) error { return store.SetResetPassword(ctx, k, c) },
+			Consume: func(k, c string) error { return store.ConsumeResetPassword(ctx, k, c) },
+		},
+		{
+			Name:    "new_password",
+			TTL:     4 * time.Minute,
+			Set:     func(k, c string) error { return store.SetNewPassword(ctx, k, c) },
+			Consume: func(k, c string) error { return store.ConsumeNewPassword(ctx, k, c) },
+		},
+	}
+	for i, flow := range flows {
+		t.Run("test_code_store_"+flow.Name, func(t *testing.T) {
+			now = time.Now()
+			if err := flow.Set(key, flow.Name); err != nil {
+				t.Fatalf("set: %v", err)
+			}
+			next := flows[(i+1)%len(flows)]
+			if err := next.Consume(key, flow.Name); !errors.Is(err, app.ErrNotFound) {
+				t.Errorf("expected %s flow not to see %s code, but got %v",
+					next.Name, flow.Name, err)
+			}
+			if err := flow.Consume(key, flow.Name); err != nil {
+				t.Errorf("expected nil, but got %v", err)
+			}
+			if err := flow.Consume(key, flow.Name); !errors.Is(err, app.ErrNotFound) {
+				t.Errorf("expected %T after consume, but got %v", app.ErrNotFound, err)
+			}
+
+			if err := flow.Set(key, flow.Name); err != nil {
+				t.Fatalf("set: %v", err)
+			}
+			now = now.Add(flow.TTL - time.Second)
+			if err := flow.Consume(key, "wrong"); !errors.Is(err, app.ErrInvalidData) {
+				t.Errorf("expected code to live until ttl, but got %v", err)
+			}
+			now = now.Add(time.Second)
+			if err := flow.Consume(key, flow.Name); !errors.Is(err, app.ErrNotFound) {
+				t.Errorf("expected %T after ttl, but got %v", app.ErrNotFound, err)
+			}
+		})
+	}
+}
+
+func TestCodeStore_Attempts(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := testCodeStore(&now)
+	key := "test@mail.com"
+	cases := []struct {
+		TestName string
+		Expected error
+		Code     string
+	}{
+		{TestName: "test_code_store_first_wrong_attempt", Expected: app.ErrInvalidData, Code: "1"},
+		{TestName: "test_code_store_second_wrong_attempt", Expected: app.ErrInvalidData, Code: "2"},
+		{TestName: "test_code_store_last_wrong_attempt", Expected: app.ErrInvalidData, Code: "3"},
+		{TestName: "test_code_store_code_invalidated", Expected: app.ErrNotFound, Code: "123456"},
+	}
+	if err := store.SetResetPassword(ctx, key, "123456"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := store.ConsumeResetPassword(ctx, key, c.Code)
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+
+	if err := store.SetResetPassword(ctx, key, "123456"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	for range 2 {
+		err := store.ConsumeResetPassword(ctx, key, "wrong")
+		if !errors.Is(err, app.ErrInvalidData) {
+			t.Fatalf("expected %T, but got %v", app.ErrInvalidData, err)
+		}
+	}
+	if err := store.SetResetPassword(ctx, key, "654321"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := store.ConsumeResetPassword(ctx, key, "wrong"); !errors.Is(err, app.ErrInvalidData) {
+		t.Fatalf("expected %T, but got %v", app.ErrInvalidData, err)
+	}
+	if err := store.ConsumeResetPassword(ctx, key, "654321"); err != nil {
+		t.Errorf("expected new code to reset attempts, but got %v", err)
+	}
+}
+
+func TestCodeStore_ConcurrentConsume(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := testCodeStore(&now)
+	key := "test@mail.com"
+	if err := store.SetResetPassword(ctx, key, "123456"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	for range 16 {
+		wg.Go(func() {
+			if err := store.ConsumeResetPassword(ctx, key, "123456"); err == nil {
+				succeeded.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+	if succeeded.Load() != 1 {
+		t.Errorf("expected exactly one successful consume, but got %d", succeeded.Load())
+	}
+}
+
+func TestCodeStore_Set(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := testCodeStore(&now)
+	if err := store.SetConfirmEmail(ctx, "old@mail.com", "111111"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := store.SetConfirmEmail(ctx, "key@mail.com", "111111"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := store.SetConfirmEmail(ctx, "key@mail.com", "222222"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := store.ConsumeConfirmEmail(ctx, "key@mail.com", "111111"); err == nil {
+		t.Errorf("expected overwritten code to be rejected")
+	}
+	if err := store.ConsumeConfirmEmail(ctx, "key@mail.com", "222222"); err != nil {
+		t.Errorf("expected overwritten code 222222 to be accepted, but got %v", err)
+	}
+
+}
+
+func TestCodeStore_PurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := testCodeStore(&now)
+	if err := store.SetConfirmEmail(ctx, "old@mail.com", "111111"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	now = now.Add(time.Hour)
+	if err := store.SetConfirmEmail(ctx, "key@mail.com", "222222"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if len(store.codes) != 2 {
+		t.Errorf("expected set not to evict other codes, but store has %d codes", len(store.codes))
+	}
+
+	purged, err := store.PurgeExpired(ctx)
+	if err != nil || purged != 1 {
+		t.Fatalf("expected one purged code, but got %d, %v", purged, err)
+	}
+	if err = store.ConsumeConfirmEmail(ctx, "key@mail.com", "222222"); err != nil {
+		t.Errorf("expected live code to survive purge, but got %v", err)
+	}
+}
+
+func TestCodeStore_ConfirmEmailBoundToEmail(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := testCodeStore(&now)
+	if err := store.SetConfirmEmail(ctx, "first@mail.com", "111111"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := store.SetConfirmEmail(ctx, "second@mail.com", "222222"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	err := store.ConsumeConfirmEmail(ctx, "other@mail.com", "111111")
+	if !errors.Is(err, app.ErrNotFound) {
+		t.Errorf("expected code not to be found for other email, but got %v", err)
+	}
+	for range 2 {
+		err := store.ConsumeConfirmEmail(ctx, "second@mail.com", "111111")
+		if !errors.Is(err, app.ErrInvalidData) {
+			t.Fatalf("expected code of first email to be rejected, but got %v", err)
+		}
+	}
+	if err := store.ConsumeConfirmEmail(ctx, "first@mail.com", "111111"); err != nil {
+		t.Errorf("expected attempts of second email not to affect first, but got %v", err)
+	}
+	if err := store.ConsumeConfirmEmail(ctx, "second@mail.com", "222222"); err != nil {
+		t.Errorf("expected nil, but got %v", err)
+	}
+}
@@ -0,0 +1,532 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/random"
+	"github.com/google/uuid"
+)
+
+type flowEmailValidator struct{}
+
+func (flowEmailValidator) Validate(email string) (string, error) {
+	return strings.ToLower(strings.TrimSpace(email)), nil
+}
+
+type flowPasswords struct{}
+
+func (flowPasswords) Validate(password, email string) error {
+	return nil
+}
+
+func (flowPasswords) Hash(password string) (string, error) {
+	return "hash:" + password, nil
+}
+
+func (flowPasswords) Compare(password, hash string) (bool, error) {
+	return hash == "hash:"+password, nil
+}
+
+func (flowPasswords) NeedsRehash(hash string) bool {
+	return false
+}
+
+type flowTOTP struct{}
+
+func (flowTOTP) Generate(account string) (*app.TwoFactorEnrollment, error) {
+	return &app.TwoFactorEnrollment{Secret: "SECRET", URI: "otpauth://totp/" + account}, nil
+}
+
+func (flowTOTP) Verify(secret, code string) (int64, bool, error) {
+	step, err := strconv.ParseInt(code, 10, 64)
+	return step, secret == "SECRET" && err == nil, nil
+}
+
+type flowTokens struct{}
+
+func (flowTokens) Issue(user *app.User) (string, error) {
+	return "access:" + user.ID.String(), nil
+}
+
+type flowCodeGenerator struct {
+	n int
+}
+
+func (g *flowCodeGenerator) Generate() string {
+	g.n++
+	return strconv.Itoa(100000 + g.n)
+}
+
+func (g *flowCodeGenerator) Normalize(code string) string {
+	return strings.TrimSpace(code)
+}
+
+type flowEnv struct {
+	users    *UserRepository
+	codes    *CodeStore
+	sessions *SessionRepository
+	outbox   *EmailOutbox
+	limiter  *RateLimiter
+	gen      *flowCodeGenerator
+}
+
+func newFlowEnv() *flowEnv {
+	now := time.Now()
+	return &flowEnv{
+		users:    MustUserRepository(),
+		codes:    testCodeStore(&now),
+		sessions: MustSessionRepository(),
+		outbox:   MustEmailOutbox(),
+		limiter:  testRateLimiter(&now),
+		gen:      &flowCodeGenerator{},
+	}
+}
+
+func (e *flowEnv) lastCode(t *testing.T, kind app.EmailKind, to string) string {
+	t.Helper()
+	emails, err := e.outbox.List(context.Background(), app.OutboxPending, 100)
+	if err != nil {
+		t.Fatalf("list outbox: %v", err)
+	}
+	for i := len(emails) - 1; i >= 0; i-- {
+		if emails[i].Kind == kind && emails[i].To == to {
+			return emails[i].Code
+		}
+	}
+	t.Fatalf("no %s email to %s in outbox", kind, to)
+	return ""
+}
+
+func (e *flowEnv) register(t *testing.T, email, password string) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	confirm := app.MustConfirmEmailUseCase(
+		e.users,
+		e.codes,
+		flowEmailValidator{},
+		e.outbox,
+		e.gen,
+		app.EnumerationProtection{},
+	)
+	registration := app.MustRegistrationUseCase(
+		e.users,
+		e.codes,
+		e.gen,
+		e.limiter,
+		flowEmailValidator{},
+		flowPasswords{},
+		flowPasswords{},
+	)
+	if err := confirm.Execute(ctx, &app.ConfirmEmailCommand{Email: email}); err != nil {
+		t.Fatalf("confirm email: %v", err)
+	}
+	id, err := registration.Execute(ctx, &app.RegistrationCommand{
+		Email:    email,
+		Password: password,
+		Code:     e.lastCode(t, app.ConfirmationEmail, email),
+	})
+	if err != nil {
+		t.Fatalf("registration: %v", err)
+	}
+	return id
+}
+
+func TestCodeFlows_Registration(t *testing.T) {
+	ctx := context.Background()
+	env := newFlowEnv()
+	confirm := app.MustConfirmEmailUseCase(
+		env.users,
+		env.codes,
+		flowEmailValidator{},
+		env.outbox,
+		env.gen,
+		app.EnumerationProtection{},
+	)
+	registration := app.MustRegistrationUseCase(
+		env.users,
+		env.codes,
+		env.gen,
+		env.limiter,
+		flowEmailValidator{},
+		flowPasswords{},
+		flowPasswords{},
+	)
+	for _, email := range []string{"first@mail.com", "second@mail.com"} {
+		if err := confirm.Execute(ctx, &app.ConfirmEmailCommand{Email: email}); err != nil {
+			t.Fatalf("confirm email: %v", err)
+		}
+	}
+	firstCode := env.lastCode(t, app.ConfirmationEmail, "first@mail.com")
+
+	_, err := registration.Execute(ctx, &app.RegistrationCommand{
+		Email:    "second@mail.com",
+		Password: "password",
+		Code:     firstCode,
+	})
+	if !errors.Is(err, app.ErrInvalidData) {
+		t.Errorf("expected code of other email to be rejected, but got %v", err)
+	}
+	_, err = registration.Execute(ctx, &app.RegistrationCommand{
+		Email:    " First@Mail.com ",
+		Password: "password",
+		Code:     firstCode,
+	})
+	if err != nil {
+		t.Fatalf("expected registration, but got %v", err)
+	}
+	_, err = registration.Execute(ctx, &app.RegistrationCommand{
+		Email:    "first@mail.com",
+		Password: "password",
+		Code:     firstCode,
+	})
+	if !errors.Is(err, app.ErrNotFound) {
+		t.Errorf("expected consumed code to be rejected, but got %v", err)
+	}
+}
+
+func TestCodeFlows_ResetPassword(t *testing.T) {
+	ctx := context.Background()
+	env := newFlowEnv()
+	id := env.register(t, "test@mail.com", "password")
+	confirm := app.MustConfirmResetPasswordUseCase(
+		env.users,
+		env.codes,
+		flowEmailValidator{},
+		env.outbox,
+		env.gen,
+		app.EnumerationProtection{},
+	)
+	reset := app.MustResetPasswordUseCase(
+		env.users,
+		env.codes,
+		env.gen,
+		env.limiter,
+		env.sessions,
+		flowEmailValidator{},
+		flowPasswords{},
+		flowPasswords{},
+		app.EnumerationProtection{},
+	)
+	command := &app.ConfirmResetPasswordCommand{Email: "Test@Mail.com"}
+	if err := confirm.Execute(ctx, command); err != nil {
+		t.Fatalf("confirm reset password: %v", err)
+	}
+	code := env.lastCode(t, app.ResetPasswordEmail, "test@mail.com")
+
+	err := reset.Execute(ctx, &app.ResetPasswordCommand{
+		Email:       "test@mail.com",
+		Code:        code,
+		NewPassword: "new-password",
+	})
+	if err != nil {
+		t.Fatalf("expected reset password, but got %v", err)
+	}
+	user, err := env.users.ByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if user.PasswordHash != "hash:new-password" {
+		t.Errorf("expected password to change, but got %s", user.PasswordHash)
+	}
+	err = reset.Execute(ctx, &app.ResetPasswordCommand{
+		Email:       "test@mail.com",
+		Code:        code,
+		NewPassword: "other-password",
+	})
+	if !errors.Is(err, app.ErrNotFound) {
+		t.Errorf("expected consumed code to be rejected, but got %v", err)
+	}
+}
+
+func TestCodeFlows_NewEmail(t *testing.T) {
+	ctx := context.Background()
+	env := newFlowEnv()
+	id := env.register(t, "old@mail.com", "password")
+	confirm := app.MustConfirmNewEmailUseCase(
+		env.users,
+		env.codes,
+		flowEmailValidator{},
+		env.outbox,
+		env.gen,
+	)
+	change := app.MustNewEmailUseCase(
+		env.users,
+		env.codes,
+		env.gen,
+		env.limiter,
+		flowEmailValidator{},
+		flowPasswords{},
+	)
+	for _, email := range []string{"new@mail.com", "other@mail.com"} {
+		err := confirm.Execute(ctx, &app.ConfirmNewEmailCommand{
+			InitiatorID: id,
+			UserID:      id,
+			NewEmail:    email,
+		})
+		if err != nil {
+			t.Fatalf("confirm new email: %v", err)
+		}
+	}
+	oldCode := env.lastCode(t, app.NewEmailConfirmation, "old@mail.com")
+	otherCode := env.lastCode(t, app.NewEmailConfirmation, "other@mail.com")
+	newCode := env.lastCode(t, app.NewEmailConfirmation, "new@mail.com")
+
+	err := change.Execute(ctx, &app.NewEmailCommand{
+		InitiatorID:  id,
+		UserID:       id,
+		NewEmail:     "other@mail.com",
+		NewEmailCode: newCode,
+		OldEmailCode: oldCode,
+		Password:     "password",
+	})
+	if !errors.Is(err, app.ErrInvalidData) {
+		t.Errorf("expected code of other new email to be rejected, but got %v", err)
+	}
+	err = change.Execute(ctx, &app.NewEmailCommand{
+		InitiatorID:  id,
+		UserID:       id,
+		NewEmail:     "other@mail.com",
+		NewEmailCode: otherCode,
+		OldEmailCode: oldCode,
+		Password:     "password",
+	})
+	if err != nil {
+		t.Fatalf("expected new email, but got %v", err)
+	}
+	user, err := env.users.ByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if user.Email != "other@mail.com" {
+		t.Errorf("expected email to change, but got %s", user.Email)
+	}
+}
+
+func TestCodeFlows_NewPassword(t *testing.T) {
+	ctx := context.Background()
+	env := newFlowEnv()
+	id := env.register(t, "test@mail.com", "password")
+	confirm := app.MustConfirmNewPasswordUseCase(env.users, env.codes, env.outbox, env.gen)
+	change := app.MustNewPasswordUseCase(
+		env.users,
+		env.codes,
+		env.gen,
+		env.limiter,
+		env.sessions,
+		flowPasswords{},
+		flowPasswords{},
+		flowPasswords{},
+	)
+	command := &app.ConfirmNewPasswordCommand{InitiatorID: id, UserID: id}
+	if err := confirm.Execute(ctx, command); err != nil {
+		t.Fatalf("confirm new password: %v", err)
+	}
+
+	err := change.Execute(ctx, &app.NewPasswordCommand{
+		InitiatorID: id,
+		UserID:      id,
+		OldPassword: "password",
+		NewPassword: "new-password",
+		Code:        env.lastCode(t, app.NewPasswordConfirmation, "test@mail.com"),
+	})
+	if err != nil {
+		t.Fatalf("expected new password, but got %v", err)
+	}
+	user, err := env.users.ByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if user.PasswordHash != "hash:new-password" {
+		t.Errorf("expected password to change, but got %s", user.PasswordHash)
+	}
+}
+
+func TestCodeFlows_NormalizedCode(t *testing.T) {
+	ctx := context.Background()
+	env := newFlowEnv()
+	id := env.register(t, "test@mail.com", "password")
+	codes := random.MustCodeGenerator(random.Profile{Kind: random.Alphanumeric, Length: 8})
+	confirm := app.MustConfirmNewPasswordUseCase(env.users, env.codes, env.outbox, codes)
+	change := app.MustNewPasswordUseCase(
+		env.users,
+		env.codes,
+		codes,
+		env.limiter,
+		env.sessions,
+		flowPasswords{},
+		flowPasswords{},
+		flowPasswords{},
+	)
+	command := &app.ConfirmNewPasswordCommand{InitiatorID: id, UserID: id}
+	if err := confirm.Execute(ctx, command); err != nil {
+		t.Fatalf("confirm new password: %v", err)
+	}
+
+	code := env.lastCode(t, app.NewPasswordConfirmation, "test@mail.com")
+	err := change.Execute(ctx, &app.NewPasswordCommand{
+		InitiatorID: id,
+		UserID:      id,
+		OldPassword: "password",
+		NewPassword: "new-password",
+		Code:        " " + strings.ToLower(code) + "\n",
+	})
+	if err != nil {
+		t.Errorf("expected typed code to match %s, but got %v", code, err)
+	}
+}
+
+func TestCodeFlows_EnumerationProtection(t *testing.T) {
+	ctx := context.Background()
+	env := newFlowEnv()
+	env.register(t, "taken@mail.com", "password")
+	protection := app.EnumerationProtection{Enabled: true}
+	confirmEmail := app.MustConfirmEmailUseCase(
+		env.users,
+		env.codes,
+		flowEmailValidator{},
+		env.outbox,
+		env.gen,
+		protection,
+	)
+	confirmReset := app.MustConfirmResetPasswordUseCase(
+		env.users,
+		env.codes,
+		flowEmailValidator{},
+		env.outbox,
+		env.gen,
+		protection,
+	)
+
+	err := confirmEmail.Execute(ctx, &app.ConfirmEmailCommand{Email: "taken@mail.com"})
+	if err != nil {
+		t.Errorf("expected taken email to be hidden, but got %v", err)
+	}
+	if code := env.lastCode(t, app.RegistrationAttempt, "taken@mail.com"); code != "" {
+		t.Errorf("expected registration attempt notice without code, but got %s", code)
+	}
+	err = confirmReset.Execute(ctx, &app.ConfirmResetPasswordCommand{Email: "unknown@mail.com"})
+	if err != nil {
+		t.Errorf("expected unknown email to be hidden, but got %v", err)
+	}
+	if code := env.lastCode(t, app.UnknownAccountReset, "unknown@mail.com"); code != "" {
+		t.Errorf("expected unknown account notice without code, but got %s", code)
+	}
+	reset := app.MustResetPasswordUseCase(
+		env.users,
+		env.codes,
+		env.gen,
+		env.limiter,
+		env.sessions,
+		flowEmailValidator{},
+		flowPasswords{},
+		flowPasswords{},
+		protection,
+	)
+	known := reset.Execute(ctx, &app.ResetPasswordCommand{
+		Email:       "taken@mail.com",
+		Code:        "000000",
+		NewPassword: "new-password",
+	})
+	unknown := reset.Execute(ctx, &app.ResetPasswordCommand{
+		Email:       "nobody@mail.com",
+		Code:        "000000",
+		NewPassword: "new-password",
+	})
+	var knownErr, unknownErr *app.Error
+	if !errors.As(known, &knownErr) || !errors.As(unknown, &unknownErr) {
+		t.Fatalf("expected app errors, but got %v and %v", known, unknown)
+	}
+	if knownErr.Code != unknownErr.Code || known.Error() != unknown.Error() {
+		t.Errorf("expected same reset response, but got %v and %v", known, unknown)
+	}
+}
+
+func TestCodeFlows_TwoFactor(t *testing.T) {
+	ctx := context.Background()
+	env := newFlowEnv()
+	id := env.register(t, "test@mail.com", "password")
+	enroll := app.MustEnrollTwoFactorUseCase(env.users, env.limiter, flowPasswords{}, flowTOTP{})
+	enable := app.MustEnableTwoFactorUseCase(env.users, env.limiter, flowTOTP{})
+	disable := app.MustDisableTwoFactorUseCase(env.users, env.limiter, flowPasswords{}, flowTOTP{})
+	login := app.MustLoginUseCase(
+		env.users,
+		env.sessions,
+		env.limiter,
+		flowEmailValidator{},
+		flowPasswords{},
+		flowPasswords{},
+		flowPasswords{},
+		flowTOTP{},
+		flowTokens{},
+		env.gen,
+		time.Hour,
+	)
+
+	enrollment, err := enroll.Execute(ctx, &app.EnrollTwoFactorCommand{
+		InitiatorID: id,
+		UserID:      id,
+		Password:    "password",
+	})
+	if err != nil {
+		t.Fatalf("enroll two factor: %v", err)
+	}
+	if enrollment.Secret != "SECRET" {
+		t.Errorf("expected generated secret, but got %s", enrollment.Secret)
+	}
+	err = enable.Execute(ctx, &app.EnableTwoFactorCommand{InitiatorID: id, UserID: id, Code: "10"})
+	if err != nil {
+		t.Fatalf("enable two factor: %v", err)
+	}
+
+	_, err = login.Execute(ctx, &app.LoginCommand{Email: "test@mail.com", Password: "password"})
+	if !errors.Is(err, app.ErrUnauthenticated) {
+		t.Errorf("expected %v without code, but got %v", app.ErrUnauthenticated, err)
+	}
+	_, err = login.Execute(ctx, &app.LoginCommand{
+		Email:    "test@mail.com",
+		Password: "password",
+		TOTPCode: "10",
+	})
+	if !errors.Is(err, app.ErrInvalidData) {
+		t.Errorf("expected enabling code to be spent, but got %v", err)
+	}
+	_, err = login.Execute(ctx, &app.LoginCommand{
+		Email:    "test@mail.com",
+		Password: "password",
+		TOTPCode: "11",
+	})
+	if err != nil {
+		t.Fatalf("expected login with code, but got %v", err)
+	}
+
+	err = disable.Execute(ctx, &app.DisableTwoFactorCommand{
+		InitiatorID: id,
+		UserID:      id,
+		Password:    "password",
+		Code:        "11",
+	})
+	if !errors.Is(err, app.ErrInvalidData) {
+		t.Errorf("expected replayed code to be rejected, but got %v", err)
+	}
+	err = disable.Execute(ctx, &app.DisableTwoFactorCommand{
+		InitiatorID: id,
+		UserID:      id,
+		Password:    "password",
+		Code:        "12",
+	})
+	if err != nil {
+		t.Fatalf("disable two factor: %v", err)
+	}
+	if _, err = login.Execute(ctx, &app.LoginCommand{
+		Email:    "test@mail.com",
+		Password: "password",
+	}); err != nil {
+		t.Errorf("expected login without code after disable, but got %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+)
+
+type EmailOutbox struct {
+	mu     sync.Mutex
+	emails map[uuid.UUID]app.OutboxEmail
+	now    func() time.Time
+}
+
+func MustEmailOutbox() *EmailOutbox {
+	return &EmailOutbox{
+		emails: make(map[uuid.UUID]app.OutboxEmail),
+		now:    time.Now,
+	}
+}
+
+func (o *EmailOutbox) SendConfirmationEmail(ctx context.Context, data app.EmailCode) error {
+	return o.enqueue(app.ConfirmationEmail, data)
+}
+
+func (o *EmailOutbox) SendConfirmationNewEmail(ctx context.Context, data []app.EmailCode) error {
+	return o.enqueue(app.NewEmailConfirmation, data...)
+}
+
+func (o *EmailOutbox) SendResetPasswordEmail(ctx context.Context, data app.EmailCode) error {
+	return o.enqueue(app.ResetPasswordEmail, data)
+}
+
+func (o *EmailOutbox) SendConfirmationNewPassword(ctx context.Context, data app.EmailCode) error {
+	return o.enqueue(app.NewPasswordConfirmation, data)
+}
+
+func (o *EmailOutbox) SendRegistrationAttemptEmail(ctx context.Context, data app.EmailCode) error {
+	return o.enqueue(app.RegistrationAttempt, data)
+}
+
+func (o *EmailOutbox) SendUnknownAccountResetEmail(ctx context.Context, data app.EmailCode) error {
+	return o.enqueue(app.UnknownAccountReset, data)
+}
+
+func (o *EmailOutbox) enqueue(kind app.EmailKind, data ...app.EmailCode) error {
+	emails := make([]app.OutboxEmail, 0, len(data))
+	now := o.now()
+	for _, d := range data {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return fmt.Errorf("%w: не удалось сгенерировать id письма: %s", app.ErrInternal, err)
+		}
+		emails = append(emails, app.OutboxEmail{
+			ID:            id,
+			Kind:          kind,
+			To:            d.To,
+			Code:          d.Code,
+			Locale:        d.Locale,
+			Status:        app.OutboxPending,
+			CreatedAt:     now,
+			NextAttemptAt: now,
+		})
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, email := range emails {
+		o.emails[email.ID] = email
+	}
+	return nil
+}
+
+func (o *EmailOutbox) Claim(
+	ctx context.Context,
+	limit int,
+	lease time.Duration,
+) ([]app.OutboxEmail, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	now := o.now()
+	claimed := make([]app.OutboxEmail, 0, limit)
+	for _, email := range o.sorted() {
+		if len(claimed) == limit {
+			break
+		}
+		if email.Status != app.OutboxPending || email.NextAttemptAt.After(now) {
+			continue
+		}
+		email.NextAttemptAt = now.Add(lease)
+		o.emails[email.ID] = email
+		claimed = append(claimed, email)
+	}
+	return claimed, nil
+}
+
+func (o *EmailOutbox) MarkDelivered(ctx context.Context, id uuid.UUID) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.emails, id)
+	return nil
+}
+
+func (o *EmailOutbox) Retry(
+	ctx context.Context,
+	id uuid.UUID,
+	lastError string,
+	delay time.Duration,
+) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	email, ok := o.emails[id]
+	if !ok {
+		return fmt.Errorf("%w: письмо с id %s не найдено", app.ErrNotFound, id)
+	}
+	email.Attempts++
+	email.LastError = lastError
+	email.NextAttemptAt = o.now().Add(delay)
+	o.emails[id] = email
+	return nil
+}
+
+func (o *EmailOutbox) Bury(ctx context.Context, id uuid.UUID, lastError string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	email, ok := o.emails[id]
+	if !ok {
+		return fmt.Errorf("%w: письмо с id %s не найдено", app.ErrNotFound, id)
+	}
+	email.Attempts++
+	email.LastError = lastError
+	email.Status = app.OutboxDead
+	email.Code = ""
+	email.DeadAt = o.now()
+	o.emails[id] = email
+	return nil
+}
+
+func (o *EmailOutbox) List(
+	ctx context.Context,
+	status app.OutboxStatus,
+	limit int,
+) ([]app.OutboxEmail, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	listed := make([]app.OutboxEmail, 0, limit)
+	for _, email := range o.sorted() {
+		if len(listed) == limit {
+			break
+		}
+		if email.Status == status {
+			listed = append(listed, email)
+		}
+	}
+	return listed, nil
+}
+
+func (o *EmailOutbox) Replay(ctx context.Context, id uuid.UUID) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	email, ok := o.emails[id]
+	if !ok || email.Status != app.OutboxDead {
+		return fmt.Errorf("%w: недоставленное письмо с id %s не найдено", app.ErrNotFound, id)
+	}
+	if email.Kind.HasCode() {
+		return fmt.Errorf(
+			"%w: код из письма %s удален, пользователь должен запросить новый",
+			app.ErrNotAllowed,
+			id,
+		)
+	}
+	email.Status = app.OutboxPending
+	email.Attempts = 0
+	email.NextAttemptAt = o.now()
+	email.DeadAt = time.Time{}
+	o.emails[id] = email
+	return nil
+}
+
+func (o *EmailOutbox) PurgeDead(ctx context.Context, retention time.Duration) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	threshold := o.now().Add(-retention)
+	purged := 0
+	for id, email := range o.emails {
+		if email.Status == app.OutboxDead && !email.DeadAt.After(threshold) {
+			delete(o.emails, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (o *EmailOutbox) sorted() []app.OutboxEmail {
+	emails := make([]app.OutboxEmail, 0, len(o.emails))
+	for _, email := range o.emails {
+		emails = append(emails, email)
+	}
+	slices.SortFunc(emails, func(a, b app.OutboxEmail) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID.String(), b.ID.String())
+	})
+	return emails
+}
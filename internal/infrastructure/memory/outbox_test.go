@@ -0,0 +1,196 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+)
+
+func testEmailOutbox(now *time.Time) *EmailOutbox {
+	outbox := MustEmailOutbox()
+	outbox.now = func() time.Time { return *now }
+	return outbox
+}
+
+func TestEmailOutbox_Claim(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	outbox := testEmailOutbox(&now)
+	if err := outbox.SendConfirmationEmail(ctx, app.EmailCode{To: "a@mail.com"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	err := outbox.SendConfirmationNewEmail(
+		ctx,
+		[]app.EmailCode{{To: "b@mail.com"}, {To: "c@mail.com"}},
+	)
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	claimed, err := outbox.Claim(ctx, 2, time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if len(claimed) != 2 || claimed[0].Kind != app.ConfirmationEmail ||
+		claimed[1].Kind != app.NewEmailConfirmation {
+		t.Fatalf("expected two oldest emails, but got %+v", claimed)
+	}
+	rest, err := outbox.Claim(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if len(rest) != 1 || rest[0].To != "c@mail.com" {
+		t.Fatalf("expected leased emails to be skipped, but got %+v", rest)
+	}
+
+	if err = outbox.MarkDelivered(ctx, claimed[0].ID); err != nil {
+		t.Fatalf("mark delivered: %v", err)
+	}
+	now = now.Add(time.Minute)
+	expired, err := outbox.Claim(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if len(expired) != 2 {
+		t.Errorf("expected undelivered emails after lease expiry, but got %+v", expired)
+	}
+}
+
+func TestEmailOutbox_RetryAndReplay(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	outbox := testEmailOutbox(&now)
+	if err := outbox.SendRegistrationAttemptEmail(ctx, app.EmailCode{To: "a@mail.com"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	claimed, err := outbox.Claim(ctx, 10, time.Second)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("claim: %v %+v", err, claimed)
+	}
+	id := claimed[0].ID
+
+	if err = outbox.Retry(ctx, id, "timeout", time.Minute); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	now = now.Add(time.Minute - time.Second)
+	if claimed, _ = outbox.Claim(ctx, 10, time.Second); len(claimed) != 0 {
+		t.Errorf("expected email to wait for backoff, but got %+v", claimed)
+	}
+	now = now.Add(time.Second)
+	if claimed, _ = outbox.Claim(ctx, 10, time.Second); len(claimed) != 1 ||
+		claimed[0].Attempts != 1 || claimed[0].LastError != "timeout" {
+		t.Fatalf("expected retried email, but got %+v", claimed)
+	}
+
+	if err = outbox.Bury(ctx, id, "rejected"); err != nil {
+		t.Fatalf("bury: %v", err)
+	}
+	now = now.Add(time.Hour)
+	if claimed, _ = outbox.Claim(ctx, 10, time.Second); len(claimed) != 0 {
+		t.Errorf("expected dead email not to be claimed, but got %+v", claimed)
+	}
+	dead, err := outbox.List(ctx, app.OutboxDead, 10)
+	if err != nil || len(dead) != 1 || dead[0].Attempts != 2 || dead[0].LastError != "rejected" {
+		t.Fatalf("expected dead email in list, but got %+v %v", dead, err)
+	}
+
+	cases := []struct {
+		TestName string
+		Expected error
+		ID       uuid.UUID
+	}{
+		{TestName: "test_email_outbox_replay_dead", Expected: nil, ID: id},
+		{TestName: "test_email_outbox_replay_pending", Expected: app.ErrNotFound, ID: id},
+		{TestName: "test_email_outbox_replay_unknown", Expected: app.ErrNotFound, ID: uuid.New()},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := outbox.Replay(ctx, c.ID)
+			if c.Expected == nil && err != nil {
+				t.Errorf("expected nil, but got %v", err)
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+	claimed, _ = outbox.Claim(ctx, 10, time.Second)
+	if len(claimed) != 1 || claimed[0].Attempts != 0 {
+		t.Errorf("expected replayed email with reset attempts, but got %+v", claimed)
+	}
+}
+
+func TestEmailOutbox_UnknownEmail(t *testing.T) {
+	outbox := MustEmailOutbox()
+	ctx := context.Background()
+	if err := outbox.Retry(ctx, uuid.New(), "", time.Second); !errors.Is(err, app.ErrNotFound) {
+		t.Errorf("expected %T, but got %v", app.ErrNotFound, err)
+	}
+	if err := outbox.Bury(ctx, uuid.New(), ""); !errors.Is(err, app.ErrNotFound) {
+		t.Errorf("expected %T, but got %v", app.ErrNotFound, err)
+	}
+}
+
+func TestEmailOutbox_PurgeDead(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	outbox := testEmailOutbox(&now)
+	for _, to := range []string{"old@mail.com", "new@mail.com", "pending@mail.com"} {
+		if err := outbox.SendResetPasswordEmail(ctx, app.EmailCode{To: to}); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+	bury := func(to string) {
+		for _, email := range outbox.sorted() {
+			if email.To == to {
+				if err := outbox.Bury(ctx, email.ID, "rejected"); err != nil {
+					t.Fatalf("bury: %v", err)
+				}
+			}
+		}
+	}
+	bury("old@mail.com")
+	now = now.Add(time.Hour)
+	bury("new@mail.com")
+
+	purged, err := outbox.PurgeDead(ctx, time.Hour)
+	if err != nil || purged != 1 {
+		t.Fatalf("expected one purged email, but got %d %v", purged, err)
+	}
+	dead, _ := outbox.List(ctx, app.OutboxDead, 10)
+	if len(dead) != 1 || dead[0].To != "new@mail.com" {
+		t.Errorf("expected recently buried email to be kept, but got %+v", dead)
+	}
+	pending, _ := outbox.List(ctx, app.OutboxPending, 10)
+	if len(pending) != 1 || pending[0].To != "pending@mail.com" {
+		t.Errorf("expected pending email to be kept, but got %+v", pending)
+	}
+}
+
+func TestEmailOutbox_BuryDiscardsCode(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	outbox := testEmailOutbox(&now)
+	data := app.EmailCode{To: "a@mail.com", Code: "123456"}
+	if err := outbox.SendResetPasswordEmail(ctx, data); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	claimed, err := outbox.Claim(ctx, 10, time.Minute)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("claim: %v %+v", err, claimed)
+	}
+	if err = outbox.Bury(ctx, claimed[0].ID, "rejected"); err != nil {
+		t.Fatalf("bury: %v", err)
+	}
+	dead, _ := outbox.List(ctx, app.OutboxDead, 10)
+	if len(dead) != 1 || dead[0].Code != "" || !dead[0].DeadAt.Equal(now) {
+		t.Errorf("expected buried email without code, but got %+v", dead)
+	}
+	if err = outbox.Replay(ctx, claimed[0].ID); !errors.Is(err, app.ErrNotAllowed) {
+		t.Errorf("expected %v for email with discarded code, but got %v", app.ErrNotAllowed, err)
+	}
+}
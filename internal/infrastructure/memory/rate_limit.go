@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/infrastructure/ratelimit"
+)
+
+// RateLimiter keeps attempts in process memory, so every replica counts its
+// own attempts and the state is lost on restart. Use the redis limiter when
+// more than one instance serves traffic.
+type RateLimiter struct {
+	mu        sync.Mutex
+	policy    *ratelimit.Policy
+	entries   map[string]*ratelimit.State
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func MustRateLimiter(cfg ratelimit.Config) *RateLimiter {
+	return &RateLimiter{
+		policy:  ratelimit.MustPolicy(cfg),
+		entries: make(map[string]*ratelimit.State),
+		now:     time.Now,
+	}
+}
+
+func (l *RateLimiter) Allow(ctx context.Context, keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+	return l.policy.Allow(now, l.states(keys)...)
+}
+
+func (l *RateLimiter) Fail(ctx context.Context, keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.policy.Fail(l.now(), l.states(keys)...)
+	return nil
+}
+
+func (l *RateLimiter) Reset(ctx context.Context, keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		delete(l.entries, key)
+	}
+	return nil
+}
+
+func (l *RateLimiter) states(keys []string) []*ratelimit.State {
+	states := make([]*ratelimit.State, len(keys))
+	for i, key := range keys {
+		state, ok := l.entries[key]
+		if !ok {
+			state = &ratelimit.State{}
+			l.entries[key] = state
+		}
+		states[i] = state
+	}
+	return states
+}
+
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.policy.Window() {
+		return
+	}
+	l.lastSweep = now
+	for key, state := range l.entries {
+		if !now.Before(l.policy.ExpiresAt(state)) {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/ratelimit"
+)
+
+func testRateLimiter(now *time.Time) *RateLimiter {
+	limiter := MustRateLimiter(ratelimit.Config{
+		Window:      time.Minute,
+		MaxAttempts: 5,
+		MaxFailures: 3,
+		Lockout:     time.Minute,
+		MaxLockout:  3 * time.Minute,
+	})
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func retryAfter(t *testing.T, err error) time.Duration {
+	t.Helper()
+	var appErr *app.Error
+	if !errors.Is(err, app.ErrTooManyRequests) || !errors.As(err, &appErr) {
+		t.Fatalf("expected %v, but got %v", app.ErrTooManyRequests, err)
+	}
+	return appErr.RetryAfter
+}
+
+func TestRateLimiter_Window(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	limiter := testRateLimiter(&now)
+
+	for i := range 5 {
+		if err := limiter.Allow(ctx, "email", "ip"); err != nil {
+			t.Fatalf("attempt %d: expected nil, but got %v", i+1, err)
+		}
+		now = now.Add(time.Second)
+	}
+	if delay := retryAfter(t, limiter.Allow(ctx, "email")); delay != 55*time.Second {
+		t.Errorf("expected retry in 55s, but got %v", delay)
+	}
+	if err := limiter.Allow(ctx, "other"); err != nil {
+		t.Errorf("expected other key to be allowed, but got %v", err)
+	}
+	if err := limiter.Allow(ctx, "other", "ip"); !errors.Is(err, app.ErrTooManyRequests) {
+		t.Errorf("expected shared ip key to block, but got %v", err)
+	}
+
+	now = now.Add(55 * time.Second)
+	if err := limiter.Allow(ctx, "email"); err != nil {
+		t.Errorf("expected oldest attempt to leave window, but got %v", err)
+	}
+}
+
+func TestRateLimiter_ProgressiveLockout(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	limiter := testRateLimiter(&now)
+	cases := []struct {
+		TestName string
+		Expected time.Duration
+	}{
+		{TestName: "test_rate_limiter_first_lockout", Expected: time.Minute},
+		{TestName: "test_rate_limiter_second_lockout", Expected: 2 * time.Minute},
+		{TestName: "test_rate_limiter_capped_lockout", Expected: 3 * time.Minute},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			for range 2 {
+				if err := limiter.Fail(ctx, "email"); err != nil {
+					t.Fatalf("fail: %v", err)
+				}
+			}
+			if err := limiter.Allow(ctx, "email"); err != nil {
+				t.Fatalf("expected nil before lockout, but got %v", err)
+			}
+			if err := limiter.Fail(ctx, "email"); err != nil {
+				t.Fatalf("fail: %v", err)
+			}
+			if delay := retryAfter(t, limiter.Allow(ctx, "email")); delay != c.Expected {
+				t.Errorf("expected retry in %v, but got %v", c.Expected, delay)
+			}
+			now = now.Add(c.Expected)
+		})
+	}
+}
+
+func TestRateLimiter_FailuresExpire(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	limiter := testRateLimiter(&now)
+
+	for range 2 {
+		if err := limiter.Fail(ctx, "email"); err != nil {
+			t.Fatalf("fail: %v", err)
+		}
+	}
+	now = now.Add(2 * time.Minute)
+	if err := limiter.Fail(ctx, "email"); err != nil {
+		t.Fatalf("fail: %v", err)
+	}
+	if err := limiter.Allow(ctx, "email"); err != nil {
+		t.Errorf("expected old failures to expire, but got %v", err)
+	}
+}
+
+func TestRateLimiter_Reset(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	limiter := testRateLimiter(&now)
+
+	for range 3 {
+		if err := limiter.Fail(ctx, "email", "ip"); err != nil {
+			t.Fatalf("fail: %v", err)
+		}
+	}
+	if err := limiter.Reset(ctx, "email"); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if err := limiter.Allow(ctx, "email"); err != nil {
+		t.Errorf("expected nil after reset, but got %v", err)
+	}
+	if err := limiter.Allow(ctx, "ip"); !errors.Is(err, app.ErrTooManyRequests) {
+		t.Errorf("expected ip key to stay locked, but got %v", err)
+	}
+}
+
+func TestRateLimiter_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	limiter := testRateLimiter(&now)
+
+	if err := limiter.Allow(ctx, "email"); err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+	for range 3 {
+		if err := limiter.Fail(ctx, "ip"); err != nil {
+			t.Fatalf("fail: %v", err)
+		}
+	}
+	now = now.Add(2 * time.Minute)
+	if err := limiter.Allow(ctx); err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+	if _, ok := limiter.entries["email"]; ok {
+		t.Error("expected idle entry to be swept")
+	}
+	if _, ok := limiter.entries["ip"]; !ok {
+		t.Error("expected recently locked entry to be kept")
+	}
+}
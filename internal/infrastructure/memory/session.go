@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+)
+
+type SessionRepository struct {
+	mu     sync.Mutex
+	byID   map[uuid.UUID]app.Session
+	byHash map[string]uuid.UUID
+}
+
+func MustSessionRepository() *SessionRepository {
+	return &SessionRepository{
+		byID:   make(map[uuid.UUID]app.Session),
+		byHash: make(map[string]uuid.UUID),
+	}
+}
+
+func (r *SessionRepository) Save(ctx context.Context, session *app.Session) error {
+	if session == nil {
+		return fmt.Errorf("%w: получен nil для сохранения сессии", app.ErrInternal)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.save(session)
+}
+
+func (r *SessionRepository) ByTokenHash(ctx context.Context, tokenHash string) (*app.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id, ok := r.byHash[tokenHash]
+	if !ok {
+		return nil, fmt.Errorf("%w: сессия не найдена", app.ErrNotFound)
+	}
+	session := r.byID[id]
+	return &session, nil
+}
+
+func (r *SessionRepository) Rotate(ctx context.Context, usedID uuid.UUID, next *app.Session) error {
+	if next == nil {
+		return fmt.Errorf("%w: получен nil для сохранения сессии", app.ErrInternal)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	used, ok := r.byID[usedID]
+	if !ok {
+		return fmt.Errorf("%w: сессия с id %s не найдена", app.ErrNotFound, usedID)
+	}
+	if used.Used || used.Revoked {
+		return fmt.Errorf("%w: сессия с id %s уже использована", app.ErrConflict, usedID)
+	}
+	if err := r.save(next); err != nil {
+		return err
+	}
+	used.Used = true
+	r.byID[usedID] = used
+	return nil
+}
+
+func (r *SessionRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, session := range r.byID {
+		if session.FamilyID == familyID {
+			session.Revoked = true
+			r.byID[id] = session
+		}
+	}
+	return nil
+}
+
+func (r *SessionRepository) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, session := range r.byID {
+		if session.UserID == userID {
+			session.Revoked = true
+			r.byID[id] = session
+		}
+	}
+	return nil
+}
+
+func (r *SessionRepository) save(session *app.Session) error {
+	if id, ok := r.byHash[session.TokenHash]; ok && id != session.ID {
+		return fmt.Errorf("%w: сессия с таким токеном уже существует", app.ErrAlreadyExists)
+	}
+	if current, ok := r.byID[session.ID]; ok {
+		delete(r.byHash, current.TokenHash)
+	}
+	r.byID[session.ID] = *session
+	r.byHash[session.TokenHash] = session.ID
+	return nil
+}
@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+)
+
+func newTestSession(userID, familyID uuid.UUID) *app.Session {
+	return &app.Session{
+		ID:        uuid.New(),
+		FamilyID:  familyID,
+		UserID:    userID,
+		TokenHash: uuid.NewString(),
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func TestSessionRepository_Rotate(t *testing.T) {
+	ctx := context.Background()
+	repo := MustSessionRepository()
+	userID, familyID := uuid.New(), uuid.New()
+	first := newTestSession(userID, familyID)
+	if err := repo.Save(ctx, first); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	revoked := newTestSession(userID, uuid.New())
+	revoked.Revoked = true
+	if err := repo.Save(ctx, revoked); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	second := newTestSession(userID, familyID)
+	cases := []struct {
+		TestName string
+		Expected error
+		UsedID   uuid.UUID
+		Next     *app.Session
+	}{
+		{TestName: "test_session_rotate_ok", Expected: nil, UsedID: first.ID, Next: second},
+		{
+			TestName: "test_session_rotate_already_used",
+			Expected: app.ErrConflict,
+			UsedID:   first.ID,
+			Next:     newTestSession(userID, familyID),
+		},
+		{
+			TestName: "test_session_rotate_revoked",
+			Expected: app.ErrConflict,
+			UsedID:   revoked.ID,
+			Next:     newTestSession(userID, revoked.FamilyID),
+		},
+		{
+			TestName: "test_session_rotate_not_found",
+			Expected: app.ErrNotFound,
+			UsedID:   uuid.New(),
+			Next:     newTestSession(userID, familyID),
+		},
+		{
+			TestName: "test_session_rotate_duplicate_hash",
+			Expected: app.ErrAlreadyExists,
+			UsedID:   second.ID,
+			Next:     &app.Session{ID: uuid.New(), FamilyID: familyID, TokenHash: first.TokenHash},
+		},
+		{TestName: "test_session_rotate_nil", Expected: app.ErrInternal, UsedID: second.ID},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := repo.Rotate(ctx, c.UsedID, c.Next)
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected nil, but got %v", err)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+
+	used, err := repo.ByTokenHash(ctx, first.TokenHash)
+	if err != nil || !used.Used {
+		t.Errorf("expected first session to be used, but got %v, %v", used, err)
+	}
+	next, err := repo.ByTokenHash(ctx, second.TokenHash)
+	if err != nil || next.Used {
+		t.Errorf("expected second session to be unused, but got %v, %v", next, err)
+	}
+	if _, err = repo.ByTokenHash(ctx, "unknown"); !errors.Is(err, app.ErrNotFound) {
+		t.Errorf("expected %T, but got %v", app.ErrNotFound, err)
+	}
+}
+
+func TestSessionRepository_ConcurrentRotate(t *testing.T) {
+	ctx := context.Background()
+	repo := MustSessionRepository()
+	session := newTestSession(uuid.New(), uuid.New())
+	if err := repo.Save(ctx, session); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := repo.Rotate(ctx, session.ID, newTestSession(session.UserID, session.FamilyID)); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != 1 {
+		t.Errorf("expected exactly one rotation, but got %d", succeeded)
+	}
+}
+
+func TestSessionRepository_Revoke(t *testing.T) {
+	ctx := context.Background()
+	repo := MustSessionRepository()
+	userID, otherUserID := uuid.New(), uuid.New()
+	familyID, otherFamilyID := uuid.New(), uuid.New()
+	family := []*app.Session{newTestSession(userID, familyID), newTestSession(userID, familyID)}
+	otherFamily := newTestSession(userID, otherFamilyID)
+	otherUser := newTestSession(otherUserID, uuid.New())
+	for _, session := range append(family, otherFamily, otherUser) {
+		if err := repo.Save(ctx, session); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+
+	revoked := func(session *app.Session) bool {
+		stored, err := repo.ByTokenHash(ctx, session.TokenHash)
+		if err != nil {
+			t.Fatalf("by token hash: %v", err)
+		}
+		return stored.Revoked
+	}
+
+	if err := repo.RevokeFamily(ctx, familyID); err != nil {
+		t.Fatalf("revoke family: %v", err)
+	}
+	for _, session := range family {
+		if !revoked(session) {
+			t.Errorf("expected session %s of family to be revoked", session.ID)
+		}
+	}
+	if revoked(otherFamily) || revoked(otherUser) {
+		t.Error("expected sessions outside family not to be revoked")
+	}
+
+	if err := repo.RevokeUser(ctx, userID); err != nil {
+		t.Fatalf("revoke user: %v", err)
+	}
+	if !revoked(otherFamily) {
+		t.Error("expected every session of user to be revoked")
+	}
+	if revoked(otherUser) {
+		t.Error("expected sessions of other users not to be revoked")
+	}
+}
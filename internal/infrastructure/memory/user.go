@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+)
+
+type UserRepository struct {
+	mu        sync.RWMutex
+	byID      map[uuid.UUID]app.User
+	byEmail   map[string]uuid.UUID
+	createdAt map[uuid.UUID]time.Time
+	now       func() time.Time
+}
+
+func MustUserRepository() *UserRepository {
+	return &UserRepository{
+		byID:      make(map[uuid.UUID]app.User),
+		byEmail:   make(map[string]uuid.UUID),
+		createdAt: make(map[uuid.UUID]time.Time),
+		now:       time.Now,
+	}
+}
+
+func (r *UserRepository) NextID(ctx context.Context) (uuid.UUID, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: не удалось сгенерировать id пользователя: %s", app.ErrInternal, err)
+	}
+	return id, nil
+}
+
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.byEmail[email]
+	return ok, nil
+}
+
+func (r *UserRepository) IDExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.byID[id]
+	return ok, nil
+}
+
+func (r *UserRepository) ByID(ctx context.Context, id uuid.UUID) (*app.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.byID[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: пользователь с id %s не найден", app.ErrNotFound, id)
+	}
+	return &user, nil
+}
+
+func (r *UserRepository) ByEmail(ctx context.Context, email string) (*app.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.byEmail[email]
+	if !ok {
+		return nil, fmt.Errorf("%w: пользователь с email %s не найден", app.ErrNotFound, email)
+	}
+	user := r.byID[id]
+	return &user, nil
+}
+
+func (r *UserRepository) Save(ctx context.Context, user *app.User) error {
+	if user == nil {
+		return fmt.Errorf("%w: получен nil для сохранения пользователя", app.ErrInternal)
+	}
+	if user.Version == 0 {
+		return fmt.Errorf("%w: версия пользователя не может быть равна 0", app.ErrInternal)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, exists := r.byID[user.ID]
+	switch {
+	case user.Version == 1 && exists:
+		return fmt.Errorf("%w: пользователь с id %s уже существует", app.ErrConflict, user.ID)
+	case user.Version > 1 && !exists:
+		return fmt.Errorf(
+			"%w: пользователь с id %s был удален другим запросом",
+			app.ErrConflict,
+			user.ID,
+		)
+	case user.Version > 1 && current.Version != user.Version-1:
+		return fmt.Errorf(
+			"%w: пользователь с id %s был изменен другим запросом",
+			app.ErrConflict,
+			user.ID,
+		)
+	}
+
+	if id, ok := r.byEmail[user.Email]; ok && id != user.ID {
+		return fmt.Errorf("%w: пользователь с email %s уже существует", app.ErrAlreadyExists, user.Email)
+	}
+
+	if exists {
+		delete(r.byEmail, current.Email)
+	} else {
+		r.createdAt[user.ID] = r.now()
+	}
+	r.byID[user.ID] = *user
+	r.byEmail[user.Email] = user.ID
+	return nil
+}
+
+func (r *UserRepository) ViewByID(ctx context.Context, id uuid.UUID) (*app.UserView, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.byID[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: пользователь с id %s не найден", app.ErrNotFound, id)
+	}
+	view := r.view(user)
+	return &view, nil
+}
+
+func (r *UserRepository) List(
+	ctx context.Context,
+	filter app.UserFilter,
+	after *app.UserCursor,
+	limit int,
+) ([]app.UserView, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	views := make([]app.UserView, 0, len(r.byID))
+	for _, user := range r.byID {
+		view := r.view(user)
+		if matchUser(view, filter, after) {
+			views = append(views, view)
+		}
+	}
+	slices.SortFunc(views, func(a, b app.UserView) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), strings.Compare(a.ID.String(), b.ID.String()))
+	})
+	if len(views) > limit {
+		views = views[:limit]
+	}
+	return views, nil
+}
+
+func (r *UserRepository) view(user app.User) app.UserView {
+	return app.UserView{
+		ID:        user.ID,
+		Email:     user.Email,
+		State:     user.State,
+		Status:    user.Status,
+		Locale:    user.Locale,
+		Version:   user.Version,
+		CreatedAt: r.createdAt[user.ID],
+	}
+}
+
+func matchUser(view app.UserView, filter app.UserFilter, after *app.UserCursor) bool {
+	switch {
+	case filter.State != "" && view.State != filter.State:
+		return false
+	case filter.Status != "" && view.Status != filter.Status:
+		return false
+	case filter.EmailContains != "" &&
+		!strings.Contains(strings.ToLower(view.Email), filter.EmailContains):
+		return false
+	case !filter.CreatedFrom.IsZero() && view.CreatedAt.Before(filter.CreatedFrom):
+		return false
+	case !filter.CreatedTo.IsZero() && !view.CreatedAt.Before(filter.CreatedTo):
+		return false
+	case after == nil:
+		return true
+	}
+	if c := view.CreatedAt.Compare(after.CreatedAt); c != 0 {
+		return c > 0
+	}
+	return strings.Compare(view.ID.String(), after.ID.String()) > 0
+}
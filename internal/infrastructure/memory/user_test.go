@@ -0,0 +1,309 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+func newTestUser(email string) *app.User {
+	return &app.User{
+		ID:           uuid.New(),
+		Email:        email,
+		State:        domain.ACTIVE,
+		Status:       domain.USER,
+		Locale:       domain.RU,
+		PasswordHash: "hash",
+		Version:      1,
+	}
+}
+
+func changedUser(user *app.User, email string, version uint) *app.User {
+	changed := *user
+	changed.Email = email
+	changed.Version = version
+	return &changed
+}
+
+func TestUserRepository_Save(t *testing.T) {
+	ctx := context.Background()
+	repo := MustUserRepository()
+	saved := newTestUser("saved@mail.com")
+	other := newTestUser("other@mail.com")
+	for _, user := range []*app.User{saved, other} {
+		if err := repo.Save(ctx, user); err != nil {
+			t.Fatalf("save user: %v", err)
+		}
+	}
+	cases := []struct {
+		TestName string
+		Expected error
+		User     *app.User
+	}{
+		{
+			TestName: "test_user_repository_save_insert_ok",
+			Expected: nil,
+			User:     newTestUser("new@mail.com"),
+		},
+		{
+			TestName: "test_user_repository_save_insert_email_exists",
+			Expected: app.ErrAlreadyExists,
+			User:     newTestUser(saved.Email),
+		},
+		{
+			TestName: "test_user_repository_save_insert_id_exists",
+			Expected: app.ErrConflict,
+			User:     changedUser(saved, "another@mail.com", 1),
+		},
+		{
+			TestName: "test_user_repository_save_update_email_exists",
+			Expected: app.ErrAlreadyExists,
+			User:     changedUser(saved, other.Email, 2),
+		},
+		{
+			TestName: "test_user_repository_save_update_ok",
+			Expected: nil,
+			User:     changedUser(saved, "changed@mail.com", 2),
+		},
+		{
+			TestName: "test_user_repository_save_update_version_conflict",
+			Expected: app.ErrConflict,
+			User:     changedUser(saved, "conflict@mail.com", 2),
+		},
+		{
+			TestName: "test_user_repository_save_update_not_exists",
+			Expected: app.ErrConflict,
+			User:     changedUser(newTestUser("ghost@mail.com"), "ghost@mail.com", 2),
+		},
+		{
+			TestName: "test_user_repository_save_zero_version",
+			Expected: app.ErrInternal,
+			User:     changedUser(newTestUser("zero@mail.com"), "zero@mail.com", 0),
+		},
+		{
+			TestName: "test_user_repository_save_nil",
+			Expected: app.ErrInternal,
+			User:     nil,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := repo.Save(ctx, c.User)
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected nil, but got %v", err)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+
+	exists, _ := repo.EmailExists(ctx, saved.Email)
+	if exists {
+		t.Errorf("expected old email %s to be released after update", saved.Email)
+	}
+	user, err := repo.ByEmail(ctx, "changed@mail.com")
+	if err != nil {
+		t.Fatalf("by email: %v", err)
+	}
+	if user.ID != saved.ID || user.Version != 2 {
+		t.Errorf("expected updated user %s with version 2, but got %v", saved.ID, user)
+	}
+}
+
+func TestUserRepository_Lookup(t *testing.T) {
+	ctx := context.Background()
+	repo := MustUserRepository()
+	saved := newTestUser("saved@mail.com")
+	if err := repo.Save(ctx, saved); err != nil {
+		t.Fatalf("save user: %v", err)
+	}
+	cases := []struct {
+		TestName string
+		Expected error
+		Lookup   func() (*app.User, error)
+	}{
+		{
+			TestName: "test_user_repository_by_id_ok",
+			Expected: nil,
+			Lookup:   func() (*app.User, error) { return repo.ByID(ctx, saved.ID) },
+		},
+		{
+			TestName: "test_user_repository_by_id_not_found",
+			Expected: app.ErrNotFound,
+			Lookup:   func() (*app.User, error) { return repo.ByID(ctx, uuid.New()) },
+		},
+		{
+			TestName: "test_user_repository_by_email_ok",
+			Expected: nil,
+			Lookup:   func() (*app.User, error) { return repo.ByEmail(ctx, saved.Email) },
+		},
+		{
+			TestName: "test_user_repository_by_email_not_found",
+			Expected: app.ErrNotFound,
+			Lookup:   func() (*app.User, error) { return repo.ByEmail(ctx, "unknown@mail.com") },
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			user, err := c.Lookup()
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+			if c.Expected == nil && *user != *saved {
+				t.Errorf("expected %v, but got %v", saved, user)
+			}
+		})
+	}
+
+	user, _ := repo.ByID(ctx, saved.ID)
+	user.Email = "mutated@mail.com"
+	if exists, _ := repo.EmailExists(ctx, saved.Email); !exists {
+		t.Error("expected repository state not to depend on returned user")
+	}
+	if exists, _ := repo.IDExists(ctx, saved.ID); !exists {
+		t.Errorf("expected id %s to exist", saved.ID)
+	}
+}
+
+func TestUserRepository_ConcurrentSave(t *testing.T) {
+	ctx := context.Background()
+	repo := MustUserRepository()
+	saved := newTestUser("saved@mail.com")
+	if err := repo.Save(ctx, saved); err != nil {
+		t.Fatalf("save user: %v", err)
+	}
+
+	const writers = 16
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user := changedUser(saved, saved.Email, 2)
+			user.PasswordHash = uuid.NewString()
+			if i%2 == 0 {
+				user.State = domain.FROZEN
+			}
+			if err := repo.Save(ctx, user); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			} else if !errors.Is(err, app.ErrConflict) {
+				t.Errorf("expected %T, but got %v", app.ErrConflict, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != 1 {
+		t.Errorf("expected exactly one successful save, but got %d", succeeded)
+	}
+}
+
+func TestUserRepository_List(t *testing.T) {
+	ctx := context.Background()
+	repo := MustUserRepository()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	repo.now = func() time.Time {
+		now = now.Add(time.Hour)
+		return now
+	}
+	first := newTestUser("first@mail.com")
+	admin := newTestUser("admin@dnd.com")
+	admin.Status = domain.ADMIN
+	frozen := newTestUser("frozen@mail.com")
+	frozen.State = domain.FROZEN
+	for _, user := range []*app.User{first, admin, frozen} {
+		if err := repo.Save(ctx, user); err != nil {
+			t.Fatalf("save user: %v", err)
+		}
+	}
+	cases := []struct {
+		TestName string
+		Expected []uuid.UUID
+		Filter   app.UserFilter
+		After    *app.UserCursor
+		Limit    int
+	}{
+		{
+			TestName: "test_user_repository_list_all",
+			Expected: []uuid.UUID{first.ID, admin.ID, frozen.ID},
+			Limit:    10,
+		},
+		{
+			TestName: "test_user_repository_list_limit",
+			Expected: []uuid.UUID{first.ID, admin.ID},
+			Limit:    2,
+		},
+		{
+			TestName: "test_user_repository_list_after",
+			Expected: []uuid.UUID{frozen.ID},
+			After:    &app.UserCursor{CreatedAt: start.Add(2 * time.Hour), ID: admin.ID},
+			Limit:    10,
+		},
+		{
+			TestName: "test_user_repository_list_state",
+			Expected: []uuid.UUID{frozen.ID},
+			Filter:   app.UserFilter{State: domain.FROZEN},
+			Limit:    10,
+		},
+		{
+			TestName: "test_user_repository_list_status",
+			Expected: []uuid.UUID{admin.ID},
+			Filter:   app.UserFilter{Status: domain.ADMIN},
+			Limit:    10,
+		},
+		{
+			TestName: "test_user_repository_list_email",
+			Expected: []uuid.UUID{first.ID, frozen.ID},
+			Filter:   app.UserFilter{EmailContains: "@mail"},
+			Limit:    10,
+		},
+		{
+			TestName: "test_user_repository_list_created_range",
+			Expected: []uuid.UUID{admin.ID},
+			Filter: app.UserFilter{
+				CreatedFrom: start.Add(2 * time.Hour),
+				CreatedTo:   start.Add(3 * time.Hour),
+			},
+			Limit: 10,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			views, err := repo.List(ctx, c.Filter, c.After, c.Limit)
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			ids := make([]uuid.UUID, 0, len(views))
+			for _, view := range views {
+				ids = append(ids, view.ID)
+			}
+			if !slices.Equal(ids, c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, ids)
+			}
+		})
+	}
+
+	view, err := repo.ViewByID(ctx, admin.ID)
+	if err != nil {
+		t.Fatalf("view by id: %v", err)
+	}
+	if view.Email != admin.Email || !view.CreatedAt.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("expected admin view created at insert, but got %v", view)
+	}
+}
@@ -0,0 +1,161 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+func DefaultArgon2idParams() Argon2idParams {
+	return Argon2idParams{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 4,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+type legacyComparer interface {
+	Compare(password, hash string) (bool, error)
+}
+
+type Argon2idHasher struct {
+	params Argon2idParams
+	legacy legacyComparer
+}
+
+func MustArgon2idHasher(params Argon2idParams, legacy legacyComparer) *Argon2idHasher {
+	if params.Memory < 8*uint32(params.Parallelism) {
+		panic("argon2id hasher got memory less than 8 KiB per thread")
+	}
+	if params.Iterations == 0 {
+		panic("argon2id hasher got zero iterations")
+	}
+	if params.Parallelism == 0 {
+		panic("argon2id hasher got zero parallelism")
+	}
+	if params.SaltLength < 8 {
+		panic("argon2id hasher got salt shorter than 8 bytes")
+	}
+	if params.KeyLength < 16 {
+		panic("argon2id hasher got key shorter than 16 bytes")
+	}
+	if legacy == nil {
+		panic("argon2id hasher did not get legacy comparer")
+	}
+	return &Argon2idHasher{params: params, legacy: legacy}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("%w: генерация соли: %s", app.ErrInternal, err)
+	}
+	key := argon2.IDKey(
+		[]byte(password),
+		salt,
+		h.params.Iterations,
+		h.params.Memory,
+		h.params.Parallelism,
+		h.params.KeyLength,
+	)
+	return encodeArgon2id(h.params, salt, key), nil
+}
+
+func (h *Argon2idHasher) Compare(password, hash string) (bool, error) {
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		return h.legacy.Compare(password, hash)
+	}
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+	actual := argon2.IDKey(
+		[]byte(password),
+		salt,
+		params.Iterations,
+		params.Memory,
+		params.Parallelism,
+		params.KeyLength,
+	)
+	return subtle.ConstantTimeCompare(actual, key) == 1, nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, _, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params != h.params
+}
+
+func encodeArgon2id(params Argon2idParams, salt, key []byte) string {
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+func decodeArgon2id(hash string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: некорректный argon2id хеш: %s", app.ErrInternal, reason)
+	}
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return params, nil, nil, invalid("неверный формат")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, invalid("неверная версия")
+	}
+	if version != argon2.Version {
+		return params, nil, nil, invalid(fmt.Sprintf("неподдерживаемая версия %d", version))
+	}
+	if _, err := fmt.Sscanf(
+		parts[3],
+		"m=%d,t=%d,p=%d",
+		&params.Memory,
+		&params.Iterations,
+		&params.Parallelism,
+	); err != nil {
+		return params, nil, nil, invalid("неверные параметры")
+	}
+	if params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, invalid("нулевые параметры")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(salt) == 0 {
+		return params, nil, nil, invalid("неверная соль")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, invalid("неверный ключ")
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
@@ -0,0 +1,159 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func testArgon2idParams() Argon2idParams {
+	return Argon2idParams{
+		Memory:      64,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+func TestArgon2idHasher_Hash(t *testing.T) {
+	hasher := MustArgon2idHasher(testArgon2idParams(), MustBcryptComparer())
+	first, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	second, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if !strings.HasPrefix(first, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("expected PHC string, but got %s", first)
+	}
+	if first == second {
+		t.Errorf("expected different salts, but got equal hashes %s", first)
+	}
+}
+
+func TestArgon2idHasher_Compare(t *testing.T) {
+	hasher := MustArgon2idHasher(testArgon2idParams(), MustBcryptComparer())
+	hash, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt hash: %v", err)
+	}
+	cases := []struct {
+		TestName string
+		Expected bool
+		Password string
+		Hash     string
+		Err      error
+	}{
+		{TestName: "test_argon2id_compare_ok", Expected: true, Password: "password", Hash: hash},
+		{TestName: "test_argon2id_compare_mismatch", Expected: false, Password: "invalid", Hash: hash},
+		{
+			TestName: "test_argon2id_compare_legacy_bcrypt_ok",
+			Expected: true,
+			Password: "password",
+			Hash:     string(legacy),
+		},
+		{
+			TestName: "test_argon2id_compare_legacy_bcrypt_mismatch",
+			Expected: false,
+			Password: "invalid",
+			Hash:     string(legacy),
+		},
+		{
+			TestName: "test_argon2id_compare_other_params_ok",
+			Expected: true,
+			Password: "password",
+			Hash: encodeArgon2id(
+				Argon2idParams{Memory: 32, Iterations: 2, Parallelism: 1},
+				[]byte("saltsaltsaltsalt"),
+				argon2.IDKey([]byte("password"), []byte("saltsaltsaltsalt"), 2, 32, 1, 16),
+			),
+		},
+		{
+			TestName: "test_argon2id_compare_malformed",
+			Expected: false,
+			Password: "password",
+			Hash:     "$argon2id$v=19$m=64,t=1$c2FsdA$a2V5",
+			Err:      app.ErrInternal,
+		},
+		{
+			TestName: "test_argon2id_compare_unsupported_version",
+			Expected: false,
+			Password: "password",
+			Hash:     "$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5",
+			Err:      app.ErrInternal,
+		},
+		{
+			TestName: "test_argon2id_compare_unknown_format",
+			Expected: false,
+			Password: "password",
+			Hash:     "plain",
+			Err:      app.ErrInternal,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			ok, err := hasher.Compare(c.Password, c.Hash)
+			if ok != c.Expected {
+				t.Errorf("expected %v, but got %v", c.Expected, ok)
+			}
+			if !errors.Is(err, c.Err) {
+				t.Errorf("expected %T, but got %v", c.Err, err)
+			}
+		})
+	}
+}
+
+func TestArgon2idHasher_NeedsRehash(t *testing.T) {
+	params := testArgon2idParams()
+	hasher := MustArgon2idHasher(params, MustBcryptComparer())
+	current, err := hasher.Hash("password")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	weaker := params
+	weaker.Iterations = 1
+	weaker.Memory = 32
+	outdated, err := MustArgon2idHasher(weaker, MustBcryptComparer()).Hash("password")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	shorter := params
+	shorter.KeyLength = 16
+	shortKey, err := MustArgon2idHasher(shorter, MustBcryptComparer()).Hash("password")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt hash: %v", err)
+	}
+	cases := []struct {
+		TestName string
+		Expected bool
+		Hash     string
+	}{
+		{TestName: "test_argon2id_needs_rehash_current", Expected: false, Hash: current},
+		{TestName: "test_argon2id_needs_rehash_outdated_params", Expected: true, Hash: outdated},
+		{TestName: "test_argon2id_needs_rehash_key_length", Expected: true, Hash: shortKey},
+		{TestName: "test_argon2id_needs_rehash_bcrypt", Expected: true, Hash: string(legacy)},
+		{TestName: "test_argon2id_needs_rehash_malformed", Expected: true, Hash: "$argon2id$"},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			if needs := hasher.NeedsRehash(c.Hash); needs != c.Expected {
+				t.Errorf("expected %v, but got %v", c.Expected, needs)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type BcryptComparer struct{}
+
+func MustBcryptComparer() *BcryptComparer {
+	return &BcryptComparer{}
+}
+
+func (c *BcryptComparer) Compare(password, hash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%w: сравнение пароля: %s", app.ErrInternal, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,46 @@
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBcryptComparer_Compare(t *testing.T) {
+	comparer := MustBcryptComparer()
+	generated, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	hash := string(generated)
+	cases := []struct {
+		TestName string
+		Expected bool
+		Password string
+		Hash     string
+		Err      error
+	}{
+		{TestName: "test_bcrypt_compare_ok", Expected: true, Password: "password", Hash: hash},
+		{TestName: "test_bcrypt_compare_mismatch", Expected: false, Password: "invalid", Hash: hash},
+		{
+			TestName: "test_bcrypt_compare_invalid_hash",
+			Expected: false,
+			Password: "password",
+			Hash:     "not_a_hash",
+			Err:      app.ErrInternal,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			ok, err := comparer.Compare(c.Password, c.Hash)
+			if ok != c.Expected {
+				t.Errorf("expected %v, but got %v", c.Expected, ok)
+			}
+			if !errors.Is(err, c.Err) {
+				t.Errorf("expected %T, but got %v", c.Err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+type BreachedList struct {
+	hashes []uint64
+}
+
+func MustBreachedList(passwords ...string) *BreachedList {
+	list := &BreachedList{hashes: make([]uint64, 0, len(passwords))}
+	for _, password := range passwords {
+		list.hashes = append(list.hashes, breachedHash(password))
+	}
+	list.sort()
+	return list
+}
+
+func LoadBreachedList(r io.Reader) (*BreachedList, error) {
+	list := &BreachedList{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		password := strings.TrimRight(scanner.Text(), "\r")
+		if password == "" {
+			continue
+		}
+		list.hashes = append(list.hashes, breachedHash(password))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("чтение списка скомпрометированных паролей: %w", err)
+	}
+	list.sort()
+	return list, nil
+}
+
+func (l *BreachedList) Len() int {
+	return len(l.hashes)
+}
+
+func (l *BreachedList) Contains(password string) bool {
+	_, found := slices.BinarySearch(l.hashes, breachedHash(password))
+	return found
+}
+
+func (l *BreachedList) sort() {
+	slices.Sort(l.hashes)
+	l.hashes = slices.Compact(l.hashes)
+}
+
+func breachedHash(password string) uint64 {
+	sum := sha256.Sum256([]byte(password))
+	return binary.BigEndian.Uint64(sum[:8])
+}
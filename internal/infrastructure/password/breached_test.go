@@ -0,0 +1,41 @@
+package password
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBreachedList_Load(t *testing.T) {
+	list, err := LoadBreachedList(strings.NewReader("123456\r\npassword\n\nqwerty\npassword\n"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if list.Len() != 3 {
+		t.Errorf("expected 3 unique passwords, but got %d", list.Len())
+	}
+	cases := []struct {
+		TestName string
+		Expected bool
+		Password string
+	}{
+		{TestName: "test_breached_list_contains", Expected: true, Password: "password"},
+		{TestName: "test_breached_list_contains_crlf", Expected: true, Password: "123456"},
+		{TestName: "test_breached_list_case_sensitive", Expected: false, Password: "Password"},
+		{TestName: "test_breached_list_not_contains", Expected: false, Password: "Dragon-42-Lair"},
+		{TestName: "test_breached_list_empty", Expected: false, Password: ""},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			if contains := list.Contains(c.Password); contains != c.Expected {
+				t.Errorf("expected %v, but got %v", c.Expected, contains)
+			}
+		})
+	}
+}
+
+func TestBreachedList_Empty(t *testing.T) {
+	list := MustBreachedList()
+	if list.Contains("password") {
+		t.Error("expected empty list to contain nothing")
+	}
+}
@@ -0,0 +1,122 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+const minEmailLocalPartLength = 3
+
+type Policy struct {
+	MinLength            int
+	MaxLength            int
+	RequireLower         bool
+	RequireUpper         bool
+	RequireDigit         bool
+	RequireSymbol        bool
+	ForbidEmailLocalPart bool
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength:            8,
+		MaxLength:            128,
+		RequireLower:         true,
+		RequireUpper:         false,
+		RequireDigit:         true,
+		RequireSymbol:        false,
+		ForbidEmailLocalPart: true,
+	}
+}
+
+type breachedChecker interface {
+	Contains(password string) bool
+}
+
+type PolicyValidator struct {
+	policy   Policy
+	breached breachedChecker
+}
+
+func MustPolicyValidator(policy Policy, breached breachedChecker) *PolicyValidator {
+	if policy.MinLength <= 0 {
+		panic("policy validator got non-positive min length")
+	}
+	if policy.MaxLength < policy.MinLength {
+		panic("policy validator got max length less than min length")
+	}
+	if breached == nil {
+		panic("policy validator did not get breached password list")
+	}
+	return &PolicyValidator{policy: policy, breached: breached}
+}
+
+func (v *PolicyValidator) Validate(password, email string) error {
+	var errs []error
+	invalid := func(rule, message string) *app.Error {
+		err := app.NewError(app.ErrInvalidData, app.CodePasswordTooWeak, message).
+			WithField("password").
+			WithParam("rule", rule)
+		errs = append(errs, err)
+		return err
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < v.policy.MinLength {
+		invalid(
+			"min_length",
+			fmt.Sprintf("пароль должен содержать не менее %d символов", v.policy.MinLength),
+		).WithParam("min", strconv.Itoa(v.policy.MinLength))
+	}
+	if length > v.policy.MaxLength {
+		invalid(
+			"max_length",
+			fmt.Sprintf("пароль должен содержать не более %d символов", v.policy.MaxLength),
+		).WithParam("max", strconv.Itoa(v.policy.MaxLength))
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if v.policy.RequireLower && !lower {
+		invalid("lower", "пароль должен содержать строчную букву")
+	}
+	if v.policy.RequireUpper && !upper {
+		invalid("upper", "пароль должен содержать заглавную букву")
+	}
+	if v.policy.RequireDigit && !digit {
+		invalid("digit", "пароль должен содержать цифру")
+	}
+	if v.policy.RequireSymbol && !symbol {
+		invalid("symbol", "пароль должен содержать специальный символ")
+	}
+
+	if v.policy.ForbidEmailLocalPart {
+		local, _, _ := strings.Cut(email, "@")
+		if utf8.RuneCountInString(local) >= minEmailLocalPartLength &&
+			strings.Contains(strings.ToLower(password), strings.ToLower(local)) {
+			invalid("email", "пароль не должен содержать имя пользователя из email")
+		}
+	}
+
+	if v.breached.Contains(password) {
+		invalid("breached", "пароль найден в списке скомпрометированных паролей")
+	}
+	return errors.Join(errs...)
+}
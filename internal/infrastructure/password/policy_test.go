@@ -0,0 +1,138 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+func TestPolicyValidator_Validate(t *testing.T) {
+	strict := Policy{
+		MinLength:            8,
+		MaxLength:            16,
+		RequireLower:         true,
+		RequireUpper:         true,
+		RequireDigit:         true,
+		RequireSymbol:        true,
+		ForbidEmailLocalPart: true,
+	}
+	breached := MustBreachedList("Qwerty123!")
+	cases := []struct {
+		TestName string
+		Expected error
+		Rules    []string
+		Policy   Policy
+		Password string
+		Email    string
+	}{
+		{
+			TestName: "test_policy_validator_ok",
+			Expected: nil,
+			Policy:   strict,
+			Password: "Dragon-42-Lair",
+			Email:    "test@mail.com",
+		},
+		{
+			TestName: "test_policy_validator_unicode_ok",
+			Expected: nil,
+			Policy:   strict,
+			Password: "Дракон-42-лог",
+			Email:    "test@mail.com",
+		},
+		{
+			TestName: "test_policy_validator_too_short",
+			Expected: app.ErrInvalidData,
+			Rules:    []string{"не менее 8 символов"},
+			Policy:   strict,
+			Password: "Dr-42-l",
+			Email:    "test@mail.com",
+		},
+		{
+			TestName: "test_policy_validator_too_long",
+			Expected: app.ErrInvalidData,
+			Rules:    []string{"не более 16 символов"},
+			Policy:   strict,
+			Password: "Dragon-42-Lair-Dragon",
+			Email:    "test@mail.com",
+		},
+		{
+			TestName: "test_policy_validator_character_classes",
+			Expected: app.ErrInvalidData,
+			Rules: []string{
+				"заглавную букву",
+				"цифру",
+				"специальный символ",
+			},
+			Policy:   strict,
+			Password: "dragonlair",
+			Email:    "test@mail.com",
+		},
+		{
+			TestName: "test_policy_validator_without_lower",
+			Expected: app.ErrInvalidData,
+			Rules:    []string{"строчную букву"},
+			Policy:   strict,
+			Password: "DRAGON-42-LAIR",
+			Email:    "test@mail.com",
+		},
+		{
+			TestName: "test_policy_validator_email_local_part",
+			Expected: app.ErrInvalidData,
+			Rules:    []string{"имя пользователя из email"},
+			Policy:   strict,
+			Password: "Gandalf-42!",
+			Email:    "gandalf@mail.com",
+		},
+		{
+			TestName: "test_policy_validator_short_email_local_part",
+			Expected: nil,
+			Policy:   strict,
+			Password: "Dragon-42-Lair",
+			Email:    "dr@mail.com",
+		},
+		{
+			TestName: "test_policy_validator_email_local_part_allowed",
+			Expected: nil,
+			Policy: Policy{
+				MinLength: 8,
+				MaxLength: 16,
+			},
+			Password: "gandalf-42",
+			Email:    "gandalf@mail.com",
+		},
+		{
+			TestName: "test_policy_validator_breached",
+			Expected: app.ErrInvalidData,
+			Rules:    []string{"скомпрометированных паролей"},
+			Policy:   strict,
+			Password: "Qwerty123!",
+			Email:    "test@mail.com",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := MustPolicyValidator(c.Policy, breached).Validate(c.Password, c.Email)
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected nil, but got %v", err)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+			var appErr *app.Error
+			if c.Expected != nil &&
+				(!errors.As(err, &appErr) || appErr.Code != app.CodePasswordTooWeak ||
+					appErr.Field != "password" || appErr.Params["rule"] == "") {
+				t.Errorf("expected %s error on password, but got %#v", app.CodePasswordTooWeak, appErr)
+			}
+			for _, rule := range c.Rules {
+				if err == nil || !strings.Contains(err.Error(), rule) {
+					t.Errorf("expected rule %q to fail, but got %v", rule, err)
+				}
+			}
+		})
+	}
+}
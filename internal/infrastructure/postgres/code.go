@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	confirmEmailFlow  = "confirm_email"
+	newEmailFlow      = "new_email"
+	resetPasswordFlow = "reset_password"
+	newPasswordFlow   = "new_password"
+)
+
+type CodeTTL struct {
+	ConfirmEmail  time.Duration
+	NewEmail      time.Duration
+	ResetPassword time.Duration
+	NewPassword   time.Duration
+}
+
+type CodeStore struct {
+	pool        *pgxpool.Pool
+	ttl         map[string]time.Duration
+	maxAttempts int
+}
+
+func MustCodeStore(pool *pgxpool.Pool, ttl CodeTTL, maxAttempts int) *CodeStore {
+	if pool == nil {
+		panic("code store did not get connection pool")
+	}
+	if ttl.ConfirmEmail <= 0 {
+		panic("code store did not get confirm email ttl")
+	}
+	if ttl.NewEmail <= 0 {
+		panic("code store did not get new email ttl")
+	}
+	if ttl.ResetPassword <= 0 {
+		panic("code store did not get reset password ttl")
+	}
+	if ttl.NewPassword <= 0 {
+		panic("code store did not get new password ttl")
+	}
+	if maxAttempts <= 0 {
+		panic("code store did not get max attempts")
+	}
+	return &CodeStore{
+		pool: pool,
+		ttl: map[string]time.Duration{
+			confirmEmailFlow:  ttl.ConfirmEmail,
+			newEmailFlow:      ttl.NewEmail,
+			resetPasswordFlow: ttl.ResetPassword,
+			newPasswordFlow:   ttl.NewPassword,
+		},
+		maxAttempts: maxAttempts,
+	}
+}
+
+func (s *CodeStore) SetConfirmEmail(ctx context.Context, key, code string) error {
+	return s.set(ctx, confirmEmailFlow, key, code)
+}
+
+func (s *CodeStore) ConsumeConfirmEmail(ctx context.Context, key, code string) error {
+	return s.consume(ctx, confirmEmailFlow, key, code)
+}
+
+func (s *CodeStore) SetNewEmail(ctx context.Context, key, code string) error {
+	return s.set(ctx, newEmailFlow, key, code)
+}
+
+func (s *CodeStore) ConsumeNewEmail(ctx context.Context, key, code string) error {
+	return s.consume(ctx, newEmailFlow, key, code)
+}
+
+func (s *CodeStore) SetResetPassword(ctx context.Context, key, code string) error {
+	return s.set(ctx, resetPasswordFlow, key, code)
+}
+
+func (s *CodeStore) ConsumeResetPassword(ctx context.Context, key, code string) error {
+	return s.consume(ctx, resetPasswordFlow, key, code)
+}
+
+func (s *CodeStore) SetNewPassword(ctx context.Context, key, code string) error {
+	return s.set(ctx, newPasswordFlow, key, code)
+}
+
+func (s *CodeStore) ConsumeNewPassword(ctx context.Context, key, code string) error {
+	return s.consume(ctx, newPasswordFlow, key, code)
+}
+
+func (s *CodeStore) set(ctx context.Context, flow, key, code string) error {
+	_, err := s.pool.Exec(
+		ctx,
+		`INSERT INTO codes (flow, key, code, attempts, expires_at)
+		VALUES ($1, $2, $3, 0, now() + $4 * interval '1 microsecond')
+		ON CONFLICT (flow, key) DO UPDATE
+		SET code = EXCLUDED.code, attempts = 0, expires_at = EXCLUDED.expires_at`,
+		flow,
+		key,
+		code,
+		s.ttl[flow].Microseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: сохранение кода: %w", app.ErrInternal, err)
+	}
+	return nil
+}
+
+func (s *CodeStore) consume(ctx context.Context, flow, key, code string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: начало транзакции проверки кода: %w", app.ErrInternal, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var stored string
+	var attempts int
+	err = tx.QueryRow(
+		ctx,
+		`SELECT code, attempts FROM codes
+		WHERE flow = $1 AND key = $2 AND expires_at > now()
+		FOR UPDATE`,
+		flow,
+		key,
+	).Scan(&stored, &attempts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return app.CodeExpiredError()
+	}
+	if err != nil {
+		return fmt.Errorf("%w: получение кода: %w", app.ErrInternal, err)
+	}
+
+	var result error
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(code)) == 1 {
+		_, err = tx.Exec(ctx, `DELETE FROM codes WHERE flow = $1 AND key = $2`, flow, key)
+	} else if attempts+1 >= s.maxAttempts {
+		_, err = tx.Exec(ctx, `DELETE FROM codes WHERE flow = $1 AND key = $2`, flow, key)
+		result = app.CodeExhaustedError()
+	} else {
+		_, err = tx.Exec(
+			ctx,
+			`UPDATE codes SET attempts = attempts + 1 WHERE flow = $1 AND key = $2`,
+			flow,
+			key,
+		)
+		result = app.CodeInvalidError()
+	}
+	if err != nil {
+		return fmt.Errorf("%w: обновление кода: %w", app.ErrInternal, err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: фиксация транзакции проверки кода: %w", app.ErrInternal, err)
+	}
+	return result
+}
+
+func (s *CodeStore) PurgeExpired(ctx context.Context) (int, error) {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM codes WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, fmt.Errorf("%w: удаление истекших кодов: %w", app.ErrInternal, err)
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+func testCodeStore(t *testing.T) *CodeStore {
+	t.Helper()
+	pool := testPool(t)
+	if _, err := pool.Exec(context.Background(), `TRUNCATE codes`); err != nil {
+		t.Fatalf("truncate codes table: %v", err)
+	}
+	return MustCodeStore(pool, CodeTTL{
+		ConfirmEmail:  time.Minute,
+		NewEmail:      time.Minute,
+		ResetPassword: time.Minute,
+		NewPassword:   time.Minute,
+	}, 3)
+}
+
+func TestCodeStore_Consume(t *testing.T) {
+	store := testCodeStore(t)
+	ctx := context.Background()
+	key := "test@mail.com"
+	if err := store.SetResetPassword(ctx, key, "123456"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	cases := []struct {
+		TestName string
+		Expected error
+		Consume  func() error
+	}{
+		{
+			TestName: "test_code_store_other_flow",
+			Expected: app.ErrNotFound,
+			Consume:  func() error { return store.ConsumeNewPassword(ctx, key, "123456") },
+		},
+		{
+			TestName: "test_code_store_wrong_code",
+			Expected: app.ErrInvalidData,
+			Consume:  func() error { return store.ConsumeResetPassword(ctx, key, "000000") },
+		},
+		{
+			TestName: "test_code_store_ok",
+			Expected: nil,
+			Consume:  func() error { return store.ConsumeResetPassword(ctx, key, "123456") },
+		},
+		{
+			TestName: "test_code_store_already_consumed",
+			Expected: app.ErrNotFound,
+			Consume:  func() error { return store.ConsumeResetPassword(ctx, key, "123456") },
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			if err := c.Consume(); !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+}
+
+func TestCodeStore_Attempts(t *testing.T) {
+	store := testCodeStore(t)
+	ctx := context.Background()
+	key := "test@mail.com"
+	if err := store.SetConfirmEmail(ctx, key, "123456"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	for range 3 {
+		err := store.ConsumeConfirmEmail(ctx, key, "000000")
+		if !errors.Is(err, app.ErrInvalidData) {
+			t.Fatalf("expected %T, but got %v", app.ErrInvalidData, err)
+		}
+	}
+	if err := store.ConsumeConfirmEmail(ctx, key, "123456"); !errors.Is(err, app.ErrNotFound) {
+		t.Errorf("expected code to be invalidated, but got %v", err)
+	}
+}
+
+func TestCodeStore_ConcurrentConsume(t *testing.T) {
+	store := testCodeStore(t)
+	ctx := context.Background()
+	key := "test@mail.com"
+	if err := store.SetResetPassword(ctx, key, "123456"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	for range 8 {
+		wg.Go(func() {
+			if err := store.ConsumeResetPassword(ctx, key, "123456"); err == nil {
+				succeeded.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+	if succeeded.Load() != 1 {
+		t.Errorf("expected exactly one successful consume, but got %d", succeeded.Load())
+	}
+}
+
+func TestCodeStore_PurgeExpired(t *testing.T) {
+	store := testCodeStore(t)
+	ctx := context.Background()
+	_, err := store.pool.Exec(
+		ctx,
+		`INSERT INTO codes (flow, key, code, attempts, expires_at)
+		VALUES ('confirm_email', 'old@mail.com', '111111', 0, now() - interval '1 second')`,
+	)
+	if err != nil {
+		t.Fatalf("insert expired code: %v", err)
+	}
+	if err = store.SetConfirmEmail(ctx, "key@mail.com", "222222"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	purged, err := store.PurgeExpired(ctx)
+	if err != nil || purged != 1 {
+		t.Fatalf("expected one purged code, but got %d, %v", purged, err)
+	}
+	if err = store.ConsumeConfirmEmail(ctx, "key@mail.com", "222222"); err != nil {
+		t.Errorf("expected live code to survive purge, but got %v", err)
+	}
+}
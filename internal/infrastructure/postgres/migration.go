@@ -0,0 +1,296 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const migrationLockID = 7_240_518_391
+
+var ErrInvalidMigration = errors.New("некорректная миграция")
+
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   uint
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+func MustMigrator(pool *pgxpool.Pool) *Migrator {
+	if pool == nil {
+		panic("migrator did not get connection pool")
+	}
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		panic(fmt.Sprintf("migrator could not open embedded migrations: %s", err))
+	}
+	migrations, err := loadMigrations(sub)
+	if err != nil {
+		panic(fmt.Sprintf("migrator could not load embedded migrations: %s", err))
+	}
+	return &Migrator{pool: pool, migrations: migrations}
+}
+
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+			if err = applyMigration(ctx, conn, migration); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+			if err = revertMigration(ctx, conn, migration); err != nil {
+				return err
+			}
+			reverted = &migration
+			return nil
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		statuses = make([]MigrationStatus, 0, len(m.migrations))
+		for _, migration := range m.migrations {
+			appliedAt, ok := versions[migration.Version]
+			statuses = append(statuses, MigrationStatus{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				Applied:   ok,
+				AppliedAt: appliedAt,
+			})
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("получение соединения для миграций: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("блокировка миграций: %w", err)
+	}
+	defer conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	if _, err = conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`); err != nil {
+		return fmt.Errorf("создание таблицы миграций: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[uint]time.Time, error) {
+	rows, err := conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("получение примененных миграций: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[uint]time.Time)
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("чтение примененных миграций: %w", err)
+		}
+		versions[uint(version)] = appliedAt
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("чтение примененных миграций: %w", err)
+	}
+	return versions, nil
+}
+
+func applyMigration(ctx context.Context, conn *pgxpool.Conn, migration Migration) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, migration.Up); err != nil {
+			return fmt.Errorf("применение миграции %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if _, err := tx.Exec(
+			ctx,
+			`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+			int64(migration.Version),
+			migration.Name,
+		); err != nil {
+			return fmt.Errorf("запись миграции %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		return nil
+	})
+}
+
+func revertMigration(ctx context.Context, conn *pgxpool.Conn, migration Migration) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, migration.Down); err != nil {
+			return fmt.Errorf("откат миграции %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if _, err := tx.Exec(
+			ctx,
+			`DELETE FROM schema_migrations WHERE version = $1`,
+			int64(migration.Version),
+		); err != nil {
+			return fmt.Errorf("удаление записи миграции %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		return nil
+	})
+}
+
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("%w: чтение каталога миграций: %w", ErrInvalidMigration, err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		version, name, direction, err := parseMigrationName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%w: чтение файла %s: %w", ErrInvalidMigration, entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf(
+				"%w: у версии %d разные названия %s и %s",
+				ErrInvalidMigration,
+				version,
+				migration.Name,
+				name,
+			)
+		}
+		switch direction {
+		case "up":
+			migration.Up = string(content)
+		case "down":
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			return nil, fmt.Errorf(
+				"%w: у миграции %04d_%s должны быть up и down файлы",
+				ErrInvalidMigration,
+				migration.Version,
+				migration.Name,
+			)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return int(a.Version) - int(b.Version)
+	})
+	return migrations, nil
+}
+
+func parseMigrationName(filename string) (uint, string, string, error) {
+	base := strings.TrimSuffix(filename, ".sql")
+	direction := path.Ext(base)
+	if direction != ".up" && direction != ".down" {
+		return 0, "", "", fmt.Errorf(
+			"%w: файл %s должен оканчиваться на .up.sql или .down.sql",
+			ErrInvalidMigration,
+			filename,
+		)
+	}
+	base = strings.TrimSuffix(base, direction)
+
+	rawVersion, name, ok := strings.Cut(base, "_")
+	if !ok || name == "" {
+		return 0, "", "", fmt.Errorf(
+			"%w: файл %s должен называться <версия>_<название>",
+			ErrInvalidMigration,
+			filename,
+		)
+	}
+	version, err := strconv.ParseUint(rawVersion, 10, 32)
+	if err != nil || version == 0 {
+		return 0, "", "", fmt.Errorf(
+			"%w: у файла %s некорректная версия %s",
+			ErrInvalidMigration,
+			filename,
+			rawVersion,
+		)
+	}
+	return uint(version), name, strings.TrimPrefix(direction, "."), nil
+}
@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMigration_LoadMigrations(t *testing.T) {
+	sql := &fstest.MapFile{Data: []byte("SELECT 1;")}
+	cases := []struct {
+		TestName string
+		Expected error
+		Versions []uint
+		FS       fstest.MapFS
+	}{
+		{
+			TestName: "test_load_migrations_ok",
+			Expected: nil,
+			Versions: []uint{1, 2, 10},
+			FS: fstest.MapFS{
+				"0010_add_index.up.sql":      sql,
+				"0010_add_index.down.sql":    sql,
+				"0002_add_column.up.sql":     sql,
+				"0002_add_column.down.sql":   sql,
+				"0001_create_users.up.sql":   sql,
+				"0001_create_users.down.sql": sql,
+				"README.md":                  sql,
+			},
+		},
+		{
+			TestName: "test_load_migrations_without_down",
+			Expected: ErrInvalidMigration,
+			FS: fstest.MapFS{
+				"0001_create_users.up.sql": sql,
+			},
+		},
+		{
+			TestName: "test_load_migrations_empty_up",
+			Expected: ErrInvalidMigration,
+			FS: fstest.MapFS{
+				"0001_create_users.up.sql":   &fstest.MapFile{Data: []byte("  \n")},
+				"0001_create_users.down.sql": sql,
+			},
+		},
+		{
+			TestName: "test_load_migrations_invalid_direction",
+			Expected: ErrInvalidMigration,
+			FS: fstest.MapFS{
+				"0001_create_users.sql": sql,
+			},
+		},
+		{
+			TestName: "test_load_migrations_invalid_version",
+			Expected: ErrInvalidMigration,
+			FS: fstest.MapFS{
+				"first_create_users.up.sql":   sql,
+				"first_create_users.down.sql": sql,
+			},
+		},
+		{
+			TestName: "test_load_migrations_zero_version",
+			Expected: ErrInvalidMigration,
+			FS: fstest.MapFS{
+				"0000_create_users.up.sql":   sql,
+				"0000_create_users.down.sql": sql,
+			},
+		},
+		{
+			TestName: "test_load_migrations_without_name",
+			Expected: ErrInvalidMigration,
+			FS: fstest.MapFS{
+				"0001.up.sql":   sql,
+				"0001.down.sql": sql,
+			},
+		},
+		{
+			TestName: "test_load_migrations_different_names",
+			Expected: ErrInvalidMigration,
+			FS: fstest.MapFS{
+				"0001_create_users.up.sql":  sql,
+				"0001_create_people.up.sql": sql,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			migrations, err := loadMigrations(c.FS)
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected nil, but got %v", err)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+			if len(migrations) != len(c.Versions) {
+				t.Fatalf("expected %d migrations, but got %d", len(c.Versions), len(migrations))
+			}
+			for i, version := range c.Versions {
+				if migrations[i].Version != version {
+					t.Errorf("expected version %d at %d, but got %d", version, i, migrations[i].Version)
+				}
+			}
+		})
+	}
+}
+
+func TestMigration_EmbeddedMigrations(t *testing.T) {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("open embedded migrations: %v", err)
+	}
+	migrations, err := loadMigrations(sub)
+	if err != nil {
+		t.Fatalf("load embedded migrations: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("expected embedded migrations, but got none")
+	}
+	for i, migration := range migrations {
+		if migration.Version != uint(i+1) {
+			t.Errorf("expected version %d, but got %d", i+1, migration.Version)
+		}
+	}
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	migrator := MustMigrator(pool)
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			t.Errorf("expected migration %d to be applied", status.Version)
+		}
+	}
+
+	last := migrator.migrations[len(migrator.migrations)-1]
+	reverted, err := migrator.Down(ctx)
+	if err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if reverted == nil || reverted.Version != last.Version {
+		t.Fatalf("expected migration %d to be reverted, but got %v", last.Version, reverted)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if len(applied) != 1 || applied[0].Version != last.Version {
+		t.Fatalf("expected migration %d to be applied, but got %v", last.Version, applied)
+	}
+}
@@ -0,0 +1,12 @@
+CREATE TABLE users (
+    id uuid PRIMARY KEY,
+    email text NOT NULL,
+    state text NOT NULL CONSTRAINT users_state_check CHECK (state IN ('active', 'frozen', 'deleted')),
+    status text NOT NULL CONSTRAINT users_status_check CHECK (status IN ('admin', 'user')),
+    password_hash text NOT NULL,
+    version bigint NOT NULL CONSTRAINT users_version_check CHECK (version > 0),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX users_email_key ON users (email);
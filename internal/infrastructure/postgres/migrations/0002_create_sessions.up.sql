@@ -0,0 +1,14 @@
+CREATE TABLE sessions (
+    id uuid PRIMARY KEY,
+    family_id uuid NOT NULL,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash text NOT NULL,
+    used boolean NOT NULL DEFAULT false,
+    revoked boolean NOT NULL DEFAULT false,
+    created_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX sessions_token_hash_key ON sessions (token_hash);
+CREATE INDEX sessions_family_id_idx ON sessions (family_id);
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
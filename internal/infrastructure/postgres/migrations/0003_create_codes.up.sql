@@ -0,0 +1,10 @@
+CREATE TABLE codes (
+    flow text NOT NULL,
+    key text NOT NULL,
+    code text NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    expires_at timestamptz NOT NULL,
+    PRIMARY KEY (flow, key)
+);
+
+CREATE INDEX codes_expires_at_idx ON codes (expires_at);
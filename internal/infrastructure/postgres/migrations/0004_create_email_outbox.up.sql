@@ -0,0 +1,13 @@
+CREATE TABLE email_outbox (
+    id uuid PRIMARY KEY,
+    kind text NOT NULL,
+    recipient text NOT NULL,
+    code text NOT NULL,
+    status text NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    last_error text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL,
+    next_attempt_at timestamptz NOT NULL
+);
+
+CREATE INDEX email_outbox_status_next_attempt_at_idx ON email_outbox (status, next_attempt_at);
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    ADD COLUMN locale text NOT NULL DEFAULT 'ru' CONSTRAINT users_locale_check CHECK (locale IN ('ru', 'en'));
+
+ALTER TABLE email_outbox ADD COLUMN locale text NOT NULL DEFAULT 'ru';
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_totp_check,
+    DROP COLUMN IF EXISTS totp_last_step,
+    DROP COLUMN IF EXISTS totp_enabled,
+    DROP COLUMN IF EXISTS totp_secret;
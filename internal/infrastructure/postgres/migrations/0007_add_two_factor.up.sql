@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN totp_secret text NOT NULL DEFAULT '',
+    ADD COLUMN totp_enabled boolean NOT NULL DEFAULT false,
+    ADD COLUMN totp_last_step bigint NOT NULL DEFAULT 0,
+    ADD CONSTRAINT users_totp_check CHECK (NOT totp_enabled OR totp_secret <> '');
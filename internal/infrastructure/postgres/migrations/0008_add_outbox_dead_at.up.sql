@@ -0,0 +1,3 @@
+ALTER TABLE email_outbox ADD COLUMN dead_at timestamptz;
+
+UPDATE email_outbox SET dead_at = now(), code = '' WHERE status = 'dead';
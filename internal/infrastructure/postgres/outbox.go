@@ -0,0 +1,268 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EmailOutbox struct {
+	pool *pgxpool.Pool
+}
+
+func MustEmailOutbox(pool *pgxpool.Pool) *EmailOutbox {
+	if pool == nil {
+		panic("email outbox did not get connection pool")
+	}
+	return &EmailOutbox{pool: pool}
+}
+
+func (o *EmailOutbox) SendConfirmationEmail(ctx context.Context, data app.EmailCode) error {
+	return o.enqueue(ctx, app.ConfirmationEmail, data)
+}
+
+func (o *EmailOutbox) SendConfirmationNewEmail(ctx context.Context, data []app.EmailCode) error {
+	return o.enqueue(ctx, app.NewEmailConfirmation, data...)
+}
+
+func (o *EmailOutbox) SendResetPasswordEmail(ctx context.Context, data app.EmailCode) error {
+	return o.enqueue(ctx, app.ResetPasswordEmail, data)
+}
+
+func (o *EmailOutbox) SendConfirmationNewPassword(ctx context.Context, data app.EmailCode) error {
+	return o.enqueue(ctx, app.NewPasswordConfirmation, data)
+}
+
+func (o *EmailOutbox) SendRegistrationAttemptEmail(ctx context.Context, data app.EmailCode) error {
+	return o.enqueue(ctx, app.RegistrationAttempt, data)
+}
+
+func (o *EmailOutbox) SendUnknownAccountResetEmail(ctx context.Context, data app.EmailCode) error {
+	return o.enqueue(ctx, app.UnknownAccountReset, data)
+}
+
+func (o *EmailOutbox) enqueue(ctx context.Context, kind app.EmailKind, data ...app.EmailCode) error {
+	batch := &pgx.Batch{}
+	for _, d := range data {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return fmt.Errorf("%w: не удалось сгенерировать id письма: %w", app.ErrInternal, err)
+		}
+		batch.Queue(
+			`INSERT INTO email_outbox
+			(id, kind, recipient, code, locale, status, created_at, next_attempt_at)
+			VALUES ($1, $2, $3, $4, $5, $6, now(), now())`,
+			id,
+			kind,
+			d.To,
+			d.Code,
+			d.Locale,
+			app.OutboxPending,
+		)
+	}
+
+	tx, err := o.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: начало транзакции записи писем: %w", app.ErrInternal, err)
+	}
+	defer tx.Rollback(ctx)
+	if err = tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("%w: запись письма в очередь: %w", app.ErrInternal, err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: фиксация транзакции записи писем: %w", app.ErrInternal, err)
+	}
+	return nil
+}
+
+func (o *EmailOutbox) Claim(
+	ctx context.Context,
+	limit int,
+	lease time.Duration,
+) ([]app.OutboxEmail, error) {
+	rows, err := o.pool.Query(
+		ctx,
+		`UPDATE email_outbox SET next_attempt_at = now() + $3 * interval '1 microsecond'
+		WHERE id IN (
+			SELECT id FROM email_outbox
+			WHERE status = $1 AND next_attempt_at <= now()
+			ORDER BY next_attempt_at, id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, kind, recipient, code, locale, status, attempts, last_error,
+			created_at, next_attempt_at, dead_at`,
+		app.OutboxPending,
+		limit,
+		lease.Microseconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: выборка писем для отправки: %w", app.ErrInternal, err)
+	}
+	return collectOutboxEmails(rows)
+}
+
+func (o *EmailOutbox) MarkDelivered(ctx context.Context, id uuid.UUID) error {
+	if _, err := o.pool.Exec(ctx, `DELETE FROM email_outbox WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("%w: удаление отправленного письма: %w", app.ErrInternal, err)
+	}
+	return nil
+}
+
+func (o *EmailOutbox) Retry(
+	ctx context.Context,
+	id uuid.UUID,
+	lastError string,
+	delay time.Duration,
+) error {
+	tag, err := o.pool.Exec(
+		ctx,
+		`UPDATE email_outbox
+		SET attempts = attempts + 1,
+			last_error = $2,
+			next_attempt_at = now() + $3 * interval '1 microsecond'
+		WHERE id = $1`,
+		id,
+		lastError,
+		delay.Microseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: перенос отправки письма: %w", app.ErrInternal, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: письмо с id %s не найдено", app.ErrNotFound, id)
+	}
+	return nil
+}
+
+func (o *EmailOutbox) Bury(ctx context.Context, id uuid.UUID, lastError string) error {
+	tag, err := o.pool.Exec(
+		ctx,
+		`UPDATE email_outbox
+		SET attempts = attempts + 1, last_error = $2, status = $3, code = '', dead_at = now()
+		WHERE id = $1`,
+		id,
+		lastError,
+		app.OutboxDead,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: перевод письма в недоставленные: %w", app.ErrInternal, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: письмо с id %s не найдено", app.ErrNotFound, id)
+	}
+	return nil
+}
+
+func (o *EmailOutbox) List(
+	ctx context.Context,
+	status app.OutboxStatus,
+	limit int,
+) ([]app.OutboxEmail, error) {
+	rows, err := o.pool.Query(
+		ctx,
+		`SELECT id, kind, recipient, code, locale, status, attempts, last_error,
+			created_at, next_attempt_at, dead_at
+		FROM email_outbox WHERE status = $1
+		ORDER BY created_at, id
+		LIMIT $2`,
+		status,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: получение писем: %w", app.ErrInternal, err)
+	}
+	return collectOutboxEmails(rows)
+}
+
+func (o *EmailOutbox) Replay(ctx context.Context, id uuid.UUID) error {
+	tx, err := o.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: начало транзакции повторной отправки: %w", app.ErrInternal, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var kind app.EmailKind
+	err = tx.QueryRow(
+		ctx,
+		`SELECT kind FROM email_outbox WHERE id = $1 AND status = $2 FOR UPDATE`,
+		id,
+		app.OutboxDead,
+	).Scan(&kind)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: недоставленное письмо с id %s не найдено", app.ErrNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: получение недоставленного письма: %w", app.ErrInternal, err)
+	}
+	if kind.HasCode() {
+		return fmt.Errorf(
+			"%w: код из письма %s удален, пользователь должен запросить новый",
+			app.ErrNotAllowed,
+			id,
+		)
+	}
+	_, err = tx.Exec(
+		ctx,
+		`UPDATE email_outbox
+		SET status = $2, attempts = 0, next_attempt_at = now(), dead_at = NULL
+		WHERE id = $1`,
+		id,
+		app.OutboxPending,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: повторная отправка письма: %w", app.ErrInternal, err)
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: фиксация повторной отправки: %w", app.ErrInternal, err)
+	}
+	return nil
+}
+
+func (o *EmailOutbox) PurgeDead(ctx context.Context, retention time.Duration) (int, error) {
+	tag, err := o.pool.Exec(
+		ctx,
+		`DELETE FROM email_outbox
+		WHERE status = $1 AND dead_at <= now() - $2 * interval '1 microsecond'`,
+		app.OutboxDead,
+		retention.Microseconds(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%w: удаление недоставленных писем: %w", app.ErrInternal, err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func collectOutboxEmails(rows pgx.Rows) ([]app.OutboxEmail, error) {
+	emails, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (app.OutboxEmail, error) {
+		var email app.OutboxEmail
+		var deadAt *time.Time
+		err := row.Scan(
+			&email.ID,
+			&email.Kind,
+			&email.To,
+			&email.Code,
+			&email.Locale,
+			&email.Status,
+			&email.Attempts,
+			&email.LastError,
+			&email.CreatedAt,
+			&email.NextAttemptAt,
+			&deadAt,
+		)
+		if deadAt != nil {
+			email.DeadAt = *deadAt
+		}
+		return email, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: чтение писем: %w", app.ErrInternal, err)
+	}
+	return emails, nil
+}
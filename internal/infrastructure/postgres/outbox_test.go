@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+)
+
+func testEmailOutbox(t *testing.T) *EmailOutbox {
+	t.Helper()
+	pool := testPool(t)
+	if _, err := pool.Exec(context.Background(), `TRUNCATE email_outbox`); err != nil {
+		t.Fatalf("truncate email outbox table: %v", err)
+	}
+	return MustEmailOutbox(pool)
+}
+
+func TestEmailOutbox_Claim(t *testing.T) {
+	outbox := testEmailOutbox(t)
+	ctx := context.Background()
+	err := outbox.SendConfirmationNewEmail(
+		ctx,
+		[]app.EmailCode{{To: "a@mail.com", Code: "1"}, {To: "b@mail.com", Code: "2"}},
+	)
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	claimed, err := outbox.Claim(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if len(claimed) != 2 || claimed[0].Kind != app.NewEmailConfirmation ||
+		claimed[0].Status != app.OutboxPending {
+		t.Fatalf("expected two pending emails, but got %+v", claimed)
+	}
+	again, err := outbox.Claim(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if len(again) != 0 {
+		t.Errorf("expected leased emails to be skipped, but got %+v", again)
+	}
+
+	if err = outbox.MarkDelivered(ctx, claimed[0].ID); err != nil {
+		t.Fatalf("mark delivered: %v", err)
+	}
+	if err = outbox.Retry(ctx, claimed[1].ID, "timeout", 0); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	retried, err := outbox.Claim(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if len(retried) != 1 || retried[0].Attempts != 1 || retried[0].LastError != "timeout" {
+		t.Errorf("expected only retried email, but got %+v", retried)
+	}
+}
+
+func TestEmailOutbox_Replay(t *testing.T) {
+	outbox := testEmailOutbox(t)
+	ctx := context.Background()
+	if err := outbox.SendRegistrationAttemptEmail(ctx, app.EmailCode{To: "a@mail.com"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	claimed, err := outbox.Claim(ctx, 10, time.Minute)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("claim: %v %+v", err, claimed)
+	}
+	id := claimed[0].ID
+	if err = outbox.Bury(ctx, id, "rejected"); err != nil {
+		t.Fatalf("bury: %v", err)
+	}
+	dead, err := outbox.List(ctx, app.OutboxDead, 10)
+	if err != nil || len(dead) != 1 || dead[0].LastError != "rejected" {
+		t.Fatalf("expected dead email in list, but got %+v %v", dead, err)
+	}
+
+	cases := []struct {
+		TestName string
+		Expected error
+		ID       uuid.UUID
+	}{
+		{TestName: "test_email_outbox_replay_dead", Expected: nil, ID: id},
+		{TestName: "test_email_outbox_replay_pending", Expected: app.ErrNotFound, ID: id},
+		{TestName: "test_email_outbox_replay_unknown", Expected: app.ErrNotFound, ID: uuid.New()},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := outbox.Replay(ctx, c.ID)
+			if c.Expected == nil && err != nil {
+				t.Errorf("expected nil, but got %v", err)
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+	claimed, err = outbox.Claim(ctx, 10, time.Minute)
+	if err != nil || len(claimed) != 1 || claimed[0].Attempts != 0 {
+		t.Errorf("expected replayed email with reset attempts, but got %+v %v", claimed, err)
+	}
+}
+
+func TestEmailOutbox_PurgeDead(t *testing.T) {
+	outbox := testEmailOutbox(t)
+	ctx := context.Background()
+	err := outbox.SendResetPasswordEmail(ctx, app.EmailCode{To: "a@mail.com", Code: "123456"})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	claimed, err := outbox.Claim(ctx, 10, time.Minute)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("claim: %v %+v", err, claimed)
+	}
+	_, err = outbox.pool.Exec(
+		ctx,
+		`UPDATE email_outbox SET created_at = now() - interval '2 hours' WHERE id = $1`,
+		claimed[0].ID,
+	)
+	if err != nil {
+		t.Fatalf("age email: %v", err)
+	}
+	if err = outbox.Bury(ctx, claimed[0].ID, "rejected"); err != nil {
+		t.Fatalf("bury: %v", err)
+	}
+	dead, err := outbox.List(ctx, app.OutboxDead, 10)
+	if err != nil || len(dead) != 1 || dead[0].Code != "" || dead[0].DeadAt.IsZero() {
+		t.Fatalf("expected buried email without code, but got %+v %v", dead, err)
+	}
+	if err = outbox.Replay(ctx, claimed[0].ID); !errors.Is(err, app.ErrNotAllowed) {
+		t.Errorf("expected %v for email with discarded code, but got %v", app.ErrNotAllowed, err)
+	}
+
+	if purged, err := outbox.PurgeDead(ctx, time.Hour); err != nil || purged != 0 {
+		t.Fatalf("expected recently buried email to be kept, but got %d %v", purged, err)
+	}
+	_, err = outbox.pool.Exec(
+		ctx,
+		`UPDATE email_outbox SET dead_at = now() - interval '2 hours' WHERE id = $1`,
+		claimed[0].ID,
+	)
+	if err != nil {
+		t.Fatalf("age dead email: %v", err)
+	}
+	if purged, err := outbox.PurgeDead(ctx, time.Hour); err != nil || purged != 1 {
+		t.Fatalf("expected old dead email to be purged, but got %d %v", purged, err)
+	}
+}
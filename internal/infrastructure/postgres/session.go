@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SessionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func MustSessionRepository(pool *pgxpool.Pool) *SessionRepository {
+	if pool == nil {
+		panic("session repository did not get connection pool")
+	}
+	return &SessionRepository{pool: pool}
+}
+
+func (r *SessionRepository) Save(ctx context.Context, session *app.Session) error {
+	if session == nil {
+		return fmt.Errorf("%w: получен nil для сохранения сессии", app.ErrInternal)
+	}
+	return saveSession(ctx, r.pool, session)
+}
+
+func (r *SessionRepository) ByTokenHash(ctx context.Context, tokenHash string) (*app.Session, error) {
+	var session app.Session
+	err := r.pool.QueryRow(
+		ctx,
+		`SELECT id, family_id, user_id, token_hash, used, revoked, created_at, expires_at
+		FROM sessions WHERE token_hash = $1`,
+		tokenHash,
+	).Scan(
+		&session.ID,
+		&session.FamilyID,
+		&session.UserID,
+		&session.TokenHash,
+		&session.Used,
+		&session.Revoked,
+		&session.CreatedAt,
+		&session.ExpiresAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: сессия не найдена", app.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: получение сессии: %w", app.ErrInternal, err)
+	}
+	return &session, nil
+}
+
+func (r *SessionRepository) Rotate(ctx context.Context, usedID uuid.UUID, next *app.Session) error {
+	if next == nil {
+		return fmt.Errorf("%w: получен nil для сохранения сессии", app.ErrInternal)
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: начало транзакции ротации сессии: %w", app.ErrInternal, err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(
+		ctx,
+		`UPDATE sessions SET used = true WHERE id = $1 AND NOT used AND NOT revoked`,
+		usedID,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: использование сессии: %w", app.ErrInternal, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: сессия с id %s уже использована или отозвана", app.ErrConflict, usedID)
+	}
+	if err = saveSession(ctx, tx, next); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: фиксация ротации сессии: %w", app.ErrInternal, err)
+	}
+	return nil
+}
+
+func (r *SessionRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	if _, err := r.pool.Exec(
+		ctx,
+		`UPDATE sessions SET revoked = true WHERE family_id = $1 AND NOT revoked`,
+		familyID,
+	); err != nil {
+		return fmt.Errorf("%w: отзыв семейства сессий: %w", app.ErrInternal, err)
+	}
+	return nil
+}
+
+func (r *SessionRepository) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	if _, err := r.pool.Exec(
+		ctx,
+		`UPDATE sessions SET revoked = true WHERE user_id = $1 AND NOT revoked`,
+		userID,
+	); err != nil {
+		return fmt.Errorf("%w: отзыв сессий пользователя: %w", app.ErrInternal, err)
+	}
+	return nil
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func saveSession(ctx context.Context, db execer, session *app.Session) error {
+	_, err := db.Exec(
+		ctx,
+		`INSERT INTO sessions (id, family_id, user_id, token_hash, used, revoked, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO UPDATE
+		SET used = EXCLUDED.used, revoked = EXCLUDED.revoked, expires_at = EXCLUDED.expires_at`,
+		session.ID,
+		session.FamilyID,
+		session.UserID,
+		session.TokenHash,
+		session.Used,
+		session.Revoked,
+		session.CreatedAt,
+		session.ExpiresAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: сессия с таким токеном уже существует", app.ErrAlreadyExists)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: сохранение сессии: %w", app.ErrInternal, err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+)
+
+func newTestSession(userID, familyID uuid.UUID) *app.Session {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	return &app.Session{
+		ID:        uuid.New(),
+		FamilyID:  familyID,
+		UserID:    userID,
+		TokenHash: uuid.NewString(),
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+}
+
+func TestSessionRepository_Rotate(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	user := newTestUser("sessions@mail.com")
+	if err := MustUserRepository(pool).Save(ctx, user); err != nil {
+		t.Fatalf("save user: %v", err)
+	}
+	repo := MustSessionRepository(pool)
+	familyID := uuid.New()
+	first := newTestSession(user.ID, familyID)
+	if err := repo.Save(ctx, first); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	second := newTestSession(user.ID, familyID)
+	cases := []struct {
+		TestName string
+		Expected error
+		UsedID   uuid.UUID
+		Next     *app.Session
+	}{
+		{TestName: "test_session_rotate_ok", Expected: nil, UsedID: first.ID, Next: second},
+		{
+			TestName: "test_session_rotate_already_used",
+			Expected: app.ErrConflict,
+			UsedID:   first.ID,
+			Next:     newTestSession(user.ID, familyID),
+		},
+		{
+			TestName: "test_session_rotate_duplicate_hash",
+			Expected: app.ErrAlreadyExists,
+			UsedID:   second.ID,
+			Next: &app.Session{
+				ID:        uuid.New(),
+				FamilyID:  familyID,
+				UserID:    user.ID,
+				TokenHash: first.TokenHash,
+				CreatedAt: first.CreatedAt,
+				ExpiresAt: first.ExpiresAt,
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := repo.Rotate(ctx, c.UsedID, c.Next)
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected nil, but got %v", err)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+
+	stored, err := repo.ByTokenHash(ctx, second.TokenHash)
+	if err != nil || stored.Used {
+		t.Errorf("expected rotation with duplicate hash to be rolled back, got %v, %v", stored, err)
+	}
+}
+
+func TestSessionRepository_Revoke(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	user := newTestUser("revoke@mail.com")
+	if err := MustUserRepository(pool).Save(ctx, user); err != nil {
+		t.Fatalf("save user: %v", err)
+	}
+	repo := MustSessionRepository(pool)
+	familyID := uuid.New()
+	inFamily := newTestSession(user.ID, familyID)
+	outOfFamily := newTestSession(user.ID, uuid.New())
+	for _, session := range []*app.Session{inFamily, outOfFamily} {
+		if err := repo.Save(ctx, session); err != nil {
+			t.Fatalf("save session: %v", err)
+		}
+	}
+
+	if err := repo.RevokeFamily(ctx, familyID); err != nil {
+		t.Fatalf("revoke family: %v", err)
+	}
+	stored, _ := repo.ByTokenHash(ctx, inFamily.TokenHash)
+	if !stored.Revoked {
+		t.Error("expected session in family to be revoked")
+	}
+	stored, _ = repo.ByTokenHash(ctx, outOfFamily.TokenHash)
+	if stored.Revoked {
+		t.Error("expected session outside family not to be revoked")
+	}
+
+	if err := repo.RevokeUser(ctx, user.ID); err != nil {
+		t.Fatalf("revoke user: %v", err)
+	}
+	stored, _ = repo.ByTokenHash(ctx, outOfFamily.TokenHash)
+	if !stored.Revoked {
+		t.Error("expected every session of user to be revoked")
+	}
+	if _, err := repo.ByTokenHash(ctx, "unknown"); !errors.Is(err, app.ErrNotFound) {
+		t.Errorf("expected %T, but got %v", app.ErrNotFound, err)
+	}
+}
@@ -0,0 +1,302 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const uniqueViolation = "23505"
+
+type UserRepository struct {
+	pool *pgxpool.Pool
+}
+
+func MustUserRepository(pool *pgxpool.Pool) *UserRepository {
+	if pool == nil {
+		panic("user repository did not get connection pool")
+	}
+	return &UserRepository{pool: pool}
+}
+
+func (r *UserRepository) NextID(ctx context.Context) (uuid.UUID, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: не удалось сгенерировать id пользователя: %w", app.ErrInternal, err)
+	}
+	return id, nil
+}
+
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	var exists bool
+	err := r.pool.QueryRow(
+		ctx,
+		`SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`,
+		email,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("%w: проверка существования email: %w", app.ErrInternal, err)
+	}
+	return exists, nil
+}
+
+func (r *UserRepository) IDExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.pool.QueryRow(
+		ctx,
+		`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`,
+		id,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("%w: проверка существования id: %w", app.ErrInternal, err)
+	}
+	return exists, nil
+}
+
+func (r *UserRepository) ByID(ctx context.Context, id uuid.UUID) (*app.User, error) {
+	row := r.pool.QueryRow(
+		ctx,
+		`SELECT id, email, state, status, locale, password_hash,
+			totp_secret, totp_enabled, totp_last_step, version
+		FROM users WHERE id = $1`,
+		id,
+	)
+	user, err := scanUser(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: пользователь с id %s не найден", app.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: получение пользователя по id: %w", app.ErrInternal, err)
+	}
+	return user, nil
+}
+
+func (r *UserRepository) ByEmail(ctx context.Context, email string) (*app.User, error) {
+	row := r.pool.QueryRow(
+		ctx,
+		`SELECT id, email, state, status, locale, password_hash,
+			totp_secret, totp_enabled, totp_last_step, version
+		FROM users WHERE email = $1`,
+		email,
+	)
+	user, err := scanUser(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: пользователь с email %s не найден", app.ErrNotFound, email)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: получение пользователя по email: %w", app.ErrInternal, err)
+	}
+	return user, nil
+}
+
+func (r *UserRepository) Save(ctx context.Context, user *app.User) error {
+	if user == nil {
+		return fmt.Errorf("%w: получен nil для сохранения пользователя", app.ErrInternal)
+	}
+	if user.Version == 0 {
+		return fmt.Errorf("%w: версия пользователя не может быть равна 0", app.ErrInternal)
+	}
+	if user.Version == 1 {
+		return r.insert(ctx, user)
+	}
+	return r.update(ctx, user)
+}
+
+func (r *UserRepository) insert(ctx context.Context, user *app.User) error {
+	_, err := r.pool.Exec(
+		ctx,
+		`INSERT INTO users (id, email, state, status, locale, password_hash,
+			totp_secret, totp_enabled, totp_last_step, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		user.ID,
+		user.Email,
+		user.State,
+		user.Status,
+		user.Locale,
+		user.PasswordHash,
+		user.TOTPSecret,
+		user.TwoFactorEnabled,
+		user.TOTPLastStep,
+		int64(user.Version),
+	)
+	if err != nil {
+		return handleSaveError(err, user)
+	}
+	return nil
+}
+
+func (r *UserRepository) update(ctx context.Context, user *app.User) error {
+	tag, err := r.pool.Exec(
+		ctx,
+		`UPDATE users
+		SET email = $2, state = $3, status = $4, locale = $5, password_hash = $6,
+			totp_secret = $7, totp_enabled = $8, totp_last_step = $9, version = $10,
+			updated_at = now()
+		WHERE id = $1 AND version = $11`,
+		user.ID,
+		user.Email,
+		user.State,
+		user.Status,
+		user.Locale,
+		user.PasswordHash,
+		user.TOTPSecret,
+		user.TwoFactorEnabled,
+		user.TOTPLastStep,
+		int64(user.Version),
+		int64(user.Version-1),
+	)
+	if err != nil {
+		return handleSaveError(err, user)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf(
+			"%w: пользователь с id %s был изменен или удален другим запросом",
+			app.ErrConflict,
+			user.ID,
+		)
+	}
+	return nil
+}
+
+func (r *UserRepository) ViewByID(ctx context.Context, id uuid.UUID) (*app.UserView, error) {
+	row := r.pool.QueryRow(
+		ctx,
+		`SELECT id, email, state, status, locale, version, created_at
+		FROM users WHERE id = $1`,
+		id,
+	)
+	view, err := scanUserView(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: пользователь с id %s не найден", app.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: получение пользователя по id: %w", app.ErrInternal, err)
+	}
+	return &view, nil
+}
+
+func (r *UserRepository) List(
+	ctx context.Context,
+	filter app.UserFilter,
+	after *app.UserCursor,
+	limit int,
+) ([]app.UserView, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	where := func(condition string, values ...any) {
+		placeholders := make([]any, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = len(args)
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, placeholders...))
+	}
+	if filter.State != "" {
+		where("state = $%d", filter.State)
+	}
+	if filter.Status != "" {
+		where("status = $%d", filter.Status)
+	}
+	if filter.EmailContains != "" {
+		where("strpos(lower(email), $%d) > 0", filter.EmailContains)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		where("created_at >= $%d", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		where("created_at < $%d", filter.CreatedTo)
+	}
+	if after != nil {
+		where("(created_at, id) > ($%d, $%d)", after.CreatedAt, after.ID)
+	}
+
+	query := `SELECT id, email, state, status, locale, version, created_at FROM users`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY created_at, id LIMIT $%d", len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: получение списка пользователей: %w", app.ErrInternal, err)
+	}
+	views, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (app.UserView, error) {
+		return scanUserView(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: чтение списка пользователей: %w", app.ErrInternal, err)
+	}
+	return views, nil
+}
+
+func scanUserView(row pgx.Row) (app.UserView, error) {
+	var (
+		view    app.UserView
+		version int64
+	)
+	if err := row.Scan(
+		&view.ID,
+		&view.Email,
+		&view.State,
+		&view.Status,
+		&view.Locale,
+		&version,
+		&view.CreatedAt,
+	); err != nil {
+		return app.UserView{}, err
+	}
+	view.Version = uint(version)
+	return view, nil
+}
+
+func scanUser(row pgx.Row) (*app.User, error) {
+	var (
+		user    app.User
+		version int64
+	)
+	if err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.State,
+		&user.Status,
+		&user.Locale,
+		&user.PasswordHash,
+		&user.TOTPSecret,
+		&user.TwoFactorEnabled,
+		&user.TOTPLastStep,
+		&version,
+	); err != nil {
+		return nil, err
+	}
+	user.Version = uint(version)
+	return &user, nil
+}
+
+func handleSaveError(err error, user *app.User) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		if pgErr.ConstraintName == "users_pkey" {
+			return fmt.Errorf(
+				"%w: пользователь с id %s уже существует",
+				app.ErrConflict,
+				user.ID,
+			)
+		}
+		return fmt.Errorf(
+			"%w: пользователь с email %s уже существует",
+			app.ErrAlreadyExists,
+			user.Email,
+		)
+	}
+	return fmt.Errorf("%w: сохранение пользователя: %w", app.ErrInternal, err)
+}
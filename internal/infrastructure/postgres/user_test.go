@@ -0,0 +1,296 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const testDSNEnv = "DND_USERS_TEST_POSTGRES_DSN"
+
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect to postgres: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if _, err = MustMigrator(pool).Up(ctx); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+	if _, err = pool.Exec(ctx, `TRUNCATE users CASCADE`); err != nil {
+		t.Fatalf("truncate users table: %v", err)
+	}
+	return pool
+}
+
+func newTestUser(email string) *app.User {
+	return &app.User{
+		ID:           uuid.New(),
+		Email:        email,
+		State:        domain.ACTIVE,
+		Status:       domain.USER,
+		Locale:       domain.RU,
+		PasswordHash: "hash",
+		Version:      1,
+	}
+}
+
+func TestUserRepository_Save(t *testing.T) {
+	repo := MustUserRepository(testPool(t))
+	ctx := context.Background()
+	saved := newTestUser("saved@mail.com")
+	if err := repo.Save(ctx, saved); err != nil {
+		t.Fatalf("save user: %v", err)
+	}
+	cases := []struct {
+		TestName string
+		Expected error
+		User     *app.User
+	}{
+		{
+			TestName: "test_user_repository_save_insert_ok",
+			Expected: nil,
+			User:     newTestUser("new@mail.com"),
+		},
+		{
+			TestName: "test_user_repository_save_email_exists",
+			Expected: app.ErrAlreadyExists,
+			User:     newTestUser(saved.Email),
+		},
+		{
+			TestName: "test_user_repository_save_update_ok",
+			Expected: nil,
+			User: &app.User{
+				ID:           saved.ID,
+				Email:        saved.Email,
+				State:        domain.FROZEN,
+				Status:       saved.Status,
+				PasswordHash: saved.PasswordHash,
+				Version:      2,
+			},
+		},
+		{
+			TestName: "test_user_repository_save_version_conflict",
+			Expected: app.ErrConflict,
+			User: &app.User{
+				ID:           saved.ID,
+				Email:        saved.Email,
+				State:        domain.DELETED,
+				Status:       saved.Status,
+				PasswordHash: saved.PasswordHash,
+				Version:      2,
+			},
+		},
+		{
+			TestName: "test_user_repository_save_zero_version",
+			Expected: app.ErrInternal,
+			User:     &app.User{ID: uuid.New(), Email: "zero@mail.com"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := repo.Save(ctx, c.User)
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected nil, but got %v", err)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+}
+
+func TestUserRepository_ByID(t *testing.T) {
+	repo := MustUserRepository(testPool(t))
+	ctx := context.Background()
+	saved := newTestUser("by_id@mail.com")
+	if err := repo.Save(ctx, saved); err != nil {
+		t.Fatalf("save user: %v", err)
+	}
+	cases := []struct {
+		TestName string
+		Expected error
+		ID       uuid.UUID
+	}{
+		{TestName: "test_user_repository_by_id_ok", Expected: nil, ID: saved.ID},
+		{TestName: "test_user_repository_by_id_not_found", Expected: app.ErrNotFound, ID: uuid.New()},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			user, err := repo.ByID(ctx, c.ID)
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+			if c.Expected == nil && *user != *saved {
+				t.Errorf("expected %v, but got %v", saved, user)
+			}
+		})
+	}
+}
+
+func TestUserRepository_WrapsDriverError(t *testing.T) {
+	repo := MustUserRepository(testPool(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := repo.ByID(ctx, uuid.New())
+	if !errors.Is(err, app.ErrInternal) || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v wrapping %v, but got %v", app.ErrInternal, context.Canceled, err)
+	}
+}
+
+func TestUserRepository_TwoFactor(t *testing.T) {
+	repo := MustUserRepository(testPool(t))
+	ctx := context.Background()
+	user := newTestUser("two_factor@mail.com")
+	if err := repo.Save(ctx, user); err != nil {
+		t.Fatalf("save user: %v", err)
+	}
+	enabled := *user
+	enabled.TOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	enabled.TwoFactorEnabled = true
+	enabled.TOTPLastStep = 42
+	enabled.Version = 2
+	if err := repo.Save(ctx, &enabled); err != nil {
+		t.Fatalf("save two factor: %v", err)
+	}
+	got, err := repo.ByEmail(ctx, user.Email)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if *got != enabled {
+		t.Errorf("expected %v, but got %v", enabled, got)
+	}
+}
+
+func TestUserRepository_ByEmail(t *testing.T) {
+	repo := MustUserRepository(testPool(t))
+	ctx := context.Background()
+	saved := newTestUser("by_email@mail.com")
+	if err := repo.Save(ctx, saved); err != nil {
+		t.Fatalf("save user: %v", err)
+	}
+	cases := []struct {
+		TestName string
+		Expected error
+		Email    string
+	}{
+		{TestName: "test_user_repository_by_email_ok", Expected: nil, Email: saved.Email},
+		{
+			TestName: "test_user_repository_by_email_not_found",
+			Expected: app.ErrNotFound,
+			Email:    "unknown@mail.com",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			user, err := repo.ByEmail(ctx, c.Email)
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+			if c.Expected == nil && *user != *saved {
+				t.Errorf("expected %v, but got %v", saved, user)
+			}
+		})
+	}
+}
+
+func TestUserRepository_Exists(t *testing.T) {
+	repo := MustUserRepository(testPool(t))
+	ctx := context.Background()
+	saved := newTestUser("exists@mail.com")
+	if err := repo.Save(ctx, saved); err != nil {
+		t.Fatalf("save user: %v", err)
+	}
+	exists, err := repo.EmailExists(ctx, saved.Email)
+	if err != nil || !exists {
+		t.Errorf("expected email %s to exist, got %v, %v", saved.Email, exists, err)
+	}
+	exists, err = repo.EmailExists(ctx, "unknown@mail.com")
+	if err != nil || exists {
+		t.Errorf("expected unknown email to be absent, got %v, %v", exists, err)
+	}
+	exists, err = repo.IDExists(ctx, saved.ID)
+	if err != nil || !exists {
+		t.Errorf("expected id %s to exist, got %v, %v", saved.ID, exists, err)
+	}
+	exists, err = repo.IDExists(ctx, uuid.New())
+	if err != nil || exists {
+		t.Errorf("expected unknown id to be absent, got %v, %v", exists, err)
+	}
+}
+
+func TestUserRepository_List(t *testing.T) {
+	repo := MustUserRepository(testPool(t))
+	ctx := context.Background()
+	suffix := uuid.NewString()[:8]
+	first := newTestUser("list_first_" + suffix + "@mail.com")
+	second := newTestUser("list_second_" + suffix + "@mail.com")
+	second.State = domain.FROZEN
+	for _, user := range []*app.User{first, second} {
+		if err := repo.Save(ctx, user); err != nil {
+			t.Fatalf("save user: %v", err)
+		}
+	}
+	all := app.UserFilter{EmailContains: suffix}
+	views, err := repo.List(ctx, all, nil, 10)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(views) != 2 || views[0].ID != first.ID || views[1].ID != second.ID {
+		t.Fatalf("expected both users in insert order, but got %v", views)
+	}
+
+	cases := []struct {
+		TestName string
+		Expected []uuid.UUID
+		Filter   app.UserFilter
+		After    *app.UserCursor
+	}{
+		{
+			TestName: "test_user_repository_list_after",
+			Expected: []uuid.UUID{second.ID},
+			Filter:   all,
+			After:    &app.UserCursor{CreatedAt: views[0].CreatedAt, ID: views[0].ID},
+		},
+		{
+			TestName: "test_user_repository_list_state",
+			Expected: []uuid.UUID{second.ID},
+			Filter:   app.UserFilter{EmailContains: suffix, State: domain.FROZEN},
+		},
+		{
+			TestName: "test_user_repository_list_created_to",
+			Expected: []uuid.UUID{first.ID},
+			Filter:   app.UserFilter{EmailContains: suffix, CreatedTo: views[1].CreatedAt},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			views, err := repo.List(ctx, c.Filter, c.After, 10)
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			if len(views) != len(c.Expected) || views[0].ID != c.Expected[0] {
+				t.Errorf("expected %v, but got %v", c.Expected, views)
+			}
+		})
+	}
+
+	view, err := repo.ViewByID(ctx, first.ID)
+	if err != nil || view.Email != first.Email || view.CreatedAt.IsZero() {
+		t.Errorf("expected view of %s, but got %v %v", first.ID, view, err)
+	}
+}
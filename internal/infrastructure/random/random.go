@@ -0,0 +1,110 @@
+package random
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	digits             = "0123456789"
+	unambiguousSymbols = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+)
+
+type Kind string
+
+const (
+	Numeric      Kind = "numeric"
+	Alphanumeric Kind = "alphanumeric"
+	URLToken     Kind = "url_token"
+)
+
+type Profile struct {
+	Kind   Kind
+	Length int
+}
+
+func (p Profile) Validate() error {
+	switch p.Kind {
+	case Numeric:
+		if p.Length < 4 || p.Length > 12 {
+			return fmt.Errorf("длина числового кода должна быть от 4 до 12, получено %d", p.Length)
+		}
+	case Alphanumeric:
+		if p.Length < 4 || p.Length > 32 {
+			return fmt.Errorf(
+				"длина буквенно-цифрового кода должна быть от 4 до 32, получено %d",
+				p.Length,
+			)
+		}
+	case URLToken:
+		if p.Length < 16 || p.Length > 64 {
+			return fmt.Errorf(
+				"длина токена для ссылки должна быть от 16 до 64 байт, получено %d",
+				p.Length,
+			)
+		}
+	default:
+		return fmt.Errorf("неизвестный тип кода %q", p.Kind)
+	}
+	return nil
+}
+
+type CodeGenerator struct {
+	profile Profile
+}
+
+func MustCodeGenerator(profile Profile) *CodeGenerator {
+	if err := profile.Validate(); err != nil {
+		panic(fmt.Sprintf("code generator got invalid profile: %s", err))
+	}
+	return &CodeGenerator{profile: profile}
+}
+
+func (g *CodeGenerator) Generate() string {
+	switch g.profile.Kind {
+	case Numeric:
+		return fromAlphabet(digits, g.profile.Length)
+	case Alphanumeric:
+		return fromAlphabet(unambiguousSymbols, g.profile.Length)
+	default:
+		token := make([]byte, g.profile.Length)
+		_, _ = rand.Read(token)
+		return base64.RawURLEncoding.EncodeToString(token)
+	}
+}
+
+// Normalize cleans up a submitted code. Only alphanumeric codes are case
+// insensitive, url tokens are base64 and keep their case.
+func (g *CodeGenerator) Normalize(code string) string {
+	code = strings.TrimSpace(code)
+	if g.profile.Kind == Alphanumeric {
+		return strings.ToUpper(code)
+	}
+	return code
+}
+
+func fromAlphabet(alphabet string, length int) string {
+	size := big.NewInt(int64(len(alphabet)))
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			panic(fmt.Sprintf("code generator: %s", err))
+		}
+		code[i] = alphabet[n.Int64()]
+	}
+	return string(code)
+}
+
+type TokenGenerator struct{}
+
+func MustTokenGenerator() *TokenGenerator {
+	return &TokenGenerator{}
+}
+
+func (g *TokenGenerator) Generate() string {
+	return rand.Text() + rand.Text()
+}
@@ -0,0 +1,174 @@
+package random
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestProfile_Validate(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Valid    bool
+		Profile  Profile
+	}{
+		{
+			TestName: "test_profile_numeric_ok",
+			Valid:    true,
+			Profile:  Profile{Kind: Numeric, Length: 6},
+		},
+		{
+			TestName: "test_profile_numeric_short",
+			Valid:    false,
+			Profile:  Profile{Kind: Numeric, Length: 3},
+		},
+		{
+			TestName: "test_profile_numeric_long",
+			Valid:    false,
+			Profile:  Profile{Kind: Numeric, Length: 13},
+		},
+		{
+			TestName: "test_profile_alphanumeric_ok",
+			Valid:    true,
+			Profile:  Profile{Kind: Alphanumeric, Length: 8},
+		},
+		{
+			TestName: "test_profile_alphanumeric_long",
+			Valid:    false,
+			Profile:  Profile{Kind: Alphanumeric, Length: 33},
+		},
+		{
+			TestName: "test_profile_url_token_ok",
+			Valid:    true,
+			Profile:  Profile{Kind: URLToken, Length: 32},
+		},
+		{
+			TestName: "test_profile_url_token_short",
+			Valid:    false,
+			Profile:  Profile{Kind: URLToken, Length: 8},
+		},
+		{
+			TestName: "test_profile_unknown_kind",
+			Valid:    false,
+			Profile:  Profile{Kind: "emoji", Length: 6},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := c.Profile.Validate()
+			if c.Valid && err != nil {
+				t.Errorf("expected nil, but got %v", err)
+			}
+			if !c.Valid && err == nil {
+				t.Error("expected error, but got nil")
+			}
+		})
+	}
+}
+
+func TestCodeGenerator_Generate(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Profile  Profile
+		Length   int
+		Alphabet string
+	}{
+		{
+			TestName: "test_code_generator_numeric",
+			Profile:  Profile{Kind: Numeric, Length: 6},
+			Length:   6,
+			Alphabet: digits,
+		},
+		{
+			TestName: "test_code_generator_alphanumeric",
+			Profile:  Profile{Kind: Alphanumeric, Length: 10},
+			Length:   10,
+			Alphabet: unambiguousSymbols,
+		},
+		{
+			TestName: "test_code_generator_url_token",
+			Profile:  Profile{Kind: URLToken, Length: 32},
+			Length:   base64.RawURLEncoding.EncodedLen(32),
+			Alphabet: "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			generator := MustCodeGenerator(c.Profile)
+			seen := make(map[rune]bool)
+			for range 200 {
+				code := generator.Generate()
+				if len(code) != c.Length {
+					t.Fatalf("expected code of length %d, but got %q", c.Length, code)
+				}
+				for _, r := range code {
+					if !strings.ContainsRune(c.Alphabet, r) {
+						t.Fatalf("expected only %q symbols, but got %q", c.Alphabet, code)
+					}
+					seen[r] = true
+				}
+			}
+			if c.Profile.Kind != URLToken && len(seen) != len(c.Alphabet) {
+				t.Errorf("expected all %d symbols to appear, but got %d", len(c.Alphabet), len(seen))
+			}
+		})
+	}
+}
+
+func TestCodeGenerator_AlphanumericWithoutAmbiguous(t *testing.T) {
+	for _, r := range "01OIL" {
+		if strings.ContainsRune(unambiguousSymbols, r) {
+			t.Errorf("expected alphabet without ambiguous symbol %q", r)
+		}
+	}
+}
+
+func TestCodeGenerator_Normalize(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Expected string
+		Profile  Profile
+		Code     string
+	}{
+		{
+			TestName: "test_normalize_numeric",
+			Expected: "123456",
+			Profile:  Profile{Kind: Numeric, Length: 6},
+			Code:     " 123456\n",
+		},
+		{
+			TestName: "test_normalize_alphanumeric",
+			Expected: "AB23CD",
+			Profile:  Profile{Kind: Alphanumeric, Length: 6},
+			Code:     " aB23cd ",
+		},
+		{
+			TestName: "test_normalize_url_token_keeps_case",
+			Expected: "aB-_cD",
+			Profile:  Profile{Kind: URLToken, Length: 16},
+			Code:     "\taB-_cD ",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			if code := MustCodeGenerator(c.Profile).Normalize(c.Code); code != c.Expected {
+				t.Errorf("expected %q, but got %q", c.Expected, code)
+			}
+		})
+	}
+}
+
+func TestTokenGenerator_Generate(t *testing.T) {
+	generator := MustTokenGenerator()
+	seen := make(map[string]bool)
+	for range 100 {
+		token := generator.Generate()
+		if len(token) != 52 {
+			t.Fatalf("expected token of length 52, but got %q", token)
+		}
+		if seen[token] {
+			t.Fatalf("expected unique tokens, but got %q twice", token)
+		}
+		seen[token] = true
+	}
+}
@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+type Config struct {
+	Window      time.Duration
+	MaxAttempts int
+	MaxFailures int
+	Lockout     time.Duration
+	MaxLockout  time.Duration
+}
+
+// State is what a limiter stores per key, adapters only load and save it.
+type State struct {
+	Attempts    []time.Time
+	Failures    int
+	LastFailure time.Time
+	Lockouts    int
+	LockedUntil time.Time
+}
+
+type Policy struct {
+	cfg Config
+}
+
+func MustPolicy(cfg Config) *Policy {
+	if err := cfg.Validate(); err != nil {
+		panic(fmt.Sprintf("rate limiter got invalid config: %s", err))
+	}
+	return &Policy{cfg: cfg}
+}
+
+func (c Config) Validate() error {
+	if c.Window <= 0 {
+		return fmt.Errorf("окно должно быть больше 0")
+	}
+	if c.MaxAttempts <= 0 {
+		return fmt.Errorf("количество попыток должно быть больше 0, получено %d", c.MaxAttempts)
+	}
+	if c.MaxFailures <= 0 {
+		return fmt.Errorf("количество ошибок должно быть больше 0, получено %d", c.MaxFailures)
+	}
+	if c.Lockout <= 0 || c.MaxLockout < c.Lockout {
+		return fmt.Errorf("блокировка должна быть больше 0 и не больше максимальной")
+	}
+	return nil
+}
+
+// Allow records an attempt for every state, or none of them when one of the
+// keys is over its limit.
+func (p *Policy) Allow(now time.Time, states ...*State) error {
+	var retryAfter time.Duration
+	for _, state := range states {
+		if state.LockedUntil.After(now) {
+			retryAfter = max(retryAfter, state.LockedUntil.Sub(now))
+		}
+		state.Attempts = p.recent(state.Attempts, now)
+		if len(state.Attempts) >= p.cfg.MaxAttempts {
+			retryAfter = max(retryAfter, state.Attempts[0].Add(p.cfg.Window).Sub(now))
+		}
+	}
+	if retryAfter > 0 {
+		return app.TooManyRequestsError(retryAfter)
+	}
+	for _, state := range states {
+		state.Attempts = append(state.Attempts, now)
+	}
+	return nil
+}
+
+func (p *Policy) Fail(now time.Time, states ...*State) {
+	for _, state := range states {
+		if now.Sub(state.LastFailure) > p.cfg.Window {
+			state.Failures = 0
+		}
+		state.Failures++
+		state.LastFailure = now
+		if state.Failures >= p.cfg.MaxFailures {
+			state.Failures = 0
+			state.Lockouts++
+			state.LockedUntil = now.Add(p.lockout(state.Lockouts))
+		}
+	}
+}
+
+// ExpiresAt is the moment the state stops affecting decisions and may be
+// dropped.
+func (p *Policy) ExpiresAt(state *State) time.Time {
+	expiresAt := state.LockedUntil.Add(p.cfg.MaxLockout)
+	for _, at := range state.Attempts {
+		expiresAt = later(expiresAt, at.Add(p.cfg.Window))
+	}
+	return later(expiresAt, state.LastFailure.Add(p.cfg.Window))
+}
+
+func (p *Policy) Window() time.Duration {
+	return p.cfg.Window
+}
+
+func (p *Policy) recent(attempts []time.Time, now time.Time) []time.Time {
+	i := 0
+	for i < len(attempts) && now.Sub(attempts[i]) >= p.cfg.Window {
+		i++
+	}
+	return attempts[i:]
+}
+
+func (p *Policy) lockout(lockouts int) time.Duration {
+	delay := p.cfg.Lockout
+	for range lockouts - 1 {
+		if delay >= p.cfg.MaxLockout/2 {
+			return p.cfg.MaxLockout
+		}
+		delay *= 2
+	}
+	return min(delay, p.cfg.MaxLockout)
+}
+
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
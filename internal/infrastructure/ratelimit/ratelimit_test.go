@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+func testConfig() Config {
+	return Config{
+		Window:      time.Minute,
+		MaxAttempts: 2,
+		MaxFailures: 2,
+		Lockout:     time.Minute,
+		MaxLockout:  3 * time.Minute,
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Change   func(cfg *Config)
+		Valid    bool
+	}{
+		{
+			TestName: "test_config_valid",
+			Change:   func(cfg *Config) {},
+			Valid:    true,
+		},
+		{
+			TestName: "test_config_no_window",
+			Change:   func(cfg *Config) { cfg.Window = 0 },
+		},
+		{
+			TestName: "test_config_no_attempts",
+			Change:   func(cfg *Config) { cfg.MaxAttempts = 0 },
+		},
+		{
+			TestName: "test_config_no_failures",
+			Change:   func(cfg *Config) { cfg.MaxFailures = 0 },
+		},
+		{
+			TestName: "test_config_max_lockout_below_lockout",
+			Change:   func(cfg *Config) { cfg.MaxLockout = time.Second },
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			cfg := testConfig()
+			c.Change(&cfg)
+			if err := cfg.Validate(); (err == nil) != c.Valid {
+				t.Errorf("expected valid %v, but got %v", c.Valid, err)
+			}
+		})
+	}
+}
+
+func TestPolicy_AllowAllOrNothing(t *testing.T) {
+	policy := MustPolicy(testConfig())
+	now := time.Now()
+	full := &State{Attempts: []time.Time{now, now}}
+	empty := &State{}
+
+	if err := policy.Allow(now, empty, full); !errors.Is(err, app.ErrTooManyRequests) {
+		t.Fatalf("expected %v, but got %v", app.ErrTooManyRequests, err)
+	}
+	if len(empty.Attempts) != 0 {
+		t.Errorf("expected rejected attempt not to be recorded, but got %v", empty.Attempts)
+	}
+}
+
+func TestPolicy_FailEscalatesLockout(t *testing.T) {
+	policy := MustPolicy(testConfig())
+	now := time.Now()
+	state := &State{}
+	for _, expected := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		policy.Fail(now, state)
+		policy.Fail(now, state)
+		if delay := state.LockedUntil.Sub(now); delay != expected {
+			t.Errorf("expected lockout %v, but got %v", expected, delay)
+		}
+	}
+}
+
+func TestPolicy_ExpiresAt(t *testing.T) {
+	policy := MustPolicy(testConfig())
+	now := time.Now()
+	cases := []struct {
+		TestName string
+		State    *State
+		Expected time.Time
+	}{
+		{
+			TestName: "test_policy_expires_after_last_attempt",
+			State:    &State{Attempts: []time.Time{now.Add(-time.Second), now}},
+			Expected: now.Add(time.Minute),
+		},
+		{
+			TestName: "test_policy_expires_after_last_failure",
+			State:    &State{Failures: 1, LastFailure: now},
+			Expected: now.Add(time.Minute),
+		},
+		{
+			TestName: "test_policy_keeps_lockout_history",
+			State:    &State{Lockouts: 1, LockedUntil: now},
+			Expected: now.Add(3 * time.Minute),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			if expiresAt := policy.ExpiresAt(c.State); !expiresAt.Equal(c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, expiresAt)
+			}
+		})
+	}
+}
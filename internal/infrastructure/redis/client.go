@@ -0,0 +1,265 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var ErrProtocol = errors.New("некорректный ответ redis")
+
+type ReplyError string
+
+func (e ReplyError) Error() string {
+	return string(e)
+}
+
+type Config struct {
+	Addr        string
+	Username    string
+	Password    string
+	DB          int
+	DialTimeout time.Duration
+	PoolSize    int
+}
+
+type Client struct {
+	cfg    Config
+	dialer net.Dialer
+	idle   chan *conn
+	mu     sync.Mutex
+	closed bool
+}
+
+type conn struct {
+	netConn net.Conn
+	r       *bufio.Reader
+	w       *bufio.Writer
+}
+
+func MustClient(cfg Config) *Client {
+	if cfg.Addr == "" {
+		panic("redis client did not get address")
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 5 * time.Second
+	}
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 10
+	}
+	return &Client{
+		cfg:    cfg,
+		dialer: net.Dialer{Timeout: cfg.DialTimeout},
+		idle:   make(chan *conn, cfg.PoolSize),
+	}
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	reply, err := c.Do(ctx, "PING")
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("%w: на PING получено %v", ErrProtocol, reply)
+	}
+	return nil
+}
+
+func (c *Client) Do(ctx context.Context, args ...string) (any, error) {
+	var reply any
+	err := c.withConn(ctx, func(cn *conn) error {
+		var err error
+		reply, err = cn.do(args...)
+		return err
+	})
+	return reply, err
+}
+
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.idle)
+	var errs []error
+	for cn := range c.idle {
+		errs = append(errs, cn.netConn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Client) withConn(ctx context.Context, fn func(cn *conn) error) error {
+	cn, err := c.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err = cn.netConn.SetDeadline(deadline); err != nil {
+		cn.netConn.Close()
+		return err
+	}
+	err = fn(cn)
+	var replyErr ReplyError
+	if err != nil && !errors.As(err, &replyErr) {
+		cn.netConn.Close()
+		return err
+	}
+	c.release(cn)
+	return err
+}
+
+func (c *Client) acquire(ctx context.Context) (*conn, error) {
+	select {
+	case cn, ok := <-c.idle:
+		if ok {
+			return cn, nil
+		}
+		return nil, errors.New("redis client closed")
+	default:
+	}
+
+	netConn, err := c.dialer.DialContext(ctx, "tcp", c.cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{
+		netConn: netConn,
+		r:       bufio.NewReader(netConn),
+		w:       bufio.NewWriter(netConn),
+	}
+	deadline, _ := ctx.Deadline()
+	if err = netConn.SetDeadline(deadline); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if c.cfg.Password != "" {
+		args := []string{"AUTH", c.cfg.Password}
+		if c.cfg.Username != "" {
+			args = []string{"AUTH", c.cfg.Username, c.cfg.Password}
+		}
+		if _, err = cn.do(args...); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	if c.cfg.DB != 0 {
+		if _, err = cn.do("SELECT", strconv.Itoa(c.cfg.DB)); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+func (c *Client) release(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		cn.netConn.Close()
+		return
+	}
+	select {
+	case c.idle <- cn:
+	default:
+		cn.netConn.Close()
+	}
+}
+
+func (cn *conn) do(args ...string) (any, error) {
+	if err := writeCommand(cn.w, args); err != nil {
+		return nil, err
+	}
+	if err := cn.w.Flush(); err != nil {
+		return nil, err
+	}
+	return readReply(cn.r)
+}
+
+func writeCommand(w *bufio.Writer, args []string) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, fmt.Errorf("%w: пустая строка", ErrProtocol)
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, ReplyError(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrProtocol, err)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrProtocol, err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrProtocol, err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			item, err := readReply(r)
+			var replyErr ReplyError
+			if err != nil && !errors.As(err, &replyErr) {
+				return nil, err
+			}
+			if err != nil {
+				item = replyErr
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("%w: неизвестный тип %q", ErrProtocol, line[0])
+	}
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("%w: строка без CRLF", ErrProtocol)
+	}
+	return line[:len(line)-2], nil
+}
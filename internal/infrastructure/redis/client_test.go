@@ -0,0 +1,395 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type simpleReply string
+
+type testEntry struct {
+	hash      map[string]string
+	expiresAt time.Time
+}
+
+type testServer struct {
+	listener net.Listener
+	password string
+	mu       sync.Mutex
+	now      time.Time
+	entries  map[string]*testEntry
+	versions map[string]int
+	conns    []net.Conn
+}
+
+func newTestServer(t *testing.T, password string) *testServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &testServer{
+		listener: listener,
+		password: password,
+		now:      time.Now(),
+		entries:  make(map[string]*testEntry),
+		versions: make(map[string]int),
+	}
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, c)
+			s.mu.Unlock()
+			wg.Go(func() { s.serve(c) })
+		}
+	})
+	t.Cleanup(func() {
+		listener.Close()
+		s.mu.Lock()
+		for _, c := range s.conns {
+			c.Close()
+		}
+		s.mu.Unlock()
+		wg.Wait()
+	})
+	return s
+}
+
+func (s *testServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *testServer) advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = s.now.Add(d)
+}
+
+func (s *testServer) clock() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now
+}
+
+func (s *testServer) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.entries))
+	for key := range s.entries {
+		if s.lookup(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+type testSession struct {
+	authed  bool
+	watched map[string]int
+	multi   bool
+	queued  [][]string
+}
+
+func (s *testServer) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	w := bufio.NewWriter(c)
+	session := &testSession{authed: s.password == "", watched: make(map[string]int)}
+	for {
+		request, err := readReply(r)
+		if err != nil {
+			return
+		}
+		items, ok := request.([]any)
+		if !ok || len(items) == 0 {
+			return
+		}
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i], _ = item.(string)
+		}
+		writeTestReply(w, s.handle(session, args))
+		if err = w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *testServer) handle(session *testSession, args []string) any {
+	name := strings.ToUpper(args[0])
+	if name == "AUTH" {
+		if args[len(args)-1] != s.password {
+			return ReplyError("WRONGPASS invalid username-password pair")
+		}
+		session.authed = true
+		return simpleReply("OK")
+	}
+	if !session.authed {
+		return ReplyError("NOAUTH Authentication required.")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch name {
+	case "MULTI":
+		session.multi = true
+		return simpleReply("OK")
+	case "DISCARD":
+		session.multi = false
+		session.queued = nil
+		clear(session.watched)
+		return simpleReply("OK")
+	case "EXEC":
+		queued := session.queued
+		session.multi = false
+		session.queued = nil
+		aborted := false
+		for key, version := range session.watched {
+			s.lookup(key)
+			if s.versions[key] != version {
+				aborted = true
+			}
+		}
+		clear(session.watched)
+		if aborted {
+			return []any(nil)
+		}
+		replies := make([]any, len(queued))
+		for i, command := range queued {
+			replies[i] = s.execute(command)
+		}
+		return replies
+	case "WATCH":
+		for _, key := range args[1:] {
+			s.lookup(key)
+			session.watched[key] = s.versions[key]
+		}
+		return simpleReply("OK")
+	case "UNWATCH":
+		clear(session.watched)
+		return simpleReply("OK")
+	}
+	if session.multi {
+		session.queued = append(session.queued, args)
+		return simpleReply("QUEUED")
+	}
+	return s.execute(args)
+}
+
+func (s *testServer) execute(args []string) any {
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return simpleReply("PONG")
+	case "SELECT":
+		return simpleReply("OK")
+	case "DEL":
+		var deleted int64
+		for _, key := range args[1:] {
+			if s.lookup(key) != nil {
+				delete(s.entries, key)
+				s.versions[key]++
+				deleted++
+			}
+		}
+		return deleted
+	case "HSET":
+		entry := s.lookup(args[1])
+		if entry == nil {
+			entry = &testEntry{hash: make(map[string]string)}
+			s.entries[args[1]] = entry
+		}
+		var added int64
+		for i := 2; i+1 < len(args); i += 2 {
+			if _, ok := entry.hash[args[i]]; !ok {
+				added++
+			}
+			entry.hash[args[i]] = args[i+1]
+		}
+		s.versions[args[1]]++
+		return added
+	case "HMGET":
+		entry := s.lookup(args[1])
+		values := make([]any, len(args)-2)
+		for i, field := range args[2:] {
+			if entry == nil {
+				continue
+			}
+			if value, ok := entry.hash[field]; ok {
+				values[i] = value
+			}
+		}
+		return values
+	case "HINCRBY":
+		entry := s.lookup(args[1])
+		if entry == nil {
+			entry = &testEntry{hash: make(map[string]string)}
+			s.entries[args[1]] = entry
+		}
+		current, _ := strconv.ParseInt(entry.hash[args[2]], 10, 64)
+		delta, _ := strconv.ParseInt(args[3], 10, 64)
+		entry.hash[args[2]] = strconv.FormatInt(current+delta, 10)
+		s.versions[args[1]]++
+		return current + delta
+	case "PEXPIRE":
+		entry := s.lookup(args[1])
+		if entry == nil {
+			return int64(0)
+		}
+		ms, _ := strconv.ParseInt(args[2], 10, 64)
+		entry.expiresAt = s.now.Add(time.Duration(ms) * time.Millisecond)
+		s.versions[args[1]]++
+		return int64(1)
+	case "PTTL":
+		entry := s.lookup(args[1])
+		if entry == nil {
+			return int64(-2)
+		}
+		if entry.expiresAt.IsZero() {
+			return int64(-1)
+		}
+		return entry.expiresAt.Sub(s.now).Milliseconds()
+	default:
+		return ReplyError(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+}
+
+func (s *testServer) lookup(key string) *testEntry {
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if !entry.expiresAt.IsZero() && !s.now.Before(entry.expiresAt) {
+		delete(s.entries, key)
+		s.versions[key]++
+		return nil
+	}
+	return entry
+}
+
+func writeTestReply(w *bufio.Writer, reply any) {
+	switch v := reply.(type) {
+	case simpleReply:
+		fmt.Fprintf(w, "+%s\r\n", v)
+	case ReplyError:
+		fmt.Fprintf(w, "-%s\r\n", v)
+	case int64:
+		fmt.Fprintf(w, ":%d\r\n", v)
+	case string:
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+	case []any:
+		if v == nil {
+			fmt.Fprint(w, "*-1\r\n")
+			return
+		}
+		fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeTestReply(w, item)
+		}
+	case nil:
+		fmt.Fprint(w, "$-1\r\n")
+	}
+}
+
+func TestClient_Do(t *testing.T) {
+	server := newTestServer(t, "")
+	client := MustClient(Config{Addr: server.addr()})
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+	cases := []struct {
+		TestName string
+		Args     []string
+		Reply    any
+		Expected error
+	}{
+		{
+			TestName: "test_client_ping",
+			Args:     []string{"PING"},
+			Reply:    "PONG",
+		},
+		{
+			TestName: "test_client_integer_reply",
+			Args:     []string{"HSET", "key", "field", "value"},
+			Reply:    int64(1),
+		},
+		{
+			TestName: "test_client_array_reply",
+			Args:     []string{"HMGET", "key", "field", "missing"},
+			Reply:    []any{"value", nil},
+		},
+		{
+			TestName: "test_client_error_reply",
+			Args:     []string{"UNKNOWN"},
+			Expected: ReplyError("ERR unknown command 'UNKNOWN'"),
+		},
+		{
+			TestName: "test_client_usable_after_error_reply",
+			Args:     []string{"PING"},
+			Reply:    "PONG",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			reply, err := client.Do(ctx, c.Args...)
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, err)
+			}
+			if fmt.Sprint(reply) != fmt.Sprint(c.Reply) {
+				t.Errorf("expected reply %v, but got %v", c.Reply, reply)
+			}
+		})
+	}
+}
+
+func TestClient_Auth(t *testing.T) {
+	server := newTestServer(t, "secret")
+	ctx := context.Background()
+	cases := []struct {
+		TestName string
+		Password string
+		Failed   bool
+	}{
+		{TestName: "test_client_auth_ok", Password: "secret"},
+		{TestName: "test_client_auth_wrong_password", Password: "wrong", Failed: true},
+		{TestName: "test_client_auth_without_password", Failed: true},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			client := MustClient(Config{Addr: server.addr(), Password: c.Password})
+			defer client.Close()
+			err := client.Ping(ctx)
+			if c.Failed && err == nil {
+				t.Errorf("expected auth error, but got nil")
+			}
+			if !c.Failed && err != nil {
+				t.Errorf("expected nil, but got %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_Close(t *testing.T) {
+	server := newTestServer(t, "")
+	client := MustClient(Config{Addr: server.addr()})
+	if err := client.Ping(context.Background()); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := client.Ping(context.Background()); err == nil {
+		t.Errorf("expected error after close, but got nil")
+	}
+}
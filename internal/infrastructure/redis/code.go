@@ -0,0 +1,243 @@
+package redis
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+const (
+	confirmEmailFlow  = "confirm_email"
+	newEmailFlow      = "new_email"
+	resetPasswordFlow = "reset_password"
+	newPasswordFlow   = "new_password"
+)
+
+const consumeRetries = 5
+
+type CodeTTL struct {
+	ConfirmEmail  time.Duration
+	NewEmail      time.Duration
+	ResetPassword time.Duration
+	NewPassword   time.Duration
+}
+
+type CodeStore struct {
+	client      *Client
+	prefix      string
+	ttl         map[string]time.Duration
+	maxAttempts int
+}
+
+func MustCodeStore(client *Client, prefix string, ttl CodeTTL, maxAttempts int) *CodeStore {
+	if client == nil {
+		panic("code store did not get redis client")
+	}
+	if ttl.ConfirmEmail <= 0 {
+		panic("code store did not get confirm email ttl")
+	}
+	if ttl.NewEmail <= 0 {
+		panic("code store did not get new email ttl")
+	}
+	if ttl.ResetPassword <= 0 {
+		panic("code store did not get reset password ttl")
+	}
+	if ttl.NewPassword <= 0 {
+		panic("code store did not get new password ttl")
+	}
+	if maxAttempts <= 0 {
+		panic("code store did not get max attempts")
+	}
+	return &CodeStore{
+		client: client,
+		prefix: prefix,
+		ttl: map[string]time.Duration{
+			confirmEmailFlow:  ttl.ConfirmEmail,
+			newEmailFlow:      ttl.NewEmail,
+			resetPasswordFlow: ttl.ResetPassword,
+			newPasswordFlow:   ttl.NewPassword,
+		},
+		maxAttempts: maxAttempts,
+	}
+}
+
+func (s *CodeStore) SetConfirmEmail(ctx context.Context, key, code string) error {
+	return s.set(ctx, confirmEmailFlow, key, code)
+}
+
+func (s *CodeStore) ConsumeConfirmEmail(ctx context.Context, key, code string) error {
+	return s.consume(ctx, confirmEmailFlow, key, code)
+}
+
+func (s *CodeStore) SetNewEmail(ctx context.Context, key, code string) error {
+	return s.set(ctx, newEmailFlow, key, code)
+}
+
+func (s *CodeStore) ConsumeNewEmail(ctx context.Context, key, code string) error {
+	return s.consume(ctx, newEmailFlow, key, code)
+}
+
+func (s *CodeStore) SetResetPassword(ctx context.Context, key, code string) error {
+	return s.set(ctx, resetPasswordFlow, key, code)
+}
+
+func (s *CodeStore) ConsumeResetPassword(ctx context.Context, key, code string) error {
+	return s.consume(ctx, resetPasswordFlow, key, code)
+}
+
+func (s *CodeStore) SetNewPassword(ctx context.Context, key, code string) error {
+	return s.set(ctx, newPasswordFlow, key, code)
+}
+
+func (s *CodeStore) ConsumeNewPassword(ctx context.Context, key, code string) error {
+	return s.consume(ctx, newPasswordFlow, key, code)
+}
+
+// PurgeExpired has nothing to do, redis drops codes by their ttl.
+func (s *CodeStore) PurgeExpired(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (s *CodeStore) key(flow, key string) string {
+	return s.prefix + flow + ":" + key
+}
+
+func (s *CodeStore) set(ctx context.Context, flow, key, code string) error {
+	redisKey := s.key(flow, key)
+	ttl := strconv.FormatInt(s.ttl[flow].Milliseconds(), 10)
+	err := s.client.withConn(ctx, func(cn *conn) error {
+		if err := expectOK(cn.do("MULTI")); err != nil {
+			return err
+		}
+		commands := [][]string{
+			{"DEL", redisKey},
+			{"HSET", redisKey, "code", code, "attempts", "0"},
+			{"PEXPIRE", redisKey, ttl},
+		}
+		for _, command := range commands {
+			if _, err := cn.do(command...); err != nil {
+				cn.do("DISCARD")
+				return err
+			}
+		}
+		reply, err := cn.do("EXEC")
+		if err != nil {
+			return err
+		}
+		return execError(reply)
+	})
+	if err != nil {
+		return fmt.Errorf("%w: сохранение кода в redis: %w", app.ErrInternal, err)
+	}
+	return nil
+}
+
+func (s *CodeStore) consume(ctx context.Context, flow, key, code string) error {
+	redisKey := s.key(flow, key)
+	for range consumeRetries {
+		var result error
+		var committed bool
+		err := s.client.withConn(ctx, func(cn *conn) error {
+			var err error
+			result, committed, err = s.tryConsume(cn, redisKey, code)
+			if err != nil {
+				cn.do("UNWATCH")
+			}
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("%w: проверка кода в redis: %w", app.ErrInternal, err)
+		}
+		if committed {
+			return result
+		}
+	}
+	return fmt.Errorf("%w: код одновременно изменяется другим запросом", app.ErrConflict)
+}
+
+func (s *CodeStore) tryConsume(
+	cn *conn,
+	redisKey, code string,
+) (result error, committed bool, err error) {
+	if err := expectOK(cn.do("WATCH", redisKey)); err != nil {
+		return nil, false, err
+	}
+	reply, err := cn.do("HMGET", redisKey, "code", "attempts")
+	if err != nil {
+		return nil, false, err
+	}
+	fields, ok := reply.([]any)
+	if !ok || len(fields) != 2 {
+		return nil, false, fmt.Errorf("%w: HMGET вернул %v", ErrProtocol, reply)
+	}
+	stored, ok := fields[0].(string)
+	if !ok {
+		if _, err = cn.do("UNWATCH"); err != nil {
+			return nil, false, err
+		}
+		result = app.CodeExpiredError()
+		return result, true, nil
+	}
+	attempts, _ := fields[1].(string)
+	used, err := strconv.Atoi(attempts)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: количество попыток %q", ErrProtocol, attempts)
+	}
+
+	var command []string
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(code)) == 1 {
+		command = []string{"DEL", redisKey}
+	} else if used+1 >= s.maxAttempts {
+		command = []string{"DEL", redisKey}
+		result = app.CodeExhaustedError()
+	} else {
+		command = []string{"HINCRBY", redisKey, "attempts", "1"}
+		result = app.CodeInvalidError()
+	}
+
+	if err = expectOK(cn.do("MULTI")); err != nil {
+		return nil, false, err
+	}
+	if _, err = cn.do(command...); err != nil {
+		cn.do("DISCARD")
+		return nil, false, err
+	}
+	reply, err = cn.do("EXEC")
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	if err = execError(reply); err != nil {
+		return nil, false, err
+	}
+	return result, true, nil
+}
+
+func expectOK(reply any, err error) error {
+	if err != nil {
+		return err
+	}
+	if reply != "OK" {
+		return fmt.Errorf("%w: ожидался OK, получено %v", ErrProtocol, reply)
+	}
+	return nil
+}
+
+func execError(reply any) error {
+	replies, ok := reply.([]any)
+	if !ok {
+		return fmt.Errorf("%w: EXEC вернул %v", ErrProtocol, reply)
+	}
+	for _, r := range replies {
+		if err, ok := r.(ReplyError); ok {
+			return err
+		}
+	}
+	return nil
+}
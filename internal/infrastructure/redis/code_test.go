@@ -0,0 +1,190 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+func testCodeStore(t *testing.T) (*CodeStore, *testServer) {
+	t.Helper()
+	server := newTestServer(t, "secret")
+	client := MustClient(Config{Addr: server.addr(), Password: "secret"})
+	t.Cleanup(func() { client.Close() })
+	store := MustCodeStore(client, "dnd_users:code:", CodeTTL{
+		ConfirmEmail:  time.Minute,
+		NewEmail:      2 * time.Minute,
+		ResetPassword: 3 * time.Minute,
+		NewPassword:   4 * time.Minute,
+	}, 3)
+	return store, server
+}
+
+func TestCodeStore_Consume(t *testing.T) {
+	store, server := testCodeStore(t)
+	ctx := context.Background()
+	key := "test@mail.com"
+	flows := []struct {
+		Name    string
+		TTL     time.Duration
+		Set     func(key, code string) error
+		Consume func(key, code string) error
+	}{
+		{
+			Name:    "confirm_email",
+			TTL:     time.Minute,
+			Set:     func(k, c string) error { return store.SetConfirmEmail(ctx, k, c) },
+			Consume: func(k, c string) error { return store.ConsumeConfirmEmail(ctx, k, c) },
+		},
+		{
+			Name:    "new_email",
+			TTL:     2 * time.Minute,
+			Set:     func(k, c string) error { return store.SetNewEmail(ctx, k, c) },
+			Consume: func(k, c string) error { return store.ConsumeNewEmail(ctx, k, c) },
+		},
+		{
+			Name:    "reset_password",
+			TTL:     3 * time.Minute,
+			Set:     func(k, c string) error { return store.SetResetPassword(ctx, k, c) },
+			Consume: func(k, c string) error { return store.ConsumeResetPassword(ctx, k, c) },
+		},
+		{
+			Name:    "new_password",
+			TTL:     4 * time.Minute,
+			Set:     func(k, c string) error { return store.SetNewPassword(ctx, k, c) },
+			Consume: func(k, c string) error { return store.ConsumeNewPassword(ctx, k, c) },
+		},
+	}
+	for i, flow := range flows {
+		t.Run("test_code_store_"+flow.Name, func(t *testing.T) {
+			if err := flow.Set(key, flow.Name); err != nil {
+				t.Fatalf("set: %v", err)
+			}
+			redisKey := "dnd_users:code:" + flow.Name + ":" + key
+			if !slices.Contains(server.keys(), redisKey) {
+				t.Errorf("expected key %s, but server has %v", redisKey, server.keys())
+			}
+			next := flows[(i+1)%len(flows)]
+			if err := next.Consume(key, flow.Name); !errors.Is(err, app.ErrNotFound) {
+				t.Errorf("expected %s flow not to see %s code, but got %v",
+					next.Name, flow.Name, err)
+			}
+			if err := flow.Consume(key, flow.Name); err != nil {
+				t.Errorf("expected nil, but got %v", err)
+			}
+			if err := flow.Consume(key, flow.Name); !errors.Is(err, app.ErrNotFound) {
+				t.Errorf("expected %T after consume, but got %v", app.ErrNotFound, err)
+			}
+
+			if err := flow.Set(key, flow.Name); err != nil {
+				t.Fatalf("set: %v", err)
+			}
+			server.advance(flow.TTL - time.Second)
+			if err := flow.Consume(key, "wrong"); !errors.Is(err, app.ErrInvalidData) {
+				t.Errorf("expected code to live until ttl, but got %v", err)
+			}
+			server.advance(time.Second)
+			if err := flow.Consume(key, flow.Name); !errors.Is(err, app.ErrNotFound) {
+				t.Errorf("expected %T after ttl, but got %v", app.ErrNotFound, err)
+			}
+		})
+	}
+}
+
+func TestCodeStore_Attempts(t *testing.T) {
+	store, _ := testCodeStore(t)
+	ctx := context.Background()
+	key := "test@mail.com"
+	cases := []struct {
+		TestName string
+		Expected error
+		Code     string
+	}{
+		{TestName: "test_code_store_first_wrong_attempt", Expected: app.ErrInvalidData, Code: "1"},
+		{TestName: "test_code_store_second_wrong_attempt", Expected: app.ErrInvalidData, Code: "2"},
+		{TestName: "test_code_store_last_wrong_attempt", Expected: app.ErrInvalidData, Code: "3"},
+		{TestName: "test_code_store_code_invalidated", Expected: app.ErrNotFound, Code: "123456"},
+	}
+	if err := store.SetResetPassword(ctx, key, "123456"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			err := store.ConsumeResetPassword(ctx, key, c.Code)
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+
+	if err := store.SetResetPassword(ctx, key, "123456"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	for range 2 {
+		err := store.ConsumeResetPassword(ctx, key, "wrong")
+		if !errors.Is(err, app.ErrInvalidData) {
+			t.Fatalf("expected %T, but got %v", app.ErrInvalidData, err)
+		}
+	}
+	if err := store.SetResetPassword(ctx, key, "654321"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := store.ConsumeResetPassword(ctx, key, "wrong"); !errors.Is(err, app.ErrInvalidData) {
+		t.Fatalf("expected %T, but got %v", app.ErrInvalidData, err)
+	}
+	if err := store.ConsumeResetPassword(ctx, key, "654321"); err != nil {
+		t.Errorf("expected new code to reset attempts, but got %v", err)
+	}
+}
+
+func TestCodeStore_ConcurrentConsume(t *testing.T) {
+	store, _ := testCodeStore(t)
+	ctx := context.Background()
+	key := "test@mail.com"
+	if err := store.SetResetPassword(ctx, key, "123456"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	for range 16 {
+		wg.Go(func() {
+			if err := store.ConsumeResetPassword(ctx, key, "123456"); err == nil {
+				succeeded.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+	if succeeded.Load() != 1 {
+		t.Errorf("expected exactly one successful consume, but got %d", succeeded.Load())
+	}
+}
+
+func TestCodeStore_ServerUnavailable(t *testing.T) {
+	client := MustClient(Config{Addr: "127.0.0.1:1", DialTimeout: time.Second})
+	defer client.Close()
+	store := MustCodeStore(client, "", CodeTTL{
+		ConfirmEmail:  time.Minute,
+		NewEmail:      time.Minute,
+		ResetPassword: time.Minute,
+		NewPassword:   time.Minute,
+	}, 3)
+	ctx := context.Background()
+	err := store.SetConfirmEmail(ctx, "key", "code")
+	if !errors.Is(err, app.ErrInternal) {
+		t.Errorf("expected %T on set, but got %v", app.ErrInternal, err)
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		t.Errorf("expected dial error to stay reachable, but got %v", err)
+	}
+	if err := store.ConsumeConfirmEmail(ctx, "key", "code"); !errors.Is(err, app.ErrInternal) {
+		t.Errorf("expected %T on consume, but got %v", app.ErrInternal, err)
+	}
+}
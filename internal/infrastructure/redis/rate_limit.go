@@ -0,0 +1,210 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/ratelimit"
+)
+
+type RateLimiter struct {
+	client *Client
+	prefix string
+	policy *ratelimit.Policy
+	now    func() time.Time
+}
+
+func MustRateLimiter(client *Client, prefix string, cfg ratelimit.Config) *RateLimiter {
+	if client == nil {
+		panic("rate limiter did not get redis client")
+	}
+	return &RateLimiter{
+		client: client,
+		prefix: prefix,
+		policy: ratelimit.MustPolicy(cfg),
+		now:    time.Now,
+	}
+}
+
+func (l *RateLimiter) Allow(ctx context.Context, keys ...string) error {
+	return l.update(ctx, keys, func(now time.Time, states []*ratelimit.State) error {
+		return l.policy.Allow(now, states...)
+	})
+}
+
+func (l *RateLimiter) Fail(ctx context.Context, keys ...string) error {
+	return l.update(ctx, keys, func(now time.Time, states []*ratelimit.State) error {
+		l.policy.Fail(now, states...)
+		return nil
+	})
+}
+
+func (l *RateLimiter) Reset(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if _, err := l.client.Do(ctx, append([]string{"DEL"}, l.keys(keys)...)...); err != nil {
+		return fmt.Errorf("%w: сброс попыток в redis: %w", app.ErrInternal, err)
+	}
+	return nil
+}
+
+func (l *RateLimiter) keys(keys []string) []string {
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		redisKeys[i] = l.prefix + key
+	}
+	return redisKeys
+}
+
+func (l *RateLimiter) update(
+	ctx context.Context,
+	keys []string,
+	apply func(now time.Time, states []*ratelimit.State) error,
+) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	redisKeys := l.keys(keys)
+	for range consumeRetries {
+		var result error
+		var committed bool
+		err := l.client.withConn(ctx, func(cn *conn) error {
+			var err error
+			result, committed, err = l.tryUpdate(cn, redisKeys, apply)
+			if err != nil {
+				cn.do("UNWATCH")
+			}
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("%w: учет попыток в redis: %w", app.ErrInternal, err)
+		}
+		if committed {
+			return result
+		}
+	}
+	return fmt.Errorf("%w: попытки одновременно изменяются другим запросом", app.ErrConflict)
+}
+
+func (l *RateLimiter) tryUpdate(
+	cn *conn,
+	redisKeys []string,
+	apply func(now time.Time, states []*ratelimit.State) error,
+) (result error, committed bool, err error) {
+	if err := expectOK(cn.do(append([]string{"WATCH"}, redisKeys...)...)); err != nil {
+		return nil, false, err
+	}
+	states := make([]*ratelimit.State, len(redisKeys))
+	for i, key := range redisKeys {
+		if states[i], err = l.load(cn, key); err != nil {
+			return nil, false, err
+		}
+	}
+
+	now := l.now().Truncate(time.Millisecond)
+	if result = apply(now, states); result != nil {
+		if _, err = cn.do("UNWATCH"); err != nil {
+			return nil, false, err
+		}
+		return result, true, nil
+	}
+
+	if err = expectOK(cn.do("MULTI")); err != nil {
+		return nil, false, err
+	}
+	for i, key := range redisKeys {
+		for _, command := range l.save(key, states[i], now) {
+			if _, err = cn.do(command...); err != nil {
+				cn.do("DISCARD")
+				return nil, false, err
+			}
+		}
+	}
+	reply, err := cn.do("EXEC")
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	if err = execError(reply); err != nil {
+		return nil, false, err
+	}
+	return nil, true, nil
+}
+
+func (l *RateLimiter) load(cn *conn, key string) (*ratelimit.State, error) {
+	reply, err := cn.do(
+		"HMGET",
+		key,
+		"attempts",
+		"failures",
+		"last_failure",
+		"lockouts",
+		"locked_until",
+	)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := reply.([]any)
+	if !ok || len(fields) != 5 {
+		return nil, fmt.Errorf("%w: HMGET вернул %v", ErrProtocol, reply)
+	}
+	values := make([]int64, 0, 4)
+	for _, field := range fields[1:] {
+		raw, _ := field.(string)
+		if raw == "" {
+			values = append(values, 0)
+			continue
+		}
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: значение попыток %q", ErrProtocol, raw)
+		}
+		values = append(values, value)
+	}
+	state := &ratelimit.State{
+		Failures:    int(values[0]),
+		LastFailure: time.UnixMilli(values[1]),
+		Lockouts:    int(values[2]),
+		LockedUntil: time.UnixMilli(values[3]),
+	}
+	raw, _ := fields[0].(string)
+	for item := range strings.SplitSeq(raw, ",") {
+		if item == "" {
+			continue
+		}
+		at, err := strconv.ParseInt(item, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: время попытки %q", ErrProtocol, item)
+		}
+		state.Attempts = append(state.Attempts, time.UnixMilli(at))
+	}
+	return state, nil
+}
+
+func (l *RateLimiter) save(key string, state *ratelimit.State, now time.Time) [][]string {
+	attempts := make([]string, len(state.Attempts))
+	for i, at := range state.Attempts {
+		attempts[i] = strconv.FormatInt(at.UnixMilli(), 10)
+	}
+	ttl := max(l.policy.ExpiresAt(state).Sub(now), time.Millisecond)
+	return [][]string{
+		{"DEL", key},
+		{
+			"HSET",
+			key,
+			"attempts", strings.Join(attempts, ","),
+			"failures", strconv.Itoa(state.Failures),
+			"last_failure", strconv.FormatInt(state.LastFailure.UnixMilli(), 10),
+			"lockouts", strconv.Itoa(state.Lockouts),
+			"locked_until", strconv.FormatInt(state.LockedUntil.UnixMilli(), 10),
+		},
+		{"PEXPIRE", key, strconv.FormatInt(ttl.Milliseconds(), 10)},
+	}
+}
@@ -0,0 +1,174 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/ratelimit"
+)
+
+func testRateLimiter(t *testing.T, server *testServer) *RateLimiter {
+	t.Helper()
+	client := MustClient(Config{Addr: server.addr(), Password: "secret"})
+	t.Cleanup(func() { client.Close() })
+	limiter := MustRateLimiter(client, "dnd_users:limit:", ratelimit.Config{
+		Window:      time.Minute,
+		MaxAttempts: 5,
+		MaxFailures: 3,
+		Lockout:     time.Minute,
+		MaxLockout:  3 * time.Minute,
+	})
+	limiter.now = server.clock
+	return limiter
+}
+
+func retryAfter(t *testing.T, err error) time.Duration {
+	t.Helper()
+	var appErr *app.Error
+	if !errors.Is(err, app.ErrTooManyRequests) || !errors.As(err, &appErr) {
+		t.Fatalf("expected %v, but got %v", app.ErrTooManyRequests, err)
+	}
+	return appErr.RetryAfter
+}
+
+func TestRateLimiter_Window(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t, "secret")
+	limiter := testRateLimiter(t, server)
+
+	for i := range 5 {
+		if err := limiter.Allow(ctx, "email", "ip"); err != nil {
+			t.Fatalf("attempt %d: expected nil, but got %v", i+1, err)
+		}
+		server.advance(time.Second)
+	}
+	if delay := retryAfter(t, limiter.Allow(ctx, "email")); delay != 55*time.Second {
+		t.Errorf("expected retry in 55s, but got %v", delay)
+	}
+	if err := limiter.Allow(ctx, "other"); err != nil {
+		t.Errorf("expected other key to be allowed, but got %v", err)
+	}
+	if err := limiter.Allow(ctx, "other", "ip"); !errors.Is(err, app.ErrTooManyRequests) {
+		t.Errorf("expected shared ip key to block, but got %v", err)
+	}
+
+	server.advance(55 * time.Second)
+	if err := limiter.Allow(ctx, "email"); err != nil {
+		t.Errorf("expected oldest attempt to leave window, but got %v", err)
+	}
+}
+
+func TestRateLimiter_ProgressiveLockout(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t, "secret")
+	limiter := testRateLimiter(t, server)
+	cases := []struct {
+		TestName string
+		Expected time.Duration
+	}{
+		{TestName: "test_rate_limiter_first_lockout", Expected: time.Minute},
+		{TestName: "test_rate_limiter_second_lockout", Expected: 2 * time.Minute},
+		{TestName: "test_rate_limiter_capped_lockout", Expected: 3 * time.Minute},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			for range 3 {
+				if err := limiter.Fail(ctx, "email"); err != nil {
+					t.Fatalf("fail: %v", err)
+				}
+			}
+			if delay := retryAfter(t, limiter.Allow(ctx, "email")); delay != c.Expected {
+				t.Errorf("expected retry in %v, but got %v", c.Expected, delay)
+			}
+			server.advance(c.Expected)
+		})
+	}
+}
+
+func TestRateLimiter_SharedState(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t, "secret")
+	first := testRateLimiter(t, server)
+	second := testRateLimiter(t, server)
+
+	for range 2 {
+		if err := first.Fail(ctx, "email"); err != nil {
+			t.Fatalf("fail: %v", err)
+		}
+	}
+	if err := second.Fail(ctx, "email"); err != nil {
+		t.Fatalf("fail: %v", err)
+	}
+	if err := first.Allow(ctx, "email"); !errors.Is(err, app.ErrTooManyRequests) {
+		t.Errorf("expected failures from both instances to lock, but got %v", err)
+	}
+}
+
+func TestRateLimiter_Reset(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t, "secret")
+	limiter := testRateLimiter(t, server)
+
+	for range 3 {
+		if err := limiter.Fail(ctx, "email", "ip"); err != nil {
+			t.Fatalf("fail: %v", err)
+		}
+	}
+	if err := limiter.Reset(ctx, "email"); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if err := limiter.Allow(ctx, "email"); err != nil {
+		t.Errorf("expected nil after reset, but got %v", err)
+	}
+	if err := limiter.Allow(ctx, "ip"); !errors.Is(err, app.ErrTooManyRequests) {
+		t.Errorf("expected ip key to stay locked, but got %v", err)
+	}
+}
+
+func TestRateLimiter_Expiry(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t, "secret")
+	limiter := testRateLimiter(t, server)
+
+	if err := limiter.Allow(ctx, "email"); err != nil {
+		t.Fatalf("allow: %v", err)
+	}
+	for range 3 {
+		if err := limiter.Fail(ctx, "ip"); err != nil {
+			t.Fatalf("fail: %v", err)
+		}
+	}
+	server.advance(2 * time.Minute)
+	if slices.Contains(server.keys(), "dnd_users:limit:email") {
+		t.Error("expected idle key to expire")
+	}
+	if !slices.Contains(server.keys(), "dnd_users:limit:ip") {
+		t.Error("expected recently locked key to be kept")
+	}
+}
+
+func TestRateLimiter_ServerUnavailable(t *testing.T) {
+	client := MustClient(Config{Addr: "127.0.0.1:1", DialTimeout: time.Second})
+	defer client.Close()
+	limiter := MustRateLimiter(client, "", ratelimit.Config{
+		Window:      time.Minute,
+		MaxAttempts: 5,
+		MaxFailures: 3,
+		Lockout:     time.Minute,
+		MaxLockout:  time.Minute,
+	})
+	ctx := context.Background()
+	if err := limiter.Allow(ctx, "email"); !errors.Is(err, app.ErrInternal) {
+		t.Errorf("expected %T on allow, but got %v", app.ErrInternal, err)
+	}
+	if err := limiter.Fail(ctx, "email"); !errors.Is(err, app.ErrInternal) {
+		t.Errorf("expected %T on fail, but got %v", app.ErrInternal, err)
+	}
+	if err := limiter.Reset(ctx, "email"); !errors.Is(err, app.ErrInternal) {
+		t.Errorf("expected %T on reset, but got %v", app.ErrInternal, err)
+	}
+}
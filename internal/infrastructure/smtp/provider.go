@@ -0,0 +1,228 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+type Security string
+
+const (
+	SecurityNone     Security = "none"
+	SecurityStartTLS Security = "starttls"
+	SecurityTLS      Security = "tls"
+)
+
+var ErrSecurity = errors.New("небезопасное соединение с smtp сервером")
+
+type Config struct {
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	From      string
+	Security  Security
+	TLSConfig *tls.Config
+	Timeout   time.Duration
+}
+
+type Provider struct {
+	host      string
+	addr      string
+	from      string
+	security  Security
+	tlsConfig *tls.Config
+	timeout   time.Duration
+	auth      smtp.Auth
+	templates *Templates
+	now       func() time.Time
+}
+
+func MustProvider(cfg Config, templates *Templates) *Provider {
+	if cfg.Host == "" {
+		panic("smtp provider did not get host")
+	}
+	if cfg.From == "" {
+		panic("smtp provider did not get sender address")
+	}
+	switch cfg.Security {
+	case SecurityNone, SecurityStartTLS, SecurityTLS:
+	default:
+		panic(fmt.Sprintf("smtp provider got unknown security mode %q", cfg.Security))
+	}
+	if templates == nil {
+		panic("smtp provider did not get templates")
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSConfig != nil {
+		tlsConfig = cfg.TLSConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = cfg.Host
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return &Provider{
+		host:      cfg.Host,
+		addr:      net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		from:      cfg.From,
+		security:  cfg.Security,
+		tlsConfig: tlsConfig,
+		timeout:   cfg.Timeout,
+		auth:      auth,
+		templates: templates,
+		now:       time.Now,
+	}
+}
+
+func (p *Provider) Send(ctx context.Context, kind app.EmailKind, data app.EmailCode) error {
+	rendered, err := p.templates.render(
+		messageType(kind),
+		templateData{To: data.To, Code: data.Code, Locale: data.Locale},
+	)
+	if err != nil {
+		return err
+	}
+	msg, err := p.message(data.To, rendered)
+	if err != nil {
+		return err
+	}
+	return p.transmit(ctx, data.To, msg)
+}
+
+func (p *Provider) transmit(ctx context.Context, to string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	var dialer interface {
+		DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+	} = &net.Dialer{}
+	if p.security == SecurityTLS {
+		dialer = &tls.Dialer{Config: p.tlsConfig}
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", p.addr, err)
+	}
+	deadline, _ := ctx.Deadline()
+	if err = conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, p.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp greeting: %w", err)
+	}
+	defer client.Close()
+
+	if p.security == SecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%w: сервер не поддерживает STARTTLS", ErrSecurity)
+		}
+		if err = client.StartTLS(p.tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if p.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("%w: сервер не поддерживает AUTH", ErrSecurity)
+		}
+		if err = client.Auth(p.auth); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+	if err = client.Mail(p.from); err != nil {
+		return fmt.Errorf("mail from: %w", err)
+	}
+	if err = client.Rcpt(to); err != nil {
+		return fmt.Errorf("rcpt to: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+	if _, err = w.Write(msg); err != nil {
+		w.Close()
+		return fmt.Errorf("write message: %w", err)
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("finish message: %w", err)
+	}
+	return client.Quit()
+}
+
+func (p *Provider) message(to string, rendered renderedMessage) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	alternatives := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", rendered.Text},
+		{"text/html; charset=utf-8", rendered.HTML},
+	}
+	for _, alternative := range alternatives {
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {alternative.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(part)
+		if _, err = qp.Write([]byte(normalizeNewlines(alternative.content))); err != nil {
+			return nil, err
+		}
+		if err = qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", p.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", rendered.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", p.now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", rand.Text(), p.domain())
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n", parts.Boundary())
+	buf.WriteString("\r\n")
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+func (p *Provider) domain() string {
+	if _, domain, ok := strings.Cut(p.from, "@"); ok {
+		return strings.Trim(domain, "<> ")
+	}
+	return p.host
+}
+
+func normalizeNewlines(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
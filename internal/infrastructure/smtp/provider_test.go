@@ -0,0 +1,442 @@
+package smtp
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/i18n"
+)
+
+type sinkMessage struct {
+	From string
+	To   []string
+	Data string
+	User string
+	TLS  bool
+}
+
+type smtpSink struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	startTLS  bool
+	username  string
+	password  string
+	mu        sync.Mutex
+	messages  []sinkMessage
+}
+
+func testTLSConfigs(t *testing.T) (server *tls.Config, client *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+	return server, &tls.Config{RootCAs: pool}
+}
+
+func newSMTPSink(
+	t *testing.T,
+	security Security,
+	username, password string,
+) (*smtpSink, *tls.Config) {
+	t.Helper()
+	serverTLS, clientTLS := testTLSConfigs(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if security == SecurityTLS {
+		listener = tls.NewListener(listener, serverTLS)
+	}
+	sink := &smtpSink{
+		listener:  listener,
+		tlsConfig: serverTLS,
+		startTLS:  security == SecurityStartTLS,
+		username:  username,
+		password:  password,
+	}
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			wg.Go(func() { sink.serve(conn, security == SecurityTLS) })
+		}
+	})
+	t.Cleanup(func() {
+		listener.Close()
+		wg.Wait()
+	})
+	return sink, clientTLS
+}
+
+func (s *smtpSink) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpSink) received() []sinkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]sinkMessage(nil), s.messages...)
+}
+
+func (s *smtpSink) serve(conn net.Conn, secure bool) {
+	defer func() { conn.Close() }()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	text := textproto.NewConn(conn)
+	var msg sinkMessage
+	var user string
+	text.PrintfLine("220 sink ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			lines := []string{"sink"}
+			if s.startTLS && !secure {
+				lines = append(lines, "STARTTLS")
+			}
+			if s.username != "" {
+				lines = append(lines, "AUTH PLAIN")
+			}
+			lines = append(lines, "8BITMIME")
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				text.PrintfLine("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			text.PrintfLine("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err = tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(conn)
+			secure = true
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			decoded, err := base64.StdEncoding.DecodeString(initial)
+			parts := strings.Split(string(decoded), "\x00")
+			if strings.ToUpper(mechanism) != "PLAIN" || err != nil || len(parts) != 3 ||
+				parts[1] != s.username || parts[2] != s.password {
+				text.PrintfLine("535 authentication failed")
+				continue
+			}
+			user = parts[1]
+			text.PrintfLine("235 authenticated")
+		case "MAIL":
+			if s.username != "" && user == "" {
+				text.PrintfLine("530 authentication required")
+				continue
+			}
+			msg = sinkMessage{From: envelopeAddress(arg, "FROM:"), User: user}
+			text.PrintfLine("250 ok")
+		case "RCPT":
+			msg.To = append(msg.To, envelopeAddress(arg, "TO:"))
+			text.PrintfLine("250 ok")
+		case "DATA":
+			text.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			msg.Data = string(data)
+			msg.TLS = secure
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			text.PrintfLine("250 queued")
+		case "RSET", "NOOP":
+			text.PrintfLine("250 ok")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+func envelopeAddress(arg, prefix string) string {
+	address, _, _ := strings.Cut(strings.TrimPrefix(arg, prefix), " ")
+	return strings.Trim(address, "<>")
+}
+
+type parsedMessage struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+func parseMessage(t *testing.T, data string) parsedMessage {
+	t.Helper()
+	msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(data)))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("decode subject: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("expected multipart/alternative, but got %s, %v", mediaType, err)
+	}
+	parsed := parsedMessage{To: msg.Header.Get("To"), Subject: subject}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		content, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatalf("decode part: %v", err)
+		}
+		switch {
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain"):
+			parsed.Text = string(content)
+		case strings.HasPrefix(part.Header.Get("Content-Type"), "text/html"):
+			parsed.HTML = string(content)
+		}
+	}
+	return parsed
+}
+
+func testProvider(t *testing.T, cfg Config, clientTLS *tls.Config) *Provider {
+	t.Helper()
+	cfg.Host = "127.0.0.1"
+	cfg.From = "noreply@mail.com"
+	cfg.TLSConfig = clientTLS
+	cfg.Timeout = 5 * time.Second
+	return MustProvider(cfg, MustDefaultTemplates(i18n.MustDefaultCatalog()))
+}
+
+func TestProvider_Send(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Security Security
+		Username string
+		TLS      bool
+	}{
+		{TestName: "test_smtp_provider_plain", Security: SecurityNone},
+		{
+			TestName: "test_smtp_provider_starttls_auth",
+			Security: SecurityStartTLS,
+			Username: "user",
+			TLS:      true,
+		},
+		{
+			TestName: "test_smtp_provider_implicit_tls_auth",
+			Security: SecurityTLS,
+			Username: "user",
+			TLS:      true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			sink, clientTLS := newSMTPSink(t, c.Security, c.Username, "secret")
+			provider := testProvider(t, Config{
+				Port:     sink.port(),
+				Username: c.Username,
+				Password: "secret",
+				Security: c.Security,
+			}, clientTLS)
+
+			expected := []struct {
+				Kind    app.EmailKind
+				To      string
+				Code    string
+				Subject string
+			}{
+				{app.ConfirmationEmail, "test@mail.com", "123456", "Подтверждение email"},
+				{app.NewEmailConfirmation, "old@mail.com", "111111", "Смена email"},
+				{app.NewEmailConfirmation, "new@mail.com", "222222", "Смена email"},
+				{app.ResetPasswordEmail, "test@mail.com", "333333", "Сброс пароля"},
+				{app.NewPasswordConfirmation, "test@mail.com", "444444", "Смена пароля"},
+			}
+			for _, e := range expected {
+				err := provider.Send(
+					context.Background(),
+					e.Kind,
+					app.EmailCode{To: e.To, Code: e.Code},
+				)
+				if err != nil {
+					t.Fatalf("send %s: %v", e.Kind, err)
+				}
+			}
+			messages := sink.received()
+			if len(messages) != len(expected) {
+				t.Fatalf("expected %d messages, but got %d", len(expected), len(messages))
+			}
+			for i, e := range expected {
+				msg := messages[i]
+				if msg.From != "noreply@mail.com" || len(msg.To) != 1 || msg.To[0] != e.To {
+					t.Errorf("expected envelope noreply@mail.com -> %s, but got %v", e.To, msg)
+				}
+				if msg.TLS != c.TLS || msg.User != c.Username {
+					t.Errorf("expected tls %v and user %q, but got %v and %q",
+						c.TLS, c.Username, msg.TLS, msg.User)
+				}
+				parsed := parseMessage(t, msg.Data)
+				if parsed.To != e.To || parsed.Subject != e.Subject {
+					t.Errorf("expected message to %s with subject %s, but got %v", e.To, e.Subject, parsed)
+				}
+				if !strings.Contains(parsed.Text, e.Code) || strings.Contains(parsed.Text, "<") {
+					t.Errorf("expected plain text part with code %s, but got %q", e.Code, parsed.Text)
+				}
+				if !strings.Contains(parsed.HTML, "<strong>"+e.Code+"</strong>") {
+					t.Errorf("expected html part with code %s, but got %q", e.Code, parsed.HTML)
+				}
+			}
+		})
+	}
+}
+
+func TestProvider_SendError(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Expected error
+		Sink     Security
+		Security Security
+		Username string
+		Password string
+		SinkUser string
+	}{
+		{
+			TestName: "test_smtp_provider_starttls_not_supported",
+			Expected: ErrSecurity,
+			Sink:     SecurityNone,
+			Security: SecurityStartTLS,
+		},
+		{
+			TestName: "test_smtp_provider_auth_not_supported",
+			Sink:     SecurityStartTLS,
+			Security: SecurityStartTLS,
+			Username: "user",
+			Password: "secret",
+			Expected: ErrSecurity,
+		},
+		{
+			TestName: "test_smtp_provider_wrong_password",
+			Sink:     SecurityTLS,
+			Security: SecurityTLS,
+			Username: "user",
+			Password: "wrong",
+			SinkUser: "user",
+		},
+		{
+			TestName: "test_smtp_provider_auth_required",
+			Sink:     SecurityStartTLS,
+			Security: SecurityStartTLS,
+			SinkUser: "user",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			sink, clientTLS := newSMTPSink(t, c.Sink, c.SinkUser, "secret")
+			provider := testProvider(t, Config{
+				Port:     sink.port(),
+				Username: c.Username,
+				Password: c.Password,
+				Security: c.Security,
+			}, clientTLS)
+			err := provider.Send(
+				context.Background(),
+				app.ConfirmationEmail,
+				app.EmailCode{To: "test@mail.com", Code: "1"},
+			)
+			if err == nil {
+				t.Fatalf("expected error, but got nil")
+			}
+			if c.Expected != nil && !errors.Is(err, c.Expected) {
+				t.Errorf("expected %v, but got %v", c.Expected, err)
+			}
+			if len(sink.received()) != 0 {
+				t.Errorf("expected no delivered messages, but got %d", len(sink.received()))
+			}
+		})
+	}
+}
+
+func TestProvider_SendUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	provider := testProvider(t, Config{Port: port, Security: SecurityNone}, nil)
+	err = provider.Send(
+		context.Background(),
+		app.ConfirmationEmail,
+		app.EmailCode{To: "test@mail.com", Code: "1"},
+	)
+	if err == nil || !strings.Contains(err.Error(), "127.0.0.1:"+strconv.Itoa(port)) {
+		t.Errorf("expected dial error, but got %v", err)
+	}
+}
+
+func TestProvider_SendUnknownKind(t *testing.T) {
+	provider := testProvider(t, Config{Port: 25, Security: SecurityNone}, nil)
+	err := provider.Send(context.Background(), "unknown", app.EmailCode{To: "test@mail.com"})
+	if !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("expected %v, but got %v", ErrInvalidTemplate, err)
+	}
+}
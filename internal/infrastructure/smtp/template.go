@@ -0,0 +1,149 @@
+package smtp
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/Nemagu/dnd_users/internal/i18n"
+)
+
+//go:embed templates/*.txt templates/*.html
+var defaultTemplates embed.FS
+
+var ErrInvalidTemplate = errors.New("некорректный шаблон письма")
+
+type messageType string
+
+const (
+	confirmEmailMessage  messageType = "confirm_email"
+	newEmailMessage      messageType = "new_email"
+	resetPasswordMessage messageType = "reset_password"
+	newPasswordMessage   messageType = "new_password"
+
+	registrationAttemptMessage messageType = "registration_attempt"
+	unknownAccountResetMessage messageType = "reset_password_unknown"
+)
+
+var messageTypes = []messageType{
+	confirmEmailMessage,
+	newEmailMessage,
+	resetPasswordMessage,
+	newPasswordMessage,
+	registrationAttemptMessage,
+	unknownAccountResetMessage,
+}
+
+type Templates struct {
+	messages map[messageType]messageTemplate
+	catalog  *i18n.Catalog
+}
+
+type messageTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+type templateData struct {
+	To     string
+	Code   string
+	Locale string
+}
+
+type renderedMessage struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+func MustDefaultTemplates(catalog *i18n.Catalog) *Templates {
+	sub, err := fs.Sub(defaultTemplates, "templates")
+	if err != nil {
+		panic(fmt.Sprintf("smtp could not open embedded templates: %s", err))
+	}
+	templates, err := LoadTemplates(sub, catalog)
+	if err != nil {
+		panic(fmt.Sprintf("smtp could not load embedded templates: %s", err))
+	}
+	return templates
+}
+
+func LoadTemplates(fsys fs.FS, catalog *i18n.Catalog) (*Templates, error) {
+	if catalog == nil {
+		return nil, fmt.Errorf("%w: не передан каталог сообщений", ErrInvalidTemplate)
+	}
+	templates := &Templates{
+		messages: make(map[messageType]messageTemplate),
+		catalog:  catalog,
+	}
+	funcs := translateFuncs(catalog, i18n.DefaultLocale)
+	for _, t := range messageTypes {
+		text, err := texttemplate.New(string(t)+".txt").
+			Funcs(texttemplate.FuncMap(funcs)).
+			ParseFS(fsys, string(t)+".txt")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s.txt: %s", ErrInvalidTemplate, t, err)
+		}
+		if text.Lookup("subject") == nil {
+			return nil, fmt.Errorf("%w: в %s.txt нет шаблона subject", ErrInvalidTemplate, t)
+		}
+		html, err := htmltemplate.New(string(t)+".html").
+			Funcs(htmltemplate.FuncMap(funcs)).
+			ParseFS(fsys, string(t)+".html")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s.html: %s", ErrInvalidTemplate, t, err)
+		}
+		templates.messages[t] = messageTemplate{text: text, html: html}
+	}
+	return templates, nil
+}
+
+func (t *Templates) render(kind messageType, data templateData) (renderedMessage, error) {
+	tmpl, ok := t.messages[kind]
+	if !ok {
+		return renderedMessage{}, fmt.Errorf("%w: неизвестный тип письма %s", ErrInvalidTemplate, kind)
+	}
+	if !t.catalog.Supports(data.Locale) {
+		data.Locale = i18n.DefaultLocale
+	}
+	funcs := translateFuncs(t.catalog, data.Locale)
+	textTmpl, err := tmpl.text.Clone()
+	if err != nil {
+		return renderedMessage{}, fmt.Errorf("%w: %s.txt: %s", ErrInvalidTemplate, kind, err)
+	}
+	htmlTmpl, err := tmpl.html.Clone()
+	if err != nil {
+		return renderedMessage{}, fmt.Errorf("%w: %s.html: %s", ErrInvalidTemplate, kind, err)
+	}
+	textTmpl.Funcs(texttemplate.FuncMap(funcs))
+	htmlTmpl.Funcs(htmltemplate.FuncMap(funcs))
+
+	var subject, text, html bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return renderedMessage{}, fmt.Errorf("%w: %s subject: %s", ErrInvalidTemplate, kind, err)
+	}
+	if err := textTmpl.Execute(&text, data); err != nil {
+		return renderedMessage{}, fmt.Errorf("%w: %s.txt: %s", ErrInvalidTemplate, kind, err)
+	}
+	if err := htmlTmpl.Execute(&html, data); err != nil {
+		return renderedMessage{}, fmt.Errorf("%w: %s.html: %s", ErrInvalidTemplate, kind, err)
+	}
+	return renderedMessage{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+func translateFuncs(catalog *i18n.Catalog, locale string) map[string]any {
+	return map[string]any{
+		"t": func(id string, args ...any) string {
+			return catalog.Message(locale, i18n.MessageID(id), args...)
+		},
+	}
+}
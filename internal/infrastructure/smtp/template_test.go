@@ -0,0 +1,180 @@
+package smtp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Nemagu/dnd_users/internal/i18n"
+)
+
+func testTemplateFS() fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, t := range messageTypes {
+		fsys[string(t)+".txt"] = &fstest.MapFile{
+			Data: []byte(`{{define "subject"}}Тема ` + string(t) + `{{end -}}` + "\nКод: {{.Code}}\n"),
+		}
+		fsys[string(t)+".html"] = &fstest.MapFile{
+			Data: []byte(`<p>Код: <b>{{.Code}}</b> для {{.To}}</p>`),
+		}
+	}
+	return fsys
+}
+
+func TestLoadTemplates(t *testing.T) {
+	missingHTML := testTemplateFS()
+	delete(missingHTML, "reset_password.html")
+	withoutSubject := testTemplateFS()
+	withoutSubject["new_email.txt"] = &fstest.MapFile{Data: []byte("Код: {{.Code}}")}
+	invalidSyntax := testTemplateFS()
+	invalidSyntax["confirm_email.html"] = &fstest.MapFile{Data: []byte("{{.Code")}
+	cases := []struct {
+		TestName string
+		Expected error
+		FS       fstest.MapFS
+		Catalog  *i18n.Catalog
+	}{
+		{
+			TestName: "test_load_templates_ok",
+			Expected: nil,
+			FS:       testTemplateFS(),
+			Catalog:  i18n.MustDefaultCatalog(),
+		},
+		{
+			TestName: "test_load_templates_missing_html",
+			Expected: ErrInvalidTemplate,
+			FS:       missingHTML,
+			Catalog:  i18n.MustDefaultCatalog(),
+		},
+		{
+			TestName: "test_load_templates_without_subject",
+			Expected: ErrInvalidTemplate,
+			FS:       withoutSubject,
+			Catalog:  i18n.MustDefaultCatalog(),
+		},
+		{
+			TestName: "test_load_templates_invalid_syntax",
+			Expected: ErrInvalidTemplate,
+			FS:       invalidSyntax,
+			Catalog:  i18n.MustDefaultCatalog(),
+		},
+		{
+			TestName: "test_load_templates_without_catalog",
+			Expected: ErrInvalidTemplate,
+			FS:       testTemplateFS(),
+			Catalog:  nil,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			_, err := LoadTemplates(c.FS, c.Catalog)
+			if c.Expected == nil && err != nil {
+				t.Errorf("expected nil, but got %v", err)
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+}
+
+func TestTemplates_Render(t *testing.T) {
+	templates, err := LoadTemplates(testTemplateFS(), i18n.MustDefaultCatalog())
+	if err != nil {
+		t.Fatalf("load templates: %v", err)
+	}
+	rendered, err := templates.render(
+		resetPasswordMessage,
+		templateData{To: "<script>@mail.com", Code: "a&b"},
+	)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if rendered.Subject != "Тема reset_password" {
+		t.Errorf("expected subject from template, but got %q", rendered.Subject)
+	}
+	if !strings.Contains(rendered.Text, "Код: a&b") {
+		t.Errorf("expected unescaped code in text part, but got %q", rendered.Text)
+	}
+	if !strings.Contains(rendered.HTML, "<b>a&amp;b</b>") ||
+		strings.Contains(rendered.HTML, "<script>") {
+		t.Errorf("expected escaped html part, but got %q", rendered.HTML)
+	}
+}
+
+func TestMustDefaultTemplates(t *testing.T) {
+	templates := MustDefaultTemplates(i18n.MustDefaultCatalog())
+	for _, kind := range messageTypes {
+		rendered, err := templates.render(
+			kind,
+			templateData{To: "test@mail.com", Code: "123456", Locale: "ru"},
+		)
+		if err != nil {
+			t.Fatalf("render %s: %v", kind, err)
+		}
+		if rendered.Subject == "" || strings.Contains(rendered.Subject, "\n") {
+			t.Errorf("expected single line subject for %s, but got %q", kind, rendered.Subject)
+		}
+		expected := "123456"
+		if kind == registrationAttemptMessage || kind == unknownAccountResetMessage {
+			expected = "test@mail.com"
+		}
+		if !strings.Contains(rendered.Text, expected) || !strings.Contains(rendered.HTML, expected) {
+			t.Errorf("expected %s in both parts of %s", expected, kind)
+		}
+	}
+}
+
+func TestMustDefaultTemplates_Locale(t *testing.T) {
+	templates := MustDefaultTemplates(i18n.MustDefaultCatalog())
+	cases := []struct {
+		TestName string
+		Subject  string
+		Hint     string
+		Lang     string
+		Locale   string
+	}{
+		{
+			TestName: "test_render_ru",
+			Subject:  "Смена email",
+			Hint:     "Код нужен, чтобы подтвердить смену адреса на new@mail.com.",
+			Lang:     `lang="ru"`,
+			Locale:   "ru",
+		},
+		{
+			TestName: "test_render_en",
+			Subject:  "Email change",
+			Hint:     "This code confirms changing your address to new@mail.com.",
+			Lang:     `lang="en"`,
+			Locale:   "en",
+		},
+		{
+			TestName: "test_render_unsupported_locale",
+			Subject:  "Смена email",
+			Hint:     "Код нужен, чтобы подтвердить смену адреса на new@mail.com.",
+			Lang:     `lang="ru"`,
+			Locale:   "de",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			rendered, err := templates.render(
+				newEmailMessage,
+				templateData{To: "new@mail.com", Code: "123456", Locale: c.Locale},
+			)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			if rendered.Subject != c.Subject {
+				t.Errorf("expected subject %q, but got %q", c.Subject, rendered.Subject)
+			}
+			if !strings.Contains(rendered.Text, c.Hint) || !strings.Contains(rendered.HTML, c.Hint) {
+				t.Errorf("expected hint %q in both parts, but got %q", c.Hint, rendered.Text)
+			}
+			if !strings.Contains(rendered.HTML, c.Lang) {
+				t.Errorf("expected %s in html part, but got %q", c.Lang, rendered.HTML)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+)
+
+const (
+	algorithm = "EdDSA"
+	tokenType = "JWT"
+	leeway    = 30 * time.Second
+)
+
+var encoding = base64.RawURLEncoding
+
+type Claims struct {
+	UserID    uuid.UUID
+	Status    string
+	State     string
+	Version   uint
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+type payload struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	Status    string `json:"status"`
+	State     string `json:"state"`
+	Version   uint   `json:"ver"`
+}
+
+type Issuer struct {
+	keys   *KeySet
+	issuer string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func MustIssuer(keys *KeySet, issuer string, ttl time.Duration) *Issuer {
+	if keys == nil {
+		panic("token issuer did not get key set")
+	}
+	if issuer == "" {
+		panic("token issuer did not get issuer name")
+	}
+	if ttl <= 0 {
+		panic("token issuer did not get token ttl")
+	}
+	return &Issuer{keys: keys, issuer: issuer, ttl: ttl, now: time.Now}
+}
+
+func (i *Issuer) Issue(user *app.User) (string, error) {
+	if user == nil {
+		return "", fmt.Errorf("%w: получен nil для выпуска токена", app.ErrInternal)
+	}
+	keyID, key := i.keys.signing()
+	now := i.now()
+
+	rawHeader, err := json.Marshal(header{Algorithm: algorithm, Type: tokenType, KeyID: keyID})
+	if err != nil {
+		return "", fmt.Errorf("%w: кодирование заголовка токена: %s", app.ErrInternal, err)
+	}
+	rawPayload, err := json.Marshal(payload{
+		Issuer:    i.issuer,
+		Subject:   user.ID.String(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(i.ttl).Unix(),
+		Status:    user.Status,
+		State:     user.State,
+		Version:   user.Version,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: кодирование данных токена: %s", app.ErrInternal, err)
+	}
+
+	signingInput := encoding.EncodeToString(rawHeader) + "." + encoding.EncodeToString(rawPayload)
+	signature := ed25519.Sign(key, []byte(signingInput))
+	return signingInput + "." + encoding.EncodeToString(signature), nil
+}
+
+type Verifier struct {
+	keys   *KeySet
+	issuer string
+	now    func() time.Time
+}
+
+func MustVerifier(keys *KeySet, issuer string) *Verifier {
+	if keys == nil {
+		panic("token verifier did not get key set")
+	}
+	if issuer == "" {
+		panic("token verifier did not get issuer name")
+	}
+	return &Verifier{keys: keys, issuer: issuer, now: time.Now}
+}
+
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: токен имеет неверный формат", app.ErrUnauthenticated)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, err
+	}
+	if h.Algorithm != algorithm {
+		return nil, fmt.Errorf(
+			"%w: алгоритм подписи токена %s не поддерживается",
+			app.ErrUnauthenticated,
+			h.Algorithm,
+		)
+	}
+	key, ok := v.keys.verifying(h.KeyID)
+	if !ok {
+		return nil, fmt.Errorf("%w: неизвестный ключ подписи токена %s", app.ErrUnauthenticated, h.KeyID)
+	}
+
+	signature, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: подпись токена имеет неверный формат", app.ErrUnauthenticated)
+	}
+	if !ed25519.Verify(key, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, fmt.Errorf("%w: неверная подпись токена", app.ErrUnauthenticated)
+	}
+
+	var p payload
+	if err = decodeSegment(parts[1], &p); err != nil {
+		return nil, err
+	}
+	if p.Issuer != v.issuer {
+		return nil, fmt.Errorf("%w: токен выпущен %s", app.ErrUnauthenticated, p.Issuer)
+	}
+	now := v.now()
+	expiresAt := time.Unix(p.ExpiresAt, 0)
+	if !now.Before(expiresAt.Add(leeway)) {
+		return nil, fmt.Errorf("%w: срок действия токена истек", app.ErrUnauthenticated)
+	}
+	issuedAt := time.Unix(p.IssuedAt, 0)
+	if issuedAt.After(now.Add(leeway)) {
+		return nil, fmt.Errorf("%w: токен выпущен в будущем", app.ErrUnauthenticated)
+	}
+	userID, err := uuid.Parse(p.Subject)
+	if err != nil || userID == uuid.Nil {
+		return nil, fmt.Errorf("%w: токен содержит неверный id пользователя", app.ErrUnauthenticated)
+	}
+
+	return &Claims{
+		UserID:    userID,
+		Status:    p.Status,
+		State:     p.State,
+		Version:   p.Version,
+		IssuedAt:  issuedAt,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (v *Verifier) InitiatorID(token string) (uuid.UUID, error) {
+	claims, err := v.Verify(token)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return claims.UserID, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	raw, err := encoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: токен имеет неверную кодировку", app.ErrUnauthenticated)
+	}
+	if err = json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%w: токен содержит неверный JSON", app.ErrUnauthenticated)
+	}
+	return nil
+}
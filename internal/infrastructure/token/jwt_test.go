@@ -0,0 +1,215 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/google/uuid"
+)
+
+const testIssuer = "dnd_users"
+
+func testKey(t *testing.T, id string) Key {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return Key{ID: id, PrivateKey: private}
+}
+
+func testUser() *app.User {
+	return &app.User{
+		ID:           uuid.New(),
+		Email:        "test@mail.com",
+		State:        domain.ACTIVE,
+		Status:       domain.ADMIN,
+		Locale:       domain.RU,
+		PasswordHash: "hash",
+		Version:      3,
+	}
+}
+
+func TestIssuer_Issue(t *testing.T) {
+	key := testKey(t, "2026-01")
+	keys := MustKeySet(key.ID, key)
+	issuer := MustIssuer(keys, testIssuer, time.Minute)
+	verifier := MustVerifier(keys, testIssuer)
+	user := testUser()
+
+	token, err := issuer.Issue(user)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	claims, err := verifier.Verify(token)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if claims.UserID != user.ID ||
+		claims.Status != user.Status ||
+		claims.State != user.State ||
+		claims.Version != user.Version {
+		t.Errorf("expected claims of %v, but got %v", user, claims)
+	}
+	if claims.ExpiresAt.Sub(claims.IssuedAt) != time.Minute {
+		t.Errorf("expected token ttl %v, but got %v", time.Minute, claims.ExpiresAt.Sub(claims.IssuedAt))
+	}
+
+	id, err := verifier.InitiatorID(token)
+	if err != nil || id != user.ID {
+		t.Errorf("expected initiator id %s, but got %s, %v", user.ID, id, err)
+	}
+
+	if _, err = issuer.Issue(nil); !errors.Is(err, app.ErrInternal) {
+		t.Errorf("expected %T, but got %v", app.ErrInternal, err)
+	}
+}
+
+func TestVerifier_Rotation(t *testing.T) {
+	oldKey := testKey(t, "old")
+	newKey := testKey(t, "new")
+	user := testUser()
+
+	oldToken, err := MustIssuer(MustKeySet(oldKey.ID, oldKey), testIssuer, time.Minute).Issue(user)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	rotated := MustKeySet(
+		newKey.ID,
+		newKey,
+		Key{ID: oldKey.ID, PublicKey: oldKey.PrivateKey.Public().(ed25519.PublicKey)},
+	)
+	newToken, err := MustIssuer(rotated, testIssuer, time.Minute).Issue(user)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	verifier := MustVerifier(rotated, testIssuer)
+	for _, token := range []string{oldToken, newToken} {
+		if _, err = verifier.Verify(token); err != nil {
+			t.Errorf("expected token to verify after rotation, but got %v", err)
+		}
+	}
+
+	retired := MustVerifier(MustKeySet(newKey.ID, newKey), testIssuer)
+	if _, err = retired.Verify(oldToken); !errors.Is(err, app.ErrUnauthenticated) {
+		t.Errorf("expected %T for retired key, but got %v", app.ErrUnauthenticated, err)
+	}
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	key := testKey(t, "key")
+	keys := MustKeySet(key.ID, key)
+	now := time.Now()
+	issuer := MustIssuer(keys, testIssuer, time.Minute)
+	issuer.now = func() time.Time { return now }
+	valid, err := issuer.Issue(testUser())
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	parts := strings.Split(valid, ".")
+
+	sign := func(h header, p payload) string {
+		rawHeader, _ := json.Marshal(h)
+		rawPayload, _ := json.Marshal(p)
+		input := encoding.EncodeToString(rawHeader) + "." + encoding.EncodeToString(rawPayload)
+		return input + "." + encoding.EncodeToString(ed25519.Sign(key.PrivateKey, []byte(input)))
+	}
+	validPayload := payload{
+		Issuer:    testIssuer,
+		Subject:   uuid.NewString(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Minute).Unix(),
+	}
+	validHeader := header{Algorithm: algorithm, Type: tokenType, KeyID: key.ID}
+
+	cases := []struct {
+		TestName string
+		Expected error
+		Token    string
+		Now      time.Time
+	}{
+		{TestName: "test_verify_ok", Expected: nil, Token: valid, Now: now},
+		{
+			TestName: "test_verify_expired",
+			Expected: app.ErrUnauthenticated,
+			Token:    valid,
+			Now:      now.Add(time.Minute + leeway),
+		},
+		{
+			TestName: "test_verify_issued_in_future",
+			Expected: app.ErrUnauthenticated,
+			Token:    valid,
+			Now:      now.Add(-time.Minute),
+		},
+		{TestName: "test_verify_malformed", Expected: app.ErrUnauthenticated, Token: "a.b", Now: now},
+		{
+			TestName: "test_verify_tampered_payload",
+			Expected: app.ErrUnauthenticated,
+			Token:    parts[0] + "." + encoding.EncodeToString([]byte(`{"sub":"x"}`)) + "." + parts[2],
+			Now:      now,
+		},
+		{
+			TestName: "test_verify_invalid_signature_encoding",
+			Expected: app.ErrUnauthenticated,
+			Token:    parts[0] + "." + parts[1] + ".***",
+			Now:      now,
+		},
+		{
+			TestName: "test_verify_alg_none",
+			Expected: app.ErrUnauthenticated,
+			Token: encoding.EncodeToString([]byte(`{"alg":"none","kid":"key"}`)) +
+				"." + parts[1] + ".",
+			Now: now,
+		},
+		{
+			TestName: "test_verify_unknown_kid",
+			Expected: app.ErrUnauthenticated,
+			Token:    sign(header{Algorithm: algorithm, KeyID: "unknown"}, validPayload),
+			Now:      now,
+		},
+		{
+			TestName: "test_verify_other_issuer",
+			Expected: app.ErrUnauthenticated,
+			Token: sign(validHeader, payload{
+				Issuer:    "other",
+				Subject:   validPayload.Subject,
+				IssuedAt:  validPayload.IssuedAt,
+				ExpiresAt: validPayload.ExpiresAt,
+			}),
+			Now: now,
+		},
+		{
+			TestName: "test_verify_invalid_subject",
+			Expected: app.ErrUnauthenticated,
+			Token: sign(validHeader, payload{
+				Issuer:    testIssuer,
+				Subject:   "not-uuid",
+				IssuedAt:  validPayload.IssuedAt,
+				ExpiresAt: validPayload.ExpiresAt,
+			}),
+			Now: now,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			verifier := MustVerifier(keys, testIssuer)
+			verifier.now = func() time.Time { return c.Now }
+			_, err := verifier.Verify(c.Token)
+			if c.Expected == nil {
+				if err != nil {
+					t.Errorf("expected nil, but got %v", err)
+				}
+			}
+			if !errors.Is(err, c.Expected) {
+				t.Errorf("expected %T, but got %v", c.Expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+type Key struct {
+	ID         string
+	PrivateKey ed25519.PrivateKey
+	PublicKey  ed25519.PublicKey
+}
+
+type KeySet struct {
+	activeID string
+	private  map[string]ed25519.PrivateKey
+	public   map[string]ed25519.PublicKey
+}
+
+func MustKeySet(activeID string, keys ...Key) *KeySet {
+	set := &KeySet{
+		activeID: activeID,
+		private:  make(map[string]ed25519.PrivateKey),
+		public:   make(map[string]ed25519.PublicKey),
+	}
+	for _, key := range keys {
+		if key.ID == "" {
+			panic("key set did not get key id")
+		}
+		if _, ok := set.public[key.ID]; ok {
+			panic(fmt.Sprintf("key set got duplicate key id %s", key.ID))
+		}
+		switch {
+		case key.PrivateKey != nil:
+			if len(key.PrivateKey) != ed25519.PrivateKeySize {
+				panic(fmt.Sprintf("key set got invalid private key %s", key.ID))
+			}
+			set.private[key.ID] = key.PrivateKey
+			set.public[key.ID] = key.PrivateKey.Public().(ed25519.PublicKey)
+		case key.PublicKey != nil:
+			if len(key.PublicKey) != ed25519.PublicKeySize {
+				panic(fmt.Sprintf("key set got invalid public key %s", key.ID))
+			}
+			set.public[key.ID] = key.PublicKey
+		default:
+			panic(fmt.Sprintf("key set did not get key material for %s", key.ID))
+		}
+	}
+	if _, ok := set.private[activeID]; !ok {
+		panic(fmt.Sprintf("key set did not get private key for active key id %s", activeID))
+	}
+	return set
+}
+
+func (s *KeySet) signing() (string, ed25519.PrivateKey) {
+	return s.activeID, s.private[s.activeID]
+}
+
+func (s *KeySet) verifying(id string) (ed25519.PublicKey, bool) {
+	key, ok := s.public[id]
+	return key, ok
+}
+
+func ParsePrivateKey(id string, data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("ключ %s не является PEM", id)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return Key{}, fmt.Errorf("разбор закрытого ключа %s: %w", id, err)
+	}
+	private, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return Key{}, fmt.Errorf("ключ %s не является Ed25519 ключом", id)
+	}
+	return Key{ID: id, PrivateKey: private}, nil
+}
+
+func ParsePublicKey(id string, data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("ключ %s не является PEM", id)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return Key{}, fmt.Errorf("разбор открытого ключа %s: %w", id, err)
+	}
+	public, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return Key{}, fmt.Errorf("ключ %s не является Ed25519 ключом", id)
+	}
+	return Key{ID: id, PublicKey: public}, nil
+}
@@ -0,0 +1,92 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestKeySet_MustKeySet(t *testing.T) {
+	_, private, _ := ed25519.GenerateKey(nil)
+	public := private.Public().(ed25519.PublicKey)
+	cases := []struct {
+		TestName string
+		Panics   bool
+		ActiveID string
+		Keys     []Key
+	}{
+		{
+			TestName: "test_key_set_ok",
+			Panics:   false,
+			ActiveID: "a",
+			Keys:     []Key{{ID: "a", PrivateKey: private}, {ID: "b", PublicKey: public}},
+		},
+		{
+			TestName: "test_key_set_active_without_private_key",
+			Panics:   true,
+			ActiveID: "b",
+			Keys:     []Key{{ID: "a", PrivateKey: private}, {ID: "b", PublicKey: public}},
+		},
+		{
+			TestName: "test_key_set_unknown_active",
+			Panics:   true,
+			ActiveID: "c",
+			Keys:     []Key{{ID: "a", PrivateKey: private}},
+		},
+		{
+			TestName: "test_key_set_duplicate_id",
+			Panics:   true,
+			ActiveID: "a",
+			Keys:     []Key{{ID: "a", PrivateKey: private}, {ID: "a", PublicKey: public}},
+		},
+		{
+			TestName: "test_key_set_empty_id",
+			Panics:   true,
+			ActiveID: "a",
+			Keys:     []Key{{ID: "a", PrivateKey: private}, {PublicKey: public}},
+		},
+		{
+			TestName: "test_key_set_without_material",
+			Panics:   true,
+			ActiveID: "a",
+			Keys:     []Key{{ID: "a", PrivateKey: private}, {ID: "b"}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != c.Panics {
+					t.Errorf("expected panic %v, but got %v", c.Panics, r)
+				}
+			}()
+			MustKeySet(c.ActiveID, c.Keys...)
+		})
+	}
+}
+
+func TestKey_Parse(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(nil)
+	rawPrivate, _ := x509.MarshalPKCS8PrivateKey(private)
+	rawPublic, _ := x509.MarshalPKIXPublicKey(public)
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: rawPrivate})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rawPublic})
+
+	key, err := ParsePrivateKey("private", privatePEM)
+	if err != nil || !key.PrivateKey.Equal(private) {
+		t.Errorf("expected parsed private key, but got %v", err)
+	}
+	key, err = ParsePublicKey("public", publicPEM)
+	if err != nil || !key.PublicKey.Equal(public) {
+		t.Errorf("expected parsed public key, but got %v", err)
+	}
+	if _, err = ParsePrivateKey("invalid", []byte("not pem")); err == nil {
+		t.Error("expected error for invalid private key")
+	}
+	if _, err = ParsePrivateKey("public", publicPEM); err == nil {
+		t.Error("expected error for public key parsed as private")
+	}
+	if _, err = ParsePublicKey("invalid", []byte("not pem")); err == nil {
+		t.Error("expected error for invalid public key")
+	}
+}
@@ -0,0 +1,121 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+const secretSize = 20
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type Config struct {
+	Issuer string
+	Digits int
+	Period time.Duration
+	Skew   int
+}
+
+type Authenticator struct {
+	cfg    Config
+	period int64
+	modulo uint32
+	now    func() time.Time
+}
+
+func MustAuthenticator(cfg Config) *Authenticator {
+	if cfg.Issuer == "" {
+		panic("totp authenticator did not get issuer")
+	}
+	if cfg.Digits != 6 && cfg.Digits != 8 {
+		panic("totp authenticator got invalid digits")
+	}
+	if cfg.Period < time.Second || cfg.Period%time.Second != 0 {
+		panic("totp authenticator got invalid period")
+	}
+	if cfg.Skew < 0 {
+		panic("totp authenticator got negative skew")
+	}
+	modulo := uint32(1)
+	for range cfg.Digits {
+		modulo *= 10
+	}
+	return &Authenticator{
+		cfg:    cfg,
+		period: int64(cfg.Period / time.Second),
+		modulo: modulo,
+		now:    time.Now,
+	}
+}
+
+func (a *Authenticator) Generate(account string) (*app.TwoFactorEnrollment, error) {
+	key := make([]byte, secretSize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf(
+			"%w: генерация секрета двухфакторной аутентификации: %s",
+			app.ErrInternal,
+			err,
+		)
+	}
+	secret := encoding.EncodeToString(key)
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {a.cfg.Issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {strconv.Itoa(a.cfg.Digits)},
+		"period":    {strconv.FormatInt(a.period, 10)},
+	}
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + a.cfg.Issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+	return &app.TwoFactorEnrollment{Secret: secret, URI: uri.String()}, nil
+}
+
+func (a *Authenticator) Verify(secret, code string) (int64, bool, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false, fmt.Errorf(
+			"%w: некорректный секрет двухфакторной аутентификации: %s",
+			app.ErrInternal,
+			err,
+		)
+	}
+	if len(code) != a.cfg.Digits || strings.Trim(code, "0123456789") != "" {
+		return 0, false, nil
+	}
+
+	current := a.now().Unix() / a.period
+	var matched int64
+	ok := false
+	for step := current - int64(a.cfg.Skew); step <= current+int64(a.cfg.Skew); step++ {
+		if subtle.ConstantTimeCompare([]byte(a.code(key, step)), []byte(code)) == 1 {
+			matched, ok = step, true
+		}
+	}
+	return matched, ok, nil
+}
+
+func (a *Authenticator) code(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", a.cfg.Digits, value%a.modulo)
+}
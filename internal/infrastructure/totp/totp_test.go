@@ -0,0 +1,134 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func testAuthenticator(digits, skew int, now time.Time) *Authenticator {
+	auth := MustAuthenticator(Config{
+		Issuer: "dnd_users",
+		Digits: digits,
+		Period: 30 * time.Second,
+		Skew:   skew,
+	})
+	auth.now = func() time.Time { return now }
+	return auth
+}
+
+func TestAuthenticator_VerifyRFC6238(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Expected string
+		Unix     int64
+	}{
+		{TestName: "test_totp_rfc_59", Expected: "94287082", Unix: 59},
+		{TestName: "test_totp_rfc_1111111109", Expected: "07081804", Unix: 1111111109},
+		{TestName: "test_totp_rfc_1111111111", Expected: "14050471", Unix: 1111111111},
+		{TestName: "test_totp_rfc_1234567890", Expected: "89005924", Unix: 1234567890},
+		{TestName: "test_totp_rfc_2000000000", Expected: "69279037", Unix: 2000000000},
+		{TestName: "test_totp_rfc_20000000000", Expected: "65353130", Unix: 20000000000},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			auth := testAuthenticator(8, 0, time.Unix(c.Unix, 0))
+			step, ok, err := auth.Verify(rfcSecret, c.Expected)
+			if err != nil || !ok {
+				t.Fatalf("expected code %s to be valid, but got %t %v", c.Expected, ok, err)
+			}
+			if step != c.Unix/30 {
+				t.Errorf("expected step %d, but got %d", c.Unix/30, step)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_VerifyDrift(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	previousStep := now.Unix()/30 - 1
+	previous := testAuthenticator(6, 0, now).code(rfcKey(t), previousStep)
+	cases := []struct {
+		TestName string
+		Expected bool
+		Skew     int
+		Code     string
+	}{
+		{TestName: "test_totp_previous_step_with_skew", Expected: true, Skew: 1, Code: previous},
+		{TestName: "test_totp_previous_step_without_skew", Expected: false, Skew: 0, Code: previous},
+		{TestName: "test_totp_wrong_length", Expected: false, Skew: 1, Code: previous[:5]},
+		{TestName: "test_totp_not_digits", Expected: false, Skew: 1, Code: "12a456"},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			auth := testAuthenticator(6, c.Skew, now)
+			step, ok, err := auth.Verify(rfcSecret, c.Code)
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if ok != c.Expected {
+				t.Errorf("expected %t, but got %t", c.Expected, ok)
+			}
+			if ok && step != previousStep {
+				t.Errorf("expected matched step %d, but got %d", previousStep, step)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_VerifyInvalidSecret(t *testing.T) {
+	auth := testAuthenticator(6, 1, time.Now())
+	if _, _, err := auth.Verify("not base32!", "123456"); err == nil {
+		t.Error("expected error for invalid secret")
+	}
+}
+
+func TestAuthenticator_Generate(t *testing.T) {
+	now := time.Now()
+	auth := testAuthenticator(6, 1, now)
+	enrollment, err := auth.Generate("test@mail.com")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	key, err := encoding.DecodeString(enrollment.Secret)
+	if err != nil || len(key) != secretSize {
+		t.Fatalf("expected %d byte base32 secret, but got %q %v", secretSize, enrollment.Secret, err)
+	}
+
+	uri, err := url.Parse(enrollment.URI)
+	if err != nil {
+		t.Fatalf("parse uri: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/dnd_users:test@mail.com" {
+		t.Errorf("expected otpauth totp label, but got %s", enrollment.URI)
+	}
+	query := uri.Query()
+	expected := map[string]string{
+		"secret":    enrollment.Secret,
+		"issuer":    "dnd_users",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+	for key, value := range expected {
+		if query.Get(key) != value {
+			t.Errorf("expected %s=%s, but got %q", key, value, query.Get(key))
+		}
+	}
+
+	code := auth.code(key, now.Unix()/30)
+	if _, ok, err := auth.Verify(enrollment.Secret, code); err != nil || !ok {
+		t.Errorf("expected generated secret to verify its code, but got %t %v", ok, err)
+	}
+}
+
+func rfcKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := encoding.DecodeString(rfcSecret)
+	if err != nil {
+		t.Fatalf("decode rfc secret: %v", err)
+	}
+	return key
+}
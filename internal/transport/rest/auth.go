@@ -0,0 +1,47 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+)
+
+type tokenVerifier interface {
+	InitiatorID(token string) (uuid.UUID, error)
+}
+
+type initiatorKey struct{}
+
+func withInitiatorID(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, initiatorKey{}, id)
+}
+
+func initiatorID(ctx context.Context) (uuid.UUID, error) {
+	id, ok := ctx.Value(initiatorKey{}).(uuid.UUID)
+	if !ok || id == uuid.Nil {
+		return uuid.Nil, fmt.Errorf("%w: запрос не содержит инициатора", app.ErrUnauthenticated)
+	}
+	return id, nil
+}
+
+func (h *Handler) authenticated(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			h.writeError(w, r, fmt.Errorf("%w: отсутствует bearer токен", app.ErrUnauthenticated))
+			return
+		}
+		id, err := h.verifier.InitiatorID(token)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			h.writeError(w, r, err)
+			return
+		}
+		next(w, r.WithContext(withInitiatorID(r.Context(), id)))
+	})
+}
@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/i18n"
+)
+
+type errorBody struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Reason  string            `json:"reason,omitempty"`
+	Field   string            `json:"field,omitempty"`
+	Params  map[string]string `json:"params,omitempty"`
+}
+
+type errorMapping struct {
+	target error
+	status int
+	code   string
+}
+
+var errorMappings = []errorMapping{
+	{target: app.ErrInvalidData, status: http.StatusBadRequest, code: "invalid_data"},
+	{target: app.ErrUnauthenticated, status: http.StatusUnauthorized, code: "unauthenticated"},
+	{target: app.ErrNotAllowed, status: http.StatusForbidden, code: "not_allowed"},
+	{target: app.ErrUserNotActive, status: http.StatusForbidden, code: "user_not_active"},
+	{target: app.ErrNotFound, status: http.StatusNotFound, code: "not_found"},
+	{target: app.ErrAlreadyExists, status: http.StatusConflict, code: "already_exists"},
+	{target: app.ErrConflict, status: http.StatusConflict, code: "conflict"},
+	{target: app.ErrIdempotent, status: http.StatusConflict, code: "idempotent"},
+	{
+		target: app.ErrTooManyRequests,
+		status: http.StatusTooManyRequests,
+		code:   "too_many_requests",
+	},
+	{target: app.ErrInternal, status: http.StatusInternalServerError, code: "internal"},
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := http.StatusInternalServerError, "internal"
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.target) {
+			status, code = mapping.status, mapping.code
+			break
+		}
+	}
+
+	locale := h.locale(r)
+	detail := errorDetail{Code: code}
+	message := h.catalog.Message(locale, i18n.MessageID("error."+code))
+	var appErr *app.Error
+	if status != http.StatusInternalServerError && errors.As(err, &appErr) {
+		detail.Reason, detail.Field, detail.Params = appErr.Code, appErr.Field, appErr.Params
+		if reason, ok := h.catalog.Reason(locale, appErr.Code, appErr.Params); ok {
+			message = reason
+		}
+		if appErr.RetryAfter > 0 {
+			seconds := int(math.Ceil(appErr.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		}
+	}
+	if status == http.StatusInternalServerError {
+		h.logger.ErrorContext(
+			r.Context(),
+			"request failed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"error", err,
+		)
+	}
+
+	detail.Message = message
+	writeJSON(w, status, errorBody{Error: detail})
+}
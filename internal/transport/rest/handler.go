@@ -0,0 +1,232 @@
+package rest
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"slices"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/i18n"
+	"github.com/google/uuid"
+)
+
+type confirmEmailUseCase interface {
+	Execute(ctx context.Context, command *app.ConfirmEmailCommand) error
+}
+
+type registrationUseCase interface {
+	Execute(ctx context.Context, command *app.RegistrationCommand) (uuid.UUID, error)
+}
+
+type loginUseCase interface {
+	Execute(ctx context.Context, command *app.LoginCommand) (*app.Tokens, error)
+}
+
+type refreshUseCase interface {
+	Execute(ctx context.Context, command *app.RefreshCommand) (*app.Tokens, error)
+}
+
+type logoutUseCase interface {
+	Execute(ctx context.Context, command *app.LogoutCommand) error
+}
+
+type confirmResetPasswordUseCase interface {
+	Execute(ctx context.Context, command *app.ConfirmResetPasswordCommand) error
+}
+
+type resetPasswordUseCase interface {
+	Execute(ctx context.Context, command *app.ResetPasswordCommand) error
+}
+
+type confirmNewEmailUseCase interface {
+	Execute(ctx context.Context, command *app.ConfirmNewEmailCommand) error
+}
+
+type newEmailUseCase interface {
+	Execute(ctx context.Context, command *app.NewEmailCommand) error
+}
+
+type confirmNewPasswordUseCase interface {
+	Execute(ctx context.Context, command *app.ConfirmNewPasswordCommand) error
+}
+
+type newPasswordUseCase interface {
+	Execute(ctx context.Context, command *app.NewPasswordCommand) error
+}
+
+type changeUserUseCase interface {
+	Execute(ctx context.Context, command *app.ChangeUserCommand) error
+}
+
+type changeLocaleUseCase interface {
+	Execute(ctx context.Context, command *app.ChangeLocaleCommand) error
+}
+
+type getUserUseCase interface {
+	Execute(ctx context.Context, query *app.GetUserQuery) (*app.UserView, error)
+}
+
+type listUsersUseCase interface {
+	Execute(ctx context.Context, query *app.ListUsersQuery) (*app.UserPage, error)
+}
+
+type enrollTwoFactorUseCase interface {
+	Execute(
+		ctx context.Context,
+		command *app.EnrollTwoFactorCommand,
+	) (*app.TwoFactorEnrollment, error)
+}
+
+type enableTwoFactorUseCase interface {
+	Execute(ctx context.Context, command *app.EnableTwoFactorCommand) error
+}
+
+type disableTwoFactorUseCase interface {
+	Execute(ctx context.Context, command *app.DisableTwoFactorCommand) error
+}
+
+type UseCases struct {
+	ConfirmEmail         confirmEmailUseCase
+	Registration         registrationUseCase
+	Login                loginUseCase
+	Refresh              refreshUseCase
+	Logout               logoutUseCase
+	ConfirmResetPassword confirmResetPasswordUseCase
+	ResetPassword        resetPasswordUseCase
+	ConfirmNewEmail      confirmNewEmailUseCase
+	NewEmail             newEmailUseCase
+	ConfirmNewPassword   confirmNewPasswordUseCase
+	NewPassword          newPasswordUseCase
+	ChangeUser           changeUserUseCase
+	ChangeLocale         changeLocaleUseCase
+	GetUser              getUserUseCase
+	ListUsers            listUsersUseCase
+	EnrollTwoFactor      enrollTwoFactorUseCase
+	EnableTwoFactor      enableTwoFactorUseCase
+	DisableTwoFactor     disableTwoFactorUseCase
+}
+
+type Handler struct {
+	useCases       UseCases
+	verifier       tokenVerifier
+	trustedProxies []netip.Prefix
+	catalog        *i18n.Catalog
+	logger         *slog.Logger
+	mux            *http.ServeMux
+}
+
+func MustHandler(
+	useCases UseCases,
+	verifier tokenVerifier,
+	trustedProxies []netip.Prefix,
+	catalog *i18n.Catalog,
+	logger *slog.Logger,
+) *Handler {
+	if useCases.ConfirmEmail == nil {
+		panic("rest handler did not get confirm email use case")
+	}
+	if useCases.Registration == nil {
+		panic("rest handler did not get registration use case")
+	}
+	if useCases.Login == nil {
+		panic("rest handler did not get login use case")
+	}
+	if useCases.Refresh == nil {
+		panic("rest handler did not get refresh use case")
+	}
+	if useCases.Logout == nil {
+		panic("rest handler did not get logout use case")
+	}
+	if useCases.ConfirmResetPassword == nil {
+		panic("rest handler did not get confirm reset password use case")
+	}
+	if useCases.ResetPassword == nil {
+		panic("rest handler did not get reset password use case")
+	}
+	if useCases.ConfirmNewEmail == nil {
+		panic("rest handler did not get confirm new email use case")
+	}
+	if useCases.NewEmail == nil {
+		panic("rest handler did not get new email use case")
+	}
+	if useCases.ConfirmNewPassword == nil {
+		panic("rest handler did not get confirm new password use case")
+	}
+	if useCases.NewPassword == nil {
+		panic("rest handler did not get new password use case")
+	}
+	if useCases.ChangeUser == nil {
+		panic("rest handler did not get change user use case")
+	}
+	if useCases.ChangeLocale == nil {
+		panic("rest handler did not get change locale use case")
+	}
+	if useCases.GetUser == nil {
+		panic("rest handler did not get get user use case")
+	}
+	if useCases.ListUsers == nil {
+		panic("rest handler did not get list users use case")
+	}
+	if useCases.EnrollTwoFactor == nil {
+		panic("rest handler did not get enroll two factor use case")
+	}
+	if useCases.EnableTwoFactor == nil {
+		panic("rest handler did not get enable two factor use case")
+	}
+	if useCases.DisableTwoFactor == nil {
+		panic("rest handler did not get disable two factor use case")
+	}
+	if verifier == nil {
+		panic("rest handler did not get token verifier")
+	}
+	if catalog == nil {
+		panic("rest handler did not get message catalog")
+	}
+	if logger == nil {
+		panic("rest handler did not get logger")
+	}
+	h := &Handler{
+		useCases:       useCases,
+		verifier:       verifier,
+		trustedProxies: slices.Clone(trustedProxies),
+		catalog:        catalog,
+		logger:         logger,
+		mux:            http.NewServeMux(),
+	}
+	h.routes()
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) routes() {
+	h.mux.HandleFunc("POST /api/v1/registration/code", h.confirmEmail)
+	h.mux.HandleFunc("POST /api/v1/registration", h.registration)
+
+	h.mux.HandleFunc("POST /api/v1/auth/login", h.login)
+	h.mux.HandleFunc("POST /api/v1/auth/refresh", h.refresh)
+	h.mux.HandleFunc("POST /api/v1/auth/logout", h.logout)
+
+	h.mux.HandleFunc("POST /api/v1/password/reset/code", h.confirmResetPassword)
+	h.mux.HandleFunc("POST /api/v1/password/reset", h.resetPassword)
+
+	h.mux.Handle("GET /api/v1/users", h.authenticated(h.listUsers))
+	h.mux.Handle("GET /api/v1/users/{id}", h.authenticated(h.getUser))
+	h.mux.Handle("POST /api/v1/users/{id}/email/code", h.authenticated(h.confirmNewEmail))
+	h.mux.Handle("PUT /api/v1/users/{id}/email", h.authenticated(h.newEmail))
+	h.mux.Handle("POST /api/v1/users/{id}/password/code", h.authenticated(h.confirmNewPassword))
+	h.mux.Handle("PUT /api/v1/users/{id}/password", h.authenticated(h.newPassword))
+	h.mux.Handle("PUT /api/v1/users/{id}/locale", h.authenticated(h.changeLocale))
+	h.mux.Handle("PATCH /api/v1/users/{id}", h.authenticated(h.changeUser))
+	h.mux.Handle("POST /api/v1/users/{id}/two-factor/secret", h.authenticated(h.enrollTwoFactor))
+	h.mux.Handle("PUT /api/v1/users/{id}/two-factor", h.authenticated(h.enableTwoFactor))
+	h.mux.Handle("DELETE /api/v1/users/{id}/two-factor", h.authenticated(h.disableTwoFactor))
+}
+
+func (h *Handler) locale(r *http.Request) string {
+	return h.catalog.Negotiate(r.Header.Get("Accept-Language"))
+}
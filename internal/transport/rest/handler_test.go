@@ -0,0 +1,902 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/i18n"
+	"github.com/google/uuid"
+)
+
+type mockCommandUseCase[C any] struct {
+	Err     error
+	Command *C
+}
+
+func (m *mockCommandUseCase[C]) Execute(ctx context.Context, command *C) error {
+	m.Command = command
+	return m.Err
+}
+
+type mockResultUseCase[C, R any] struct {
+	Result  R
+	Err     error
+	Command *C
+}
+
+func (m *mockResultUseCase[C, R]) Execute(ctx context.Context, command *C) (R, error) {
+	m.Command = command
+	return m.Result, m.Err
+}
+
+type mockTokenVerifier struct {
+	Tokens map[string]uuid.UUID
+}
+
+func (m *mockTokenVerifier) InitiatorID(token string) (uuid.UUID, error) {
+	id, ok := m.Tokens[token]
+	if !ok {
+		return uuid.Nil, fmt.Errorf("%w: invalid token", app.ErrUnauthenticated)
+	}
+	return id, nil
+}
+
+type testUseCases struct {
+	ConfirmEmail         *mockCommandUseCase[app.ConfirmEmailCommand]
+	Registration         *mockResultUseCase[app.RegistrationCommand, uuid.UUID]
+	Login                *mockResultUseCase[app.LoginCommand, *app.Tokens]
+	Refresh              *mockResultUseCase[app.RefreshCommand, *app.Tokens]
+	Logout               *mockCommandUseCase[app.LogoutCommand]
+	ConfirmResetPassword *mockCommandUseCase[app.ConfirmResetPasswordCommand]
+	ResetPassword        *mockCommandUseCase[app.ResetPasswordCommand]
+	ConfirmNewEmail      *mockCommandUseCase[app.ConfirmNewEmailCommand]
+	NewEmail             *mockCommandUseCase[app.NewEmailCommand]
+	ConfirmNewPassword   *mockCommandUseCase[app.ConfirmNewPasswordCommand]
+	NewPassword          *mockCommandUseCase[app.NewPasswordCommand]
+	ChangeUser           *mockCommandUseCase[app.ChangeUserCommand]
+	ChangeLocale         *mockCommandUseCase[app.ChangeLocaleCommand]
+	GetUser              *mockResultUseCase[app.GetUserQuery, *app.UserView]
+	ListUsers            *mockResultUseCase[app.ListUsersQuery, *app.UserPage]
+	EnrollTwoFactor      *mockResultUseCase[app.EnrollTwoFactorCommand, *app.TwoFactorEnrollment]
+	EnableTwoFactor      *mockCommandUseCase[app.EnableTwoFactorCommand]
+	DisableTwoFactor     *mockCommandUseCase[app.DisableTwoFactorCommand]
+}
+
+func newTestHandler(initiator uuid.UUID, err error) (*Handler, *testUseCases) {
+	tokens := &app.Tokens{AccessToken: "access", RefreshToken: "refresh"}
+	view := &app.UserView{ID: initiator, Email: "test@mail.com", State: "active", Status: "user"}
+	page := &app.UserPage{Users: []app.UserView{*view}, NextCursor: "next"}
+	enrollment := &app.TwoFactorEnrollment{Secret: "SECRET", URI: "otpauth://totp/test"}
+	m := &testUseCases{
+		ConfirmEmail:         &mockCommandUseCase[app.ConfirmEmailCommand]{Err: err},
+		Registration:         &mockResultUseCase[app.RegistrationCommand, uuid.UUID]{Result: initiator, Err: err},
+		Login:                &mockResultUseCase[app.LoginCommand, *app.Tokens]{Result: tokens, Err: err},
+		Refresh:              &mockResultUseCase[app.RefreshCommand, *app.Tokens]{Result: tokens, Err: err},
+		Logout:               &mockCommandUseCase[app.LogoutCommand]{Err: err},
+		ConfirmResetPassword: &mockCommandUseCase[app.ConfirmResetPasswordCommand]{Err: err},
+		ResetPassword:        &mockCommandUseCase[app.ResetPasswordCommand]{Err: err},
+		ConfirmNewEmail:      &mockCommandUseCase[app.ConfirmNewEmailCommand]{Err: err},
+		NewEmail:             &mockCommandUseCase[app.NewEmailCommand]{Err: err},
+		ConfirmNewPassword:   &mockCommandUseCase[app.ConfirmNewPasswordCommand]{Err: err},
+		NewPassword:          &mockCommandUseCase[app.NewPasswordCommand]{Err: err},
+		ChangeUser:           &mockCommandUseCase[app.ChangeUserCommand]{Err: err},
+		ChangeLocale:         &mockCommandUseCase[app.ChangeLocaleCommand]{Err: err},
+		GetUser:              &mockResultUseCase[app.GetUserQuery, *app.UserView]{Result: view, Err: err},
+		ListUsers:            &mockResultUseCase[app.ListUsersQuery, *app.UserPage]{Result: page, Err: err},
+		EnrollTwoFactor: &mockResultUseCase[app.EnrollTwoFactorCommand, *app.TwoFactorEnrollment]{
+			Result: enrollment,
+			Err:    err,
+		},
+		EnableTwoFactor:  &mockCommandUseCase[app.EnableTwoFactorCommand]{Err: err},
+		DisableTwoFactor: &mockCommandUseCase[app.DisableTwoFactorCommand]{Err: err},
+	}
+	h := MustHandler(
+		UseCases{
+			ConfirmEmail:         m.ConfirmEmail,
+			Registration:         m.Registration,
+			Login:                m.Login,
+			Refresh:              m.Refresh,
+			Logout:               m.Logout,
+			ConfirmResetPassword: m.ConfirmResetPassword,
+			ResetPassword:        m.ResetPassword,
+			ConfirmNewEmail:      m.ConfirmNewEmail,
+			NewEmail:             m.NewEmail,
+			ConfirmNewPassword:   m.ConfirmNewPassword,
+			NewPassword:          m.NewPassword,
+			ChangeUser:           m.ChangeUser,
+			ChangeLocale:         m.ChangeLocale,
+			GetUser:              m.GetUser,
+			ListUsers:            m.ListUsers,
+			EnrollTwoFactor:      m.EnrollTwoFactor,
+			EnableTwoFactor:      m.EnableTwoFactor,
+			DisableTwoFactor:     m.DisableTwoFactor,
+		},
+		&mockTokenVerifier{Tokens: map[string]uuid.UUID{"valid": initiator}},
+		[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		i18n.MustDefaultCatalog(),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	return h, m
+}
+
+func TestHandler_Routes(t *testing.T) {
+	initiator := uuid.New()
+	userPath := "/api/v1/users/" + initiator.String()
+	cases := []struct {
+		TestName string
+		Expected int
+		Method   string
+		Path     string
+		Token    string
+		Language string
+		Body     string
+		Executed func(m *testUseCases) bool
+	}{
+		{
+			TestName: "test_handler_confirm_email",
+			Expected: http.StatusAccepted,
+			Method:   http.MethodPost,
+			Path:     "/api/v1/registration/code",
+			Body:     `{"email":"test@mail.com"}`,
+			Executed: func(m *testUseCases) bool {
+				return *m.ConfirmEmail.Command == app.ConfirmEmailCommand{
+					Email:  "test@mail.com",
+					Locale: "ru",
+				}
+			},
+		},
+		{
+			TestName: "test_handler_confirm_email_accept_language",
+			Expected: http.StatusAccepted,
+			Method:   http.MethodPost,
+			Path:     "/api/v1/registration/code",
+			Language: "en-US,ru;q=0.8",
+			Body:     `{"email":"test@mail.com"}`,
+			Executed: func(m *testUseCases) bool {
+				return m.ConfirmEmail.Command.Locale == "en"
+			},
+		},
+		{
+			TestName: "test_handler_registration",
+			Expected: http.StatusCreated,
+			Method:   http.MethodPost,
+			Path:     "/api/v1/registration",
+			Body:     `{"email":"test@mail.com","password":"password","code":"123456"}`,
+			Executed: func(m *testUseCases) bool {
+				return *m.Registration.Command == app.RegistrationCommand{
+					Email:    "test@mail.com",
+					Password: "password",
+					Code:     "123456",
+					Locale:   "ru",
+					ClientIP: "192.0.2.1",
+				}
+			},
+		},
+		{
+			TestName: "test_handler_registration_locale",
+			Expected: http.StatusCreated,
+			Method:   http.MethodPost,
+			Path:     "/api/v1/registration",
+			Language: "ru",
+			Body:     `{"email":"test@mail.com","password":"password","code":"1","locale":"en"}`,
+			Executed: func(m *testUseCases) bool {
+				return m.Registration.Command.Locale == "en"
+			},
+		},
+		{
+			TestName: "test_handler_login",
+			Expected: http.StatusOK,
+			Method:   http.MethodPost,
+			Path:     "/api/v1/auth/login",
+			Body:     `{"email":"test@mail.com","password":"password"}`,
+			Executed: func(m *testUseCases) bool {
+				return m.Login.Command.Email == "test@mail.com"
+			},
+		},
+		{
+			TestName: "test_handler_login_two_factor",
+			Expected: http.StatusOK,
+			Method:   http.MethodPost,
+			Path:     "/api/v1/auth/login",
+			Body:     `{"email":"test@mail.com","password":"password","totp_code":"123456"}`,
+			Executed: func(m *testUseCases) bool {
+				return *m.Login.Command == app.LoginCommand{
+					Email:    "test@mail.com",
+					Password: "password",
+					TOTPCode: "123456",
+					ClientIP: "192.0.2.1",
+				}
+			},
+		},
+		{
+			TestName: "test_handler_refresh",
+			Expected: http.StatusOK,
+			Method:   http.MethodPost,
+			Path:     "/api/v1/auth/refresh",
+			Body:     `{"refresh_token":"refresh"}`,
+			Executed: func(m *testUseCases) bool {
+				return m.Refresh.Command.RefreshToken == "refresh"
+			},
+		},
+		{
+			TestName: "test_handler_logout",
+			Expected: http.StatusNoContent,
+			Method:   http.MethodPost,
+			Path:     "/api/v1/auth/logout",
+			Body:     `{"refresh_token":"refresh"}`,
+			Executed: func(m *testUseCases) bool {
+				return m.Logout.Command.RefreshToken == "refresh"
+			},
+		},
+		{
+			TestName: "test_handler_confirm_reset_password",
+			Expected: http.StatusAccepted,
+			Method:   http.MethodPost,
+			Path:     "/api/v1/password/reset/code",
+			Body:     `{"email":"test@mail.com"}`,
+			Language: "en",
+			Executed: func(m *testUseCases) bool {
+				return *m.ConfirmResetPassword.Command == app.ConfirmResetPasswordCommand{
+					Email:  "test@mail.com",
+					Locale: "en",
+				}
+			},
+		},
+		{
+			TestName: "test_handler_reset_password",
+			Expected: http.StatusNoContent,
+			Method:   http.MethodPost,
+			Path:     "/api/v1/password/reset",
+			Body:     `{"email":"test@mail.com","code":"123456","new_password":"password"}`,
+			Executed: func(m *testUseCases) bool {
+				return m.ResetPassword.Command.NewPassword == "password" &&
+					m.ResetPassword.Command.ClientIP == "192.0.2.1"
+			},
+		},
+		{
+			TestName: "test_handler_confirm_new_email",
+			Expected: http.StatusAccepted,
+			Method:   http.MethodPost,
+			Path:     userPath + "/email/code",
+			Token:    "valid",
+			Body:     `{"new_email":"new@mail.com"}`,
+			Executed: func(m *testUseCases) bool {
+				return m.ConfirmNewEmail.Command.InitiatorID == initiator &&
+					m.ConfirmNewEmail.Command.UserID == initiator
+			},
+		},
+		{
+			TestName: "test_handler_new_email",
+			Expected: http.StatusNoContent,
+			Method:   http.MethodPut,
+			Path:     userPath + "/email",
+			Token:    "valid",
+			Body: `{"new_email":"new@mail.com","new_email_code":"1",` +
+				`"old_email_code":"2","password":"password"}`,
+			Executed: func(m *testUseCases) bool {
+				return m.NewEmail.Command.InitiatorID == initiator &&
+					m.NewEmail.Command.OldEmailCode == "2"
+			},
+		},
+		{
+			TestName: "test_handler_confirm_new_password",
+			Expected: http.StatusAccepted,
+			Method:   http.MethodPost,
+			Path:     userPath + "/password/code",
+			Token:    "valid",
+			Executed: func(m *testUseCases) bool {
+				return m.ConfirmNewPassword.Command.InitiatorID == initiator
+			},
+		},
+		{
+			TestName: "test_handler_new_password",
+			Expected: http.StatusNoContent,
+			Method:   http.MethodPut,
+			Path:     userPath + "/password",
+			Token:    "valid",
+			Body:     `{"old_password":"old","new_password":"new","code":"123456"}`,
+			Executed: func(m *testUseCases) bool {
+				return m.NewPassword.Command.InitiatorID == initiator &&
+					m.NewPassword.Command.NewPassword == "new"
+			},
+		},
+		{
+			TestName: "test_handler_change_user",
+			Expected: http.StatusNoContent,
+			Method:   http.MethodPatch,
+			Path:     userPath,
+			Token:    "valid",
+			Body:     `{"state":"frozen"}`,
+			Executed: func(m *testUseCases) bool {
+				return m.ChangeUser.Command.InitiatorID == initiator &&
+					m.ChangeUser.Command.State == "frozen"
+			},
+		},
+		{
+			TestName: "test_handler_change_locale",
+			Expected: http.StatusNoContent,
+			Method:   http.MethodPut,
+			Path:     userPath + "/locale",
+			Token:    "valid",
+			Body:     `{"locale":"en"}`,
+			Executed: func(m *testUseCases) bool {
+				return *m.ChangeLocale.Command == app.ChangeLocaleCommand{
+					InitiatorID: initiator,
+					UserID:      initiator,
+					Locale:      "en",
+				}
+			},
+		},
+		{
+			TestName: "test_handler_enroll_two_factor",
+			Expected: http.StatusOK,
+			Method:   http.MethodPost,
+			Path:     userPath + "/two-factor/secret",
+			Token:    "valid",
+			Body:     `{"password":"password"}`,
+			Executed: func(m *testUseCases) bool {
+				return *m.EnrollTwoFactor.Command == app.EnrollTwoFactorCommand{
+					InitiatorID: initiator,
+					UserID:      initiator,
+					Password:    "password",
+					ClientIP:    "192.0.2.1",
+				}
+			},
+		},
+		{
+			TestName: "test_handler_enable_two_factor",
+			Expected: http.StatusNoContent,
+			Method:   http.MethodPut,
+			Path:     userPath + "/two-factor",
+			Token:    "valid",
+			Body:     `{"code":"123456"}`,
+			Executed: func(m *testUseCases) bool {
+				return *m.EnableTwoFactor.Command == app.EnableTwoFactorCommand{
+					InitiatorID: initiator,
+					UserID:      initiator,
+					Code:        "123456",
+					ClientIP:    "192.0.2.1",
+				}
+			},
+		},
+		{
+			TestName: "test_handler_disable_two_factor",
+			Expected: http.StatusNoContent,
+			Method:   http.MethodDelete,
+			Path:     userPath + "/two-factor",
+			Token:    "valid",
+			Body:     `{"password":"password","code":"123456"}`,
+			Executed: func(m *testUseCases) bool {
+				return *m.DisableTwoFactor.Command == app.DisableTwoFactorCommand{
+					InitiatorID: initiator,
+					UserID:      initiator,
+					Password:    "password",
+					Code:        "123456",
+					ClientIP:    "192.0.2.1",
+				}
+			},
+		},
+		{
+			TestName: "test_handler_enable_two_factor_without_token",
+			Expected: http.StatusUnauthorized,
+			Method:   http.MethodPut,
+			Path:     userPath + "/two-factor",
+			Body:     `{"code":"123456"}`,
+			Executed: func(m *testUseCases) bool { return m.EnableTwoFactor.Command == nil },
+		},
+		{
+			TestName: "test_handler_change_user_without_token",
+			Expected: http.StatusUnauthorized,
+			Method:   http.MethodPatch,
+			Path:     userPath,
+			Body:     `{"state":"frozen"}`,
+			Executed: func(m *testUseCases) bool { return m.ChangeUser.Command == nil },
+		},
+		{
+			TestName: "test_handler_change_user_invalid_token",
+			Expected: http.StatusUnauthorized,
+			Method:   http.MethodPatch,
+			Path:     userPath,
+			Token:    "invalid",
+			Body:     `{"state":"frozen"}`,
+			Executed: func(m *testUseCases) bool { return m.ChangeUser.Command == nil },
+		},
+		{
+			TestName: "test_handler_change_user_invalid_id",
+			Expected: http.StatusBadRequest,
+			Method:   http.MethodPatch,
+			Path:     "/api/v1/users/not-uuid",
+			Token:    "valid",
+			Body:     `{"state":"frozen"}`,
+			Executed: func(m *testUseCases) bool { return m.ChangeUser.Command == nil },
+		},
+		{
+			TestName: "test_handler_get_user",
+			Expected: http.StatusOK,
+			Method:   http.MethodGet,
+			Path:     userPath,
+			Token:    "valid",
+			Executed: func(m *testUseCases) bool {
+				return *m.GetUser.Command == app.GetUserQuery{
+					InitiatorID: initiator,
+					UserID:      initiator,
+				}
+			},
+		},
+		{
+			TestName: "test_handler_get_user_without_token",
+			Expected: http.StatusUnauthorized,
+			Method:   http.MethodGet,
+			Path:     userPath,
+			Executed: func(m *testUseCases) bool { return m.GetUser.Command == nil },
+		},
+		{
+			TestName: "test_handler_list_users",
+			Expected: http.StatusOK,
+			Method:   http.MethodGet,
+			Path: "/api/v1/users?state=active&status=admin&email=mail" +
+				"&created_from=2026-01-01T00:00:00Z&created_to=2026-02-01T00:00:00Z" +
+				"&cursor=abc&limit=10",
+			Token: "valid",
+			Executed: func(m *testUseCases) bool {
+				return *m.ListUsers.Command == app.ListUsersQuery{
+					InitiatorID:   initiator,
+					State:         "active",
+					Status:        "admin",
+					EmailContains: "mail",
+					CreatedFrom:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+					CreatedTo:     time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+					Cursor:        "abc",
+					Limit:         10,
+				}
+			},
+		},
+		{
+			TestName: "test_handler_list_users_invalid_limit",
+			Expected: http.StatusBadRequest,
+			Method:   http.MethodGet,
+			Path:     "/api/v1/users?limit=ten",
+			Token:    "valid",
+			Executed: func(m *testUseCases) bool { return m.ListUsers.Command == nil },
+		},
+		{
+			TestName: "test_handler_list_users_invalid_time",
+			Expected: http.StatusBadRequest,
+			Method:   http.MethodGet,
+			Path:     "/api/v1/users?created_from=yesterday",
+			Token:    "valid",
+			Executed: func(m *testUseCases) bool { return m.ListUsers.Command == nil },
+		},
+		{
+			TestName: "test_handler_invalid_json",
+			Expected: http.StatusBadRequest,
+			Method:   http.MethodPost,
+			Path:     "/api/v1/auth/login",
+			Body:     `{"email":`,
+			Executed: func(m *testUseCases) bool { return m.Login.Command == nil },
+		},
+		{
+			TestName: "test_handler_unknown_field",
+			Expected: http.StatusBadRequest,
+			Method:   http.MethodPost,
+			Path:     "/api/v1/auth/login",
+			Body:     `{"email":"test@mail.com","initiator_id":"x"}`,
+			Executed: func(m *testUseCases) bool { return m.Login.Command == nil },
+		},
+		{
+			TestName: "test_handler_method_not_allowed",
+			Expected: http.StatusMethodNotAllowed,
+			Method:   http.MethodGet,
+			Path:     "/api/v1/auth/login",
+			Executed: func(m *testUseCases) bool { return m.Login.Command == nil },
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			h, m := newTestHandler(initiator, nil)
+			req := httptest.NewRequest(c.Method, c.Path, strings.NewReader(c.Body))
+			if c.Token != "" {
+				req.Header.Set("Authorization", "Bearer "+c.Token)
+			}
+			if c.Language != "" {
+				req.Header.Set("Accept-Language", c.Language)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != c.Expected {
+				t.Errorf("expected status %d, but got %d: %s", c.Expected, rec.Code, rec.Body)
+			}
+			if !c.Executed(m) {
+				t.Error("use case got unexpected command")
+			}
+		})
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Err      error
+		Status   int
+		Code     string
+	}{
+		{
+			TestName: "test_handler_invalid_data",
+			Err:      app.ErrInvalidData,
+			Status:   400,
+			Code:     "invalid_data",
+		},
+		{
+			TestName: "test_handler_unauthenticated",
+			Err:      app.ErrUnauthenticated,
+			Status:   401,
+			Code:     "unauthenticated",
+		},
+		{
+			TestName: "test_handler_not_allowed",
+			Err:      app.ErrNotAllowed,
+			Status:   403,
+			Code:     "not_allowed",
+		},
+		{
+			TestName: "test_handler_user_not_active",
+			Err:      app.ErrUserNotActive,
+			Status:   403,
+			Code:     "user_not_active",
+		},
+		{
+			TestName: "test_handler_not_found",
+			Err:      app.ErrNotFound,
+			Status:   404,
+			Code:     "not_found",
+		},
+		{
+			TestName: "test_handler_already_exists",
+			Err:      app.ErrAlreadyExists,
+			Status:   409,
+			Code:     "already_exists",
+		},
+		{
+			TestName: "test_handler_conflict",
+			Err:      app.ErrConflict,
+			Status:   409,
+			Code:     "conflict",
+		},
+		{
+			TestName: "test_handler_idempotent",
+			Err:      app.ErrIdempotent,
+			Status:   409,
+			Code:     "idempotent",
+		},
+		{
+			TestName: "test_handler_too_many_requests",
+			Err:      app.ErrTooManyRequests,
+			Status:   429,
+			Code:     "too_many_requests",
+		},
+		{
+			TestName: "test_handler_internal",
+			Err:      app.ErrInternal,
+			Status:   500,
+			Code:     "internal",
+		},
+		{
+			TestName: "test_handler_unknown",
+			Err:      io.ErrUnexpectedEOF,
+			Status:   500,
+			Code:     "internal",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			h, _ := newTestHandler(uuid.New(), fmt.Errorf("%w: secret details", c.Err))
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/api/v1/auth/login",
+				strings.NewReader(`{"email":"test@mail.com","password":"password"}`),
+			)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != c.Status {
+				t.Errorf("expected status %d, but got %d", c.Status, rec.Code)
+			}
+			var body errorBody
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode error body: %v", err)
+			}
+			if body.Error.Code != c.Code {
+				t.Errorf("expected code %s, but got %s", c.Code, body.Error.Code)
+			}
+			if c.Status == http.StatusInternalServerError &&
+				strings.Contains(body.Error.Message, "secret details") {
+				t.Error("expected internal error details to be hidden")
+			}
+		})
+	}
+}
+
+func TestHandler_LocalizedErrors(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Expected string
+		Err      error
+		Language string
+	}{
+		{
+			TestName: "test_handler_error_ru",
+			Expected: "некорректные данные",
+			Err:      fmt.Errorf("%w: email пустой", app.ErrInvalidData),
+			Language: "ru",
+		},
+		{
+			TestName: "test_handler_error_without_language",
+			Expected: "некорректные данные",
+			Err:      fmt.Errorf("%w: email пустой", app.ErrInvalidData),
+			Language: "",
+		},
+		{
+			TestName: "test_handler_error_en",
+			Expected: "invalid data",
+			Err:      fmt.Errorf("%w: email пустой", app.ErrInvalidData),
+			Language: "en-GB, ru;q=0.5",
+		},
+		{
+			TestName: "test_handler_reason_ru",
+			Expected: "пароль должен содержать не менее 8 символов",
+			Err: app.NewError(app.ErrInvalidData, app.CodePasswordTooWeak, "слабый пароль").
+				WithParam("rule", "min_length").
+				WithParam("min", "8"),
+			Language: "ru",
+		},
+		{
+			TestName: "test_handler_reason_en",
+			Expected: "password must contain at least 8 characters",
+			Err: app.NewError(app.ErrInvalidData, app.CodePasswordTooWeak, "слабый пароль").
+				WithParam("rule", "min_length").
+				WithParam("min", "8"),
+			Language: "en",
+		},
+		{
+			TestName: "test_handler_unknown_reason_en",
+			Expected: "invalid data",
+			Err:      app.NewError(app.ErrInvalidData, "unknown.reason", "неизвестная причина"),
+			Language: "en",
+		},
+		{
+			TestName: "test_handler_internal_error_ru",
+			Expected: "внутренняя ошибка",
+			Err:      fmt.Errorf("%w: secret details", app.ErrInternal),
+			Language: "ru",
+		},
+		{
+			TestName: "test_handler_internal_error_en",
+			Expected: "internal error",
+			Err:      fmt.Errorf("%w: secret details", app.ErrInternal),
+			Language: "en",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			h, _ := newTestHandler(uuid.New(), c.Err)
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/api/v1/auth/login",
+				strings.NewReader(`{"email":"test@mail.com","password":"password"}`),
+			)
+			if c.Language != "" {
+				req.Header.Set("Accept-Language", c.Language)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			var body errorBody
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode error body: %v", err)
+			}
+			if body.Error.Message != c.Expected {
+				t.Errorf("expected message %q, but got %q", c.Expected, body.Error.Message)
+			}
+		})
+	}
+}
+
+func TestHandler_ErrorDetails(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Expected errorDetail
+		Err      error
+	}{
+		{
+			TestName: "test_handler_error_details",
+			Expected: errorDetail{
+				Code:    "already_exists",
+				Message: "email test@mail.com уже существует",
+				Reason:  app.CodeUserEmailTaken,
+				Field:   "email",
+				Params:  map[string]string{"email": "test@mail.com"},
+			},
+			Err: app.NewError(
+				app.ErrAlreadyExists,
+				app.CodeUserEmailTaken,
+				"email test@mail.com уже существует",
+			).WithField("email").WithParam("email", "test@mail.com"),
+		},
+		{
+			TestName: "test_handler_error_details_wrapped",
+			Expected: errorDetail{
+				Code:    "invalid_data",
+				Message: "неверный код подтверждения",
+				Reason:  app.CodeCodeInvalid,
+				Field:   "code",
+			},
+			Err: fmt.Errorf("отправка: %w", app.CodeInvalidError()),
+		},
+		{
+			TestName: "test_handler_error_details_sentinel",
+			Expected: errorDetail{
+				Code:    "not_found",
+				Message: "объект не найден",
+			},
+			Err: fmt.Errorf("%w: user", app.ErrNotFound),
+		},
+		{
+			TestName: "test_handler_error_details_internal",
+			Expected: errorDetail{
+				Code:    "internal",
+				Message: "внутренняя ошибка",
+			},
+			Err: app.NewError(app.ErrInternal, "internal.secret", "secret").WithField("secret"),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			h, _ := newTestHandler(uuid.New(), c.Err)
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/api/v1/auth/login",
+				strings.NewReader(`{"email":"test@mail.com","password":"password"}`),
+			)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			var body errorBody
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode error body: %v", err)
+			}
+			if !reflect.DeepEqual(body.Error, c.Expected) {
+				t.Errorf("expected %+v, but got %+v", c.Expected, body.Error)
+			}
+		})
+	}
+}
+
+func TestHandler_RetryAfter(t *testing.T) {
+	h, _ := newTestHandler(uuid.New(), app.TooManyRequestsError(1500*time.Millisecond))
+	req := httptest.NewRequest(
+		http.MethodPost,
+		"/api/v1/auth/login",
+		strings.NewReader(`{"email":"test@mail.com","password":"password"}`),
+	)
+	req.Header.Set("Accept-Language", "en")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status %d, but got %d", http.StatusTooManyRequests, rec.Code)
+	}
+	if retry := rec.Header().Get("Retry-After"); retry != "2" {
+		t.Errorf("expected Retry-After 2, but got %q", retry)
+	}
+	var body errorBody
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error body: %v", err)
+	}
+	if body.Error.Message != "too many attempts, retry in 2 s" ||
+		body.Error.Params["retry_after"] != "2" {
+		t.Errorf("expected localized retry details, but got %+v", body.Error)
+	}
+}
+
+func TestHandler_ListUsersResponse(t *testing.T) {
+	initiator := uuid.New()
+	h, _ := newTestHandler(initiator, nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+	req.Header.Set("Authorization", "Bearer valid")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, but got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	var body usersResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode users body: %v", err)
+	}
+	expected := usersResponse{
+		Users: []userResponse{{
+			ID:     initiator.String(),
+			Email:  "test@mail.com",
+			State:  "active",
+			Status: "user",
+		}},
+		NextCursor: "next",
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("expected %+v, but got %+v", expected, body)
+	}
+}
+
+func TestHandler_ClientIP(t *testing.T) {
+	cases := []struct {
+		TestName   string
+		Expected   string
+		RemoteAddr string
+		Forwarded  []string
+	}{
+		{
+			TestName:   "test_handler_client_ip_direct",
+			Expected:   "192.0.2.1",
+			RemoteAddr: "192.0.2.1:1234",
+		},
+		{
+			TestName:   "test_handler_client_ip_untrusted_forwarded",
+			Expected:   "192.0.2.1",
+			RemoteAddr: "192.0.2.1:1234",
+			Forwarded:  []string{"198.51.100.7"},
+		},
+		{
+			TestName:   "test_handler_client_ip_trusted_proxy",
+			Expected:   "198.51.100.7",
+			RemoteAddr: "10.0.0.1:1234",
+			Forwarded:  []string{"198.51.100.7"},
+		},
+		{
+			TestName:   "test_handler_client_ip_spoofed_hop",
+			Expected:   "198.51.100.7",
+			RemoteAddr: "10.0.0.1:1234",
+			Forwarded:  []string{"203.0.113.9, 198.51.100.7"},
+		},
+		{
+			TestName:   "test_handler_client_ip_proxy_chain",
+			Expected:   "198.51.100.7",
+			RemoteAddr: "10.0.0.1:1234",
+			Forwarded:  []string{"203.0.113.9, 198.51.100.7", "10.0.0.2"},
+		},
+		{
+			TestName:   "test_handler_client_ip_invalid_hop",
+			Expected:   "10.0.0.1",
+			RemoteAddr: "10.0.0.1:1234",
+			Forwarded:  []string{"not-an-ip"},
+		},
+		{
+			TestName:   "test_handler_client_ip_without_forwarded",
+			Expected:   "10.0.0.1",
+			RemoteAddr: "10.0.0.1:1234",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			h, m := newTestHandler(uuid.New(), nil)
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/api/v1/auth/login",
+				strings.NewReader(`{"email":"test@mail.com","password":"password"}`),
+			)
+			req.RemoteAddr = c.RemoteAddr
+			for _, value := range c.Forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			if m.Login.Command == nil || m.Login.Command.ClientIP != c.Expected {
+				t.Errorf("expected client ip %s, but got %+v", c.Expected, m.Login.Command)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+type confirmResetPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type resetPasswordRequest struct {
+	Email       string `json:"email"`
+	Code        string `json:"code"`
+	NewPassword string `json:"new_password"`
+}
+
+type newPasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+	Code        string `json:"code"`
+}
+
+func (h *Handler) confirmResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req confirmResetPasswordRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err := h.useCases.ConfirmResetPassword.Execute(
+		r.Context(),
+		&app.ConfirmResetPasswordCommand{Email: req.Email, Locale: h.locale(r)},
+	); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) resetPassword(w http.ResponseWriter, r *http.Request) {
+	var req resetPasswordRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err := h.useCases.ResetPassword.Execute(r.Context(), &app.ResetPasswordCommand{
+		Email:       req.Email,
+		Code:        req.Code,
+		NewPassword: req.NewPassword,
+		ClientIP:    h.clientIP(r),
+	}); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) confirmNewPassword(w http.ResponseWriter, r *http.Request) {
+	initiator, err := initiatorID(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	userID, err := pathID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err = h.useCases.ConfirmNewPassword.Execute(r.Context(), &app.ConfirmNewPasswordCommand{
+		InitiatorID: initiator,
+		UserID:      userID,
+	}); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) newPassword(w http.ResponseWriter, r *http.Request) {
+	initiator, err := initiatorID(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	userID, err := pathID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	var req newPasswordRequest
+	if err = decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err = h.useCases.NewPassword.Execute(r.Context(), &app.NewPasswordCommand{
+		InitiatorID: initiator,
+		UserID:      userID,
+		OldPassword: req.OldPassword,
+		NewPassword: req.NewPassword,
+		Code:        req.Code,
+		ClientIP:    h.clientIP(r),
+	}); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+type confirmEmailRequest struct {
+	Email string `json:"email"`
+}
+
+type registrationRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Code     string `json:"code"`
+	Locale   string `json:"locale"`
+}
+
+type registrationResponse struct {
+	ID string `json:"id"`
+}
+
+func (h *Handler) confirmEmail(w http.ResponseWriter, r *http.Request) {
+	var req confirmEmailRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err := h.useCases.ConfirmEmail.Execute(
+		r.Context(),
+		&app.ConfirmEmailCommand{Email: req.Email, Locale: h.locale(r)},
+	); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) registration(w http.ResponseWriter, r *http.Request) {
+	var req registrationRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if req.Locale == "" {
+		req.Locale = h.locale(r)
+	}
+	id, err := h.useCases.Registration.Execute(r.Context(), &app.RegistrationCommand{
+		Email:    req.Email,
+		Password: req.Password,
+		Code:     req.Code,
+		Locale:   req.Locale,
+		ClientIP: h.clientIP(r),
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, registrationResponse{ID: id.String()})
+}
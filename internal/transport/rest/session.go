@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+type loginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	TOTPCode string `json:"totp_code"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type tokensResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+}
+
+func newTokensResponse(tokens *app.Tokens) tokensResponse {
+	return tokensResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+	}
+}
+
+func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	tokens, err := h.useCases.Login.Execute(r.Context(), &app.LoginCommand{
+		Email:    req.Email,
+		Password: req.Password,
+		TOTPCode: req.TOTPCode,
+		ClientIP: h.clientIP(r),
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newTokensResponse(tokens))
+}
+
+func (h *Handler) refresh(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	tokens, err := h.useCases.Refresh.Execute(
+		r.Context(),
+		&app.RefreshCommand{RefreshToken: req.RefreshToken},
+	)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newTokensResponse(tokens))
+}
+
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err := h.useCases.Logout.Execute(
+		r.Context(),
+		&app.LogoutCommand{RefreshToken: req.RefreshToken},
+	); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
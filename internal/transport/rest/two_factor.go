@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+type enrollTwoFactorRequest struct {
+	Password string `json:"password"`
+}
+
+type enrollTwoFactorResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type enableTwoFactorRequest struct {
+	Code string `json:"code"`
+}
+
+type disableTwoFactorRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+func (h *Handler) enrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	initiator, err := initiatorID(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	userID, err := pathID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	var req enrollTwoFactorRequest
+	if err = decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	enrollment, err := h.useCases.EnrollTwoFactor.Execute(r.Context(), &app.EnrollTwoFactorCommand{
+		InitiatorID: initiator,
+		UserID:      userID,
+		Password:    req.Password,
+		ClientIP:    h.clientIP(r),
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, enrollTwoFactorResponse{
+		Secret: enrollment.Secret,
+		URI:    enrollment.URI,
+	})
+}
+
+func (h *Handler) enableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	initiator, err := initiatorID(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	userID, err := pathID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	var req enableTwoFactorRequest
+	if err = decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err = h.useCases.EnableTwoFactor.Execute(r.Context(), &app.EnableTwoFactorCommand{
+		InitiatorID: initiator,
+		UserID:      userID,
+		Code:        req.Code,
+		ClientIP:    h.clientIP(r),
+	}); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) disableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	initiator, err := initiatorID(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	userID, err := pathID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	var req disableTwoFactorRequest
+	if err = decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err = h.useCases.DisableTwoFactor.Execute(r.Context(), &app.DisableTwoFactorCommand{
+		InitiatorID: initiator,
+		UserID:      userID,
+		Password:    req.Password,
+		Code:        req.Code,
+		ClientIP:    h.clientIP(r),
+	}); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,264 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+)
+
+type confirmNewEmailRequest struct {
+	NewEmail string `json:"new_email"`
+}
+
+type newEmailRequest struct {
+	NewEmail     string `json:"new_email"`
+	NewEmailCode string `json:"new_email_code"`
+	OldEmailCode string `json:"old_email_code"`
+	Password     string `json:"password"`
+}
+
+type changeUserRequest struct {
+	Email    string `json:"email"`
+	State    string `json:"state"`
+	Status   string `json:"status"`
+	Password string `json:"password"`
+	Locale   string `json:"locale"`
+}
+
+type changeLocaleRequest struct {
+	Locale string `json:"locale"`
+}
+
+type userResponse struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	State     string    `json:"state"`
+	Status    string    `json:"status"`
+	Locale    string    `json:"locale"`
+	Version   uint      `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type usersResponse struct {
+	Users      []userResponse `json:"users"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+func newUserResponse(user *app.UserView) userResponse {
+	return userResponse{
+		ID:        user.ID.String(),
+		Email:     user.Email,
+		State:     user.State,
+		Status:    user.Status,
+		Locale:    user.Locale,
+		Version:   user.Version,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
+	initiator, err := initiatorID(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	userID, err := pathID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	user, err := h.useCases.GetUser.Execute(r.Context(), &app.GetUserQuery{
+		InitiatorID: initiator,
+		UserID:      userID,
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newUserResponse(user))
+}
+
+func (h *Handler) listUsers(w http.ResponseWriter, r *http.Request) {
+	initiator, err := initiatorID(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	query, err := listUsersQuery(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	query.InitiatorID = initiator
+	page, err := h.useCases.ListUsers.Execute(r.Context(), query)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	resp := usersResponse{Users: make([]userResponse, 0, len(page.Users)), NextCursor: page.NextCursor}
+	for i := range page.Users {
+		resp.Users = append(resp.Users, newUserResponse(&page.Users[i]))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func listUsersQuery(r *http.Request) (*app.ListUsersQuery, error) {
+	values := r.URL.Query()
+	query := &app.ListUsersQuery{
+		State:         values.Get("state"),
+		Status:        values.Get("status"),
+		EmailContains: values.Get("email"),
+		Cursor:        values.Get("cursor"),
+	}
+	var err error
+	if query.CreatedFrom, err = queryTime(values.Get("created_from"), "created_from"); err != nil {
+		return nil, err
+	}
+	if query.CreatedTo, err = queryTime(values.Get("created_to"), "created_to"); err != nil {
+		return nil, err
+	}
+	if limit := values.Get("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, app.NewError(
+				app.ErrInvalidData,
+				app.CodeUnsupported,
+				fmt.Sprintf("некорректный размер страницы %s", limit),
+			).WithField("limit")
+		}
+	}
+	return query, nil
+}
+
+func queryTime(value, field string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, app.NewError(
+			app.ErrInvalidData,
+			app.CodeUnsupported,
+			fmt.Sprintf("некорректное время %s, ожидается RFC 3339", value),
+		).WithField(field)
+	}
+	return t, nil
+}
+
+func (h *Handler) confirmNewEmail(w http.ResponseWriter, r *http.Request) {
+	initiator, err := initiatorID(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	userID, err := pathID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	var req confirmNewEmailRequest
+	if err = decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err = h.useCases.ConfirmNewEmail.Execute(r.Context(), &app.ConfirmNewEmailCommand{
+		InitiatorID: initiator,
+		UserID:      userID,
+		NewEmail:    req.NewEmail,
+	}); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) newEmail(w http.ResponseWriter, r *http.Request) {
+	initiator, err := initiatorID(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	userID, err := pathID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	var req newEmailRequest
+	if err = decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err = h.useCases.NewEmail.Execute(r.Context(), &app.NewEmailCommand{
+		InitiatorID:  initiator,
+		UserID:       userID,
+		NewEmail:     req.NewEmail,
+		NewEmailCode: req.NewEmailCode,
+		OldEmailCode: req.OldEmailCode,
+		Password:     req.Password,
+		ClientIP:     h.clientIP(r),
+	}); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) changeUser(w http.ResponseWriter, r *http.Request) {
+	initiator, err := initiatorID(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	userID, err := pathID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	var req changeUserRequest
+	if err = decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err = h.useCases.ChangeUser.Execute(r.Context(), &app.ChangeUserCommand{
+		InitiatorID: initiator,
+		UserID:      userID,
+		Email:       req.Email,
+		State:       req.State,
+		Status:      req.Status,
+		Password:    req.Password,
+		Locale:      req.Locale,
+	}); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) changeLocale(w http.ResponseWriter, r *http.Request) {
+	initiator, err := initiatorID(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	userID, err := pathID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	var req changeLocaleRequest
+	if err = decodeJSON(w, r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err = h.useCases.ChangeLocale.Execute(r.Context(), &app.ChangeLocaleCommand{
+		InitiatorID: initiator,
+		UserID:      userID,
+		Locale:      req.Locale,
+	}); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
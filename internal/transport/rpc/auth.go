@@ -0,0 +1,28 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/metadata"
+)
+
+func (s *Server) initiatorID(ctx context.Context) (uuid.UUID, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) != 1 {
+		return uuid.Nil, fmt.Errorf("%w: отсутствует bearer токен", app.ErrUnauthenticated)
+	}
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return uuid.Nil, fmt.Errorf("%w: отсутствует bearer токен", app.ErrUnauthenticated)
+	}
+	claims, err := s.verifier.Verify(token)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return claims.UserID, nil
+}
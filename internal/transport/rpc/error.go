@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/i18n"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const errorDomain = "dnd_users"
+
+type errorMapping struct {
+	target  error
+	code    codes.Code
+	message i18n.MessageID
+}
+
+var errorMappings = []errorMapping{
+	{target: app.ErrInvalidData, code: codes.InvalidArgument, message: "error.invalid_data"},
+	{target: app.ErrUnauthenticated, code: codes.Unauthenticated, message: "error.unauthenticated"},
+	{target: app.ErrNotAllowed, code: codes.PermissionDenied, message: "error.not_allowed"},
+	{target: app.ErrUserNotActive, code: codes.FailedPrecondition, message: "error.user_not_active"},
+	{target: app.ErrNotFound, code: codes.NotFound, message: "error.not_found"},
+	{target: app.ErrAlreadyExists, code: codes.AlreadyExists, message: "error.already_exists"},
+	{target: app.ErrConflict, code: codes.Aborted, message: "error.conflict"},
+	{target: app.ErrIdempotent, code: codes.FailedPrecondition, message: "error.idempotent"},
+	{
+		target:  app.ErrTooManyRequests,
+		code:    codes.ResourceExhausted,
+		message: "error.too_many_requests",
+	},
+	{target: app.ErrInternal, code: codes.Internal, message: "error.internal"},
+}
+
+func (s *Server) statusError(ctx context.Context, err error) error {
+	code, id := codes.Internal, i18n.MessageID("error.internal")
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.target) {
+			code, id = mapping.code, mapping.message
+			break
+		}
+	}
+
+	locale := s.locale(ctx)
+	if code == codes.Internal {
+		method, _ := grpc.Method(ctx)
+		s.logger.ErrorContext(ctx, "rpc failed", "method", method, "error", err)
+		return status.Error(codes.Internal, s.catalog.Message(locale, id))
+	}
+	message := s.catalog.Message(locale, id)
+	var appErr *app.Error
+	if !errors.As(err, &appErr) {
+		return status.Error(code, message)
+	}
+	if reason, ok := s.catalog.Reason(locale, appErr.Code, appErr.Params); ok {
+		message = reason
+	}
+	st := status.New(code, message)
+	details := []protoadapt.MessageV1{
+		&errdetails.ErrorInfo{Reason: appErr.Code, Domain: errorDomain, Metadata: appErr.Params},
+	}
+	if appErr.Field != "" {
+		details = append(details, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: appErr.Field, Description: message, Reason: appErr.Code},
+			},
+		})
+	}
+	if appErr.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{
+			RetryDelay: durationpb.New(appErr.RetryAfter),
+		})
+	}
+	detailed, detailsErr := st.WithDetails(details...)
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func (s *Server) locale(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return s.catalog.Negotiate(strings.Join(md.Get("accept-language"), ","))
+}
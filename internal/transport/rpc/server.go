@@ -0,0 +1,175 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	usersv1 "github.com/Nemagu/dnd_users/api/users/v1"
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/Nemagu/dnd_users/internal/i18n"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/token"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type lookupUserUseCase interface {
+	Execute(ctx context.Context, query *app.LookupUserQuery) (*app.UserView, error)
+}
+
+type changeUserUseCase interface {
+	Execute(ctx context.Context, command *app.ChangeUserCommand) error
+}
+
+type tokenVerifier interface {
+	Verify(token string) (*token.Claims, error)
+}
+
+type Server struct {
+	usersv1.UnimplementedUsersServiceServer
+
+	lookupUser lookupUserUseCase
+	changeUser changeUserUseCase
+	verifier   tokenVerifier
+	clients    []serviceClient
+	catalog    *i18n.Catalog
+	logger     *slog.Logger
+}
+
+func MustServer(
+	lookupUser lookupUserUseCase,
+	changeUser changeUserUseCase,
+	verifier tokenVerifier,
+	clients []ServiceClient,
+	catalog *i18n.Catalog,
+	logger *slog.Logger,
+) *Server {
+	if lookupUser == nil {
+		panic("rpc server did not get lookup user use case")
+	}
+	if changeUser == nil {
+		panic("rpc server did not get change user use case")
+	}
+	if verifier == nil {
+		panic("rpc server did not get token verifier")
+	}
+	if catalog == nil {
+		panic("rpc server did not get message catalog")
+	}
+	if logger == nil {
+		panic("rpc server did not get logger")
+	}
+	return &Server{
+		lookupUser: lookupUser,
+		changeUser: changeUser,
+		verifier:   verifier,
+		clients:    mustServiceClients(clients),
+		catalog:    catalog,
+		logger:     logger,
+	}
+}
+
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	usersv1.RegisterUsersServiceServer(registrar, s)
+}
+
+func (s *Server) GetUser(
+	ctx context.Context,
+	req *usersv1.GetUserRequest,
+) (*usersv1.GetUserResponse, error) {
+	query := &app.LookupUserQuery{}
+	switch lookup := req.GetLookup().(type) {
+	case *usersv1.GetUserRequest_Id:
+		id, err := parseID(lookup.Id)
+		if err != nil {
+			return nil, s.statusError(ctx, err)
+		}
+		query.ID = id
+	case *usersv1.GetUserRequest_Email:
+		query.Email = lookup.Email
+	}
+
+	view, err := s.lookupUser.Execute(ctx, query)
+	if err != nil {
+		return nil, s.statusError(ctx, err)
+	}
+
+	return &usersv1.GetUserResponse{User: &usersv1.User{
+		Id:      view.ID.String(),
+		Email:   view.Email,
+		State:   view.State,
+		Status:  view.Status,
+		Version: uint64(view.Version),
+	}}, nil
+}
+
+func (s *Server) ValidateToken(
+	ctx context.Context,
+	req *usersv1.ValidateTokenRequest,
+) (*usersv1.ValidateTokenResponse, error) {
+	claims, err := s.verifier.Verify(req.GetToken())
+	if err != nil {
+		return nil, s.statusError(ctx, err)
+	}
+
+	// The signature outlives blocking the user or changing the password, so
+	// the token is only as good as the user it was issued for.
+	view, err := s.lookupUser.Execute(ctx, &app.LookupUserQuery{ID: claims.UserID})
+	if err != nil && !errors.Is(err, app.ErrNotFound) {
+		return nil, s.statusError(ctx, err)
+	}
+	if err != nil || view.State != domain.ACTIVE || view.Version != claims.Version {
+		return nil, s.statusError(ctx, app.NewError(
+			app.ErrUnauthenticated,
+			app.CodeTokenRevoked,
+			fmt.Sprintf("токен пользователя %s больше не действителен", claims.UserID),
+		))
+	}
+
+	return &usersv1.ValidateTokenResponse{
+		UserId:    claims.UserID.String(),
+		State:     claims.State,
+		Status:    claims.Status,
+		Version:   uint64(claims.Version),
+		IssuedAt:  timestamppb.New(claims.IssuedAt),
+		ExpiresAt: timestamppb.New(claims.ExpiresAt),
+	}, nil
+}
+
+func (s *Server) ChangeUser(
+	ctx context.Context,
+	req *usersv1.ChangeUserRequest,
+) (*usersv1.ChangeUserResponse, error) {
+	initiator, err := s.initiatorID(ctx)
+	if err != nil {
+		return nil, s.statusError(ctx, err)
+	}
+	userID, err := parseID(req.GetUserId())
+	if err != nil {
+		return nil, s.statusError(ctx, err)
+	}
+
+	if err = s.changeUser.Execute(ctx, &app.ChangeUserCommand{
+		InitiatorID: initiator,
+		UserID:      userID,
+		Email:       req.GetEmail(),
+		State:       req.GetState(),
+		Status:      req.GetStatus(),
+		Password:    req.GetPassword(),
+	}); err != nil {
+		return nil, s.statusError(ctx, err)
+	}
+
+	return &usersv1.ChangeUserResponse{}, nil
+}
+
+func parseID(raw string) (uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: некорректный id пользователя %s", app.ErrInvalidData, raw)
+	}
+	return id, nil
+}
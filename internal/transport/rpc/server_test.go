@@ -0,0 +1,549 @@
+package rpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	usersv1 "github.com/Nemagu/dnd_users/api/users/v1"
+	"github.com/Nemagu/dnd_users/internal/app"
+	"github.com/Nemagu/dnd_users/internal/domain"
+	"github.com/Nemagu/dnd_users/internal/i18n"
+	"github.com/Nemagu/dnd_users/internal/infrastructure/token"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type mockLookupUserUseCase struct {
+	View  *app.UserView
+	Err   error
+	Query *app.LookupUserQuery
+}
+
+func (m *mockLookupUserUseCase) Execute(
+	ctx context.Context,
+	query *app.LookupUserQuery,
+) (*app.UserView, error) {
+	m.Query = query
+	return m.View, m.Err
+}
+
+type mockChangeUserUseCase struct {
+	Err     error
+	Command *app.ChangeUserCommand
+}
+
+func (m *mockChangeUserUseCase) Execute(ctx context.Context, command *app.ChangeUserCommand) error {
+	m.Command = command
+	return m.Err
+}
+
+type mockTokenVerifier struct {
+	Claims map[string]*token.Claims
+}
+
+func (m *mockTokenVerifier) Verify(raw string) (*token.Claims, error) {
+	claims, ok := m.Claims[raw]
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid token", app.ErrUnauthenticated)
+	}
+	return claims, nil
+}
+
+func tokenHash(raw string) string {
+	hash := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(hash[:])
+}
+
+func serviceTokenInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if len(md.Get(serviceTokenHeader)) == 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, serviceTokenHeader, "service-token")
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func testClient(
+	t *testing.T,
+	lookup *mockLookupUserUseCase,
+	change *mockChangeUserUseCase,
+	claims *token.Claims,
+) usersv1.UsersServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	srv := MustServer(
+		lookup,
+		change,
+		&mockTokenVerifier{Claims: map[string]*token.Claims{"valid": claims}},
+		[]ServiceClient{
+			{
+				Name:        "service",
+				TokenSHA256: tokenHash("service-token"),
+				Methods:     []string{"GetUser", "ValidateToken", "ChangeUser"},
+			},
+			{
+				Name:        "limited",
+				TokenSHA256: tokenHash("limited-token"),
+				Methods:     []string{"ValidateToken"},
+			},
+		},
+		i18n.MustDefaultCatalog(),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	server := grpc.NewServer(grpc.UnaryInterceptor(srv.UnaryInterceptor()))
+	srv.Register(server)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(serviceTokenInterceptor),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return usersv1.NewUsersServiceClient(conn)
+}
+
+func TestServer_GetUser(t *testing.T) {
+	view := &app.UserView{
+		ID:      uuid.New(),
+		Email:   "test@mail.com",
+		State:   domain.ACTIVE,
+		Status:  domain.USER,
+		Version: 4,
+	}
+	cases := []struct {
+		TestName string
+		Expected codes.Code
+		Lookup   *mockLookupUserUseCase
+		Request  *usersv1.GetUserRequest
+		Query    *app.LookupUserQuery
+	}{
+		{
+			TestName: "test_server_get_user_by_id",
+			Expected: codes.OK,
+			Lookup:   &mockLookupUserUseCase{View: view},
+			Request: &usersv1.GetUserRequest{
+				Lookup: &usersv1.GetUserRequest_Id{Id: view.ID.String()},
+			},
+			Query: &app.LookupUserQuery{ID: view.ID},
+		},
+		{
+			TestName: "test_server_get_user_by_email",
+			Expected: codes.OK,
+			Lookup:   &mockLookupUserUseCase{View: view},
+			Request: &usersv1.GetUserRequest{
+				Lookup: &usersv1.GetUserRequest_Email{Email: view.Email},
+			},
+			Query: &app.LookupUserQuery{Email: view.Email},
+		},
+		{
+			TestName: "test_server_get_user_invalid_id",
+			Expected: codes.InvalidArgument,
+			Lookup:   &mockLookupUserUseCase{View: view},
+			Request: &usersv1.GetUserRequest{
+				Lookup: &usersv1.GetUserRequest_Id{Id: "not-uuid"},
+			},
+		},
+		{
+			TestName: "test_server_get_user_not_found",
+			Expected: codes.NotFound,
+			Lookup:   &mockLookupUserUseCase{Err: fmt.Errorf("%w: user", app.ErrNotFound)},
+			Request: &usersv1.GetUserRequest{
+				Lookup: &usersv1.GetUserRequest_Email{Email: view.Email},
+			},
+			Query: &app.LookupUserQuery{Email: view.Email},
+		},
+		{
+			TestName: "test_server_get_user_internal",
+			Expected: codes.Internal,
+			Lookup:   &mockLookupUserUseCase{Err: fmt.Errorf("%w: secret", app.ErrInternal)},
+			Request: &usersv1.GetUserRequest{
+				Lookup: &usersv1.GetUserRequest_Email{Email: view.Email},
+			},
+			Query: &app.LookupUserQuery{Email: view.Email},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			client := testClient(t, c.Lookup, &mockChangeUserUseCase{}, &token.Claims{})
+			resp, err := client.GetUser(context.Background(), c.Request)
+			if status.Code(err) != c.Expected {
+				t.Errorf("expected %s, but got %v", c.Expected, err)
+			}
+			if c.Expected == codes.OK && resp.GetUser().GetId() != view.ID.String() {
+				t.Errorf("expected user %s, but got %v", view.ID, resp.GetUser())
+			}
+			if c.Expected == codes.Internal && status.Convert(err).Message() != app.ErrInternal.Error() {
+				t.Errorf("expected internal details to be hidden, but got %v", err)
+			}
+			if c.Query != nil && *c.Lookup.Query != *c.Query {
+				t.Errorf("expected query %v, but got %v", c.Query, c.Lookup.Query)
+			}
+		})
+	}
+}
+
+func TestServer_ValidateToken(t *testing.T) {
+	claims := &token.Claims{
+		UserID:    uuid.New(),
+		Status:    domain.ADMIN,
+		State:     domain.ACTIVE,
+		Version:   2,
+		IssuedAt:  time.Now().Truncate(time.Second),
+		ExpiresAt: time.Now().Add(time.Minute).Truncate(time.Second),
+	}
+	cases := []struct {
+		TestName string
+		Expected codes.Code
+		Token    string
+		Lookup   *mockLookupUserUseCase
+	}{
+		{
+			TestName: "test_server_validate_token_ok",
+			Expected: codes.OK,
+			Token:    "valid",
+			Lookup: &mockLookupUserUseCase{
+				View: &app.UserView{ID: claims.UserID, State: domain.ACTIVE, Version: 2},
+			},
+		},
+		{
+			TestName: "test_server_validate_token_invalid_signature",
+			Expected: codes.Unauthenticated,
+			Token:    "invalid",
+			Lookup:   &mockLookupUserUseCase{},
+		},
+		{
+			TestName: "test_server_validate_token_user_frozen",
+			Expected: codes.Unauthenticated,
+			Token:    "valid",
+			Lookup: &mockLookupUserUseCase{
+				View: &app.UserView{ID: claims.UserID, State: domain.FROZEN, Version: 2},
+			},
+		},
+		{
+			TestName: "test_server_validate_token_stale_version",
+			Expected: codes.Unauthenticated,
+			Token:    "valid",
+			Lookup: &mockLookupUserUseCase{
+				View: &app.UserView{ID: claims.UserID, State: domain.ACTIVE, Version: 3},
+			},
+		},
+		{
+			TestName: "test_server_validate_token_user_deleted",
+			Expected: codes.Unauthenticated,
+			Token:    "valid",
+			Lookup:   &mockLookupUserUseCase{Err: app.ErrNotFound},
+		},
+		{
+			TestName: "test_server_validate_token_internal_error",
+			Expected: codes.Internal,
+			Token:    "valid",
+			Lookup:   &mockLookupUserUseCase{Err: app.ErrInternal},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			client := testClient(t, c.Lookup, &mockChangeUserUseCase{}, claims)
+			resp, err := client.ValidateToken(
+				context.Background(),
+				&usersv1.ValidateTokenRequest{Token: c.Token},
+			)
+			if status.Code(err) != c.Expected {
+				t.Fatalf("expected %s, but got %v", c.Expected, err)
+			}
+			if c.Expected != codes.OK {
+				return
+			}
+			if resp.GetUserId() != claims.UserID.String() ||
+				resp.GetStatus() != claims.Status ||
+				!resp.GetExpiresAt().AsTime().Equal(claims.ExpiresAt) {
+				t.Errorf("expected claims %v, but got %v", claims, resp)
+			}
+			if c.Lookup.Query.ID != claims.UserID {
+				t.Errorf("expected lookup of %s, but got %v", claims.UserID, c.Lookup.Query)
+			}
+		})
+	}
+}
+
+func TestServer_ChangeUser(t *testing.T) {
+	initiator := uuid.New()
+	userID := uuid.New()
+	cases := []struct {
+		TestName string
+		Expected codes.Code
+		Change   *mockChangeUserUseCase
+		Auth     string
+		Request  *usersv1.ChangeUserRequest
+	}{
+		{
+			TestName: "test_server_change_user_ok",
+			Expected: codes.OK,
+			Change:   &mockChangeUserUseCase{},
+			Auth:     "Bearer valid",
+			Request:  &usersv1.ChangeUserRequest{UserId: userID.String(), State: domain.FROZEN},
+		},
+		{
+			TestName: "test_server_change_user_without_token",
+			Expected: codes.Unauthenticated,
+			Change:   &mockChangeUserUseCase{},
+			Request:  &usersv1.ChangeUserRequest{UserId: userID.String(), State: domain.FROZEN},
+		},
+		{
+			TestName: "test_server_change_user_invalid_token",
+			Expected: codes.Unauthenticated,
+			Change:   &mockChangeUserUseCase{},
+			Auth:     "Bearer invalid",
+			Request:  &usersv1.ChangeUserRequest{UserId: userID.String(), State: domain.FROZEN},
+		},
+		{
+			TestName: "test_server_change_user_invalid_id",
+			Expected: codes.InvalidArgument,
+			Change:   &mockChangeUserUseCase{},
+			Auth:     "Bearer valid",
+			Request:  &usersv1.ChangeUserRequest{UserId: "not-uuid", State: domain.FROZEN},
+		},
+		{
+			TestName: "test_server_change_user_not_allowed",
+			Expected: codes.PermissionDenied,
+			Change:   &mockChangeUserUseCase{Err: fmt.Errorf("%w: admin only", app.ErrNotAllowed)},
+			Auth:     "Bearer valid",
+			Request:  &usersv1.ChangeUserRequest{UserId: userID.String(), State: domain.FROZEN},
+		},
+		{
+			TestName: "test_server_change_user_conflict",
+			Expected: codes.Aborted,
+			Change:   &mockChangeUserUseCase{Err: fmt.Errorf("%w: version", app.ErrConflict)},
+			Auth:     "Bearer valid",
+			Request:  &usersv1.ChangeUserRequest{UserId: userID.String(), State: domain.FROZEN},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			client := testClient(t, &mockLookupUserUseCase{}, c.Change, &token.Claims{UserID: initiator})
+			ctx := context.Background()
+			if c.Auth != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", c.Auth)
+			}
+			_, err := client.ChangeUser(ctx, c.Request)
+			if status.Code(err) != c.Expected {
+				t.Errorf("expected %s, but got %v", c.Expected, err)
+			}
+			if c.Expected == codes.OK &&
+				(c.Change.Command.InitiatorID != initiator || c.Change.Command.UserID != userID) {
+				t.Errorf("expected initiator from token, but got %v", c.Change.Command)
+			}
+		})
+	}
+}
+
+func TestServer_LocalizedErrors(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Expected string
+		Err      error
+		Language string
+	}{
+		{
+			TestName: "test_server_error_ru",
+			Expected: "объект не найден",
+			Err:      fmt.Errorf("%w: user", app.ErrNotFound),
+			Language: "",
+		},
+		{
+			TestName: "test_server_error_en",
+			Expected: "object not found",
+			Err:      fmt.Errorf("%w: user", app.ErrNotFound),
+			Language: "en-US",
+		},
+		{
+			TestName: "test_server_reason_en",
+			Expected: "user not found",
+			Err:      app.NewError(app.ErrNotFound, app.CodeUserNotFound, "пользователь не найден"),
+			Language: "en",
+		},
+		{
+			TestName: "test_server_internal_error_en",
+			Expected: "internal error",
+			Err:      fmt.Errorf("%w: secret", app.ErrInternal),
+			Language: "en",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			lookup := &mockLookupUserUseCase{Err: c.Err}
+			client := testClient(t, lookup, &mockChangeUserUseCase{}, &token.Claims{})
+			ctx := context.Background()
+			if c.Language != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "accept-language", c.Language)
+			}
+			_, err := client.GetUser(ctx, &usersv1.GetUserRequest{
+				Lookup: &usersv1.GetUserRequest_Email{Email: "test@mail.com"},
+			})
+			if message := status.Convert(err).Message(); message != c.Expected {
+				t.Errorf("expected message %q, but got %q", c.Expected, message)
+			}
+		})
+	}
+}
+
+func TestServer_ErrorDetails(t *testing.T) {
+	lookup := &mockLookupUserUseCase{
+		Err: app.NewError(app.ErrInvalidData, app.CodeEmailInvalid, "некорректный email").
+			WithField("email").
+			WithParam("email", "test"),
+	}
+	client := testClient(t, lookup, &mockChangeUserUseCase{}, &token.Claims{})
+	_, err := client.GetUser(context.Background(), &usersv1.GetUserRequest{
+		Lookup: &usersv1.GetUserRequest_Email{Email: "test"},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected %s, but got %v", codes.InvalidArgument, err)
+	}
+	var (
+		info       *errdetails.ErrorInfo
+		badRequest *errdetails.BadRequest
+	)
+	for _, detail := range status.Convert(err).Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.BadRequest:
+			badRequest = d
+		}
+	}
+	if info.GetReason() != app.CodeEmailInvalid || info.GetMetadata()["email"] != "test" {
+		t.Errorf("expected error info with reason and params, but got %v", info)
+	}
+	violations := badRequest.GetFieldViolations()
+	if len(violations) != 1 || violations[0].GetField() != "email" {
+		t.Errorf("expected email field violation, but got %v", violations)
+	}
+}
+
+func TestServer_RetryInfo(t *testing.T) {
+	lookup := &mockLookupUserUseCase{Err: app.TooManyRequestsError(time.Minute)}
+	client := testClient(t, lookup, &mockChangeUserUseCase{}, &token.Claims{})
+	_, err := client.GetUser(context.Background(), &usersv1.GetUserRequest{
+		Lookup: &usersv1.GetUserRequest_Email{Email: "test@mail.com"},
+	})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected %s, but got %v", codes.ResourceExhausted, err)
+	}
+	var retry *errdetails.RetryInfo
+	for _, detail := range status.Convert(err).Details() {
+		if d, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = d
+		}
+	}
+	if retry.GetRetryDelay().AsDuration() != time.Minute {
+		t.Errorf("expected retry delay %v, but got %v", time.Minute, retry)
+	}
+}
+
+func TestServer_ServiceAuth(t *testing.T) {
+	cases := []struct {
+		TestName string
+		Expected codes.Code
+		Token    string
+		Call     func(ctx context.Context, client usersv1.UsersServiceClient) error
+	}{
+		{
+			TestName: "test_server_service_auth_ok",
+			Expected: codes.OK,
+			Token:    "service-token",
+			Call: func(ctx context.Context, client usersv1.UsersServiceClient) error {
+				_, err := client.GetUser(ctx, &usersv1.GetUserRequest{
+					Lookup: &usersv1.GetUserRequest_Email{Email: "test@mail.com"},
+				})
+				return err
+			},
+		},
+		{
+			TestName: "test_server_service_auth_empty_token",
+			Expected: codes.Unauthenticated,
+			Token:    "",
+			Call: func(ctx context.Context, client usersv1.UsersServiceClient) error {
+				_, err := client.GetUser(ctx, &usersv1.GetUserRequest{
+					Lookup: &usersv1.GetUserRequest_Email{Email: "test@mail.com"},
+				})
+				return err
+			},
+		},
+		{
+			TestName: "test_server_service_auth_unknown_token",
+			Expected: codes.Unauthenticated,
+			Token:    "unknown-token",
+			Call: func(ctx context.Context, client usersv1.UsersServiceClient) error {
+				_, err := client.ValidateToken(ctx, &usersv1.ValidateTokenRequest{Token: "valid"})
+				return err
+			},
+		},
+		{
+			TestName: "test_server_service_auth_method_not_allowed",
+			Expected: codes.PermissionDenied,
+			Token:    "limited-token",
+			Call: func(ctx context.Context, client usersv1.UsersServiceClient) error {
+				_, err := client.GetUser(ctx, &usersv1.GetUserRequest{
+					Lookup: &usersv1.GetUserRequest_Email{Email: "test@mail.com"},
+				})
+				return err
+			},
+		},
+		{
+			TestName: "test_server_service_auth_method_allowed",
+			Expected: codes.OK,
+			Token:    "limited-token",
+			Call: func(ctx context.Context, client usersv1.UsersServiceClient) error {
+				_, err := client.ValidateToken(ctx, &usersv1.ValidateTokenRequest{Token: "valid"})
+				return err
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.TestName, func(t *testing.T) {
+			lookup := &mockLookupUserUseCase{
+				View: &app.UserView{ID: uuid.New(), State: domain.ACTIVE},
+			}
+			client := testClient(t, lookup, &mockChangeUserUseCase{}, &token.Claims{})
+			ctx := metadata.AppendToOutgoingContext(
+				context.Background(),
+				serviceTokenHeader,
+				c.Token,
+			)
+			err := c.Call(ctx, client)
+			if status.Code(err) != c.Expected {
+				t.Errorf("expected %s, but got %v", c.Expected, err)
+			}
+			if c.Expected != codes.OK && lookup.Query != nil {
+				t.Error("expected use case not to be executed")
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"slices"
+
+	usersv1 "github.com/Nemagu/dnd_users/api/users/v1"
+	"github.com/Nemagu/dnd_users/internal/app"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const serviceTokenHeader = "x-service-token"
+
+var serviceMethods = []string{
+	path.Base(usersv1.UsersService_GetUser_FullMethodName),
+	path.Base(usersv1.UsersService_ValidateToken_FullMethodName),
+	path.Base(usersv1.UsersService_ChangeUser_FullMethodName),
+}
+
+type ServiceClient struct {
+	Name        string
+	TokenSHA256 string
+	Methods     []string
+}
+
+type serviceClient struct {
+	name      string
+	tokenHash []byte
+	methods   []string
+}
+
+func mustServiceClients(clients []ServiceClient) []serviceClient {
+	if len(clients) == 0 {
+		panic("rpc server did not get service clients")
+	}
+	result := make([]serviceClient, 0, len(clients))
+	for _, client := range clients {
+		if client.Name == "" {
+			panic("rpc server got service client without name")
+		}
+		hash, err := hex.DecodeString(client.TokenSHA256)
+		if err != nil || len(hash) != sha256.Size {
+			panic("rpc server got invalid service client token hash")
+		}
+		if len(client.Methods) == 0 {
+			panic("rpc server got service client without methods")
+		}
+		for _, method := range client.Methods {
+			if !slices.Contains(serviceMethods, method) {
+				panic("rpc server got unknown service client method " + method)
+			}
+		}
+		result = append(result, serviceClient{
+			name:      client.Name,
+			tokenHash: hash,
+			methods:   slices.Clone(client.Methods),
+		})
+	}
+	return result
+}
+
+func (s *Server) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		if err := s.authorizeService(ctx, info.FullMethod); err != nil {
+			return nil, s.statusError(ctx, err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (s *Server) authorizeService(ctx context.Context, fullMethod string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(serviceTokenHeader)
+	if len(values) != 1 || values[0] == "" {
+		return fmt.Errorf("%w: отсутствует токен сервиса", app.ErrUnauthenticated)
+	}
+	hash := sha256.Sum256([]byte(values[0]))
+	var client *serviceClient
+	for i := range s.clients {
+		if subtle.ConstantTimeCompare(hash[:], s.clients[i].tokenHash) == 1 {
+			client = &s.clients[i]
+		}
+	}
+	if client == nil {
+		return fmt.Errorf("%w: неизвестный токен сервиса", app.ErrUnauthenticated)
+	}
+	if !slices.Contains(client.methods, path.Base(fullMethod)) {
+		return fmt.Errorf(
+			"%w: сервису %s запрещен вызов %s",
+			app.ErrNotAllowed,
+			client.name,
+			fullMethod,
+		)
+	}
+	return nil
+}